@@ -0,0 +1,12 @@
+// Package api - спецификация HTTP API сервера в формате OpenAPI 3.1.
+//
+// Тест в internal/gateways/http сверяет её с маршрутами роутера, поэтому новый маршрут
+// без описания здесь (и описание без маршрута) не пройдёт тесты.
+package api
+
+import _ "embed"
+
+// OpenAPI - спецификация в YAML
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
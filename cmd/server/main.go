@@ -3,60 +3,371 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
+	"fmt"
+	"homework/internal/anomaly"
+	"homework/internal/config"
+	"homework/internal/health"
+	"homework/internal/logging"
+	"homework/internal/metrics"
+	"homework/internal/ratelimit"
+	"homework/internal/tracing"
 	"homework/internal/usecase"
-	"log"
+	"homework/internal/worker"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
+	"syscall"
+	"time"
+	// часовые пояса пользователей не должны зависеть от tzdata в образе
+	_ "time/tzdata"
 
+	"github.com/jackc/pgx/v5/multitracer"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	httpGateway "homework/internal/gateways/http"
+	"homework/internal/repository/cached"
+	"homework/internal/repository/durable"
+	eventInmemory "homework/internal/repository/event/inmemory"
 	eventRepository "homework/internal/repository/event/postgres"
+	eventSqlite "homework/internal/repository/event/sqlite"
+	"homework/internal/repository/instrumented"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
 	sensorRepository "homework/internal/repository/sensor/postgres"
+	sensorSqlite "homework/internal/repository/sensor/sqlite"
+	"homework/internal/repository/traced"
+	userInmemory "homework/internal/repository/user/inmemory"
 	userRepository "homework/internal/repository/user/postgres"
+	userSqlite "homework/internal/repository/user/sqlite"
+	"homework/migrations"
+	sqliteMigrations "homework/migrations/sqlite"
+	"homework/pkg/sqlite"
+	"homework/pkg/tlsreload"
+	"homework/pkg/wal"
 )
 
-func main() {
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
-	defer cancel()
+type repositories struct {
+	event       usecase.EventRepository
+	sensor      usecase.SensorRepository
+	user        usecase.UserRepository
+	sensorOwner usecase.SensorOwnerRepository
+	// pool - пул postgres, nil для остальных хранилищ
+	pool *pgxpool.Pool
+	// checks - проверки готовности хранилища для /readyz
+	checks map[string]health.CheckFunc
+	close  func()
+}
 
-	config, err := pgxpool.ParseConfig(os.Getenv("DATABASE_URL"))
+// trace - оборачивает репозитории декораторами, открывающими спан на каждый вызов
+func (r *repositories) trace() {
+	r.event = traced.NewEventRepository(r.event)
+	r.sensor = traced.NewSensorRepository(r.sensor)
+	r.user = traced.NewUserRepository(r.user)
+	r.sensorOwner = traced.NewSensorOwnerRepository(r.sensorOwner)
+}
+
+// instrument - оборачивает репозитории декораторами с замером длительности вызовов
+func (r *repositories) instrument(m *metrics.Metrics) {
+	r.event = instrumented.NewEventRepository(r.event, m)
+	r.sensor = instrumented.NewSensorRepository(r.sensor, m)
+	r.user = instrumented.NewUserRepository(r.user, m)
+	r.sensorOwner = instrumented.NewSensorOwnerRepository(r.sensorOwner, m)
+	if r.pool != nil {
+		m.ObservePgxPool(r.pool)
+	}
+}
+
+// cache - кэширует поиск датчика по серийному номеру поверх остальных декораторов
+func (r *repositories) cache(ttl time.Duration, options ...func(*cached.SensorRepository)) {
+	r.sensor = cached.NewSensorRepository(r.sensor, ttl, options...)
+}
+
+func newPostgresRepositories(ctx context.Context, cfg config.Postgres) (*repositories, error) {
+	poolConfig, err := pgxpool.ParseConfig(cfg.URL)
 	if err != nil {
-		log.Fatalf("can't parse pgxpool config")
+		return nil, fmt.Errorf("can't parse pgxpool config: %w", err)
+	}
+	if cfg.MaxConns > 0 {
+		poolConfig.MaxConns = cfg.MaxConns
 	}
+	poolConfig.MinConns = cfg.MinConns
+	if cfg.MaxConnLifetime.Duration > 0 {
+		poolConfig.MaxConnLifetime = cfg.MaxConnLifetime.Duration
+	}
+	if cfg.MaxConnIdleTime.Duration > 0 {
+		poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime.Duration
+	}
+	if cfg.ConnectTimeout.Duration > 0 {
+		poolConfig.ConnConfig.ConnectTimeout = cfg.ConnectTimeout.Duration
+	}
+	// без настроенного экспорта спаны не создаются, а запросы пишутся в лог только на уровне debug,
+	// так что оба трассировщика ставим всегда
+	poolConfig.ConnConfig.Tracer = multitracer.New(tracing.NewPgxTracer(), logging.NewPgxTracer())
 
-	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("can't create new pool: %w", err)
+	}
+	schemaVersion, err := health.LatestMigration(migrations.FS)
 	if err != nil {
-		log.Fatalf("can't create new pool")
+		pool.Close()
+		return nil, fmt.Errorf("can't read postgres migrations: %w", err)
 	}
-	defer pool.Close()
 
-	er := eventRepository.NewEventRepository(pool)
-	sr := sensorRepository.NewSensorRepository(pool)
-	ur := userRepository.NewUserRepository(pool)
-	sor := userRepository.NewSensorOwnerRepository(pool)
+	return &repositories{
+		event:       eventRepository.NewEventRepository(pool),
+		sensor:      sensorRepository.NewSensorRepository(pool),
+		user:        userRepository.NewUserRepository(pool),
+		sensorOwner: userRepository.NewSensorOwnerRepository(pool),
+		pool:        pool,
+		checks: map[string]health.CheckFunc{
+			"postgres":            health.Ping(pool),
+			"postgres_migrations": health.Migrations(health.PgxMigrationVersion(pool), schemaVersion),
+		},
+		close: pool.Close,
+	}, nil
+}
 
-	useCases := httpGateway.UseCases{
-		Event:  usecase.NewEvent(er, sr),
-		Sensor: usecase.NewSensor(sr),
-		User:   usecase.NewUser(ur, sor, sr),
+func newSqliteRepositories(ctx context.Context, cfg config.Sqlite) (*repositories, error) {
+	db, err := sqlite.Open(ctx, cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	schemaVersion, err := health.LatestMigration(sqliteMigrations.FS)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("can't read sqlite migrations: %w", err)
 	}
 
-	host := os.Getenv("HTTP_HOST")
-	if host == "" {
-		host = "localhost"
+	return &repositories{
+		event:       eventSqlite.NewEventRepository(db),
+		sensor:      sensorSqlite.NewSensorRepository(db),
+		user:        userSqlite.NewUserRepository(db),
+		sensorOwner: userSqlite.NewSensorOwnerRepository(db),
+		checks: map[string]health.CheckFunc{
+			"sqlite":            db.PingContext,
+			"sqlite_migrations": health.Migrations(health.SQLMigrationVersion(db), schemaVersion),
+		},
+		close: func() { _ = db.Close() },
+	}, nil
+}
+
+func newInmemoryRepositories(ctx context.Context, cfg config.Inmemory) (*repositories, error) {
+	if cfg.WALDir == "" {
+		return &repositories{
+			event:       eventInmemory.NewEventRepository(),
+			sensor:      sensorInmemory.NewSensorRepository(),
+			user:        userInmemory.NewUserRepository(),
+			sensorOwner: userInmemory.NewSensorOwnerRepository(),
+			close:       func() {},
+		}, nil
+	}
+
+	syncPolicy, err := wal.ParseSyncPolicy(cfg.WALSync)
+	if err != nil {
+		return nil, err
+	}
+	store, err := durable.Open(cfg.WALDir, durable.Options{
+		WAL:              wal.Options{Sync: syncPolicy},
+		SnapshotInterval: cfg.SnapshotInterval.Duration,
+		SnapshotSize:     cfg.SnapshotSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	go store.Run(ctx)
+
+	return &repositories{
+		event:       store.EventRepository(),
+		sensor:      store.SensorRepository(),
+		user:        store.UserRepository(),
+		sensorOwner: store.SensorOwnerRepository(),
+		close: func() {
+			if err := store.Close(); err != nil {
+				slog.Error("can't close durable store", "err", err)
+			}
+		},
+	}, nil
+}
+
+func newRepositories(ctx context.Context, cfg config.Storage) (*repositories, error) {
+	switch cfg.Backend {
+	case config.BackendPostgres:
+		return newPostgresRepositories(ctx, cfg.Postgres)
+	case config.BackendSqlite:
+		return newSqliteRepositories(ctx, cfg.Sqlite)
+	case config.BackendInmemory:
+		return newInmemoryRepositories(ctx, cfg.Inmemory)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
 	}
+}
 
-	port, err := strconv.Atoi(os.Getenv("HTTP_PORT"))
+// fatal - пишет ошибку запуска и завершает процесс
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
+
+func main() {
+	cfg, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal("can't load config", err)
+	}
+	level, err := logging.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		fatal("can't init logger", err)
+	}
+	logger, err := logging.New(os.Stderr, cfg.Log.Format, level)
 	if err != nil {
-		port = 8080
+		fatal("can't init logger", err)
 	}
-	go func() {
-		r := httpGateway.NewServer(useCases, httpGateway.WithHost(host), httpGateway.WithPort(uint16(port)))
-		if err := r.Run(ctx, cancel); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Printf("error during server shutdown: %v", err)
+	slog.SetDefault(logger)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		ServiceName: cfg.Tracing.ServiceName,
+	})
+	if err != nil {
+		fatal("can't init tracing", err)
+	}
+	defer func() {
+		// ctx к этому моменту отменён, поэтому досылаем спаны с отдельным таймаутом
+		shutdownCtx, stop := context.WithTimeout(context.Background(), 5*time.Second)
+		defer stop()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.Error("can't flush traces", "err", err)
 		}
 	}()
+
+	sensorTypes, err := cfg.SensorTypeRegistry()
+	if err != nil {
+		fatal("can't init sensor types", err)
+	}
+
+	repos, err := newRepositories(ctx, cfg.Storage)
+	if err != nil {
+		fatal("can't init storage", err)
+	}
+	defer repos.close()
+
+	options := []func(*httpGateway.Server){
+		httpGateway.WithHost(cfg.HTTP.Host),
+		httpGateway.WithPort(cfg.HTTP.Port),
+		httpGateway.WithTimeouts(cfg.HTTP.ReadTimeout.Duration, cfg.HTTP.WriteTimeout.Duration, cfg.HTTP.IdleTimeout.Duration),
+		httpGateway.WithShutdownTimeout(cfg.HTTP.ShutdownTimeout.Duration),
+		httpGateway.WithShutdownDelay(cfg.HTTP.ShutdownDelay.Duration),
+		httpGateway.WithTrustedProxies(cfg.HTTP.TrustedProxies),
+		httpGateway.WithWebSocket(cfg.WebSocket.PollInterval.Duration, cfg.WebSocket.WriteTimeout.Duration),
+		httpGateway.WithAdminConfig(cfg.Admin.Token, cfg.Redacted()),
+		httpGateway.WithLogger(logger),
+	}
+	eventOptions := []func(*usecase.Event){usecase.WithEventSensorTypes(sensorTypes)}
+	var limitOptions []func(*ratelimit.Scope)
+	var cacheOptions []func(*cached.SensorRepository)
+	if cfg.Tracing.Exporter != tracing.ExporterNone {
+		repos.trace()
+		options = append(options, httpGateway.WithTracing(cfg.Tracing.ServiceName))
+	}
+	if cfg.Metrics.Enabled {
+		m := metrics.New()
+		repos.instrument(m)
+		eventOptions = append(eventOptions, usecase.WithEventObserver(m))
+		limitOptions = append(limitOptions, ratelimit.WithObserver(m))
+		cacheOptions = append(cacheOptions, cached.WithObserver(m))
+		options = append(options, httpGateway.WithMetrics(m))
+	}
+	if ttl := cfg.Cache.SensorTTL.Duration; ttl > 0 {
+		repos.cache(ttl, cacheOptions...)
+	}
+	if rl := cfg.RateLimit; rl.Enabled {
+		limiter := ratelimit.NewMemoryLimiter()
+		events := ratelimit.NewScope("events", limiter, limits(rl.Events.Rate, rl.Events.Burst, rl.Events.ByType), limitOptions...)
+		reads := ratelimit.NewScope("reads", limiter, limits(rl.Reads.Rate, rl.Reads.Burst, rl.Reads.Plans), limitOptions...)
+		eventOptions = append(eventOptions, usecase.WithEventRateLimit(events))
+		options = append(options, httpGateway.WithReadRateLimit(reads, rl.Reads.APIKeys))
+	}
+
+	if an := cfg.Anomaly; an.Enabled {
+		var detectorOptions []func(*anomaly.Detector)
+		if an.Alerts {
+			detectorOptions = append(detectorOptions, anomaly.WithAlerter(anomaly.LogAlerter{}))
+		}
+		detector := anomaly.NewDetector(anomalyConfigs(an), detectorOptions...)
+		eventOptions = append(eventOptions, usecase.WithEventAnomalyDetector(detector))
+	}
+	eventOptions = append(eventOptions, usecase.WithEventSeriesLimits(usecase.SeriesLimits{
+		MaxSensors: cfg.Query.MaxSensors,
+		MaxRange:   cfg.Query.MaxRange.Duration,
+		MaxBuckets: cfg.Query.MaxBuckets,
+	}))
+
+	useCases := httpGateway.UseCases{
+		Event:  usecase.NewEvent(repos.event, repos.sensor, eventOptions...),
+		Sensor: usecase.NewSensor(repos.sensor, usecase.WithSensorTypes(sensorTypes)),
+		User:   usecase.NewUser(repos.user, repos.sensorOwner, repos.sensor),
+	}
+
+	checker := health.NewChecker(cfg.Health.Timeout.Duration)
+	for name, check := range repos.checks {
+		checker.Add(name, check)
+	}
+	if cfg.Retention.Events.Duration > 0 {
+		// одна пропущенная итерация допустима, две подряд - задача зависла
+		heartbeat := health.NewHeartbeat(2*cfg.Retention.CheckInterval.Duration + cfg.Health.Timeout.Duration)
+		checker.Add("worker_retention", heartbeat.Check)
+		go worker.NewRetention(useCases.Event, cfg.Retention.Events.Duration, cfg.Retention.CheckInterval.Duration,
+			worker.WithHeartbeat(heartbeat.Beat)).Run(ctx)
+	}
+	options = append(options, httpGateway.WithHealth(checker))
+
+	if cfg.HTTP.TLS.Enabled {
+		tls := cfg.HTTP.TLS
+		options = append(options, httpGateway.WithTLS(tls.CertFile, tls.KeyFile, tls.ReloadInterval.Duration))
+		if tls.ClientCAFile != "" {
+			options = append(options, httpGateway.WithClientCA(tls.ClientCAFile, tlsreload.ClientAuth(tls.ClientAuth)))
+		}
+	}
+
+	legacy := cfg.HTTP.Legacy
+	options = append(options, httpGateway.WithLegacyRoutes(legacy.Enabled, legacy.DeprecatedAt, legacy.Sunset))
+
+	r := httpGateway.NewServer(useCases, options...)
+	if err := r.Run(ctx, cancel); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("server stopped with error", "err", err)
+	}
+}
+
+// limits - лимиты ratelimit из конфигурации: по умолчанию и для классов
+func limits(rate float64, burst int, classes map[string]config.Limit) ratelimit.Limits {
+	l := ratelimit.Limits{
+		Default: ratelimit.Limit{Rate: rate, Burst: burst},
+		Classes: make(map[string]ratelimit.Limit, len(classes)),
+	}
+	for name, c := range classes {
+		l.Classes[name] = ratelimit.Limit{Rate: c.Rate, Burst: c.Burst}
+	}
+	return l
+}
+
+// anomalyConfigs - параметры детектора из конфигурации: общие и для типов датчиков
+func anomalyConfigs(an config.Anomaly) anomaly.Configs {
+	configs := anomaly.Configs{
+		Default: anomaly.Config{Window: an.Window, Threshold: an.Threshold, MaxRate: an.MaxRate, StuckAfter: an.StuckAfter.Duration},
+		ByType:  make(map[string]anomaly.Config, len(an.ByType)),
+	}
+	for name, c := range an.ByType {
+		configs.ByType[name] = anomaly.Config{Window: c.Window, Threshold: c.Threshold, MaxRate: c.MaxRate, StuckAfter: c.StuckAfter.Duration}
+	}
+	return configs
 }
@@ -0,0 +1,225 @@
+// Package anomaly - потоковое обнаружение недостоверных показаний датчиков.
+//
+// Detector держит для каждого потока значений (датчика или канала многоканального датчика)
+// последние значения и проверяет каждое новое тремя способами: робастным z-показателем по медиане
+// и MAD окна последних значений, скоростью изменения относительно последнего значения, прошедшего
+// эту проверку, и залипанием на одном значении. Состояние хранится в памяти процесса: после
+// перезапуска окно набирается заново, а у нескольких реплик окна свои.
+package anomaly
+
+import (
+	"context"
+	"homework/internal/domain"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// MinWindow - сколько значений должно накопиться в окне, прежде чем искать выбросы
+	MinWindow = 5
+	// madScale - приводит MAD к стандартному отклонению для нормального распределения
+	madScale = 1.4826
+)
+
+// Config - параметры проверок потока; нулевое поле отключает свою проверку
+type Config struct {
+	// Window - сколько последних значений учитывать для медианы и MAD, не меньше MinWindow
+	Window int
+	// Threshold - порог робастного z-показателя |x - медиана| / (1.4826 × MAD)
+	Threshold float64
+	// MaxRate - наибольшая правдоподобная скорость изменения, единиц датчика в секунду
+	MaxRate float64
+	// StuckAfter - сколько значение может не меняться
+	StuckAfter time.Duration
+}
+
+func (c Config) disabled() bool {
+	return c.Threshold <= 0 && c.MaxRate <= 0 && c.StuckAfter <= 0
+}
+
+// Configs - параметры по умолчанию и отдельные для типов датчиков
+type Configs struct {
+	Default Config
+	ByType  map[string]Config
+}
+
+// For - параметры типа sensorType или параметры по умолчанию
+func (c Configs) For(sensorType string) Config {
+	if config, ok := c.ByType[sensorType]; ok {
+		return config
+	}
+	return c.Default
+}
+
+// Key - поток значений: датчик или канал многоканального датчика
+type Key struct {
+	SensorID int64
+	Channel  string
+}
+
+// Alert - в потоке появился признак недостоверности, которого не было у предыдущего значения
+type Alert struct {
+	Key
+	Timestamp time.Time
+	Value     float64
+	// Quality - все признаки значения, а не только новые
+	Quality domain.Quality
+}
+
+// Alerter - получатель оповещений о недостоверных значениях
+type Alerter interface {
+	Alert(ctx context.Context, alert Alert)
+}
+
+type Detector struct {
+	configs Configs
+	alerter Alerter
+	now     func() time.Time
+
+	mu      sync.Mutex
+	streams map[Key]*stream
+
+	// idleAfter - поток без значений дольше этого удаляется, чтобы карта не росла за счёт удалённых датчиков
+	idleAfter     time.Duration
+	sweepInterval time.Duration
+	lastSweep     time.Time
+}
+
+type stream struct {
+	// window - последние значения, старые в начале
+	window []float64
+	// last, lastAt - последнее значение без признака QualityRate, от него считается скорость
+	last   float64
+	lastAt time.Time
+	// current, since - текущее значение и время, с которого оно не меняется
+	current float64
+	since   time.Time
+	// quality - признаки предыдущего значения, чтобы оповещать только о новых
+	quality domain.Quality
+	// seen - когда поток получал значение, по часам процесса
+	seen time.Time
+}
+
+func NewDetector(configs Configs, options ...func(*Detector)) *Detector {
+	d := &Detector{
+		configs:       configs,
+		now:           time.Now,
+		streams:       make(map[Key]*stream),
+		idleAfter:     24 * time.Hour,
+		sweepInterval: time.Hour,
+	}
+	for _, o := range options {
+		o(d)
+	}
+	return d
+}
+
+// WithAlerter - сообщать alerter о каждом новом признаке недостоверности в потоке
+func WithAlerter(alerter Alerter) func(*Detector) {
+	return func(d *Detector) {
+		d.alerter = alerter
+	}
+}
+
+// Check - проверяет значение value потока key в момент at по параметрам типа sensorType,
+// запоминает его и возвращает найденные признаки
+func (d *Detector) Check(ctx context.Context, key Key, sensorType string, at time.Time, value float64) domain.Quality {
+	config := d.configs.For(sensorType)
+	if config.disabled() {
+		return 0
+	}
+
+	d.mu.Lock()
+	now := d.now()
+	if now.Sub(d.lastSweep) >= d.sweepInterval {
+		d.sweep(now)
+	}
+	s, ok := d.streams[key]
+	if !ok {
+		s = &stream{last: value, lastAt: at, current: value, since: at}
+		d.streams[key] = s
+	}
+	quality := s.check(config, at, value)
+	raised := quality &^ s.quality
+	s.quality = quality
+	s.seen = now
+	d.mu.Unlock()
+
+	if raised != 0 && d.alerter != nil {
+		d.alerter.Alert(ctx, Alert{Key: key, Timestamp: at, Value: value, Quality: quality})
+	}
+	return quality
+}
+
+// Len - число потоков в памяти
+func (d *Detector) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.streams)
+}
+
+func (d *Detector) sweep(now time.Time) {
+	for key, s := range d.streams {
+		if now.Sub(s.seen) >= d.idleAfter {
+			delete(d.streams, key)
+		}
+	}
+	d.lastSweep = now
+}
+
+func (s *stream) check(config Config, at time.Time, value float64) domain.Quality {
+	var quality domain.Quality
+	if config.Threshold > 0 && len(s.window) >= MinWindow {
+		// при нулевом MAD окно постоянно, и любое отличие было бы выбросом; такие скачки ловит MaxRate
+		if median, mad := medianMAD(s.window); mad > 0 && math.Abs(value-median)/(madScale*mad) > config.Threshold {
+			quality |= domain.QualityOutlier
+		}
+	}
+	// события, пришедшие не по порядку, не участвуют в проверке скорости и залипания
+	inOrder := at.After(s.lastAt)
+	if config.MaxRate > 0 && inOrder {
+		if math.Abs(value-s.last)/at.Sub(s.lastAt).Seconds() > config.MaxRate {
+			quality |= domain.QualityRate
+		}
+	}
+	if value != s.current {
+		s.current, s.since = value, at
+	} else if config.StuckAfter > 0 && at.Sub(s.since) >= config.StuckAfter {
+		quality |= domain.QualityStuck
+	}
+	if inOrder && quality&domain.QualityRate == 0 {
+		// после одиночного скачка скорость считается от значения до него, а не от самого скачка
+		s.last, s.lastAt = value, at
+	}
+
+	if config.Window > 0 {
+		s.window = append(s.window, value)
+		if len(s.window) > config.Window {
+			s.window = s.window[len(s.window)-config.Window:]
+		}
+	}
+	return quality
+}
+
+// medianMAD - медиана значений и медиана абсолютных отклонений от неё
+func medianMAD(values []float64) (float64, float64) {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	m := median(sorted)
+	for i, v := range sorted {
+		sorted[i] = math.Abs(v - m)
+	}
+	slices.Sort(sorted)
+	return m, median(sorted)
+}
+
+// median - медиана отсортированных значений
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
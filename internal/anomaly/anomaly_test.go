@@ -0,0 +1,121 @@
+package anomaly
+
+import (
+	"context"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingAlerter struct {
+	alerts []Alert
+}
+
+func (a *recordingAlerter) Alert(_ context.Context, alert Alert) {
+	a.alerts = append(a.alerts, alert)
+}
+
+func TestDetector_Outlier(t *testing.T) {
+	d := NewDetector(Configs{Default: Config{Window: 10, Threshold: 5}})
+	key := Key{SensorID: 1}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, v := range []float64{21, 21.2, 20.9, 21.1, 21, 20.8, 21.3} {
+		assert.Zero(t, d.Check(context.Background(), key, "adc", start.Add(time.Duration(i)*time.Minute), v), v)
+	}
+	assert.Equal(t, domain.QualityOutlier, d.Check(context.Background(), key, "adc", start.Add(10*time.Minute), 61))
+	assert.Zero(t, d.Check(context.Background(), key, "adc", start.Add(11*time.Minute), 21.4))
+	// у другого канала своё окно
+	assert.Zero(t, d.Check(context.Background(), Key{SensorID: 1, Channel: "humidity"}, "adc", start, 61))
+}
+
+func TestDetector_OutlierNeedsWindow(t *testing.T) {
+	d := NewDetector(Configs{Default: Config{Window: 10, Threshold: 5}})
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, v := range []float64{21, 21.2, 20.9, 100} {
+		assert.Zero(t, d.Check(context.Background(), Key{SensorID: 1}, "adc", start.Add(time.Duration(i)*time.Minute), v), v)
+	}
+}
+
+func TestDetector_Rate(t *testing.T) {
+	d := NewDetector(Configs{Default: Config{MaxRate: 1}})
+	key := Key{SensorID: 1}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Zero(t, d.Check(context.Background(), key, "adc", start, 20))
+	assert.Zero(t, d.Check(context.Background(), key, "adc", start.Add(10*time.Second), 25))
+	// скачок на 40 градусов за секунду
+	assert.Equal(t, domain.QualityRate, d.Check(context.Background(), key, "adc", start.Add(11*time.Second), 65))
+	// возврат после скачка считается от значения до него
+	assert.Zero(t, d.Check(context.Background(), key, "adc", start.Add(12*time.Second), 25.5))
+	// событие не по порядку не проверяется
+	assert.Zero(t, d.Check(context.Background(), key, "adc", start, 100))
+	// настоящий сдвиг: скорость от последнего достоверного значения падает со временем
+	assert.Equal(t, domain.QualityRate, d.Check(context.Background(), key, "adc", start.Add(13*time.Second), 45))
+	assert.Zero(t, d.Check(context.Background(), key, "adc", start.Add(40*time.Second), 45))
+}
+
+func TestDetector_Stuck(t *testing.T) {
+	d := NewDetector(Configs{Default: Config{StuckAfter: time.Hour}})
+	key := Key{SensorID: 1}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Zero(t, d.Check(context.Background(), key, "adc", start, 20))
+	assert.Zero(t, d.Check(context.Background(), key, "adc", start.Add(30*time.Minute), 20))
+	assert.Equal(t, domain.QualityStuck, d.Check(context.Background(), key, "adc", start.Add(time.Hour), 20))
+	assert.Equal(t, domain.QualityStuck, d.Check(context.Background(), key, "adc", start.Add(2*time.Hour), 20))
+	assert.Zero(t, d.Check(context.Background(), key, "adc", start.Add(3*time.Hour), 20.5))
+}
+
+func TestDetector_ByType(t *testing.T) {
+	d := NewDetector(Configs{
+		Default: Config{MaxRate: 1},
+		ByType:  map[string]Config{"co2": {}},
+	})
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Zero(t, d.Check(context.Background(), Key{SensorID: 1}, "co2", start, 400))
+	assert.Zero(t, d.Check(context.Background(), Key{SensorID: 1}, "co2", start.Add(time.Second), 900))
+	assert.Zero(t, d.Len(), "отключённые проверки не заводят поток")
+}
+
+func TestDetector_Alerts(t *testing.T) {
+	alerter := &recordingAlerter{}
+	d := NewDetector(Configs{Default: Config{MaxRate: 1, StuckAfter: time.Minute}}, WithAlerter(alerter))
+	key := Key{SensorID: 1}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	d.Check(context.Background(), key, "adc", start, 20)
+	d.Check(context.Background(), key, "adc", start.Add(time.Minute), 20)
+	d.Check(context.Background(), key, "adc", start.Add(2*time.Minute), 20)
+	d.Check(context.Background(), key, "adc", start.Add(2*time.Minute+time.Second), 80)
+	d.Check(context.Background(), key, "adc", start.Add(2*time.Minute+2*time.Second), 90)
+
+	// залипание - одно оповещение на весь период, скачок - одно на два значения подряд
+	assert.Equal(t, []Alert{
+		{Key: key, Timestamp: start.Add(time.Minute), Value: 20, Quality: domain.QualityStuck},
+		{Key: key, Timestamp: start.Add(2*time.Minute + time.Second), Value: 80, Quality: domain.QualityRate},
+	}, alerter.alerts)
+}
+
+func TestDetector_Sweep(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := NewDetector(Configs{Default: Config{MaxRate: 1}})
+	d.now = func() time.Time { return now }
+
+	d.Check(context.Background(), Key{SensorID: 1}, "adc", now, 20)
+	now = now.Add(23 * time.Hour)
+	d.Check(context.Background(), Key{SensorID: 2}, "adc", now, 20)
+	assert.Equal(t, 2, d.Len())
+
+	now = now.Add(2 * time.Hour)
+	d.Check(context.Background(), Key{SensorID: 2}, "adc", now, 20)
+	assert.Equal(t, 1, d.Len())
+}
+
+func TestQuality_Flags(t *testing.T) {
+	assert.Nil(t, domain.Quality(0).Flags())
+	assert.Equal(t, []string{"outlier", "stuck"}, (domain.QualityOutlier | domain.QualityStuck).Flags())
+}
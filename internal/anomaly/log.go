@@ -0,0 +1,16 @@
+package anomaly
+
+import (
+	"context"
+	"homework/internal/logging"
+)
+
+// LogAlerter - пишет оповещения предупреждениями в лог запроса, в котором пришло значение
+type LogAlerter struct{}
+
+// Alert - реализует Alerter
+func (LogAlerter) Alert(ctx context.Context, alert Alert) {
+	logging.FromContext(ctx).Warn("anomalous reading",
+		"sensor_id", alert.SensorID, "channel", alert.Channel, "timestamp", alert.Timestamp,
+		"value", alert.Value, "quality", alert.Quality.Flags())
+}
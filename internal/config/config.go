@@ -0,0 +1,500 @@
+// Package config - типизированная конфигурация сервера.
+//
+// Значения собираются по слоям: значения по умолчанию, файл (YAML или TOML),
+// переменные окружения и флаги командной строки. Каждый следующий слой
+// перекрывает предыдущий, итог проверяется Validate.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"time"
+
+	"homework/internal/anomaly"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/sensortype"
+	"homework/pkg/tlsreload"
+	"homework/pkg/wal"
+)
+
+const (
+	BackendPostgres = "postgres"
+	BackendSqlite   = "sqlite"
+	BackendInmemory = "inmemory"
+)
+
+// redacted - то, чем заменяются секреты в Redacted
+const redacted = "xxxxx"
+
+// dsnPassword - пароль в строке подключения вида "host=... password=..."
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// Config - конфигурация сервера
+type Config struct {
+	HTTP      HTTP      `yaml:"http" toml:"http" json:"http"`
+	Storage   Storage   `yaml:"storage" toml:"storage" json:"storage"`
+	WebSocket WebSocket `yaml:"websocket" toml:"websocket" json:"websocket"`
+	Retention Retention `yaml:"retention" toml:"retention" json:"retention"`
+	Cache     Cache     `yaml:"cache" toml:"cache" json:"cache"`
+	Metrics   Metrics   `yaml:"metrics" toml:"metrics" json:"metrics"`
+	Tracing   Tracing   `yaml:"tracing" toml:"tracing" json:"tracing"`
+	Log       Log       `yaml:"log" toml:"log" json:"log"`
+	Health    Health    `yaml:"health" toml:"health" json:"health"`
+	Admin     Admin     `yaml:"admin" toml:"admin" json:"admin"`
+	RateLimit RateLimit `yaml:"rate_limit" toml:"rate_limit" json:"rate_limit"`
+	Anomaly   Anomaly   `yaml:"anomaly" toml:"anomaly" json:"anomaly"`
+	Query     Query     `yaml:"query" toml:"query" json:"query"`
+	// SensorTypes - типы датчиков сверх встроенных cc и adc по имени, задаются только в файле
+	SensorTypes map[string]SensorType `yaml:"sensor_types" toml:"sensor_types" json:"sensor_types"`
+}
+
+// HTTP - настройки HTTP-сервера
+type HTTP struct {
+	Host            string   `yaml:"host" toml:"host" json:"host" env:"SMART_HOME_HTTP_HOST,HTTP_HOST" usage:"адрес, на котором слушает сервер"`
+	Port            uint16   `yaml:"port" toml:"port" json:"port" env:"SMART_HOME_HTTP_PORT,HTTP_PORT" usage:"порт HTTP-сервера"`
+	ReadTimeout     Duration `yaml:"read_timeout" toml:"read_timeout" json:"read_timeout" env:"SMART_HOME_HTTP_READ_TIMEOUT" usage:"таймаут чтения запроса"`
+	WriteTimeout    Duration `yaml:"write_timeout" toml:"write_timeout" json:"write_timeout" env:"SMART_HOME_HTTP_WRITE_TIMEOUT" usage:"таймаут записи ответа"`
+	IdleTimeout     Duration `yaml:"idle_timeout" toml:"idle_timeout" json:"idle_timeout" env:"SMART_HOME_HTTP_IDLE_TIMEOUT" usage:"таймаут простаивающего keep-alive соединения"`
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" json:"shutdown_timeout" env:"SMART_HOME_HTTP_SHUTDOWN_TIMEOUT" usage:"сколько ждать завершения запросов при остановке"`
+	ShutdownDelay   Duration `yaml:"shutdown_delay" toml:"shutdown_delay" json:"shutdown_delay" env:"SMART_HOME_HTTP_SHUTDOWN_DELAY" usage:"сколько отвечать ошибкой на /readyz перед остановкой"`
+	TLS             TLS      `yaml:"tls" toml:"tls" json:"tls"`
+	Legacy          Legacy   `yaml:"legacy" toml:"legacy" json:"legacy"`
+	// TrustedProxies - адреса и подсети обратных прокси, от которых принимается X-Forwarded-For;
+	// пустой список - адрес клиента всегда берётся из соединения
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies" json:"trusted_proxies" env:"SMART_HOME_HTTP_TRUSTED_PROXIES" usage:"адреса и подсети обратных прокси через запятую, которым доверяется X-Forwarded-For"`
+}
+
+// Legacy - пути API без префикса версии, оставленные для совместимости
+type Legacy struct {
+	Enabled      bool      `yaml:"enabled" toml:"enabled" json:"enabled" env:"SMART_HOME_HTTP_LEGACY_ENABLED" usage:"обслуживать пути API без префикса /v1"`
+	DeprecatedAt time.Time `yaml:"deprecated_at" toml:"deprecated_at" json:"deprecated_at" env:"SMART_HOME_HTTP_LEGACY_DEPRECATED_AT" usage:"дата устаревания путей без версии для заголовка Deprecation (RFC 3339)"`
+	// Sunset - когда пути без версии будут отключены, нулевое - дата не назначена
+	Sunset time.Time `yaml:"sunset" toml:"sunset" json:"sunset" env:"SMART_HOME_HTTP_LEGACY_SUNSET" usage:"дата отключения путей без версии для заголовка Sunset (RFC 3339)"`
+}
+
+// TLS - настройки TLS для HTTP-сервера
+type TLS struct {
+	Enabled        bool     `yaml:"enabled" toml:"enabled" json:"enabled" env:"SMART_HOME_TLS_ENABLED" usage:"включить TLS"`
+	CertFile       string   `yaml:"cert_file" toml:"cert_file" json:"cert_file" env:"SMART_HOME_TLS_CERT_FILE" usage:"путь к сертификату сервера"`
+	KeyFile        string   `yaml:"key_file" toml:"key_file" json:"key_file" env:"SMART_HOME_TLS_KEY_FILE" usage:"путь к ключу сервера"`
+	ReloadInterval Duration `yaml:"reload_interval" toml:"reload_interval" json:"reload_interval" env:"SMART_HOME_TLS_RELOAD_INTERVAL" usage:"как часто проверять файлы сертификатов на изменения"`
+	// ClientCAFile - корневые сертификаты устройств, непустой включает mTLS
+	ClientCAFile string `yaml:"client_ca_file" toml:"client_ca_file" json:"client_ca_file" env:"SMART_HOME_TLS_CLIENT_CA_FILE" usage:"корневые сертификаты клиентов для mTLS"`
+	ClientAuth   string `yaml:"client_auth" toml:"client_auth" json:"client_auth" env:"SMART_HOME_TLS_CLIENT_AUTH" usage:"проверка клиентских сертификатов: optional или require"`
+}
+
+// Storage - выбор и настройки хранилища
+type Storage struct {
+	Backend  string   `yaml:"backend" toml:"backend" json:"backend" env:"SMART_HOME_STORAGE_BACKEND,STORAGE_BACKEND" usage:"хранилище: postgres, sqlite или inmemory"`
+	Postgres Postgres `yaml:"postgres" toml:"postgres" json:"postgres"`
+	Sqlite   Sqlite   `yaml:"sqlite" toml:"sqlite" json:"sqlite"`
+	Inmemory Inmemory `yaml:"inmemory" toml:"inmemory" json:"inmemory"`
+}
+
+// Postgres - настройки подключения и пула pgxpool
+type Postgres struct {
+	URL             string   `yaml:"url" toml:"url" json:"url" env:"SMART_HOME_DATABASE_URL,DATABASE_URL" usage:"строка подключения к postgres"`
+	MaxConns        int32    `yaml:"max_conns" toml:"max_conns" json:"max_conns" env:"SMART_HOME_POSTGRES_MAX_CONNS" usage:"максимальный размер пула, 0 - по умолчанию pgxpool"`
+	MinConns        int32    `yaml:"min_conns" toml:"min_conns" json:"min_conns" env:"SMART_HOME_POSTGRES_MIN_CONNS" usage:"минимальный размер пула"`
+	MaxConnLifetime Duration `yaml:"max_conn_lifetime" toml:"max_conn_lifetime" json:"max_conn_lifetime" env:"SMART_HOME_POSTGRES_MAX_CONN_LIFETIME" usage:"время жизни соединения, 0 - по умолчанию pgxpool"`
+	MaxConnIdleTime Duration `yaml:"max_conn_idle_time" toml:"max_conn_idle_time" json:"max_conn_idle_time" env:"SMART_HOME_POSTGRES_MAX_CONN_IDLE_TIME" usage:"время простоя соединения, 0 - по умолчанию pgxpool"`
+	ConnectTimeout  Duration `yaml:"connect_timeout" toml:"connect_timeout" json:"connect_timeout" env:"SMART_HOME_POSTGRES_CONNECT_TIMEOUT" usage:"таймаут подключения к базе"`
+}
+
+// Sqlite - настройки sqlite
+type Sqlite struct {
+	Path string `yaml:"path" toml:"path" json:"path" env:"SMART_HOME_SQLITE_PATH,SQLITE_PATH" usage:"путь к файлу базы sqlite"`
+}
+
+// Inmemory - настройки inmemory-хранилища
+type Inmemory struct {
+	// WALDir - каталог журнала, пустой отключает журнал
+	WALDir           string   `yaml:"wal_dir" toml:"wal_dir" json:"wal_dir" env:"SMART_HOME_INMEMORY_WAL_DIR,INMEMORY_WAL_DIR" usage:"каталог журнала inmemory-хранилища"`
+	WALSync          string   `yaml:"wal_sync" toml:"wal_sync" json:"wal_sync" env:"SMART_HOME_INMEMORY_WAL_SYNC,INMEMORY_WAL_SYNC" usage:"политика fsync журнала: always, interval или never"`
+	SnapshotInterval Duration `yaml:"snapshot_interval" toml:"snapshot_interval" json:"snapshot_interval" env:"SMART_HOME_INMEMORY_SNAPSHOT_INTERVAL" usage:"период снапшотов, 0 отключает"`
+	SnapshotSize     int64    `yaml:"snapshot_size" toml:"snapshot_size" json:"snapshot_size" env:"SMART_HOME_INMEMORY_SNAPSHOT_SIZE" usage:"размер журнала в байтах, после которого делается снапшот"`
+}
+
+// WebSocket - настройки подписки на события датчика
+type WebSocket struct {
+	PollInterval Duration `yaml:"poll_interval" toml:"poll_interval" json:"poll_interval" env:"SMART_HOME_WEBSOCKET_POLL_INTERVAL" usage:"как часто проверять новые события"`
+	WriteTimeout Duration `yaml:"write_timeout" toml:"write_timeout" json:"write_timeout" env:"SMART_HOME_WEBSOCKET_WRITE_TIMEOUT" usage:"таймаут отправки сообщения клиенту"`
+}
+
+// Retention - срок хранения событий
+type Retention struct {
+	// Events - сколько хранить события, 0 - хранить всегда
+	Events        Duration `yaml:"events" toml:"events" json:"events" env:"SMART_HOME_RETENTION_EVENTS" usage:"срок хранения событий, 0 - хранить всегда"`
+	CheckInterval Duration `yaml:"check_interval" toml:"check_interval" json:"check_interval" env:"SMART_HOME_RETENTION_CHECK_INTERVAL" usage:"как часто удалять устаревшие события"`
+}
+
+// Cache - настройки кэша поверх хранилища
+type Cache struct {
+	// SensorTTL - сколько хранить датчик, найденный по серийному номеру, 0 - не кэшировать
+	SensorTTL Duration `yaml:"sensor_ttl" toml:"sensor_ttl" json:"sensor_ttl" env:"SMART_HOME_CACHE_SENSOR_TTL" usage:"время жизни датчика в кэше поиска по серийному номеру, 0 - без кэша"`
+}
+
+// Metrics - настройки метрик Prometheus
+type Metrics struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" json:"enabled" env:"SMART_HOME_METRICS_ENABLED" usage:"отдавать метрики на /metrics"`
+}
+
+// Tracing - настройки OpenTelemetry-трассировки
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" toml:"exporter" json:"exporter" env:"SMART_HOME_TRACING_EXPORTER" usage:"экспорт спанов: none, stdout или otlp"`
+	Endpoint    string  `yaml:"endpoint" toml:"endpoint" json:"endpoint" env:"SMART_HOME_TRACING_ENDPOINT" usage:"адрес OTLP/HTTP коллектора host:port"`
+	Insecure    bool    `yaml:"insecure" toml:"insecure" json:"insecure" env:"SMART_HOME_TRACING_INSECURE" usage:"отправлять спаны в коллектор без TLS"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" json:"sample_ratio" env:"SMART_HOME_TRACING_SAMPLE_RATIO" usage:"доля сэмплируемых трасс от 0 до 1"`
+	ServiceName string  `yaml:"service_name" toml:"service_name" json:"service_name" env:"SMART_HOME_TRACING_SERVICE_NAME,OTEL_SERVICE_NAME" usage:"имя сервиса в трассах"`
+}
+
+// Log - настройки логирования
+type Log struct {
+	Format string `yaml:"format" toml:"format" json:"format" env:"SMART_HOME_LOG_FORMAT" usage:"формат логов: text или json"`
+	Level  string `yaml:"level" toml:"level" json:"level" env:"SMART_HOME_LOG_LEVEL" usage:"минимальный уровень: debug, info, warn или error"`
+}
+
+// Health - настройки проверок готовности
+type Health struct {
+	Timeout Duration `yaml:"timeout" toml:"timeout" json:"timeout" env:"SMART_HOME_HEALTH_TIMEOUT" usage:"таймаут проверок /readyz"`
+}
+
+// Admin - настройки служебных эндпоинтов
+type Admin struct {
+	// Token - bearer-токен для /admin, пустой оставляет эндпоинты открытыми
+	Token string `yaml:"token" toml:"token" json:"token" env:"SMART_HOME_ADMIN_TOKEN" usage:"bearer-токен для служебных эндпоинтов"`
+}
+
+// RateLimit - ограничение частоты запросов корзиной токенов
+type RateLimit struct {
+	Enabled bool           `yaml:"enabled" toml:"enabled" json:"enabled" env:"SMART_HOME_RATE_LIMIT_ENABLED" usage:"ограничивать частоту запросов"`
+	Events  EventRateLimit `yaml:"events" toml:"events" json:"events"`
+	Reads   ReadRateLimit  `yaml:"reads" toml:"reads" json:"reads"`
+}
+
+// Limit - корзина токенов: rate запросов в секунду, не больше burst подряд; нулевой rate снимает ограничение
+type Limit struct {
+	Rate  float64 `yaml:"rate" toml:"rate" json:"rate"`
+	Burst int     `yaml:"burst" toml:"burst" json:"burst"`
+}
+
+// EventRateLimit - лимит приёма событий от одного датчика
+type EventRateLimit struct {
+	Rate  float64 `yaml:"rate" toml:"rate" json:"rate" env:"SMART_HOME_RATE_LIMIT_EVENTS_RATE" usage:"событий в секунду от одного датчика, 0 - без ограничения"`
+	Burst int     `yaml:"burst" toml:"burst" json:"burst" env:"SMART_HOME_RATE_LIMIT_EVENTS_BURST" usage:"сколько событий датчик может прислать подряд"`
+	// ByType - отдельные лимиты для типов датчиков, задаются только в файле
+	ByType map[string]Limit `yaml:"by_type" toml:"by_type" json:"by_type"`
+}
+
+// ReadRateLimit - лимит чтений для одного клиента
+type ReadRateLimit struct {
+	Rate  float64 `yaml:"rate" toml:"rate" json:"rate" env:"SMART_HOME_RATE_LIMIT_READS_RATE" usage:"чтений в секунду от одного клиента без API-ключа, 0 - без ограничения"`
+	Burst int     `yaml:"burst" toml:"burst" json:"burst" env:"SMART_HOME_RATE_LIMIT_READS_BURST" usage:"сколько чтений клиент может сделать подряд"`
+	// Plans - лимиты тарифов, задаются только в файле
+	Plans map[string]Limit `yaml:"plans" toml:"plans" json:"plans"`
+	// APIKeys - тариф для каждого API-ключа из заголовка X-API-Key, задаются только в файле
+	APIKeys map[string]string `yaml:"api_keys" toml:"api_keys" json:"api_keys"`
+}
+
+// Anomaly - пометка недостоверных значений при приёме событий. Нулевой параметр отключает свою проверку
+type Anomaly struct {
+	Enabled    bool     `yaml:"enabled" toml:"enabled" json:"enabled" env:"SMART_HOME_ANOMALY_ENABLED" usage:"помечать недостоверные значения событий"`
+	Window     int      `yaml:"window" toml:"window" json:"window" env:"SMART_HOME_ANOMALY_WINDOW" usage:"сколько последних значений датчика учитывать при поиске выбросов"`
+	Threshold  float64  `yaml:"threshold" toml:"threshold" json:"threshold" env:"SMART_HOME_ANOMALY_THRESHOLD" usage:"порог робастного z-показателя выброса, 0 - не искать выбросы"`
+	MaxRate    float64  `yaml:"max_rate" toml:"max_rate" json:"max_rate" env:"SMART_HOME_ANOMALY_MAX_RATE" usage:"наибольшая скорость изменения значения в единицах датчика в секунду, 0 - без проверки"`
+	StuckAfter Duration `yaml:"stuck_after" toml:"stuck_after" json:"stuck_after" env:"SMART_HOME_ANOMALY_STUCK_AFTER" usage:"сколько значение может не меняться, 0 - без проверки"`
+	Alerts     bool     `yaml:"alerts" toml:"alerts" json:"alerts" env:"SMART_HOME_ANOMALY_ALERTS" usage:"писать предупреждение в лог, когда у датчика появляется признак недостоверности"`
+	// ByType - параметры для типов датчиков, целиком заменяют общие; задаются только в файле
+	ByType map[string]AnomalyCheck `yaml:"by_type" toml:"by_type" json:"by_type"`
+}
+
+// AnomalyCheck - параметры проверок для типа датчиков
+type AnomalyCheck struct {
+	Window     int      `yaml:"window" toml:"window" json:"window"`
+	Threshold  float64  `yaml:"threshold" toml:"threshold" json:"threshold"`
+	MaxRate    float64  `yaml:"max_rate" toml:"max_rate" json:"max_rate"`
+	StuckAfter Duration `yaml:"stuck_after" toml:"stuck_after" json:"stuck_after"`
+}
+
+// Query - ограничения запросов POST /query, сравнивающих несколько датчиков; 0 снимает ограничение
+type Query struct {
+	MaxSensors int      `yaml:"max_sensors" toml:"max_sensors" json:"max_sensors" env:"SMART_HOME_QUERY_MAX_SENSORS" usage:"сколько датчиков можно сравнить одним запросом, 0 - без ограничения"`
+	MaxRange   Duration `yaml:"max_range" toml:"max_range" json:"max_range" env:"SMART_HOME_QUERY_MAX_RANGE" usage:"наибольшая длина периода запроса, 0 - без ограничения"`
+	MaxBuckets int      `yaml:"max_buckets" toml:"max_buckets" json:"max_buckets" env:"SMART_HOME_QUERY_MAX_BUCKETS" usage:"сколько интервалов может быть в ряду, 0 - без ограничения"`
+}
+
+// SensorType - тип датчика, объявленный в конфигурации
+type SensorType struct {
+	Description string `yaml:"description" toml:"description" json:"description"`
+	// Kind - вид значений: binary, integer или number
+	Kind string `yaml:"kind" toml:"kind" json:"kind"`
+	// Min, Max - допустимый диапазон значений, отсутствующая граница не ограничивает
+	Min *float64 `yaml:"min" toml:"min" json:"min,omitempty"`
+	Max *float64 `yaml:"max" toml:"max" json:"max,omitempty"`
+	// SerialNumber - регулярное выражение для серийного номера, пустое - десять цифр
+	SerialNumber   string   `yaml:"serial_number" toml:"serial_number" json:"serial_number"`
+	ReportInterval Duration `yaml:"report_interval" toml:"report_interval" json:"report_interval"`
+	// Aggregation - сведение значений за интервал: mean, last, min, max или sum
+	Aggregation string `yaml:"aggregation" toml:"aggregation" json:"aggregation"`
+}
+
+// SensorTypeRegistry - реестр из встроенных типов датчиков и объявленных в SensorTypes
+func (c *Config) SensorTypeRegistry() (*sensortype.Registry, error) {
+	types := sensortype.Builtin()
+	for _, name := range slices.Sorted(maps.Keys(c.SensorTypes)) {
+		t := c.SensorTypes[name]
+		var serialNumber *regexp.Regexp
+		if t.SerialNumber != "" {
+			var err error
+			if serialNumber, err = regexp.Compile(t.SerialNumber); err != nil {
+				return nil, fmt.Errorf("sensor_types.%s.serial_number: %w", name, err)
+			}
+		}
+		types = append(types, sensortype.Type{
+			Name:           domain.SensorType(name),
+			Description:    t.Description,
+			Kind:           sensortype.Kind(t.Kind),
+			Min:            t.Min,
+			Max:            t.Max,
+			SerialNumber:   serialNumber,
+			ReportInterval: t.ReportInterval.Duration,
+			Aggregation:    sensortype.Aggregation(t.Aggregation),
+		})
+	}
+	return sensortype.NewRegistry(types...)
+}
+
+// Default - конфигурация по умолчанию
+func Default() Config {
+	return Config{
+		HTTP: HTTP{
+			Host:            "localhost",
+			Port:            8080,
+			ReadTimeout:     Duration{10 * time.Second},
+			WriteTimeout:    Duration{10 * time.Second},
+			IdleTimeout:     Duration{time.Minute},
+			ShutdownTimeout: Duration{10 * time.Second},
+			TLS: TLS{
+				ReloadInterval: Duration{10 * time.Second},
+				ClientAuth:     string(tlsreload.ClientAuthOptional),
+			},
+			Legacy: Legacy{
+				Enabled:      true,
+				DeprecatedAt: time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		Storage: Storage{
+			Backend: BackendPostgres,
+			Postgres: Postgres{
+				ConnectTimeout: Duration{5 * time.Second},
+			},
+			Sqlite: Sqlite{Path: "smart-home.db"},
+			Inmemory: Inmemory{
+				WALSync:          "always",
+				SnapshotInterval: Duration{5 * time.Minute},
+				SnapshotSize:     64 << 20,
+			},
+		},
+		WebSocket: WebSocket{
+			PollInterval: Duration{500 * time.Millisecond},
+			WriteTimeout: Duration{5 * time.Second},
+		},
+		Retention: Retention{
+			CheckInterval: Duration{time.Hour},
+		},
+		Cache:   Cache{SensorTTL: Duration{time.Minute}},
+		Metrics: Metrics{Enabled: true},
+		Tracing: Tracing{
+			Exporter:    "none",
+			SampleRatio: 1,
+			ServiceName: "smart-home",
+		},
+		Health: Health{Timeout: Duration{2 * time.Second}},
+		RateLimit: RateLimit{
+			Events: EventRateLimit{Rate: 10, Burst: 20},
+			Reads:  ReadRateLimit{Rate: 50, Burst: 100},
+		},
+		Anomaly: Anomaly{
+			Enabled:    true,
+			Window:     30,
+			Threshold:  6,
+			StuckAfter: Duration{6 * time.Hour},
+		},
+		Query: Query{
+			MaxSensors: 20,
+			MaxRange:   Duration{366 * 24 * time.Hour},
+			MaxBuckets: 1000,
+		},
+		Log: Log{
+			Format: logging.FormatText,
+			Level:  "info",
+		},
+	}
+}
+
+// Validate - проверяет конфигурацию и возвращает все найденные ошибки сразу
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.HTTP.Port != 0, "http.port must be set")
+	check(c.HTTP.ReadTimeout.Duration >= 0, "http.read_timeout must not be negative")
+	check(c.HTTP.WriteTimeout.Duration >= 0, "http.write_timeout must not be negative")
+	check(c.HTTP.IdleTimeout.Duration >= 0, "http.idle_timeout must not be negative")
+	check(c.HTTP.ShutdownTimeout.Duration > 0, "http.shutdown_timeout must be positive")
+	check(c.HTTP.ShutdownDelay.Duration >= 0, "http.shutdown_delay must not be negative")
+	if c.HTTP.TLS.Enabled {
+		check(c.HTTP.TLS.CertFile != "", "http.tls.cert_file is required when tls is enabled")
+		check(c.HTTP.TLS.KeyFile != "", "http.tls.key_file is required when tls is enabled")
+		check(c.HTTP.TLS.ReloadInterval.Duration > 0, "http.tls.reload_interval must be positive")
+	}
+	if c.HTTP.TLS.ClientCAFile != "" {
+		check(c.HTTP.TLS.Enabled, "http.tls.client_ca_file requires tls to be enabled")
+		auth, err := tlsreload.ParseClientAuth(c.HTTP.TLS.ClientAuth)
+		check(err == nil, "http.tls.client_auth: %v", err)
+		check(err != nil || auth != tlsreload.ClientAuthNone, "http.tls.client_auth must be optional or require when client_ca_file is set")
+	}
+	for _, proxy := range c.HTTP.TrustedProxies {
+		_, _, err := net.ParseCIDR(proxy)
+		check(err == nil || net.ParseIP(proxy) != nil, "http.trusted_proxies: %q is neither an address nor a subnet", proxy)
+	}
+	if c.HTTP.Legacy.Enabled && !c.HTTP.Legacy.Sunset.IsZero() {
+		check(c.HTTP.Legacy.Sunset.After(c.HTTP.Legacy.DeprecatedAt), "http.legacy.sunset must be after http.legacy.deprecated_at")
+	}
+
+	switch c.Storage.Backend {
+	case BackendPostgres:
+		check(c.Storage.Postgres.URL != "", "storage.postgres.url is required for postgres backend")
+	case BackendSqlite:
+		check(c.Storage.Sqlite.Path != "", "storage.sqlite.path is required for sqlite backend")
+	case BackendInmemory:
+	default:
+		check(false, "storage.backend must be one of %s, %s, %s, got %q",
+			BackendPostgres, BackendSqlite, BackendInmemory, c.Storage.Backend)
+	}
+	pg := c.Storage.Postgres
+	check(pg.MaxConns >= 0, "storage.postgres.max_conns must not be negative")
+	check(pg.MinConns >= 0, "storage.postgres.min_conns must not be negative")
+	check(pg.MaxConns == 0 || pg.MinConns <= pg.MaxConns, "storage.postgres.min_conns must not exceed max_conns")
+	check(pg.MaxConnLifetime.Duration >= 0, "storage.postgres.max_conn_lifetime must not be negative")
+	check(pg.MaxConnIdleTime.Duration >= 0, "storage.postgres.max_conn_idle_time must not be negative")
+	check(pg.ConnectTimeout.Duration >= 0, "storage.postgres.connect_timeout must not be negative")
+
+	_, err := wal.ParseSyncPolicy(c.Storage.Inmemory.WALSync)
+	check(err == nil, "storage.inmemory.wal_sync: %v", err)
+	check(c.Storage.Inmemory.SnapshotInterval.Duration >= 0, "storage.inmemory.snapshot_interval must not be negative")
+	check(c.Storage.Inmemory.SnapshotSize >= 0, "storage.inmemory.snapshot_size must not be negative")
+
+	check(c.WebSocket.PollInterval.Duration > 0, "websocket.poll_interval must be positive")
+	check(c.WebSocket.WriteTimeout.Duration > 0, "websocket.write_timeout must be positive")
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		check(false, "tracing.exporter must be one of none, stdout, otlp, got %q", c.Tracing.Exporter)
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+	check(c.Tracing.Exporter == "none" || c.Tracing.ServiceName != "", "tracing.service_name is required when tracing is enabled")
+
+	check(c.Health.Timeout.Duration > 0, "health.timeout must be positive")
+
+	check(c.Log.Format == logging.FormatText || c.Log.Format == logging.FormatJSON,
+		"log.format must be one of text, json, got %q", c.Log.Format)
+	_, err = logging.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level: %v", err)
+
+	checkLimit := func(name string, l Limit) {
+		check(l.Rate >= 0, "%s.rate must not be negative", name)
+		check(l.Rate == 0 || l.Burst >= 1, "%s.burst must be at least 1 when rate is set", name)
+	}
+	types, err := c.SensorTypeRegistry()
+	check(err == nil, "sensor_types: %v", err)
+	events, reads := c.RateLimit.Events, c.RateLimit.Reads
+	checkLimit("rate_limit.events", Limit{events.Rate, events.Burst})
+	for name, l := range events.ByType {
+		if types != nil {
+			_, ok := types.Lookup(domain.SensorType(name))
+			check(ok, "rate_limit.events.by_type: unknown sensor type %q", name)
+		}
+		checkLimit("rate_limit.events.by_type."+name, l)
+	}
+	checkLimit("rate_limit.reads", Limit{reads.Rate, reads.Burst})
+	for name, l := range reads.Plans {
+		checkLimit("rate_limit.reads.plans."+name, l)
+	}
+	for _, plan := range reads.APIKeys {
+		_, ok := reads.Plans[plan]
+		check(ok, "rate_limit.reads.api_keys: unknown plan %q", plan)
+	}
+
+	checkAnomaly := func(name string, a AnomalyCheck) {
+		check(a.Window >= 0, "%s.window must not be negative", name)
+		check(a.Threshold >= 0, "%s.threshold must not be negative", name)
+		check(a.Threshold == 0 || a.Window >= anomaly.MinWindow, "%s.window must be at least %d when threshold is set", name, anomaly.MinWindow)
+		check(a.MaxRate >= 0, "%s.max_rate must not be negative", name)
+		check(a.StuckAfter.Duration >= 0, "%s.stuck_after must not be negative", name)
+	}
+	an := c.Anomaly
+	checkAnomaly("anomaly", AnomalyCheck{an.Window, an.Threshold, an.MaxRate, an.StuckAfter})
+	for name, a := range an.ByType {
+		if types != nil {
+			_, ok := types.Lookup(domain.SensorType(name))
+			check(ok, "anomaly.by_type: unknown sensor type %q", name)
+		}
+		checkAnomaly("anomaly.by_type."+name, a)
+	}
+
+	check(c.Query.MaxSensors >= 0, "query.max_sensors must not be negative")
+	check(c.Query.MaxRange.Duration >= 0, "query.max_range must not be negative")
+	check(c.Query.MaxBuckets >= 0, "query.max_buckets must not be negative")
+
+	check(c.Retention.Events.Duration >= 0, "retention.events must not be negative")
+	if c.Retention.Events.Duration > 0 {
+		check(c.Retention.CheckInterval.Duration > 0, "retention.check_interval must be positive when retention is enabled")
+	}
+	check(c.Cache.SensorTTL.Duration >= 0, "cache.sensor_ttl must not be negative")
+
+	return errors.Join(errs...)
+}
+
+// Redacted - копия конфигурации, безопасная для показа: пароли и токены скрыты
+func (c Config) Redacted() Config {
+	c.Storage.Postgres.URL = redactDSN(c.Storage.Postgres.URL)
+	if c.Admin.Token != "" {
+		c.Admin.Token = redacted
+	}
+	if keys := c.RateLimit.Reads.APIKeys; len(keys) > 0 {
+		// сами ключи - секреты, показываем только их тарифы
+		c.RateLimit.Reads.APIKeys = make(map[string]string, len(keys))
+		for i, key := range slices.Sorted(maps.Keys(keys)) {
+			c.RateLimit.Reads.APIKeys[fmt.Sprintf("%s-%d", redacted, i+1)] = keys[key]
+		}
+	}
+	return c
+}
+
+// redactDSN - скрывает пароль в строке подключения postgres, URL или key=value
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+		q := u.Query()
+		if q.Has("password") {
+			q.Set("password", redacted)
+			u.RawQuery = q.Encode()
+		}
+		return u.String()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+redacted)
+}
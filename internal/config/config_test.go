@@ -0,0 +1,408 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+const yamlConfig = `
+http:
+  host: 0.0.0.0
+  port: 9090
+  read_timeout: 3s
+storage:
+  backend: postgres
+  postgres:
+    url: postgres://user:secret@db:5432/smart_home
+    max_conns: 20
+    min_conns: 2
+    max_conn_lifetime: 30m
+websocket:
+  poll_interval: 1s
+retention:
+  events: 720h
+  check_interval: 10m
+`
+
+const tomlConfig = `
+[http]
+host = "0.0.0.0"
+port = 9090
+read_timeout = "3s"
+
+[storage]
+backend = "postgres"
+
+[storage.postgres]
+url = "postgres://user:secret@db:5432/smart_home"
+max_conns = 20
+min_conns = 2
+max_conn_lifetime = "30m"
+
+[websocket]
+poll_interval = "1s"
+
+[retention]
+events = "720h"
+check_interval = "10m"
+`
+
+func TestLoad_File(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"yaml", "config.yaml", yamlConfig},
+		{"toml", "config.toml", tomlConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, tt.file, tt.content)
+
+			cfg, err := Load([]string{"-config", path}, env(nil))
+			require.NoError(t, err)
+
+			assert.Equal(t, "0.0.0.0", cfg.HTTP.Host)
+			assert.Equal(t, uint16(9090), cfg.HTTP.Port)
+			assert.Equal(t, 3*time.Second, cfg.HTTP.ReadTimeout.Duration)
+			assert.Equal(t, int32(20), cfg.Storage.Postgres.MaxConns)
+			assert.Equal(t, int32(2), cfg.Storage.Postgres.MinConns)
+			assert.Equal(t, 30*time.Minute, cfg.Storage.Postgres.MaxConnLifetime.Duration)
+			assert.Equal(t, time.Second, cfg.WebSocket.PollInterval.Duration)
+			assert.Equal(t, 720*time.Hour, cfg.Retention.Events.Duration)
+			assert.Equal(t, 10*time.Minute, cfg.Retention.CheckInterval.Duration)
+			// то, чего нет в файле, остаётся по умолчанию
+			assert.Equal(t, Default().HTTP.WriteTimeout, cfg.HTTP.WriteTimeout)
+			assert.Equal(t, Default().WebSocket.WriteTimeout, cfg.WebSocket.WriteTimeout)
+		})
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", yamlConfig)
+
+	t.Run("env overrides file", func(t *testing.T) {
+		cfg, err := Load(nil, env(map[string]string{
+			"SMART_HOME_CONFIG":             path,
+			"HTTP_PORT":                     "7070",
+			"SMART_HOME_POSTGRES_MAX_CONNS": "5",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, uint16(7070), cfg.HTTP.Port)
+		assert.Equal(t, int32(5), cfg.Storage.Postgres.MaxConns)
+		assert.Equal(t, "0.0.0.0", cfg.HTTP.Host)
+	})
+
+	t.Run("prefixed env wins over legacy", func(t *testing.T) {
+		cfg, err := Load(nil, env(map[string]string{
+			"DATABASE_URL":            "postgres://legacy",
+			"SMART_HOME_DATABASE_URL": "postgres://prefixed",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "postgres://prefixed", cfg.Storage.Postgres.URL)
+	})
+
+	t.Run("empty env is ignored", func(t *testing.T) {
+		cfg, err := Load(nil, env(map[string]string{
+			"DATABASE_URL": "postgres://db",
+			"HTTP_HOST":    "",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "localhost", cfg.HTTP.Host)
+	})
+
+	t.Run("legacy dates", func(t *testing.T) {
+		cfg, err := Load([]string{"-http.legacy.sunset", "2027-04-01T00:00:00Z"},
+			env(map[string]string{"DATABASE_URL": "postgres://db", "SMART_HOME_HTTP_LEGACY_DEPRECATED_AT": "2026-11-01T00:00:00Z"}))
+		require.NoError(t, err)
+		assert.True(t, cfg.HTTP.Legacy.Enabled)
+		assert.Equal(t, time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC), cfg.HTTP.Legacy.DeprecatedAt)
+		assert.Equal(t, time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC), cfg.HTTP.Legacy.Sunset)
+	})
+
+	t.Run("trusted proxies", func(t *testing.T) {
+		cfg, err := Load(nil, env(map[string]string{
+			"DATABASE_URL":                    "postgres://db",
+			"SMART_HOME_HTTP_TRUSTED_PROXIES": "10.0.0.1, 192.168.0.0/16,",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10.0.0.1", "192.168.0.0/16"}, cfg.HTTP.TrustedProxies)
+	})
+
+	t.Run("flags override env", func(t *testing.T) {
+		cfg, err := Load([]string{"-config", path, "-http.port", "6060", "-storage.backend", "inmemory"},
+			env(map[string]string{"HTTP_PORT": "7070", "STORAGE_BACKEND": "sqlite"}))
+		require.NoError(t, err)
+		assert.Equal(t, uint16(6060), cfg.HTTP.Port)
+		assert.Equal(t, BackendInmemory, cfg.Storage.Backend)
+	})
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+	}{
+		{"invalid port in env", nil, map[string]string{"DATABASE_URL": "postgres://db", "HTTP_PORT": "http"}},
+		{"port out of range", nil, map[string]string{"DATABASE_URL": "postgres://db", "HTTP_PORT": "70000"}},
+		{"invalid duration in flag", []string{"-websocket.poll_interval", "often"}, map[string]string{"DATABASE_URL": "postgres://db"}},
+		{"unknown flag", []string{"-http.prot", "1"}, nil},
+		{"positional arguments", []string{"serve"}, map[string]string{"DATABASE_URL": "postgres://db"}},
+		{"missing database url", nil, nil},
+		{"unknown backend", nil, map[string]string{"STORAGE_BACKEND": "mysql"}},
+		{"missing config file", []string{"-config", "/nonexistent/config.yaml"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.args, env(tt.env))
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("help", func(t *testing.T) {
+		_, err := Load([]string{"-h"}, env(nil))
+		assert.ErrorIs(t, err, flag.ErrHelp)
+	})
+
+	t.Run("unknown key in file", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", "http:\n  prot: 9090\n")
+		_, err := Load([]string{"-config", path}, env(map[string]string{"DATABASE_URL": "postgres://db"}))
+		assert.Error(t, err)
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		path := writeFile(t, "config.ini", "port=9090\n")
+		_, err := Load([]string{"-config", path}, env(map[string]string{"DATABASE_URL": "postgres://db"}))
+		assert.Error(t, err)
+	})
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := func() Config {
+		cfg := Default()
+		cfg.Storage.Postgres.URL = "postgres://db"
+		return cfg
+	}
+	require.NoError(t, func() *Config { cfg := valid(); return &cfg }().Validate())
+
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+	}{
+		{"zero port", func(cfg *Config) { cfg.HTTP.Port = 0 }},
+		{"negative timeout", func(cfg *Config) { cfg.HTTP.ReadTimeout.Duration = -time.Second }},
+		{"zero shutdown timeout", func(cfg *Config) { cfg.HTTP.ShutdownTimeout.Duration = 0 }},
+		{"tls without cert", func(cfg *Config) { cfg.HTTP.TLS.Enabled = true; cfg.HTTP.TLS.KeyFile = "key.pem" }},
+		{"tls without key", func(cfg *Config) { cfg.HTTP.TLS.Enabled = true; cfg.HTTP.TLS.CertFile = "cert.pem" }},
+		{"client ca without tls", func(cfg *Config) { cfg.HTTP.TLS.ClientCAFile = "ca.pem" }},
+		{"unknown client auth", func(cfg *Config) {
+			cfg.HTTP.TLS = TLS{Enabled: true, CertFile: "cert.pem", KeyFile: "key.pem", ReloadInterval: Duration{time.Second},
+				ClientCAFile: "ca.pem", ClientAuth: "always"}
+		}},
+		{"client ca without verification", func(cfg *Config) {
+			cfg.HTTP.TLS = TLS{Enabled: true, CertFile: "cert.pem", KeyFile: "key.pem", ReloadInterval: Duration{time.Second},
+				ClientCAFile: "ca.pem", ClientAuth: "none"}
+		}},
+		{"sqlite without path", func(cfg *Config) { cfg.Storage.Backend = BackendSqlite; cfg.Storage.Sqlite.Path = "" }},
+		{"min conns above max", func(cfg *Config) { cfg.Storage.Postgres.MinConns = 10; cfg.Storage.Postgres.MaxConns = 5 }},
+		{"negative max conns", func(cfg *Config) { cfg.Storage.Postgres.MaxConns = -1 }},
+		{"unknown wal sync", func(cfg *Config) { cfg.Storage.Inmemory.WALSync = "sometimes" }},
+		{"zero websocket poll interval", func(cfg *Config) { cfg.WebSocket.PollInterval.Duration = 0 }},
+		{"retention without interval", func(cfg *Config) {
+			cfg.Retention.Events.Duration = time.Hour
+			cfg.Retention.CheckInterval.Duration = 0
+		}},
+		{"negative cache ttl", func(cfg *Config) { cfg.Cache.SensorTTL.Duration = -time.Second }},
+		{"unknown trace exporter", func(cfg *Config) { cfg.Tracing.Exporter = "jaeger" }},
+		{"sample ratio above one", func(cfg *Config) { cfg.Tracing.SampleRatio = 1.5 }},
+		{"negative shutdown delay", func(cfg *Config) { cfg.HTTP.ShutdownDelay.Duration = -time.Second }},
+		{"zero health timeout", func(cfg *Config) { cfg.Health.Timeout.Duration = 0 }},
+		{"unknown log format", func(cfg *Config) { cfg.Log.Format = "logfmt" }},
+		{"unknown log level", func(cfg *Config) { cfg.Log.Level = "verbose" }},
+		{"tracing without service name", func(cfg *Config) { cfg.Tracing.Exporter = "otlp"; cfg.Tracing.ServiceName = "" }},
+		{"negative event rate", func(cfg *Config) { cfg.RateLimit.Events.Rate = -1 }},
+		{"rate without burst", func(cfg *Config) { cfg.RateLimit.Reads.Burst = 0 }},
+		{"unknown sensor type limit", func(cfg *Config) {
+			cfg.RateLimit.Events.ByType = map[string]Limit{"thermo": {Rate: 1, Burst: 1}}
+		}},
+		{"legacy sunset before deprecation", func(cfg *Config) {
+			cfg.HTTP.Legacy.Sunset = cfg.HTTP.Legacy.DeprecatedAt.Add(-time.Hour)
+		}},
+		{"bad trusted proxy", func(cfg *Config) { cfg.HTTP.TrustedProxies = []string{"proxy.local"} }},
+		{"api key with unknown plan", func(cfg *Config) { cfg.RateLimit.Reads.APIKeys = map[string]string{"key": "gold"} }},
+		{"sensor type without kind", func(cfg *Config) {
+			cfg.SensorTypes = map[string]SensorType{"motion": {Aggregation: "last"}}
+		}},
+		{"sensor type with bad serial number", func(cfg *Config) {
+			cfg.SensorTypes = map[string]SensorType{"motion": {Kind: "binary", Aggregation: "last", SerialNumber: "("}}
+		}},
+		{"builtin sensor type redeclared", func(cfg *Config) {
+			cfg.SensorTypes = map[string]SensorType{"cc": {Kind: "binary", Aggregation: "last"}}
+		}},
+		{"anomaly threshold with short window", func(cfg *Config) { cfg.Anomaly.Window = 2 }},
+		{"negative anomaly max rate", func(cfg *Config) { cfg.Anomaly.MaxRate = -1 }},
+		{"unknown sensor type anomaly check", func(cfg *Config) {
+			cfg.Anomaly.ByType = map[string]AnomalyCheck{"thermo": {MaxRate: 1}}
+		}},
+		{"negative query max sensors", func(cfg *Config) { cfg.Query.MaxSensors = -1 }},
+		{"negative query max range", func(cfg *Config) { cfg.Query.MaxRange.Duration = -time.Hour }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.modify(&cfg)
+			assert.Error(t, cfg.Validate())
+		})
+	}
+
+	t.Run("all errors at once", func(t *testing.T) {
+		cfg := valid()
+		cfg.HTTP.Port = 0
+		cfg.WebSocket.PollInterval.Duration = 0
+		err := cfg.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "http.port")
+		assert.Contains(t, err.Error(), "websocket.poll_interval")
+	})
+}
+
+func TestLoad_RateLimit(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+rate_limit:
+  enabled: true
+  events:
+    rate: 5
+    by_type:
+      adc: {rate: 20, burst: 40}
+  reads:
+    plans:
+      partner: {rate: 500, burst: 1000}
+    api_keys:
+      secret-key: partner
+`)
+	cfg, err := Load([]string{"-config", path, "-rate_limit.events.burst", "7"}, env(map[string]string{"DATABASE_URL": "postgres://db"}))
+	require.NoError(t, err)
+
+	assert.True(t, cfg.RateLimit.Enabled)
+	assert.Equal(t, EventRateLimit{Rate: 5, Burst: 7, ByType: map[string]Limit{"adc": {Rate: 20, Burst: 40}}}, cfg.RateLimit.Events)
+	assert.Equal(t, Default().RateLimit.Reads.Rate, cfg.RateLimit.Reads.Rate)
+	assert.Equal(t, map[string]Limit{"partner": {Rate: 500, Burst: 1000}}, cfg.RateLimit.Reads.Plans)
+	assert.Equal(t, map[string]string{"secret-key": "partner"}, cfg.RateLimit.Reads.APIKeys)
+	assert.Equal(t, map[string]string{"xxxxx-1": "partner"}, cfg.Redacted().RateLimit.Reads.APIKeys)
+}
+
+func TestLoad_Anomaly(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+anomaly:
+  max_rate: 2
+  by_type:
+    adc: {window: 10, threshold: 4, stuck_after: 1h}
+`)
+	cfg, err := Load([]string{"-config", path, "-anomaly.alerts", "true"}, env(map[string]string{
+		"DATABASE_URL":                   "postgres://db",
+		"SMART_HOME_ANOMALY_STUCK_AFTER": "30m",
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, Anomaly{
+		Enabled:    true,
+		Window:     Default().Anomaly.Window,
+		Threshold:  Default().Anomaly.Threshold,
+		MaxRate:    2,
+		StuckAfter: Duration{30 * time.Minute},
+		Alerts:     true,
+		ByType:     map[string]AnomalyCheck{"adc": {Window: 10, Threshold: 4, StuckAfter: Duration{time.Hour}}},
+	}, cfg.Anomaly)
+}
+
+func TestLoad_Query(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+query:
+  max_sensors: 5
+`)
+	cfg, err := Load([]string{"-config", path, "-query.max_buckets", "0"}, env(map[string]string{
+		"DATABASE_URL":               "postgres://db",
+		"SMART_HOME_QUERY_MAX_RANGE": "720h",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, Query{MaxSensors: 5, MaxRange: Duration{30 * 24 * time.Hour}, MaxBuckets: 0}, cfg.Query)
+}
+
+func TestLoad_SensorTypes(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+sensor_types:
+  co2:
+    description: Датчик углекислого газа
+    kind: integer
+    min: 400
+    max: 5000
+    serial_number: ^CO2-\d{6}$
+    report_interval: 5m
+    aggregation: mean
+rate_limit:
+  events:
+    by_type:
+      co2: {rate: 1, burst: 1}
+`)
+	cfg, err := Load([]string{"-config", path}, env(map[string]string{"DATABASE_URL": "postgres://db"}))
+	require.NoError(t, err)
+
+	types, err := cfg.SensorTypeRegistry()
+	require.NoError(t, err)
+	assert.Len(t, types.Types(), 3)
+	co2, ok := types.Lookup("co2")
+	require.True(t, ok)
+	assert.Equal(t, 5*time.Minute, co2.ReportInterval)
+	assert.True(t, co2.ValidSerialNumber("CO2-000001"))
+	assert.True(t, co2.ValidPayload(800))
+	assert.False(t, co2.ValidPayload(300))
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"url with password", "postgres://user:secret@db:5432/smart_home", "postgres://user:xxxxx@db:5432/smart_home"},
+		{"password in query", "postgres://db/smart_home?user=user&password=secret", "postgres://db/smart_home?password=xxxxx&user=user"},
+		{"url without password", "postgres://user@db/smart_home", "postgres://user@db/smart_home"},
+		{"key value", "host=db user=user password=secret dbname=smart_home", "host=db user=user password=xxxxx dbname=smart_home"},
+		{"quoted key value", "host=db password='se cret' dbname=smart_home", "host=db password=xxxxx dbname=smart_home"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			cfg.Storage.Postgres.URL = tt.url
+			cfg.Admin.Token = "token"
+
+			redactedCfg := cfg.Redacted()
+			assert.Equal(t, tt.want, redactedCfg.Storage.Postgres.URL)
+			assert.Equal(t, "xxxxx", redactedCfg.Admin.Token)
+			// исходная конфигурация не меняется
+			assert.Equal(t, tt.url, cfg.Storage.Postgres.URL)
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileEnv - переменные окружения с путём к файлу конфигурации
+var configFileEnv = []string{"SMART_HOME_CONFIG", "CONFIG_FILE"}
+
+// Duration - time.Duration, который читается из строк вида "1m30s" во всех форматах
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// Load - собирает конфигурацию из значений по умолчанию, файла, окружения и флагов.
+//
+// Путь к файлу берётся из флага -config или переменной SMART_HOME_CONFIG (CONFIG_FILE).
+// Формат файла определяется по расширению: .yaml, .yml, .toml или .json.
+// Каждое поле можно задать флагом вида -http.port, имена переменных окружения
+// указаны в тегах env. Ошибка flag.ErrHelp означает, что был запрошен -help.
+func Load(args []string, lookupEnv func(string) (string, bool)) (Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("smart-home", flag.ContinueOnError)
+	configFile := fs.String("config", "", "путь к файлу конфигурации (yaml, toml или json)")
+	// флаги применяются последними, поэтому пока только запоминаем их
+	type flagValue struct{ name, value string }
+	var flags []flagValue
+	walk(reflect.ValueOf(&cfg).Elem(), "", func(path string, field reflect.StructField, _ reflect.Value) {
+		fs.Func(path, field.Tag.Get("usage"), func(s string) error {
+			flags = append(flags, flagValue{path, s})
+			return nil
+		})
+	})
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if fs.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	path := *configFile
+	for _, name := range configFileEnv {
+		if path != "" {
+			break
+		}
+		path, _ = lookupEnv(name)
+	}
+	if path != "" {
+		if err := loadFile(&cfg, path); err != nil {
+			return Config{}, fmt.Errorf("load config file %s: %w", path, err)
+		}
+	}
+
+	var errs []error
+	walk(reflect.ValueOf(&cfg).Elem(), "", func(path string, field reflect.StructField, v reflect.Value) {
+		for _, name := range strings.Split(field.Tag.Get("env"), ",") {
+			if name == "" {
+				continue
+			}
+			if s, ok := lookupEnv(name); ok && s != "" {
+				if err := setValue(v, s); err != nil {
+					errs = append(errs, fmt.Errorf("env %s: %w", name, err))
+				}
+				break
+			}
+		}
+	})
+	values := make(map[string]reflect.Value, len(flags))
+	walk(reflect.ValueOf(&cfg).Elem(), "", func(path string, _ reflect.StructField, v reflect.Value) {
+		values[path] = v
+	})
+	for _, f := range flags {
+		if err := setValue(values[f.name], f.value); err != nil {
+			errs = append(errs, fmt.Errorf("flag -%s: %w", f.name, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return decode(cfg, f, filepath.Ext(path))
+}
+
+// decode - читает конфигурацию поверх cfg; неизвестные ключи считаются ошибкой
+func decode(cfg *Config, r io.Reader, ext string) error {
+	var err error
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(r)
+		dec.KnownFields(true)
+		err = dec.Decode(cfg)
+	case ".toml":
+		err = toml.NewDecoder(r).DisallowUnknownFields().Decode(cfg)
+	case ".json":
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		err = dec.Decode(cfg)
+	default:
+		return fmt.Errorf("unsupported config format %q", ext)
+	}
+	if errors.Is(err, io.EOF) {
+		// пустой файл - не ошибка
+		return nil
+	}
+	return err
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// walk - обходит листовые поля конфигурации, path собирается из yaml-тегов через точку
+func walk(v reflect.Value, prefix string, fn func(path string, field reflect.StructField, v reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		path := prefix + name
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Map {
+			// словари задаются только в файле
+			continue
+		}
+		if fv.Kind() == reflect.Struct && !reflect.PointerTo(fv.Type()).Implements(textUnmarshalerType) {
+			walk(fv, path+".", fn)
+			continue
+		}
+		fn(path, field, fv)
+	}
+}
+
+// setValue - разбирает строку в значение поля
+func setValue(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported config field type %s", v.Type())
+		}
+		// список задаётся через запятую
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items).Convert(v.Type()))
+	default:
+		return fmt.Errorf("unsupported config field type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Calibration - профиль калибровки показаний датчика: откалиброванное значение
+// равно Gain × Table(raw) + Offset, где raw - показание после учёта десятичного масштаба
+type Calibration struct {
+	// Offset - смещение, прибавляемое после умножения на Gain
+	Offset float64
+	// Gain - коэффициент усиления
+	Gain float64
+	// Table - таблица линеаризации по возрастанию Raw: между точками значение интерполируется
+	// линейно, за крайними точками продолжается крайний отрезок. Пустая - без линеаризации
+	Table []CalibrationPoint
+}
+
+// CalibrationPoint - точка таблицы линеаризации
+type CalibrationPoint struct {
+	// Raw - показание датчика
+	Raw float64
+	// Value - соответствующее ему значение
+	Value float64
+}
+
+// Apply - откалиброванное значение показания raw; nil-профиль возвращает raw как есть
+func (c *Calibration) Apply(raw float64) float64 {
+	if c == nil {
+		return raw
+	}
+	return c.Gain*c.linearize(raw) + c.Offset
+}
+
+func (c *Calibration) linearize(raw float64) float64 {
+	if len(c.Table) < 2 {
+		return raw
+	}
+	i, _ := slices.BinarySearchFunc(c.Table, raw, func(p CalibrationPoint, raw float64) int {
+		return cmp.Compare(p.Raw, raw)
+	})
+	// отрезок [i-1, i], за пределами таблицы - крайний
+	i = min(max(i, 1), len(c.Table)-1)
+	a, b := c.Table[i-1], c.Table[i]
+	return a.Value + (raw-a.Raw)*(b.Value-a.Value)/(b.Raw-a.Raw)
+}
+
+// Clone - копия профиля, не разделяющая с ним таблицу; nil для nil
+func (c *Calibration) Clone() *Calibration {
+	if c == nil {
+		return nil
+	}
+	clone := *c
+	clone.Table = slices.Clone(c.Table)
+	return &clone
+}
+
+// CalibrationChange - запись журнала изменений калибровки датчика
+type CalibrationChange struct {
+	// SensorID - id датчика
+	SensorID int64
+	// Version - версия датчика, получившаяся после изменения
+	Version int64
+	// Calibration - новый профиль, nil - калибровка снята
+	Calibration *Calibration
+	// Previous - профиль до изменения, nil - калибровки не было
+	Previous *Calibration
+	// Reason - причина изменения со слов того, кто его сделал
+	Reason string
+	// RequestID - id запроса, которым сделано изменение, чтобы найти его в журнале запросов
+	RequestID string
+	// ChangedAt - время изменения
+	ChangedAt time.Time
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"math/bits"
+	"time"
+)
 
 // Event - структура события по датчику
 type Event struct {
@@ -10,6 +13,43 @@ type Event struct {
 	SensorSerialNumber string
 	// SensorID - id датчика
 	SensorID int64
-	// Payload - данные события
-	Payload int64
+	// Payload - значение измерения в единицах Unit после калибровки датчика
+	Payload float64
+	// Raw - значение до калибровки, но с учётом десятичного масштаба; равно Payload,
+	// если датчик не откалиброван
+	Raw float64
+	// Unit - единица измерения, пустая для безразмерных значений и состояний
+	Unit string
+	// Channel - канал многоканального датчика, пустой для датчиков с одним значением
+	Channel string
+	// Quality - признаки недостоверности значения, найденные при приёме
+	Quality Quality
+}
+
+// Quality - признаки недостоверности значения, битовая маска; 0 - значение в порядке
+type Quality uint8
+
+const (
+	// QualityOutlier - выброс: значение далеко от медианы последних значений
+	QualityOutlier Quality = 1 << iota
+	// QualityRate - значение изменилось быстрее, чем это физически возможно
+	QualityRate
+	// QualityStuck - значение не меняется дольше допустимого
+	QualityStuck
+)
+
+// qualityNames - имена признаков в порядке битов, они же значения в API
+var qualityNames = []string{"outlier", "rate", "stuck"}
+
+// Flags - имена установленных признаков, nil для 0
+func (q Quality) Flags() []string {
+	var flags []string
+	for q != 0 {
+		i := bits.TrailingZeros8(uint8(q))
+		if i < len(qualityNames) {
+			flags = append(flags, qualityNames[i])
+		}
+		q &^= 1 << i
+	}
+	return flags
 }
@@ -0,0 +1,55 @@
+package domain
+
+import "time"
+
+// Fill - чем заполнять пропуски в истории датчика
+type Fill string
+
+const (
+	// FillNone - не заполнять, только сообщить о пропусках
+	FillNone Fill = "none"
+	// FillPrevious - повторять последнее значение перед пропуском
+	FillPrevious Fill = "previous"
+	// FillLinear - интерполировать между значениями по краям пропуска; состояния повторяются, как в FillPrevious
+	FillLinear Fill = "linear"
+	// FillNull - ставить точки без значения, чтобы график прерывался
+	FillNull Fill = "null"
+)
+
+// HistoryQuery - запрос истории датчика с заполнением пропусков
+type HistoryQuery struct {
+	// Channel - канал многоканального датчика, пустой - все каналы
+	Channel string
+	// Start, End - границы периода
+	Start, End time.Time
+	// Fill - чем заполнять пропуски
+	Fill Fill
+	// Step - шаг подставляемых точек, 0 - обычный интервал отчётов типа датчика
+	Step time.Duration
+}
+
+// History - история датчика: сохранённые события вперемешку с подставленными точками и найденные пропуски
+type History struct {
+	// Points - точки по времени, затем по каналу
+	Points []HistoryPoint
+	// Gaps - пропуски по времени, затем по каналу
+	Gaps []Gap
+}
+
+// HistoryPoint - сохранённое событие или точка, подставленная в пропуск
+type HistoryPoint struct {
+	*Event
+	// Filled - точка подставлена, а не пришла от датчика
+	Filled bool
+	// Null - значение точки неизвестно, Payload и Raw не заданы
+	Null bool
+}
+
+// Gap - промежуток, за который от датчика не пришло ожидаемых событий
+type Gap struct {
+	// Channel - канал многоканального датчика
+	Channel string
+	// Start, End - время последнего события перед пропуском и первого после него
+	// или границы запрошенного периода
+	Start, End time.Time
+}
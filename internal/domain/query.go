@@ -0,0 +1,91 @@
+package domain
+
+import "time"
+
+// Aggregation - как значения сводятся за интервал
+type Aggregation string
+
+const (
+	// AggregationMean - среднее, для измерений вроде температуры
+	AggregationMean Aggregation = "mean"
+	// AggregationLast - последнее значение, для состояний
+	AggregationLast Aggregation = "last"
+	// AggregationMin - наименьшее значение
+	AggregationMin Aggregation = "min"
+	// AggregationMax - наибольшее значение
+	AggregationMax Aggregation = "max"
+	// AggregationSum - сумма, для счётчиков
+	AggregationSum Aggregation = "sum"
+)
+
+// Aggregations - все функции сведения
+var Aggregations = []Aggregation{AggregationMean, AggregationLast, AggregationMin, AggregationMax, AggregationSum}
+
+// SeriesQuery - запрос значений нескольких датчиков, сведённых по интервалам одной длины.
+// Интервал из целых суток - календарный: он начинается в полночь по Location, поэтому при переходе
+// на летнее время или обратно бывает на час короче или длиннее
+type SeriesQuery struct {
+	// SensorIDs - датчики в порядке, в котором нужны ряды
+	SensorIDs []int64
+	// Start, End - период [Start, End); интервалы отсчитываются от Start,
+	// суточные - от полуночи дня Start
+	Start, End time.Time
+	// Bucket - длина интервала
+	Bucket time.Duration
+	// Location - часовой пояс суточных интервалов и ответа, nil - UTC
+	Location *time.Location
+	// Aggregation - функция сведения, пустая - своя у каждого типа датчика
+	Aggregation Aggregation
+	// Fill - чем заполнять интервалы без событий
+	Fill Fill
+}
+
+// Aggregate - сводка событий датчика или его канала за один интервал
+type Aggregate struct {
+	SensorID int64
+	Channel  string
+	// Start - начало интервала
+	Start time.Time
+	// Count - число событий в интервале
+	Count int64
+	// Mean, Min, Max, Sum - сводки значений событий; Last - значение самого позднего события
+	Mean, Min, Max, Sum, Last float64
+}
+
+// Value - значение сводки для функции aggregation
+func (a *Aggregate) Value(aggregation Aggregation) float64 {
+	switch aggregation {
+	case AggregationLast:
+		return a.Last
+	case AggregationMin:
+		return a.Min
+	case AggregationMax:
+		return a.Max
+	case AggregationSum:
+		return a.Sum
+	default:
+		return a.Mean
+	}
+}
+
+// Series - значения датчика или его канала по интервалам запроса
+type Series struct {
+	SensorID int64
+	Channel  string
+	// Unit - единица измерения значений
+	Unit string
+	// Aggregation - функция, которой сведены значения
+	Aggregation Aggregation
+	// Values - значение в каждом интервале, nil - в интервале нет событий и он не заполнен
+	Values []*float64
+	// Counts - число событий в каждом интервале; 0 при значении - интервал заполнен
+	Counts []int64
+}
+
+// SeriesResult - ряды значений датчиков, выровненные по общим интервалам
+type SeriesResult struct {
+	// Buckets - начала интервалов
+	Buckets []time.Time
+	// Series - ряды в порядке датчиков запроса, каналы датчика подряд
+	Series []Series
+}
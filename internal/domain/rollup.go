@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// RollupResolutions - длины интервалов, по которым хранилище ведёт сводки событий, от крупных к мелким.
+// Интервалы выровнены по UTC: минуты, часы и сутки с полуночи UTC
+var RollupResolutions = []time.Duration{24 * time.Hour, time.Hour, time.Minute}
+
+// RollupResolution - самая крупная длина сводок, из которых собираются интервалы длины bucket от start,
+// или 0, если таких нет: bucket должен делиться на неё, а start - быть выровнен по ней
+func RollupResolution(start time.Time, bucket time.Duration) time.Duration {
+	for _, resolution := range RollupResolutions {
+		if bucket%resolution == 0 && start.Truncate(resolution).Equal(start) {
+			return resolution
+		}
+	}
+	return 0
+}
+
+// RollupPeriod - период [start, end), расширенный до целых суток UTC, чтобы покрыть целиком сводки
+// всех длин, которые его задевают
+func RollupPeriod(start, end time.Time) (time.Time, time.Time) {
+	day := RollupResolutions[0]
+	if aligned := end.Truncate(day); aligned.Before(end) {
+		end = aligned.Add(day)
+	}
+	return start.Truncate(day), end
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 type SensorType string
 
@@ -17,8 +20,13 @@ type Sensor struct {
 	SerialNumber string
 	// Type - тип датчика
 	Type SensorType
-	// CurrentState - текущее состояние датчика
-	CurrentState int64
+	// CurrentState - текущее состояние датчика, значение последнего события в единицах Unit
+	CurrentState float64
+	// Unit - единица измерения показаний, например "°C"; пустая для безразмерных значений
+	Unit string
+	// Scale - десятичный масштаб показаний: датчик присылает значение × 10^Scale,
+	// например 215 при Scale 1 - это 21.5. 0 - значения приходят как есть
+	Scale int
 	// Description - описание датчика
 	Description string
 	// IsActive - активен ли датчик
@@ -27,4 +35,53 @@ type Sensor struct {
 	RegisteredAt time.Time
 	// LastActivity - дата последнего изменения состояния датчика
 	LastActivity time.Time
+	// Version - версия записи: 1 после регистрации, хранилище увеличивает её при каждом сохранении
+	Version int64
+	// Channels - каналы многоканального датчика, пусто для датчиков с одним значением
+	Channels []Channel
+	// Calibration - калибровка показаний датчика без каналов, nil - показания сохраняются как есть
+	Calibration *Calibration
+}
+
+// Channel - именованный канал многоканального датчика, например температура или влажность
+type Channel struct {
+	// Name - имя канала, уникальное в пределах датчика
+	Name string
+	// Unit - единица измерения значений канала
+	Unit string
+	// Scale - десятичный масштаб значений канала, как Sensor.Scale
+	Scale int
+	// CurrentState - последнее значение канала в единицах Unit
+	CurrentState float64
+	// LastActivity - время последнего значения канала
+	LastActivity time.Time
+}
+
+// Clone - копия датчика, не разделяющая с ним каналы и калибровку
+func (s Sensor) Clone() Sensor {
+	s.Channels = slices.Clone(s.Channels)
+	s.Calibration = s.Calibration.Clone()
+	return s
+}
+
+// Channel - канал датчика по имени, nil если такого нет
+func (s *Sensor) Channel(name string) *Channel {
+	for i := range s.Channels {
+		if s.Channels[i].Name == name {
+			return &s.Channels[i]
+		}
+	}
+	return nil
+}
+
+// SensorPatch - изменение настроек датчика, nil-поля не меняются
+type SensorPatch struct {
+	// Description - новое описание датчика
+	Description *string
+	// IsActive - новое значение активности датчика
+	IsActive *bool
+	// Unit - новая единица измерения
+	Unit *string
+	// Scale - новый десятичный масштаб показаний
+	Scale *int
 }
@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+// Transition - смена состояния датчика вида binary: дверь открылась или закрылась, протечка началась или кончилась
+type Transition struct {
+	SensorID int64
+	// Channel - канал многоканального датчика
+	Channel string
+	// Timestamp - время события с новым состоянием
+	Timestamp time.Time
+	// From, To - прежнее и новое состояние
+	From, To float64
+	// Lasted - сколько длилось прежнее состояние, от прошлой смены; 0 - неизвестно, это первая смена
+	Lasted time.Duration
+}
+
+// Opened - состояние 1: дверь открыта, протечка обнаружена
+func (t *Transition) Opened() bool {
+	return t.To == 1
+}
+
+// TransitionFilter - какие смены состояния датчика нужны
+type TransitionFilter struct {
+	// Channel - канал многоканального датчика, пустой - все каналы
+	Channel string
+	// Start, End - границы периода включительно
+	Start, End time.Time
+	// To - только смены в это состояние, nil - в любое
+	To *float64
+	// MinLasted - только смены, прежнее состояние перед которыми длилось не меньше; 0 - все
+	MinLasted time.Duration
+	// Limit - сколько первых смен вернуть, 0 - все
+	Limit int
+}
+
+// Match - подходит ли смена состояния под фильтр
+func (f *TransitionFilter) Match(t *Transition) bool {
+	return (f.Channel == "" || t.Channel == f.Channel) &&
+		!t.Timestamp.Before(f.Start) && !t.Timestamp.After(f.End) &&
+		(f.To == nil || t.To == *f.To) &&
+		(f.MinLasted == 0 || t.Lasted >= f.MinLasted)
+}
+
+// DayCount - число событий за календарные сутки
+type DayCount struct {
+	// Day - полночь суток в часовом поясе запроса
+	Day   time.Time
+	Count int64
+}
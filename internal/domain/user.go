@@ -1,11 +1,24 @@
 package domain
 
+import "time"
+
 // User - структура для хранения пользователя
 type User struct {
 	// ID - id пользователя
 	ID int64
 	// Name - имя пользователя
 	Name string
+	// TimeZone - часовой пояс дома пользователя из базы IANA, например Europe/Moscow; пустой - UTC
+	TimeZone string
+}
+
+// Location - часовой пояс пользователя; неизвестный пояс считается UTC
+func (u *User) Location() *time.Location {
+	location, err := time.LoadLocation(u.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
 }
 
 // SensorOwner - структура для связи пользователя и датчика
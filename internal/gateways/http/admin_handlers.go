@@ -0,0 +1,62 @@
+package http
+
+import (
+	"crypto/subtle"
+	"homework/internal/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func setupAdminRouter(r *gin.Engine, us UseCases, token string, cfg any) {
+	admin := r.Group("/admin", adminAuth(token))
+	admin.GET("/config", getAdminConfig(cfg))
+	admin.POST("/rollups/rebuild", postRollupRebuild(us))
+}
+
+// adminAuth - проверяет bearer-токен. Без настроенного токена служебные эндпоинты закрыты
+// для всех, а не открыты: иначе конфигурация и пересборка сводок доступны любому по умолчанию
+func adminAuth(token string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if token == "" {
+			abort(ctx, errAdminTokenRequired)
+			return
+		}
+		got, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			ctx.Header("WWW-Authenticate", "Bearer")
+			abort(ctx, errUnauthorized)
+		}
+	}
+}
+
+func getAdminConfig(cfg any) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, cfg)
+	}
+}
+
+// postRollupRebuild - пересобирает сводки событий за период из сохранённых событий, если они разошлись
+func postRollupRebuild(us UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := checkAccept(ctx); err != nil {
+			abort(ctx, err)
+			return
+		}
+		rebuild := &models.RollupRebuild{}
+		if err := validate(ctx, rebuild); err != nil {
+			abort(ctx, err)
+			return
+		}
+
+		rebuilt, err := us.Event.RebuildRollups(ctx, rebuild.SensorIds,
+			time.Time(*rebuild.StartDate), time.Time(*rebuild.EndDate))
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, models.RollupRebuildResult{Rebuilt: &rebuilt})
+	}
+}
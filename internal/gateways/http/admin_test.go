@@ -0,0 +1,129 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	eventInmemory "homework/internal/repository/event/inmemory"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminConfig(t *testing.T) {
+	cfg := map[string]any{"http": map[string]any{"port": 8080}}
+
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   int
+	}{
+		{"no token configured", "", "", http.StatusForbidden},
+		{"no token configured, any header", "", "Bearer ", http.StatusForbidden},
+		{"valid token", "secret", "Bearer secret", http.StatusOK},
+		{"missing token", "secret", "", http.StatusUnauthorized},
+		{"wrong token", "secret", "Bearer wrong", http.StatusUnauthorized},
+		{"wrong scheme", "secret", "Basic secret", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.Use(errorMiddleware())
+			setupAdminRouter(engine, UseCases{}, tt.token, cfg)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/admin/config", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			engine.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Code, "Получили в ответ не тот код")
+			if tt.want == http.StatusForbidden {
+				assert.Contains(t, w.Body.String(), "admin_token_required")
+			}
+			if tt.want == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+			}
+			if tt.want != http.StatusOK {
+				assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+				return
+			}
+			var actual map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &actual))
+			assert.Equal(t, map[string]any{"http": map[string]any{"port": float64(8080)}}, actual)
+		})
+	}
+}
+
+// rollupEventRepository - хранилище событий в памяти, запоминающее запросы пересборки сводок
+type rollupEventRepository struct {
+	*eventInmemory.EventRepository
+	ids        []int64
+	start, end time.Time
+}
+
+func (r *rollupEventRepository) RebuildRollups(_ context.Context, ids []int64, start, end time.Time) (int64, error) {
+	r.ids, r.start, r.end = ids, start, end
+	return 72, nil
+}
+
+func TestAdminRollupRebuild(t *testing.T) {
+	repo := &rollupEventRepository{EventRepository: eventInmemory.NewEventRepository()}
+	us := UseCases{Event: usecase.NewEvent(repo, sensorInmemory.NewSensorRepository())}
+	rebuild := func(us UseCases, header, body string) *httptest.ResponseRecorder {
+		engine := gin.New()
+		engine.Use(errorMiddleware())
+		setupAdminRouter(engine, us, "secret", struct{}{})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/admin/rollups/rebuild", strings.NewReader(body))
+		req.Header.Set("Authorization", header)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Type", "application/json")
+		engine.ServeHTTP(w, req)
+		return w
+	}
+
+	w := rebuild(us, "Bearer secret", `{"start_date": "2025-01-01T00:00:00Z", "end_date": "2025-01-02T00:00:00Z", "sensor_ids": [1, 2]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"rebuilt": 72}`, w.Body.String())
+	assert.Equal(t, []int64{1, 2}, repo.ids)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), repo.start.UTC())
+	assert.Equal(t, time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC), repo.end.UTC())
+
+	w = rebuild(us, "Bearer wrong", `{"start_date": "2025-01-01T00:00:00Z", "end_date": "2025-01-02T00:00:00Z"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = rebuild(us, "Bearer secret", `{"start_date": "2025-01-01T00:00:00Z"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	w = rebuild(us, "Bearer secret", `{"start_date": "2025-01-02T00:00:00Z", "end_date": "2025-01-01T00:00:00Z"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_event_timestamp")
+
+	// без токена служебные эндпоинты закрыты, а не открыты всем
+	engine := gin.New()
+	engine.Use(errorMiddleware())
+	setupAdminRouter(engine, us, "", struct{}{})
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/admin/rollups/rebuild",
+		strings.NewReader(`{"start_date": "2025-01-01T00:00:00Z", "end_date": "2025-01-02T00:00:00Z"}`))
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "admin_token_required")
+
+	// хранилище в памяти сводок не ведёт
+	inmemory := UseCases{Event: usecase.NewEvent(eventInmemory.NewEventRepository(), sensorInmemory.NewSensorRepository())}
+	w = rebuild(inmemory, "Bearer secret", `{"start_date": "2025-01-01T00:00:00Z", "end_date": "2025-01-02T00:00:00Z"}`)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
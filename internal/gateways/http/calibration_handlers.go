@@ -0,0 +1,163 @@
+package http
+
+import (
+	"homework/internal/domain"
+	"homework/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-openapi/strfmt"
+)
+
+// maxCalibrationReason - наибольшая длина причины снятия калибровки, как у reason в CalibrationToUpdate
+const maxCalibrationReason = 256
+
+func makeCalibration(calibration *domain.Calibration) *models.Calibration {
+	if calibration == nil {
+		return nil
+	}
+	result := &models.Calibration{Gain: &calibration.Gain, Offset: calibration.Offset}
+	for i := range calibration.Table {
+		point := &calibration.Table[i]
+		result.Table = append(result.Table, &models.CalibrationPoint{Raw: &point.Raw, Value: &point.Value})
+	}
+	return result
+}
+
+func makeCalibrationChange(change *domain.CalibrationChange) models.CalibrationChange {
+	changedAt := strfmt.DateTime(change.ChangedAt)
+	return models.CalibrationChange{
+		Version:     &change.Version,
+		Calibration: makeCalibration(change.Calibration),
+		Previous:    makeCalibration(change.Previous),
+		Reason:      change.Reason,
+		RequestID:   change.RequestID,
+		ChangedAt:   &changedAt,
+	}
+}
+
+// putCalibration - задаёт профиль калибровки датчика. Как и в PATCH датчика, нужен If-Match с его ETag
+func putCalibration(us UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := checkAccept(ctx); err != nil {
+			abort(ctx, err)
+			return
+		}
+		sensorID, err := pathID(ctx, "sensor_id")
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		precondition, err := ifMatch(ctx)
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		toUpdate := &models.CalibrationToUpdate{}
+		if err := validate(ctx, toUpdate); err != nil {
+			abort(ctx, err)
+			return
+		}
+
+		calibration := &domain.Calibration{Gain: *toUpdate.Gain, Offset: toUpdate.Offset}
+		for _, point := range toUpdate.Table {
+			if point != nil {
+				calibration.Table = append(calibration.Table, domain.CalibrationPoint{Raw: *point.Raw, Value: *point.Value})
+			}
+		}
+		updateCalibration(ctx, us, sensorID, domain.CalibrationChange{Calibration: calibration, Reason: toUpdate.Reason}, precondition)
+	}
+}
+
+// deleteCalibration - снимает калибровку датчика; причину можно передать в параметре reason
+func deleteCalibration(us UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := checkAccept(ctx); err != nil {
+			abort(ctx, err)
+			return
+		}
+		sensorID, err := pathID(ctx, "sensor_id")
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		precondition, err := ifMatch(ctx)
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		reason := ctx.Query("reason")
+		if len(reason) > maxCalibrationReason {
+			abort(ctx, errInvalidQuery("reason is too long"))
+			return
+		}
+		updateCalibration(ctx, us, sensorID, domain.CalibrationChange{Reason: reason}, precondition)
+	}
+}
+
+// updateCalibration - общая часть PUT и DELETE калибровки: в журнал попадает id запроса из X-Request-ID
+func updateCalibration(ctx *gin.Context, us UseCases, sensorID int64, change domain.CalibrationChange, precondition func(*domain.Sensor) bool) {
+	change.RequestID = ctx.Writer.Header().Get(requestIDHeader)
+	sensor, err := us.Sensor.UpdateCalibration(ctx, sensorID, change, precondition)
+	if err != nil {
+		abort(ctx, err)
+		return
+	}
+	ctx.Header("ETag", sensorETag(sensor))
+	ctx.JSON(http.StatusOK, makeSens(sensor))
+}
+
+// getCalibrationHistory - журнал изменений калибровки датчика от старых к новым
+func getCalibrationHistory(us UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := checkAccept(ctx); err != nil {
+			abort(ctx, err)
+			return
+		}
+		sensorID, err := pathID(ctx, "sensor_id")
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+
+		changes, err := us.Sensor.GetCalibrationChanges(ctx, sensorID)
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		result := make([]models.CalibrationChange, 0, len(changes))
+		for i := range changes {
+			result = append(result, makeCalibrationChange(&changes[i]))
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
+
+// postRecalibration - пересчитывает сохранённые события датчика за диапазон по текущей калибровке
+func postRecalibration(us UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := checkAccept(ctx); err != nil {
+			abort(ctx, err)
+			return
+		}
+		sensorID, err := pathID(ctx, "sensor_id")
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		recalibration := &models.Recalibration{}
+		if err := validate(ctx, recalibration); err != nil {
+			abort(ctx, err)
+			return
+		}
+
+		updated, err := us.Event.Recalibrate(ctx, sensorID,
+			time.Time(*recalibration.StartDate), time.Time(*recalibration.EndDate))
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, models.RecalibrationResult{Updated: &updated})
+	}
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"encoding/json"
+	eventInmemory "homework/internal/repository/event/inmemory"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
+	"homework/internal/usecase"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalibration(t *testing.T) {
+	sensors := sensorInmemory.NewSensorRepository()
+	s := NewServer(UseCases{
+		Sensor: usecase.NewSensor(sensors),
+		Event:  usecase.NewEvent(eventInmemory.NewEventRepository(), sensors),
+	})
+	headers := map[string]string{"Content-Type": "application/json"}
+	start := time.Now().Add(-time.Hour)
+
+	w := serve(s, http.MethodPost, "/v1/sensors", headers, `{"serial_number":"0000000001","type":"adc","description":"t","is_active":true}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = serve(s, http.MethodPost, "/v1/events", headers, `{"sensor_serial_number":"0000000001","payload":10}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	path := "/v1/sensors/1/calibration"
+	body := `{"gain":2,"offset":1,"reason":"поверка"}`
+	w = serve(s, http.MethodPut, path, headers, body)
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+
+	etag := serve(s, http.MethodGet, "/v1/sensors/1", nil, "").Header().Get("ETag")
+	w = serve(s, http.MethodPut, path, map[string]string{"Content-Type": "application/json", "If-Match": etag}, `{"gain":0}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "wrong_calibration")
+
+	w = serve(s, http.MethodPut, path, map[string]string{"Content-Type": "application/json", "If-Match": etag, requestIDHeader: "req-1"}, body)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	var sensor struct {
+		Calibration *struct {
+			Gain   float64 `json:"gain"`
+			Offset float64 `json:"offset"`
+		} `json:"calibration"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sensor))
+	require.NotNil(t, sensor.Calibration)
+	assert.Equal(t, 2.0, sensor.Calibration.Gain)
+	assert.Equal(t, 1.0, sensor.Calibration.Offset)
+
+	// старый ETag больше не подходит
+	w = serve(s, http.MethodPut, path, map[string]string{"Content-Type": "application/json", "If-Match": etag}, body)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = serve(s, http.MethodPost, "/v1/events", headers, `{"sensor_serial_number":"0000000001","payload":10}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	type event struct {
+		Payload float64 `json:"payload"`
+		Raw     float64 `json:"raw"`
+	}
+	history := func() []event {
+		t.Helper()
+		query := url.Values{
+			"start_date": {start.UTC().Format(time.RFC1123)},
+			"end_date":   {time.Now().Add(time.Hour).UTC().Format(time.RFC1123)},
+		}
+		w := serve(s, http.MethodGet, "/v1/sensors/1/history?"+query.Encode(), nil, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var events []event
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+		return events
+	}
+	assert.Equal(t, []event{{10, 10}, {21, 10}}, history())
+
+	recompute := `{"start_date":"` + start.Format(time.RFC3339) + `","end_date":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`
+	w = serve(s, http.MethodPost, path+"/recompute", headers, recompute)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"updated":1}`, w.Body.String())
+	assert.Equal(t, []event{{21, 10}, {21, 10}}, history())
+
+	etag = serve(s, http.MethodGet, "/v1/sensors/1", nil, "").Header().Get("ETag")
+	w = serve(s, http.MethodDelete, path+"?reason=снят", map[string]string{"If-Match": etag}, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "calibration")
+
+	w = serve(s, http.MethodGet, path+"/history", nil, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var changes []struct {
+		Version     int64           `json:"version"`
+		Calibration json.RawMessage `json:"calibration"`
+		Previous    json.RawMessage `json:"previous"`
+		Reason      string          `json:"reason"`
+		RequestID   string          `json:"request_id"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &changes))
+	require.Len(t, changes, 2)
+	assert.Equal(t, "поверка", changes[0].Reason)
+	assert.Equal(t, "req-1", changes[0].RequestID)
+	assert.JSONEq(t, `{"gain":2,"offset":1}`, string(changes[0].Calibration))
+	assert.Nil(t, changes[0].Previous)
+	assert.Equal(t, "снят", changes[1].Reason)
+	assert.Nil(t, changes[1].Calibration)
+	assert.JSONEq(t, `{"gain":2,"offset":1}`, string(changes[1].Previous))
+	assert.Less(t, changes[0].Version, changes[1].Version)
+
+	w = serve(s, http.MethodGet, "/v1/sensors/2/calibration/history", nil, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
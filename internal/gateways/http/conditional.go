@@ -0,0 +1,95 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"homework/internal/domain"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jsonContentType - тип тела ответов API, тот же, что ставит gin в ctx.JSON
+const jsonContentType = "application/json; charset=utf-8"
+
+var errPreconditionRequired = &httpError{http.StatusPreconditionRequired, "precondition_required", "if-match header is required"}
+
+// sensorETag - сильный ETag датчика. Хранилище увеличивает версию при каждом сохранении,
+// время последней активности добавлено, чтобы ETag менялся и у записей, сохранённых до появления версий
+func sensorETag(sensor *domain.Sensor) string {
+	return fmt.Sprintf(`"%d-%x"`, sensor.Version, sensor.LastActivity.UnixNano())
+}
+
+// bodyETag - сильный ETag по содержимому ответа, для коллекций без собственной версии
+func bodyETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// etagMatches - есть ли etag в списке из If-Match или If-None-Match. weak - слабое сравнение
+// (RFC 9110, 8.8.3.2): префикс W/ не учитывается; If-Match требует сильного
+func etagMatches(header, etag string, weak bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak {
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified - у клиента актуальная копия ответа: If-None-Match совпал с etag.
+// Last-Modified не отправляется: ни у датчика, ни у истории нет времени, которое менялось бы
+// при каждой правке, поэтому If-Modified-Since без If-None-Match игнорируется (RFC 9110, 13.1.3)
+func notModified(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	return header != "" && etagMatches(header, etag, true)
+}
+
+// ifMatch - условие для изменения датчика из заголовка If-Match; без заголовка ошибка 428,
+// чтобы клиент не затёр чужие изменения, не прочитав текущую версию
+func ifMatch(ctx *gin.Context) (func(*domain.Sensor) bool, error) {
+	header := ctx.GetHeader("If-Match")
+	if header == "" {
+		return nil, errPreconditionRequired
+	}
+	return func(sensor *domain.Sensor) bool {
+		return etagMatches(header, sensorETag(sensor), false)
+	}, nil
+}
+
+// writeConditional - отвечает на GET и HEAD телом v с заголовком ETag
+// или 304, если у клиента актуальная копия. etag пустой - вычисляется по телу.
+// На HEAD тело не пишется, но Content-Length совпадает с длиной тела GET
+func writeConditional(ctx *gin.Context, v any, etag string) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		abort(ctx, err)
+		return
+	}
+	if etag == "" {
+		etag = bodyETag(body)
+	}
+	ctx.Header("ETag", etag)
+	if notModified(ctx.Request, etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+	if ctx.Request.Method == http.MethodHead {
+		ctx.Header("Content-Type", jsonContentType)
+		ctx.Header("Content-Length", strconv.Itoa(len(body)))
+		ctx.Status(http.StatusOK)
+		return
+	}
+	ctx.Data(http.StatusOK, jsonContentType, body)
+}
@@ -0,0 +1,198 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"homework/internal/domain"
+	eventInmemory "homework/internal/repository/event/inmemory"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func conditionalServer(t *testing.T) (*Server, *domain.Sensor, *usecase.Event) {
+	sensors := sensorInmemory.NewSensorRepository()
+	events := eventInmemory.NewEventRepository()
+	us := UseCases{
+		Sensor: usecase.NewSensor(sensors),
+		Event:  usecase.NewEvent(events, sensors),
+	}
+	sensor, err := us.Sensor.RegisterSensor(context.Background(), &domain.Sensor{
+		SerialNumber: "0000000001",
+		Type:         domain.SensorTypeADC,
+		Description:  "kitchen",
+		IsActive:     true,
+	})
+	require.NoError(t, err)
+	return NewServer(us), sensor, us.Event
+}
+
+func serve(s *Server, method, path string, headers map[string]string, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Accept", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestConditionalGet(t *testing.T) {
+	s, sensor, events := conditionalServer(t)
+	id := strconv.FormatInt(sensor.ID, 10)
+	for _, tt := range []struct {
+		path string
+		head bool
+	}{
+		{"/v1/sensors", true},
+		{"/v1/sensors/" + id, true},
+		{"/v1/sensors/" + id + "/history?start_date=Mon,%2001%20Jan%202024%2000:00:00%20UTC&end_date=Mon,%2001%20Jan%202035%2000:00:00%20UTC", false},
+	} {
+		path := tt.path
+		t.Run(path, func(t *testing.T) {
+			w := serve(s, http.MethodGet, path, nil, "")
+			require.Equal(t, http.StatusOK, w.Code)
+			etag := w.Header().Get("ETag")
+			require.NotEmpty(t, etag)
+			assert.Empty(t, w.Header().Get("Last-Modified"))
+
+			if tt.head {
+				// HEAD отдаёт те же заголовки и длину тела GET
+				head := serve(s, http.MethodHead, path, nil, "")
+				assert.Equal(t, http.StatusOK, head.Code)
+				assert.Equal(t, etag, head.Header().Get("ETag"))
+				assert.Equal(t, strconv.Itoa(w.Body.Len()), head.Header().Get("Content-Length"))
+				assert.Empty(t, head.Body.String())
+
+				head = serve(s, http.MethodHead, path, map[string]string{"If-None-Match": etag}, "")
+				assert.Equal(t, http.StatusNotModified, head.Code)
+			}
+
+			w = serve(s, http.MethodGet, path, map[string]string{"If-None-Match": `"other", W/` + etag}, "")
+			assert.Equal(t, http.StatusNotModified, w.Code)
+			assert.Empty(t, w.Body.String())
+
+			// новое событие меняет и датчик, и историю
+			require.NoError(t, events.ReceiveEvent(context.Background(), &domain.Event{
+				SensorSerialNumber: sensor.SerialNumber,
+				Timestamp:          time.Now(),
+				Payload:            float64(time.Now().UnixNano()),
+			}))
+			w = serve(s, http.MethodGet, path, map[string]string{"If-None-Match": etag}, "")
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.NotEqual(t, etag, w.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestConditionalGet_SensorEdit(t *testing.T) {
+	s, sensor, _ := conditionalServer(t)
+	path := "/v1/sensors/" + strconv.FormatInt(sensor.ID, 10)
+	future := map[string]string{"If-Modified-Since": time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}
+
+	for _, list := range []string{path, "/v1/sensors"} {
+		w := serve(s, http.MethodGet, list, nil, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		etag := w.Header().Get("ETag")
+
+		// правка меняет версию датчика, но не время его активности
+		w = serve(s, http.MethodPatch, path, map[string]string{
+			"Content-Type": "application/json", "If-Match": serve(s, http.MethodGet, path, nil, "").Header().Get("ETag"),
+		}, `{"description": "`+list+`"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = serve(s, http.MethodGet, list, future, "")
+		assert.Equal(t, http.StatusOK, w.Code, list)
+		w = serve(s, http.MethodGet, list, map[string]string{"If-None-Match": etag}, "")
+		assert.Equal(t, http.StatusOK, w.Code, list)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"), list)
+	}
+}
+
+func TestConditionalGet_History(t *testing.T) {
+	s, sensor, events := conditionalServer(t)
+	require.NoError(t, events.ReceiveEvent(context.Background(), &domain.Event{
+		SensorSerialNumber: sensor.SerialNumber, Timestamp: time.Now().Add(-time.Minute), Payload: 1,
+	}))
+	path := "/v1/sensors/" + strconv.FormatInt(sensor.ID, 10) + "/history?last=1h"
+
+	// событие уйдёт из окна last без смены активности датчика, поэтому ответ сверяется только по ETag
+	w := serve(s, http.MethodGet, path, nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Last-Modified"))
+	w = serve(s, http.MethodGet, path, map[string]string{"If-Modified-Since": time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = serve(s, http.MethodGet, path, map[string]string{"If-None-Match": w.Header().Get("ETag")}, "")
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	// пересчёт калибровки меняет значения, не меняя активности датчика
+	etag := w.Header().Get("ETag")
+	sensorPath := "/v1/sensors/" + strconv.FormatInt(sensor.ID, 10)
+	w = serve(s, http.MethodPut, sensorPath+"/calibration", map[string]string{
+		"Content-Type": "application/json", "If-Match": serve(s, http.MethodGet, sensorPath, nil, "").Header().Get("ETag"),
+	}, `{"gain": 2}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	_, err := events.Recalibrate(context.Background(), sensor.ID, time.Now().Add(-time.Hour), time.Now())
+	require.NoError(t, err)
+	w = serve(s, http.MethodGet, path, map[string]string{"If-None-Match": etag}, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestPatchSensor(t *testing.T) {
+	s, sensor, _ := conditionalServer(t)
+	path := "/v1/sensors/" + strconv.FormatInt(sensor.ID, 10)
+	jsonBody := map[string]string{"Content-Type": "application/json"}
+	with := func(name, value string) map[string]string {
+		return map[string]string{"Content-Type": "application/json", name: value}
+	}
+
+	etag := serve(s, http.MethodGet, path, nil, "").Header().Get("ETag")
+
+	w := serve(s, http.MethodPatch, path, jsonBody, `{"description":"no precondition"}`)
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+
+	w = serve(s, http.MethodPatch, path, with("If-Match", etag), `{}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	// первый администратор меняет описание
+	w = serve(s, http.MethodPatch, path, with("If-Match", etag), `{"description":"first admin"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var updated struct {
+		Description string `json:"description"`
+		IsActive    bool   `json:"is_active"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, "first admin", updated.Description)
+	assert.True(t, updated.IsActive)
+	newETag := w.Header().Get("ETag")
+	assert.NotEqual(t, etag, newETag)
+
+	// второй прочитал датчик раньше и получает 412 вместо того, чтобы затереть изменения
+	w = serve(s, http.MethodPatch, path, with("If-Match", etag), `{"description":"second admin"}`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Contains(t, w.Body.String(), "sensor_modified")
+
+	// слабый ETag для If-Match не годится
+	w = serve(s, http.MethodPatch, path, with("If-Match", "W/"+newETag), `{"is_active":false}`)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	w = serve(s, http.MethodPatch, path, with("If-Match", newETag), `{"is_active":false}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serve(s, http.MethodGet, path, nil, "")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, "first admin", updated.Description)
+	assert.False(t, updated.IsActive)
+
+	w = serve(s, http.MethodPatch, "/v1/sensors/100", with("If-Match", "*"), `{"is_active":true}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,39 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// deprecation - сведения об устаревании набора маршрутов
+type deprecation struct {
+	// since - с какого момента маршруты устарели, нулевое - дата не объявлена
+	since time.Time
+	// sunset - когда маршруты перестанут работать, нулевое - дата не назначена
+	sunset time.Time
+}
+
+// deprecationMiddleware - заголовки Deprecation (RFC 9745), Sunset (RFC 8594) и ссылка на тот же путь
+// в версии successor, например /v1. Без даты since заголовок Deprecation не отправляется
+func deprecationMiddleware(d deprecation, successor string) gin.HandlerFunc {
+	var value string
+	if !d.since.IsZero() {
+		value = fmt.Sprintf("@%d", d.since.Unix())
+	}
+	var sunset string
+	if !d.sunset.IsZero() {
+		sunset = d.sunset.UTC().Format(http.TimeFormat)
+	}
+	return func(ctx *gin.Context) {
+		if value != "" {
+			ctx.Header("Deprecation", value)
+		}
+		if sunset != "" {
+			ctx.Header("Sunset", sunset)
+		}
+		ctx.Header("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, successor, ctx.Request.URL.Path))
+	}
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLegacyRoutes(t *testing.T) {
+	since := time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, time.April, 18, 0, 0, 0, 0, time.UTC)
+	s := NewServer(UseCases{}, WithLegacyRoutes(true, since, sunset))
+
+	do := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	// без Accept обработчик отвечает 406 - значит, маршрут найден
+	w := do("/sensors/42")
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.Equal(t, "@1792281600", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Sun, 18 Apr 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `</v1/sensors/42>; rel="successor-version"`, w.Header().Get("Link"))
+
+	w = do("/v1/sensors/42")
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.Empty(t, w.Header().Get("Deprecation"))
+	assert.Empty(t, w.Header().Get("Sunset"))
+
+	// служебные эндпоинты не версионируются
+	w = do("/ping")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	// без версии обслуживаются только маршруты, существовавшие до /v1
+	versioned := map[string]bool{}
+	for _, route := range NewServer(UseCases{}, WithLegacyRoutes(false, time.Time{}, time.Time{})).router.Routes() {
+		versioned[route.Method+" "+route.Path] = true
+	}
+	legacy := map[string]bool{}
+	for _, route := range s.router.Routes() {
+		if !versioned[route.Method+" "+route.Path] {
+			legacy[route.Method+" "+route.Path] = true
+		}
+	}
+	assert.Equal(t, map[string]bool{
+		"POST /users":                     true,
+		"OPTIONS /users":                  true,
+		"GET /sensors":                    true,
+		"HEAD /sensors":                   true,
+		"POST /sensors":                   true,
+		"OPTIONS /sensors":                true,
+		"GET /sensors/:sensor_id/events":  true,
+		"GET /sensors/:sensor_id":         true,
+		"HEAD /sensors/:sensor_id":        true,
+		"OPTIONS /sensors/:sensor_id":     true,
+		"GET /sensors/:sensor_id/history": true,
+		"GET /users/:user_id/sensors":     true,
+		"HEAD /users/:user_id/sensors":    true,
+		"POST /users/:user_id/sensors":    true,
+		"OPTIONS /users/:user_id/sensors": true,
+		"POST /events":                    true,
+		"OPTIONS /events":                 true,
+	}, legacy)
+
+	// новые эндпоинты без версии не обслуживаются
+	w = httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/query", nil)
+	s.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do("/sensor-types")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestLegacyRoutes_NoDates(t *testing.T) {
+	s := NewServer(UseCases{}, WithLegacyRoutes(true, time.Time{}, time.Time{}))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/sensors/42", nil)
+	s.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.Empty(t, w.Header().Get("Deprecation"))
+	assert.Empty(t, w.Header().Get("Sunset"))
+	assert.Equal(t, `</v1/sensors/42>; rel="successor-version"`, w.Header().Get("Link"))
+}
+
+func TestLegacyRoutes_Disabled(t *testing.T) {
+	s := NewServer(UseCases{}, WithLegacyRoutes(false, time.Time{}, time.Time{}))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/sensors/42", nil)
+	s.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPut, "/v1/sensors/42", nil)
+	s.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"crypto/tls"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// deviceNamesKey - ключ gin-контекста с именами из сертификата устройства
+const deviceNamesKey = "device_names"
+
+var (
+	errCertificateRequired = &httpError{http.StatusUnauthorized, "certificate_required", "client certificate is required"}
+	errCertificateMismatch = &httpError{http.StatusForbidden, "certificate_mismatch", "client certificate does not match sensor serial number"}
+)
+
+// deviceAuthMiddleware - запоминает имена из проверенного клиентского сертификата, по ним
+// authorizeDevice решает, может ли клиент присылать события от имени датчика
+func deviceAuthMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set(deviceNamesKey, certificateNames(ctx.Request.TLS))
+	}
+}
+
+// authorizeDevice - серийный номер serial должен быть в сертификате клиента.
+// Без deviceAuthMiddleware проверка отключена
+func authorizeDevice(ctx *gin.Context, serial string) error {
+	v, ok := ctx.Get(deviceNamesKey)
+	if !ok {
+		return nil
+	}
+	names, _ := v.([]string)
+	if len(names) == 0 {
+		return errCertificateRequired
+	}
+	if !slices.Contains(names, serial) {
+		return errCertificateMismatch
+	}
+	return nil
+}
+
+// certificateNames - CN и DNS SAN сертификата клиента, если он прошёл проверку
+func certificateNames(state *tls.ConnectionState) []string {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	leaf := state.VerifiedChains[0][0]
+	names := append([]string{}, leaf.DNSNames...)
+	if leaf.Subject.CommonName != "" {
+		names = append(names, leaf.Subject.CommonName)
+	}
+	return names
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"homework/pkg/tlsreload"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tlstest "homework/pkg/tls_test"
+)
+
+func TestDeviceAuth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	sr := usecase.NewMockSensorRepository(ctrl)
+	sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), "0000000001").AnyTimes().Return(&domain.Sensor{ID: 1, SerialNumber: "0000000001"}, nil)
+	sr.EXPECT().UpdateSensor(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+	er := usecase.NewMockEventRepository(ctrl)
+	er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
+
+	dir := t.TempDir()
+	ca := tlstest.NewCA(t, "devices")
+	certFile, keyFile := ca.Server(t, "smart-home").Write(t, dir, "server")
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, ca.PEM, 0o600))
+
+	s := NewServer(UseCases{Event: usecase.NewEvent(er, sr)},
+		WithTLS(certFile, keyFile, 0), WithClientCA(caFile, tlsreload.ClientAuthOptional))
+	certs, err := tlsreload.New(certFile, keyFile, tlsreload.WithClientCA(caFile, tlsreload.ClientAuthOptional))
+	require.NoError(t, err)
+	srv := httptest.NewUnstartedServer(s.router)
+	srv.TLS = certs.TLSConfig()
+	srv.StartTLS()
+	defer srv.Close()
+
+	client := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      ca.Pool(),
+			Certificates: certs,
+		}}}
+	}
+	post := func(c *http.Client) int {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, srv.URL+"/v1/events",
+			bytes.NewBufferString(`{"sensor_serial_number":"0000000001","payload":1}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := c.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	tests := []struct {
+		name   string
+		client *http.Client
+		want   int
+	}{
+		{"serial in common name", client(ca.Client(t, "0000000001").TLS(t)), http.StatusCreated},
+		{"serial in dns san", client(ca.Client(t, "sensor", "0000000001").TLS(t)), http.StatusCreated},
+		{"other device", client(ca.Client(t, "0000000002").TLS(t)), http.StatusForbidden},
+		{"no certificate", client(), http.StatusUnauthorized},
+		// клиент не предлагает сертификат, выпущенный не тем CA, и остаётся анонимным
+		{"untrusted issuer", client(tlstest.NewCA(t, "rogue").Client(t, "0000000001").TLS(t)), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, post(tt.client))
+		})
+	}
+
+	// чтения доступны без сертификата в режиме optional
+	resp, err := client().Get(srv.URL + "/ping")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestRun_TLSError(t *testing.T) {
+	s := NewServer(UseCases{}, WithPort(0), WithTLS("missing.crt", "missing.key", 0))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.ErrorContains(t, s.Run(ctx, cancel), "load tls certificates")
+}
+
+func TestClientAuthRequire(t *testing.T) {
+	dir := t.TempDir()
+	ca := tlstest.NewCA(t, "devices")
+	certFile, keyFile := ca.Server(t, "smart-home").Write(t, dir, "server")
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, ca.PEM, 0o600))
+
+	certs, err := tlsreload.New(certFile, keyFile, tlsreload.WithClientCA(caFile, tlsreload.ClientAuthRequire))
+	require.NoError(t, err)
+	srv := httptest.NewUnstartedServer(NewServer(UseCases{}).router)
+	srv.TLS = certs.TLSConfig()
+	srv.StartTLS()
+	defer srv.Close()
+
+	anonymous := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: ca.Pool()}}}
+	_, err = anonymous.Get(srv.URL + "/ping")
+	assert.Error(t, err, "без сертификата соединение не устанавливается")
+
+	device := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      ca.Pool(),
+		Certificates: []tls.Certificate{ca.Client(t, "0000000001").TLS(t)},
+	}}}
+	resp, err := device.Get(srv.URL + "/ping")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// docsFS - собственный просмотрщик спецификации (не Swagger UI) со скриптами и стилями; всё встроено
+// в бинарник, так что /docs открывается и без доступа к внешним CDN
+//
+//go:embed docs
+var docsFS embed.FS
+
+// setupDocsRouter - спецификация spec (YAML) на /openapi.yaml и /openapi.json и страница документации на /docs.
+// Прежний адрес спецификации /swagger.json перенаправляет на /openapi.json.
+func setupDocsRouter(r *gin.Engine, spec []byte) {
+	specJSON, err := yamlToJSON(spec)
+
+	r.GET("/openapi.yaml", func(ctx *gin.Context) {
+		ctx.Data(http.StatusOK, "application/yaml", spec)
+	})
+	r.GET("/openapi.json", func(ctx *gin.Context) {
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		ctx.Data(http.StatusOK, "application/json", specJSON)
+	})
+	r.GET("/swagger.json", func(ctx *gin.Context) {
+		ctx.Redirect(http.StatusMovedPermanently, "/openapi.json")
+	})
+	r.GET("/docs", func(ctx *gin.Context) {
+		serveDocsFile(ctx, "index.html")
+	})
+	r.GET("/docs/:file", func(ctx *gin.Context) {
+		serveDocsFile(ctx, ctx.Param("file"))
+	})
+}
+
+// serveDocsFile - отдаёт файл из каталога docs, тип определяется по расширению
+func serveDocsFile(ctx *gin.Context, name string) {
+	data, err := fs.ReadFile(docsFS, path.Join("docs", name))
+	if err != nil {
+		abort(ctx, errRouteNotFound)
+		return
+	}
+	ctx.Data(http.StatusOK, mime.TypeByExtension(path.Ext(name)), data)
+}
+
+func yamlToJSON(spec []byte) ([]byte, error) {
+	var doc any
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("parse openapi spec: %w", err)
+	}
+	return json.Marshal(doc)
+}
@@ -0,0 +1,116 @@
+// Просмотр спецификации OpenAPI без внешних зависимостей: страница встроена в бинарник
+// и должна открываться в сети без доступа к CDN.
+(() => {
+  "use strict";
+
+  const methods = ["get", "head", "post", "put", "patch", "delete", "options"];
+  const specURL = document.currentScript.dataset.spec;
+  const root = document.getElementById("spec");
+
+  const el = (tag, props, ...children) => {
+    const node = document.createElement(tag);
+    Object.assign(node, props);
+    node.append(...children.filter((c) => c !== undefined && c !== null));
+    return node;
+  };
+
+  const resolve = (spec, value) => {
+    if (!value || !value.$ref) {
+      return value;
+    }
+    return value.$ref.replace(/^#\//, "").split("/").reduce((node, part) => node && node[part], spec);
+  };
+
+  const schemaName = (schema) => {
+    if (!schema) {
+      return "";
+    }
+    if (schema.$ref) {
+      return schema.$ref.split("/").pop();
+    }
+    if (schema.type === "array") {
+      return schemaName(schema.items) + "[]";
+    }
+    return [].concat(schema.type || "object").join(" | ") + (schema.format ? " (" + schema.format + ")" : "");
+  };
+
+  const text = (value) => (value ? el("p", { textContent: value }) : null);
+
+  const table = (head, rows) => el("table", {},
+    el("tr", {}, ...head.map((h) => el("th", { textContent: h }))),
+    ...rows.map((row) => el("tr", {}, ...row.map((cell) => el("td", { textContent: cell ?? "" })))));
+
+  const parameters = (spec, op, item) => {
+    const params = [...(item.parameters || []), ...(op.parameters || [])].map((p) => resolve(spec, p));
+    if (params.length === 0) {
+      return null;
+    }
+    return el("div", {}, el("h4", { textContent: "Параметры" }),
+      table(["Имя", "Где", "Тип", "Обязателен", "Описание"],
+        params.map((p) => [p.name, p.in, schemaName(p.schema), p.required ? "да" : "", p.description])));
+  };
+
+  const content = (value) => Object.entries(value || {}).map(([type, media]) => type + ": " + schemaName(media.schema)).join(", ");
+
+  const requestBody = (spec, op) => {
+    const body = resolve(spec, op.requestBody);
+    if (!body) {
+      return null;
+    }
+    return el("div", {}, el("h4", { textContent: "Тело запроса" }), text(body.description), el("pre", { textContent: content(body.content) }));
+  };
+
+  const responses = (spec, op) => el("div", {}, el("h4", { textContent: "Ответы" }),
+    table(["Код", "Описание", "Тело"], Object.entries(op.responses || {}).map(([code, r]) => {
+      const response = resolve(spec, r) || {};
+      return [code, response.description, content(response.content)];
+    })));
+
+  const operation = (spec, path, item, method) => {
+    const op = item[method];
+    return el("details", {},
+      el("summary", { className: op.deprecated ? "deprecated" : "" },
+        el("span", { className: "method " + method, textContent: method }),
+        el("span", { className: "path", textContent: path }),
+        op.summary ? " - " + op.summary : ""),
+      el("div", {}, text(op.description), parameters(spec, op, item), requestBody(spec, op), responses(spec, op)));
+  };
+
+  const schemas = (spec) => Object.entries((spec.components || {}).schemas || {}).map(([name, schema]) =>
+    el("details", {}, el("summary", { textContent: name }),
+      el("div", {}, text(schema.description), el("pre", { textContent: JSON.stringify(schema, null, 2) }))));
+
+  const render = (spec) => {
+    document.title = spec.info.title;
+    document.getElementById("title").textContent = spec.info.title + " " + spec.info.version;
+
+    const byTag = new Map((spec.tags || []).map((tag) => [tag.name, []]));
+    for (const [path, item] of Object.entries(spec.paths || {})) {
+      for (const method of methods.filter((m) => item[m])) {
+        const tag = (item[method].tags || ["default"])[0];
+        if (!byTag.has(tag)) {
+          byTag.set(tag, []);
+        }
+        byTag.get(tag).push(operation(spec, path, item, method));
+      }
+    }
+
+    root.replaceChildren(text(spec.info.description));
+    for (const [tag, ops] of byTag) {
+      if (ops.length > 0) {
+        root.append(el("h2", { textContent: tag }), ...ops);
+      }
+    }
+    root.append(el("h2", { textContent: "Схемы" }), ...schemas(spec));
+  };
+
+  fetch(specURL)
+    .then((response) => {
+      if (!response.ok) {
+        throw new Error(response.status + " " + response.statusText);
+      }
+      return response.json();
+    })
+    .then(render)
+    .catch((err) => root.replaceChildren(el("p", { textContent: "Не удалось загрузить спецификацию: " + err.message })));
+})();
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"homework/internal/models"
+	"homework/internal/ratelimit"
+	"homework/internal/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// problemContentType - тип тела ответа об ошибке по RFC 7807
+const problemContentType = "application/problem+json"
+
+// httpError - ошибка транспортного уровня: заголовки, тело запроса, параметры пути и запроса
+type httpError struct {
+	status int
+	code   string
+	detail string
+}
+
+func (e *httpError) Error() string {
+	return e.detail
+}
+
+var (
+	errUnsupportedMediaType = &httpError{http.StatusUnsupportedMediaType, "unsupported_media_type", "content-type must be application/json"}
+	errNotAcceptable        = &httpError{http.StatusNotAcceptable, "not_acceptable", "accept header must be application/json"}
+	errRouteNotFound        = &httpError{http.StatusNotFound, "route_not_found", "route not found"}
+	errMethodNotAllowed     = &httpError{http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed"}
+	errUnauthorized         = &httpError{http.StatusUnauthorized, "unauthorized", "invalid or missing bearer token"}
+	errAdminTokenRequired   = &httpError{http.StatusForbidden, "admin_token_required", "admin.token must be configured to use admin endpoints"}
+	errPanic                = errors.New("panic in handler")
+)
+
+// errMalformedBody - тело запроса не разбирается как JSON
+func errMalformedBody(err error) error {
+	return &httpError{http.StatusBadRequest, "malformed_body", err.Error()}
+}
+
+// errValidation - тело запроса разобрано, но не прошло проверку схемы
+func errValidation(err error) error {
+	return &httpError{http.StatusUnprocessableEntity, "validation_failed", err.Error()}
+}
+
+// errInvalidParameter - параметр пути не число
+func errInvalidParameter(name string) error {
+	return &httpError{http.StatusUnprocessableEntity, "invalid_parameter", name + " must be a number"}
+}
+
+// errInvalidQuery - параметр запроса отсутствует или некорректен
+func errInvalidQuery(detail string) error {
+	return &httpError{http.StatusBadRequest, "invalid_query", detail}
+}
+
+// kindStatus - код ответа для каждого класса ошибок usecase
+var kindStatus = map[usecase.Kind]int{
+	usecase.KindInvalid:      http.StatusUnprocessableEntity,
+	usecase.KindNotFound:     http.StatusNotFound,
+	usecase.KindConflict:     http.StatusConflict,
+	usecase.KindForbidden:    http.StatusForbidden,
+	usecase.KindPrecondition: http.StatusPreconditionFailed,
+}
+
+// abort - прерывает обработку запроса; ответ с ошибкой запишет errorMiddleware
+func abort(ctx *gin.Context, err error) {
+	_ = ctx.Error(err)
+	ctx.Abort()
+}
+
+// errorMiddleware - превращает последнюю ошибку запроса в ответ application/problem+json,
+// если обработчик сам ничего не записал. Сама ошибка попадает в access-лог из ctx.Errors
+func errorMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+		writeProblem(ctx, ctx.Errors.Last().Err)
+	}
+}
+
+func writeProblem(ctx *gin.Context, err error) {
+	var limited *ratelimit.Error
+	if errors.As(err, &limited) {
+		ctx.Header("Retry-After", strconv.Itoa(limited.RetryAfterSeconds()))
+	}
+	problem := problemFor(err)
+	problem.Instance = ctx.Request.URL.Path
+	ctx.Render(int(*problem.Status), problemRender{problem})
+}
+
+// problemFor - тело ответа для ошибки; причины внутренних ошибок клиенту не показываются
+func problemFor(err error) *models.Problem {
+	status, code, detail := http.StatusInternalServerError, "internal_error", "internal server error"
+
+	var httpErr *httpError
+	var ucErr *usecase.Error
+	var limited *ratelimit.Error
+	switch {
+	case errors.As(err, &httpErr):
+		status, code, detail = httpErr.status, httpErr.code, httpErr.detail
+	case errors.As(err, &ucErr):
+		if s, ok := kindStatus[ucErr.Kind]; ok {
+			status, code, detail = s, ucErr.Code, ucErr.Message
+		}
+	case errors.As(err, &limited):
+		status, code, detail = http.StatusTooManyRequests, "rate_limited", "rate limit exceeded"
+	case errors.Is(err, context.DeadlineExceeded):
+		status, code, detail = http.StatusGatewayTimeout, "timeout", "request timed out"
+	}
+
+	s := int64(status)
+	title := http.StatusText(status)
+	return &models.Problem{
+		Type:   "about:blank",
+		Title:  &title,
+		Status: &s,
+		Code:   &code,
+		Detail: detail,
+	}
+}
+
+// problemRender - gin-рендер JSON с типом application/problem+json
+type problemRender struct {
+	problem *models.Problem
+}
+
+func (r problemRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	body, err := r.problem.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+func (r problemRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", problemContentType)
+}
@@ -0,0 +1,75 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/models"
+	"homework/internal/ratelimit"
+	"homework/internal/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblemFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int64
+		code   string
+		detail string
+	}{
+		{"transport", errNotAcceptable, http.StatusNotAcceptable, "not_acceptable", "accept header must be application/json"},
+		{"invalid parameter", errInvalidParameter("sensor_id"), http.StatusUnprocessableEntity, "invalid_parameter", "sensor_id must be a number"},
+		{"usecase not found", usecase.ErrSensorNotFound, http.StatusNotFound, "sensor_not_found", "sensor not found"},
+		{"usecase conflict", usecase.ErrSensorAlreadyAttached, http.StatusConflict, "sensor_already_attached", "sensor is already attached to user"},
+		{"wrapped usecase", fmt.Errorf("attach: %w", usecase.ErrUserNotFound), http.StatusNotFound, "user_not_found", "user not found"},
+		{"rate limited", &ratelimit.Error{Scope: "events"}, http.StatusTooManyRequests, "rate_limited", "rate limit exceeded"},
+		{"timeout", fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, "timeout", "request timed out"},
+		{"internal", errors.New("pq: password authentication failed"), http.StatusInternalServerError, "internal_error", "internal server error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := problemFor(tt.err)
+			require.NoError(t, problem.Validate(nil))
+			assert.Equal(t, tt.status, *problem.Status)
+			assert.Equal(t, tt.code, *problem.Code)
+			assert.Equal(t, tt.detail, problem.Detail)
+			assert.Equal(t, http.StatusText(int(tt.status)), *problem.Title)
+		})
+	}
+}
+
+func TestErrorMiddleware(t *testing.T) {
+	engine := gin.New()
+	engine.Use(errorMiddleware())
+	engine.GET("/sensors/:sensor_id", func(ctx *gin.Context) { abort(ctx, usecase.ErrSensorNotFound) })
+	engine.GET("/written", func(ctx *gin.Context) {
+		_ = ctx.Error(errors.New("already handled"))
+		ctx.String(http.StatusTeapot, "handled")
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/sensors/42", nil)
+	engine.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+	var problem models.Problem
+	require.NoError(t, problem.UnmarshalBinary(w.Body.Bytes()))
+	assert.Equal(t, "sensor_not_found", *problem.Code)
+	assert.Equal(t, "/sensors/42", problem.Instance)
+	assert.Equal(t, "about:blank", problem.Type)
+
+	// ответ, который обработчик записал сам, не переписывается
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/written", nil)
+	engine.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, "handled", w.Body.String())
+}
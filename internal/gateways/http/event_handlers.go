@@ -2,8 +2,11 @@ package http
 
 import (
 	"homework/internal/domain"
+	"homework/internal/logging"
 	"homework/internal/models"
+	"maps"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,23 +15,46 @@ import (
 func postEvent(us UseCases) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		toCreate := &models.SensorEvent{}
-
-		validate(ctx, toCreate)
-
-		if ctx.IsAborted() {
+		if err := validate(ctx, toCreate); err != nil {
+			abort(ctx, err)
 			return
 		}
 
-		err := us.Event.ReceiveEvent(ctx, &domain.Event{
-			Timestamp:          time.Now(),
-			SensorSerialNumber: *toCreate.SensorSerialNumber,
-			Payload:            *toCreate.Payload,
-		})
+		logging.AddFields(ctx, "sensor_serial", *toCreate.SensorSerialNumber)
+		if err := authorizeDevice(ctx, *toCreate.SensorSerialNumber); err != nil {
+			abort(ctx, err)
+			return
+		}
+		var err error
+		if toCreate.Values != nil {
+			err = us.Event.ReceiveEvents(ctx, channelEvents(toCreate, time.Now()))
+		} else {
+			err = us.Event.ReceiveEvent(ctx, &domain.Event{
+				Timestamp:          time.Now(),
+				SensorSerialNumber: *toCreate.SensorSerialNumber,
+				Payload:            *toCreate.Payload,
+				Unit:               toCreate.Unit,
+			})
+		}
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			abort(ctx, err)
 			return
 		}
 
 		ctx.Status(http.StatusCreated)
 	}
 }
+
+// channelEvents - пакет многоканального датчика как события по каналам в порядке имён
+func channelEvents(toCreate *models.SensorEvent, timestamp time.Time) []*domain.Event {
+	events := make([]*domain.Event, 0, len(toCreate.Values))
+	for _, channel := range slices.Sorted(maps.Keys(toCreate.Values)) {
+		events = append(events, &domain.Event{
+			Timestamp:          timestamp,
+			SensorSerialNumber: *toCreate.SensorSerialNumber,
+			Channel:            channel,
+			Payload:            toCreate.Values[channel],
+		})
+	}
+	return events
+}
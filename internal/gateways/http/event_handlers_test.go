@@ -0,0 +1,127 @@
+package http
+
+import (
+	"encoding/json"
+	eventInmemory "homework/internal/repository/event/inmemory"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
+	"homework/internal/usecase"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasurements(t *testing.T) {
+	sensors := sensorInmemory.NewSensorRepository()
+	s := NewServer(UseCases{
+		Sensor: usecase.NewSensor(sensors),
+		Event:  usecase.NewEvent(eventInmemory.NewEventRepository(), sensors),
+	})
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	w := serve(s, http.MethodPost, "/v1/sensors", headers,
+		`{"serial_number":"0000000001","type":"adc","description":"thermometer","is_active":true,"unit":"°C","scale":1}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var sensor map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sensor))
+	assert.Equal(t, "°C", sensor["unit"])
+	assert.Equal(t, float64(1), sensor["scale"])
+
+	// целое значение в десятых долях градуса и дробное, уже умноженное на 10^scale
+	for _, body := range []string{
+		`{"sensor_serial_number":"0000000001","payload":215}`,
+		`{"sensor_serial_number":"0000000001","payload":222.5,"unit":"°C"}`,
+	} {
+		w = serve(s, http.MethodPost, "/v1/events", headers, body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+	w = serve(s, http.MethodPost, "/v1/events", headers, `{"sensor_serial_number":"0000000001","payload":70,"unit":"°F"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "unit_mismatch")
+
+	w = serve(s, http.MethodGet, "/v1/sensors/1", nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"current_state":22.25`)
+
+	w = serve(s, http.MethodGet, "/v1/sensors/1/history?start_date=Mon,%2001%20Jan%202024%2000:00:00%20UTC&end_date=Mon,%2001%20Jan%202035%2000:00:00%20UTC", nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var history []struct {
+		Payload float64 `json:"payload"`
+		Unit    string  `json:"unit"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+	require.Len(t, history, 2)
+	assert.Equal(t, 21.5, history[0].Payload)
+	assert.Equal(t, 22.25, history[1].Payload)
+	assert.Equal(t, "°C", history[1].Unit)
+}
+
+func TestChannels(t *testing.T) {
+	sensors := sensorInmemory.NewSensorRepository()
+	s := NewServer(UseCases{
+		Sensor: usecase.NewSensor(sensors),
+		Event:  usecase.NewEvent(eventInmemory.NewEventRepository(), sensors),
+	})
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	w := serve(s, http.MethodPost, "/v1/sensors", headers, `{"serial_number":"0000000001","type":"adc","description":"climate","is_active":true,
+		"channels":[{"name":"temperature","unit":"°C","scale":1},{"name":"humidity","unit":"%"},{"name":"battery","unit":"%"}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = serve(s, http.MethodPost, "/v1/events", headers,
+		`{"sensor_serial_number":"0000000001","values":{"temperature":215,"humidity":45.5,"battery":87}}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	for _, tt := range []struct {
+		body string
+		code string
+	}{
+		{`{"sensor_serial_number":"0000000001","payload":1}`, "channel_required"},
+		{`{"sensor_serial_number":"0000000001","values":{"pressure":1000}}`, "unknown_channel"},
+		{`{"sensor_serial_number":"0000000001","payload":1,"values":{"humidity":1}}`, "validation_failed"},
+		{`{"sensor_serial_number":"0000000001","values":{}}`, "validation_failed"},
+	} {
+		w = serve(s, http.MethodPost, "/v1/events", headers, tt.body)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, tt.body)
+		assert.Contains(t, w.Body.String(), tt.code, tt.body)
+	}
+
+	w = serve(s, http.MethodGet, "/v1/sensors/1", nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var sensor struct {
+		Channels []struct {
+			Name         string  `json:"name"`
+			Unit         string  `json:"unit"`
+			CurrentState float64 `json:"current_state"`
+		} `json:"channels"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sensor))
+	require.Len(t, sensor.Channels, 3)
+	assert.Equal(t, "temperature", sensor.Channels[0].Name)
+	assert.Equal(t, 21.5, sensor.Channels[0].CurrentState)
+	assert.Equal(t, 45.5, sensor.Channels[1].CurrentState)
+
+	history := "/v1/sensors/1/history?start_date=Mon,%2001%20Jan%202024%2000:00:00%20UTC&end_date=Mon,%2001%20Jan%202035%2000:00:00%20UTC"
+	w = serve(s, http.MethodGet, history+"&channel=humidity", nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var humidity []struct {
+		Channel string  `json:"channel"`
+		Payload float64 `json:"payload"`
+		Unit    string  `json:"unit"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &humidity))
+	require.Len(t, humidity, 1)
+	assert.Equal(t, "humidity", humidity[0].Channel)
+	assert.Equal(t, 45.5, humidity[0].Payload)
+	assert.Equal(t, "%", humidity[0].Unit)
+
+	w = serve(s, http.MethodGet, history, nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var all []map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &all))
+	assert.Len(t, all, 3)
+
+	w = serve(s, http.MethodGet, history+"&channel=pressure", nil, "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
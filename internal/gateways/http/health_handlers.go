@@ -0,0 +1,42 @@
+package http
+
+import (
+	"errors"
+	"homework/internal/health"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// shutdownCheck - имя проверки, которая не проходит, пока сервер останавливается
+const shutdownCheck = "shutdown"
+
+var errShuttingDown = errors.New("server is shutting down")
+
+func setupHealthRouter(r *gin.Engine, checker *health.Checker, draining *atomic.Bool) {
+	// /healthz отвечает, пока процесс жив и обслуживает запросы; зависимости не проверяются,
+	// чтобы падение базы не приводило к перезапуску процесса оркестратором
+	r.GET("/healthz", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+	})
+	r.GET("/readyz", getReadiness(checker, draining))
+}
+
+func getReadiness(checker *health.Checker, draining *atomic.Bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		report := health.Report{Status: health.StatusOK, Checks: map[string]health.Result{}}
+		if checker != nil {
+			report = checker.Run(ctx)
+		}
+		if draining.Load() {
+			report.Fail(shutdownCheck, errShuttingDown)
+		}
+
+		status := http.StatusOK
+		if report.Status != health.StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		ctx.JSON(status, report)
+	}
+}
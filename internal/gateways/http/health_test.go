@@ -0,0 +1,76 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"homework/internal/health"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getReport(t *testing.T, s *Server, path string) (int, health.Report) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, path, nil)
+	s.router.ServeHTTP(w, req)
+
+	var report health.Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	return w.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	dbErr := errors.New("connection refused")
+	var dbDown bool
+	checker := health.NewChecker(time.Second)
+	checker.Add("postgres", func(context.Context) error {
+		if dbDown {
+			return dbErr
+		}
+		return nil
+	})
+	s := NewServer(UseCases{}, WithHealth(checker))
+
+	code, report := getReport(t, s, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusOK, report.Status)
+	assert.Equal(t, health.StatusOK, report.Checks["postgres"].Status)
+
+	dbDown = true
+	code, report = getReport(t, s, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusFail, report.Status)
+	assert.Equal(t, dbErr.Error(), report.Checks["postgres"].Error)
+
+	// живость от зависимостей не зависит
+	code, report = getReport(t, s, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusOK, report.Status)
+}
+
+func TestReadiness_Shutdown(t *testing.T) {
+	s := NewServer(UseCases{}, WithPort(0), WithShutdownDelay(200*time.Millisecond))
+
+	code, _ := getReport(t, s, "/readyz")
+	require.Equal(t, http.StatusOK, code)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx, cancel) }()
+	cancel()
+
+	assert.Eventually(t, func() bool {
+		code, report := getReport(t, s, "/readyz")
+		return code == http.StatusServiceUnavailable && report.Checks[shutdownCheck].Status == health.StatusFail
+	}, time.Second, 10*time.Millisecond)
+	// пока идёт задержка, остальные запросы обслуживаются
+	code, _ = getReport(t, s, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+
+	require.NoError(t, <-done)
+}
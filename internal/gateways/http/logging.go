@@ -0,0 +1,94 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"homework/internal/logging"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestIDHeader - заголовок с ID запроса; пришедший от клиента ID сохраняется
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength - более длинные ID от клиента заменяются своими, чтобы не раздувать логи
+const maxRequestIDLength = 128
+
+// requestIDMiddleware - присваивает запросу ID и кладёт в контекст логгер с ним и с trace_id
+func requestIDMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(requestIDHeader)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = newRequestID()
+		}
+		ctx.Header(requestIDHeader, id)
+
+		l := logger.With("request_id", id)
+		if sc := trace.SpanContextFromContext(ctx.Request.Context()); sc.IsValid() {
+			l = l.With("trace_id", sc.TraceID().String())
+		}
+		reqCtx := logging.WithLogger(ctx.Request.Context(), l)
+		ctx.Request = ctx.Request.WithContext(logging.WithFields(reqCtx))
+		ctx.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// accessLogMiddleware - пишет запись на каждый запрос; 5xx - как ошибку, 4xx - как предупреждение.
+// Параметры пути (sensor_id, user_id) попадают в запись сами, остальное обработчики добавляют через logging.AddFields
+func accessLogMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		attrs := []any{
+			"method", ctx.Request.Method,
+			"route", route,
+			"path", ctx.Request.URL.Path,
+			"status", status,
+			"duration", time.Since(start),
+			"bytes", max(ctx.Writer.Size(), 0),
+			"client_ip", ctx.ClientIP(),
+		}
+		for _, param := range ctx.Params {
+			attrs = append(attrs, param.Key, param.Value)
+		}
+		attrs = append(attrs, logging.Fields(ctx)...)
+		if len(ctx.Errors) > 0 {
+			attrs = append(attrs, "err", ctx.Errors.String())
+		}
+		logging.FromContext(ctx).Log(ctx, level, "http request", attrs...)
+	}
+}
+
+// recoveryMiddleware - отвечает 500 на панику в обработчике и пишет её в лог запроса
+func recoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(ctx *gin.Context, recovered any) {
+		logging.FromContext(ctx).Error("panic in handler", "panic", recovered, "stack", string(debug.Stack()))
+		ctx.Abort()
+		writeProblem(ctx, errPanic)
+	})
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"homework/internal/logging"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLoggedEngine - роутер с middleware логирования, пишущий записи в JSON в buf
+func newLoggedEngine(t *testing.T, buf *bytes.Buffer) *gin.Engine {
+	logger, err := logging.New(buf, logging.FormatJSON, slog.LevelDebug)
+	require.NoError(t, err)
+
+	engine := gin.New()
+	engine.ContextWithFallback = true
+	engine.Use(requestIDMiddleware(logger), accessLogMiddleware(), recoveryMiddleware())
+	return engine
+}
+
+// records - записи лога по одной на строку
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var result []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		result = append(result, record)
+	}
+	return result
+}
+
+func TestRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	engine := newLoggedEngine(t, &buf)
+	engine.GET("/ping", func(c *gin.Context) { c.String(http.StatusOK, "pong") })
+
+	t.Run("generated", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/ping", nil)
+		engine.ServeHTTP(w, req)
+		assert.Len(t, w.Header().Get(requestIDHeader), 32)
+	})
+
+	t.Run("from client", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/ping", nil)
+		req.Header.Set(requestIDHeader, "client-id")
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, "client-id", w.Header().Get(requestIDHeader))
+	})
+
+	t.Run("too long from client", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/ping", nil)
+		req.Header.Set(requestIDHeader, strings.Repeat("a", maxRequestIDLength+1))
+		engine.ServeHTTP(w, req)
+		assert.Len(t, w.Header().Get(requestIDHeader), 32)
+	})
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	engine := newLoggedEngine(t, &buf)
+	engine.POST("/users/:user_id/sensors", func(c *gin.Context) {
+		logging.FromContext(c).Info("inside handler")
+		logging.AddFields(c, "sensor_serial", "0000000001")
+		c.Status(http.StatusCreated)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/users/7/sensors", nil)
+	req.Header.Set(requestIDHeader, "req-1")
+	engine.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	recs := records(t, &buf)
+	require.Len(t, recs, 2)
+	assert.Equal(t, "inside handler", recs[0]["msg"])
+	assert.Equal(t, "req-1", recs[0]["request_id"])
+
+	access := recs[1]
+	assert.Equal(t, "http request", access["msg"])
+	assert.Equal(t, "INFO", access["level"])
+	assert.Equal(t, "req-1", access["request_id"])
+	assert.Equal(t, "/users/:user_id/sensors", access["route"])
+	assert.Equal(t, float64(http.StatusCreated), access["status"])
+	assert.Equal(t, "7", access["user_id"])
+	assert.Equal(t, "0000000001", access["sensor_serial"])
+}
+
+func TestAccessLog_Errors(t *testing.T) {
+	var buf bytes.Buffer
+	engine := newLoggedEngine(t, &buf)
+	engine.GET("/fail", func(c *gin.Context) {
+		_ = c.Error(assert.AnError)
+		c.Status(http.StatusInternalServerError)
+	})
+	engine.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	for _, path := range []string{"/fail", "/panic"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusInternalServerError, w.Code, path)
+	}
+
+	recs := records(t, &buf)
+	require.Len(t, recs, 3)
+	assert.Equal(t, "ERROR", recs[0]["level"])
+	assert.Contains(t, recs[0]["err"], assert.AnError.Error())
+	assert.Equal(t, "panic in handler", recs[1]["msg"])
+	assert.Equal(t, "boom", recs[1]["panic"])
+	assert.Equal(t, "ERROR", recs[2]["level"])
+	assert.Equal(t, "/panic", recs[2]["route"])
+}
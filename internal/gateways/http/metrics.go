@@ -0,0 +1,29 @@
+package http
+
+import (
+	"homework/internal/metrics"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute - метка маршрута для запросов, не попавших ни в один маршрут
+const unmatchedRoute = "unmatched"
+
+// metricsMiddleware - замеряет длительность запросов; маршрут берётся шаблоном, чтобы не плодить метки по ID
+func metricsMiddleware(m *metrics.Metrics) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		m.ObserveHTTPRequest(ctx.Request.Method, route, ctx.Writer.Status(), time.Since(start))
+	}
+}
+
+func setupMetricsRouter(r *gin.Engine, m *metrics.Metrics) {
+	r.GET("/metrics", gin.WrapH(m.Handler()))
+}
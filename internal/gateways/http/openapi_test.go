@@ -0,0 +1,286 @@
+package http
+
+import (
+	"encoding/json"
+	"homework/api"
+	"homework/internal/health"
+	"homework/internal/metrics"
+	"homework/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// specOperations - операции из спецификации в виде "GET /sensors/{sensor_id}"
+func specOperations(t *testing.T, spec map[string]any) []string {
+	paths, ok := spec["paths"].(map[string]any)
+	require.True(t, ok, "в спецификации нет paths")
+
+	var ops []string
+	for path, item := range paths {
+		for method := range item.(map[string]any) {
+			switch method {
+			case "get", "head", "post", "put", "patch", "delete", "options":
+				ops = append(ops, strings.ToUpper(method)+" "+path)
+			}
+		}
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+var ginParam = regexp.MustCompile(`[:*]([A-Za-z_]+)`)
+
+// routerOperations - маршруты роутера со всеми включёнными опциями
+func routerOperations() []string {
+	// пути без версии - копия v1, в спецификации они описаны только словами
+	s := NewServer(UseCases{}, WithMetrics(metrics.New()), WithAdminConfig("", map[string]any{}), WithLegacyRoutes(false, time.Time{}, time.Time{}))
+	var ops []string
+	for _, route := range s.router.Routes() {
+		ops = append(ops, route.Method+" "+ginParam.ReplaceAllString(route.Path, "{$1}"))
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+func loadSpec(t *testing.T) map[string]any {
+	var spec map[string]any
+	require.NoError(t, yaml.Unmarshal(api.OpenAPI, &spec))
+	return spec
+}
+
+// Спецификация и роутер должны описывать одни и те же маршруты
+func TestOpenAPI_MatchesRouter(t *testing.T) {
+	spec := loadSpec(t)
+	assert.Equal(t, "3.1.0", spec["openapi"])
+	assert.Equal(t, routerOperations(), specOperations(t, spec),
+		"маршруты роутера и api/openapi.yaml разошлись")
+}
+
+// Каждый параметр пути объявлен в операции, а каждая ссылка ведёт на существующий компонент
+func TestOpenAPI_Consistent(t *testing.T) {
+	spec := loadSpec(t)
+
+	pathParam := regexp.MustCompile(`\{([^}]+)\}`)
+	for path, item := range spec["paths"].(map[string]any) {
+		for method, op := range item.(map[string]any) {
+			declared := map[string]bool{}
+			if params, ok := op.(map[string]any)["parameters"].([]any); ok {
+				for _, p := range params {
+					p := p.(map[string]any)
+					if p["in"] == "path" {
+						declared[p["name"].(string)] = true
+					}
+				}
+			}
+			for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+				assert.True(t, declared[m[1]], "%s %s: не описан параметр пути %s", method, path, m[1])
+			}
+			assert.Len(t, declared, len(pathParam.FindAllString(path, -1)), "%s %s: лишние параметры пути", method, path)
+		}
+	}
+
+	var refs []string
+	var collect func(v any)
+	collect = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, child := range v {
+				if ref, ok := child.(string); ok && k == "$ref" {
+					refs = append(refs, ref)
+				}
+				collect(child)
+			}
+		case []any:
+			for _, child := range v {
+				collect(child)
+			}
+		}
+	}
+	collect(spec)
+	require.NotEmpty(t, refs)
+	for _, ref := range refs {
+		var node any = spec
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			m, ok := node.(map[string]any)
+			require.True(t, ok, "ссылка %s не разрешается", ref)
+			node, ok = m[part]
+			require.True(t, ok, "ссылка %s не разрешается", ref)
+		}
+	}
+}
+
+// schemaModels - типы, которыми обработчики читают и отдают схемы из components.schemas
+var schemaModels = map[string]any{
+	"User":                  models.User{},
+	"UserToCreate":          models.UserToCreate{},
+	"Problem":               models.Problem{},
+	"Sensor":                models.Sensor{},
+	"SensorType":            models.SensorType{},
+	"SensorChannel":         models.SensorChannel{},
+	"SensorToCreate":        models.SensorToCreate{},
+	"SensorChannelToCreate": models.SensorChannelToCreate{},
+	"SensorPatch":           models.SensorPatch{},
+	"SensorToUserBinding":   models.SensorToUserBinding{},
+	"SensorEvent":           models.SensorEvent{},
+	"HistoryOfEvents":       models.HistoryOfEvents{},
+	"History":               models.History{},
+	"HistoryGap":            models.HistoryGap{},
+	"Transition":            models.Transition{},
+	"DayCount":              models.DayCount{},
+	"SeriesQuery":           models.SeriesQuery{},
+	"SeriesResult":          models.SeriesResult{},
+	"Series":                models.Series{},
+	"Calibration":           models.Calibration{},
+	"CalibrationPoint":      models.CalibrationPoint{},
+	"CalibrationToUpdate":   models.CalibrationToUpdate{},
+	"CalibrationChange":     models.CalibrationChange{},
+	"Recalibration":         models.Recalibration{},
+	"RecalibrationResult":   models.RecalibrationResult{},
+	"RollupRebuild":         models.RollupRebuild{},
+	"RollupRebuildResult":   models.RollupRebuildResult{},
+	"HealthReport":          health.Report{},
+	"CheckResult":           health.Result{},
+}
+
+// jsonType - тип JSON Schema, в который кодируется значение типа t
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Struct:
+		// strfmt.DateTime, strfmt.Date и т.п. кодируются строкой
+		if t.Implements(reflect.TypeOf((*interface{ MarshalText() ([]byte, error) })(nil)).Elem()) ||
+			reflect.PointerTo(t).Implements(reflect.TypeOf((*interface{ MarshalText() ([]byte, error) })(nil)).Elem()) {
+			return "string"
+		}
+	}
+	return "object"
+}
+
+// Схемы запросов и ответов совпадают с моделями: те же свойства и типы, а обязательные свойства -
+// это поля без omitempty
+func TestOpenAPI_SchemasMatchModels(t *testing.T) {
+	schemas := loadSpec(t)["components"].(map[string]any)["schemas"].(map[string]any)
+
+	for name := range schemas {
+		assert.Contains(t, schemaModels, name, "схема %s не сопоставлена модели", name)
+	}
+	for name, model := range schemaModels {
+		schema, ok := schemas[name].(map[string]any)
+		if !assert.True(t, ok, "нет схемы %s", name) {
+			continue
+		}
+		properties, _ := schema["properties"].(map[string]any)
+
+		fields := map[string]string{}
+		var required []string
+		typ := reflect.TypeOf(model)
+		for i := 0; i < typ.NumField(); i++ {
+			tag, ok := typ.Field(i).Tag.Lookup("json")
+			if !ok || tag == "-" {
+				continue
+			}
+			field, options, _ := strings.Cut(tag, ",")
+			fields[field] = jsonType(typ.Field(i).Type)
+			if options != "omitempty" {
+				required = append(required, field)
+			}
+		}
+
+		specFields := map[string]string{}
+		for field, p := range properties {
+			p := p.(map[string]any)
+			switch v := p["type"].(type) {
+			case string:
+				specFields[field] = v
+			case []any:
+				// ["number", "null"] - значение может быть null
+				for _, alt := range v {
+					if alt != "null" {
+						specFields[field] = alt.(string)
+					}
+				}
+			default:
+				// $ref, oneOf - вложенный объект
+				specFields[field] = "object"
+			}
+		}
+		assert.Equal(t, fields, specFields, "свойства схемы %s и поля модели разошлись", name)
+
+		var specRequired []string
+		if list, ok := schema["required"].([]any); ok {
+			for _, field := range list {
+				specRequired = append(specRequired, field.(string))
+			}
+		}
+		assert.ElementsMatch(t, required, specRequired, "обязательные свойства схемы %s и поля модели без omitempty разошлись", name)
+	}
+}
+
+func TestDocs(t *testing.T) {
+	s := NewServer(UseCases{})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/openapi.json", nil)
+	s.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var spec map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+	assert.Equal(t, "3.1.0", spec["openapi"])
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/openapi.yaml", nil)
+	s.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, api.OpenAPI, w.Body.Bytes())
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/swagger.json", nil)
+	s.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/openapi.json", w.Header().Get("Location"))
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/docs", nil)
+	s.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), `src="docs/docs.js"`)
+	assert.NotContains(t, w.Body.String(), "https://", "страница не должна тянуть ресурсы с CDN")
+
+	for file, contentType := range map[string]string{"docs.js": "javascript", "docs.css": "text/css"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodGet, "/docs/"+file, nil)
+		s.router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, file)
+		assert.Contains(t, w.Header().Get("Content-Type"), contentType, file)
+		assert.NotEmpty(t, w.Body.Bytes(), file)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/docs/missing.js", nil)
+	s.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
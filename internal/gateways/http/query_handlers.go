@@ -0,0 +1,89 @@
+package http
+
+import (
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/models"
+	"homework/internal/usecase"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-openapi/strfmt"
+)
+
+// postSeriesQuery - значения нескольких датчиков по общим интервалам для сравнения на одном графике.
+// Сравнивать можно только датчики, привязанные к пользователю user_id; суточные интервалы и время
+// в ответе - в часовом поясе пользователя, если запрос не задаёт свой
+func postSeriesQuery(us UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := checkAccept(ctx); err != nil {
+			abort(ctx, err)
+			return
+		}
+		request := &models.SeriesQuery{}
+		if err := validate(ctx, request); err != nil {
+			abort(ctx, err)
+			return
+		}
+		bucket, err := parseDuration(*request.Bucket)
+		if err != nil || bucket <= 0 {
+			abort(ctx, errValidation(errors.New("bucket must be a positive duration such as 15m, 1h or 1d")))
+			return
+		}
+
+		logging.AddFields(ctx, "user_id", *request.UserID)
+		user, err := us.User.GetUser(ctx, *request.UserID)
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		location := user.Location()
+		if request.TimeZone != "" {
+			if location, err = usecase.LoadTimeZone(request.TimeZone); err != nil {
+				abort(ctx, err)
+				return
+			}
+		}
+		sensors, err := us.User.GetUserSensors(ctx, user.ID)
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		result, err := us.Event.QuerySeries(ctx, domain.SeriesQuery{
+			SensorIDs:   request.SensorIds,
+			Start:       time.Time(*request.StartDate),
+			End:         time.Time(*request.EndDate),
+			Bucket:      bucket,
+			Aggregation: domain.Aggregation(request.Aggregation),
+			Fill:        domain.Fill(request.Fill),
+			Location:    location,
+		}, sensors)
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+
+		answer := models.SeriesResult{
+			Buckets: make([]strfmt.DateTime, len(result.Buckets)),
+			Series:  make([]*models.Series, len(result.Series)),
+		}
+		for i, start := range result.Buckets {
+			answer.Buckets[i] = strfmt.DateTime(start.In(location))
+		}
+		for i := range result.Series {
+			series := &result.Series[i]
+			aggregation := string(series.Aggregation)
+			answer.Series[i] = &models.Series{
+				SensorID:    &series.SensorID,
+				Channel:     series.Channel,
+				Unit:        series.Unit,
+				Aggregation: &aggregation,
+				Values:      series.Values,
+				Counts:      series.Counts,
+			}
+		}
+		ctx.JSON(http.StatusOK, answer)
+	}
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"homework/internal/domain"
+	eventInmemory "homework/internal/repository/event/inmemory"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
+	userInmemory "homework/internal/repository/user/inmemory"
+	"homework/internal/usecase"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesQuery(t *testing.T) {
+	ctx := context.Background()
+	sensors := sensorInmemory.NewSensorRepository()
+	events := eventInmemory.NewEventRepository()
+	users := usecase.NewUser(userInmemory.NewUserRepository(), userInmemory.NewSensorOwnerRepository(), sensors)
+	s := NewServer(UseCases{
+		Sensor: usecase.NewSensor(sensors),
+		Event:  usecase.NewEvent(events, sensors),
+		User:   users,
+	})
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, serial := range []string{"0000000001", "0000000002", "0000000003"} {
+		sensorType := domain.SensorTypeADC
+		if serial == "0000000002" {
+			sensorType = domain.SensorTypeContactClosure
+		}
+		require.NoError(t, sensors.SaveSensor(ctx, &domain.Sensor{SerialNumber: serial, Type: sensorType, Unit: "°C"}))
+	}
+	user, err := users.RegisterUser(ctx, &domain.User{Name: "owner"})
+	require.NoError(t, err)
+	require.NoError(t, users.AttachSensorToUser(ctx, user.ID, 1))
+	require.NoError(t, users.AttachSensorToUser(ctx, user.ID, 2))
+	for _, event := range []domain.Event{
+		{SensorID: 1, Timestamp: start, Payload: 10},
+		{SensorID: 1, Timestamp: start.Add(time.Minute), Payload: 20},
+		{SensorID: 2, Timestamp: start, Payload: 1},
+	} {
+		require.NoError(t, events.SaveEvent(ctx, &event))
+	}
+
+	query := func(body string) *http.Response {
+		t.Helper()
+		return serve(s, http.MethodPost, "/v1/query", map[string]string{"Content-Type": "application/json"}, body).Result()
+	}
+
+	resp := query(`{"user_id":1,"sensor_ids":[2,1],"start_date":"2025-01-01T00:00:00Z","end_date":"2025-01-01T00:30:00Z","bucket":"10m","fill":"previous"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var answer struct {
+		Buckets []time.Time `json:"buckets"`
+		Series  []struct {
+			SensorID    int64      `json:"sensor_id"`
+			Aggregation string     `json:"aggregation"`
+			Values      []*float64 `json:"values"`
+			Counts      []int64    `json:"counts"`
+		} `json:"series"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&answer))
+	require.Len(t, answer.Buckets, 3)
+	assert.True(t, answer.Buckets[1].Equal(start.Add(10*time.Minute)))
+	require.Len(t, answer.Series, 2)
+	// ряды идут в порядке запроса, функция сведения - от типа датчика
+	assert.Equal(t, int64(2), answer.Series[0].SensorID)
+	assert.Equal(t, "last", answer.Series[0].Aggregation)
+	assert.Equal(t, "mean", answer.Series[1].Aggregation)
+	assert.Equal(t, []int64{2, 0, 0}, answer.Series[1].Counts)
+	for i, value := range answer.Series[1].Values {
+		require.NotNil(t, value, i)
+		assert.InDelta(t, 15.0, *value, 1e-9, i)
+	}
+
+	// суточные интервалы начинаются в полночь по поясу пользователя
+	resp = serve(s, http.MethodPost, "/v1/users", map[string]string{"Content-Type": "application/json"}, `{"name":"tokyo","time_zone":"Asia/Tokyo"}`).Result()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var tokyo struct {
+		ID       int64  `json:"id"`
+		TimeZone string `json:"time_zone"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&tokyo))
+	assert.Equal(t, "Asia/Tokyo", tokyo.TimeZone)
+	require.NoError(t, users.AttachSensorToUser(ctx, tokyo.ID, 1))
+	var days struct {
+		Buckets []string `json:"buckets"`
+	}
+	resp = query(`{"user_id":2,"sensor_ids":[1],"start_date":"2025-01-01T00:00:00Z","end_date":"2025-01-02T00:00:00Z","bucket":"1d"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&days))
+	assert.Equal(t, []string{"2025-01-01T00:00:00.000+09:00", "2025-01-02T00:00:00.000+09:00"}, days.Buckets)
+	resp = query(`{"user_id":2,"sensor_ids":[1],"start_date":"2025-01-01T00:00:00Z","end_date":"2025-01-02T00:00:00Z","bucket":"1d","time_zone":"UTC"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&days))
+	assert.Equal(t, []string{"2025-01-01T00:00:00.000Z"}, days.Buckets)
+
+	for _, tt := range []struct {
+		body   string
+		status int
+		code   string
+	}{
+		{`{"user_id":1,"sensor_ids":[1,3],"start_date":"2025-01-01T00:00:00Z","end_date":"2025-01-01T00:30:00Z","bucket":"10m"}`, http.StatusForbidden, "sensor_access_denied"},
+		{`{"user_id":9,"sensor_ids":[1],"start_date":"2025-01-01T00:00:00Z","end_date":"2025-01-01T00:30:00Z","bucket":"10m"}`, http.StatusNotFound, "user_not_found"},
+		{`{"user_id":1,"sensor_ids":[1],"start_date":"2025-01-01T00:00:00Z","end_date":"2025-01-01T00:30:00Z","bucket":"soon"}`, http.StatusUnprocessableEntity, "validation_failed"},
+		{`{"user_id":1,"sensor_ids":[],"start_date":"2025-01-01T00:00:00Z","end_date":"2025-01-01T00:30:00Z","bucket":"10m"}`, http.StatusUnprocessableEntity, "validation_failed"},
+		{`{"user_id":1,"sensor_ids":[1],"start_date":"2025-01-01T00:30:00Z","end_date":"2025-01-01T00:00:00Z","bucket":"10m"}`, http.StatusUnprocessableEntity, "wrong_series_query"},
+		{`{"user_id":1,"sensor_ids":[1],"start_date":"2025-01-01T00:00:00Z","end_date":"2025-01-01T00:30:00Z","bucket":"1ms"}`, http.StatusUnprocessableEntity, "series_query_too_large"},
+		{`{"user_id":1,"sensor_ids":[1],"start_date":"2025-01-01T00:00:00Z","end_date":"2025-01-01T00:30:00Z","bucket":"1d","time_zone":"Local"}`, http.StatusUnprocessableEntity, "invalid_time_zone"},
+	} {
+		resp := query(tt.body)
+		assert.Equal(t, tt.status, resp.StatusCode, tt.body)
+		var problem struct {
+			Code string `json:"code"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+		assert.Equal(t, tt.code, problem.Code, tt.body)
+	}
+}
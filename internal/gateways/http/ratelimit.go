@@ -0,0 +1,34 @@
+package http
+
+import (
+	"homework/internal/logging"
+	"homework/internal/ratelimit"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyHeader - заголовок с API-ключом клиента
+const apiKeyHeader = "X-API-Key"
+
+// readRateLimitMiddleware - ограничивает частоту чтений (GET и HEAD). Клиент с известным API-ключом
+// получает лимит своего тарифа из plans, остальные - лимит по умолчанию по своему адресу. Пользователей
+// сервис не аутентифицирует, поэтому клиенты без ключа за одним NAT делят одну корзину. Адрес
+// из X-Forwarded-For учитывается только от доверенных прокси, см. WithTrustedProxies
+func readRateLimitMiddleware(limit *ratelimit.Scope, plans map[string]string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead {
+			return
+		}
+		key, plan := "ip:"+ctx.ClientIP(), ""
+		if apiKey := ctx.GetHeader(apiKeyHeader); apiKey != "" {
+			if p, ok := plans[apiKey]; ok {
+				key, plan = "key:"+apiKey, p
+				logging.AddFields(ctx, "plan", plan)
+			}
+		}
+		if err := limit.Allow(ctx, key, plan); err != nil {
+			abort(ctx, err)
+		}
+	}
+}
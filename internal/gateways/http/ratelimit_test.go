@@ -0,0 +1,76 @@
+package http
+
+import (
+	"homework/internal/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadRateLimit(t *testing.T) {
+	limit := ratelimit.NewScope("reads", ratelimit.NewMemoryLimiter(), ratelimit.Limits{
+		Default: ratelimit.Limit{Rate: 0.001, Burst: 2},
+		Classes: map[string]ratelimit.Limit{"unlimited": {}},
+	})
+	s := NewServer(UseCases{}, WithReadRateLimit(limit, map[string]string{"partner-key": "unlimited"}))
+
+	do := func(method, path, apiKey string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		if apiKey != "" {
+			req.Header.Set(apiKeyHeader, apiKey)
+		}
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	// без Accept обработчик отвечает 406, но запрос уже учтён ограничителем
+	assert.Equal(t, http.StatusNotAcceptable, do(http.MethodGet, "/v1/sensors", "").Code)
+	assert.Equal(t, http.StatusNotAcceptable, do(http.MethodHead, "/v1/sensors/1", "").Code)
+	w := do(http.MethodGet, "/v1/sensors", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "1000", w.Header().Get("Retry-After"))
+
+	// неизвестный ключ не даёт тарифа и делит лимит с адресом клиента
+	assert.Equal(t, http.StatusTooManyRequests, do(http.MethodGet, "/v1/sensors", "unknown").Code)
+	for range 5 {
+		assert.Equal(t, http.StatusNotAcceptable, do(http.MethodGet, "/v1/sensors", "partner-key").Code)
+	}
+
+	// запись и служебные эндпоинты не ограничиваются
+	assert.Equal(t, http.StatusUnsupportedMediaType, do(http.MethodPost, "/v1/users", "").Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodOptions, "/v1/sensors", "").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/healthz", "").Code)
+}
+
+func TestReadRateLimit_ForwardedFor(t *testing.T) {
+	limits := ratelimit.Limits{Default: ratelimit.Limit{Rate: 0.001, Burst: 1}}
+	do := func(s *Server, forwardedFor string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/v1/sensors", nil)
+		// адрес соединения, как у httptest
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		s.router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("untrusted peer", func(t *testing.T) {
+		limit := ratelimit.NewScope("reads", ratelimit.NewMemoryLimiter(), limits)
+		s := NewServer(UseCases{}, WithReadRateLimit(limit, nil))
+		assert.Equal(t, http.StatusNotAcceptable, do(s, "203.0.113.1"))
+		// поддельный X-Forwarded-For не даёт новой корзины
+		assert.Equal(t, http.StatusTooManyRequests, do(s, "203.0.113.2"))
+	})
+
+	t.Run("trusted proxy", func(t *testing.T) {
+		limit := ratelimit.NewScope("reads", ratelimit.NewMemoryLimiter(), limits)
+		s := NewServer(UseCases{}, WithReadRateLimit(limit, nil), WithTrustedProxies([]string{"192.0.2.0/24"}))
+		assert.Equal(t, http.StatusNotAcceptable, do(s, "203.0.113.1"))
+		assert.Equal(t, http.StatusNotAcceptable, do(s, "203.0.113.2"))
+		assert.Equal(t, http.StatusTooManyRequests, do(s, "203.0.113.1"))
+	})
+}
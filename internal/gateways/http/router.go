@@ -2,6 +2,7 @@ package http
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -12,16 +13,35 @@ type Validatable interface {
 	Validate(formats strfmt.Registry) error
 }
 
-func validate(ctx *gin.Context, toCreate Validatable) {
+// validate - разбирает JSON-тело запроса в toCreate и проверяет его по схеме
+func validate(ctx *gin.Context, toCreate Validatable) error {
 	if ctx.GetHeader("content-type") != "application/json" {
-		ctx.AbortWithStatus(http.StatusUnsupportedMediaType)
+		return errUnsupportedMediaType
 	}
 	if err := ctx.ShouldBindJSON(toCreate); err != nil {
-		ctx.AbortWithStatus(http.StatusBadRequest)
+		return errMalformedBody(err)
 	}
 	if err := toCreate.Validate(nil); err != nil {
-		ctx.AbortWithStatus(http.StatusUnprocessableEntity)
+		return errValidation(err)
 	}
+	return nil
+}
+
+// checkAccept - клиент должен принимать JSON
+func checkAccept(ctx *gin.Context) error {
+	if ctx.GetHeader("Accept") != "application/json" {
+		return errNotAcceptable
+	}
+	return nil
+}
+
+// pathID - числовой параметр пути name
+func pathID(ctx *gin.Context, name string) (int64, error) {
+	id, err := strconv.ParseInt(ctx.Param(name), 10, 64)
+	if err != nil {
+		return 0, errInvalidParameter(name)
+	}
+	return id, nil
 }
 
 func optionsHandler(methods ...string) gin.HandlerFunc {
@@ -31,38 +51,127 @@ func optionsHandler(methods ...string) gin.HandlerFunc {
 	}
 }
 
-func setupRouter(r *gin.Engine, us UseCases, wsh *WebSocketHandler) {
-	r.GET("/ping", func(c *gin.Context) {
-		c.String(200, "pong")
-	})
+// routerOptions - настройки маршрутов API
+type routerOptions struct {
+	// middleware - применяются ко всем версиям API, но не к служебным эндпоинтам
+	middleware []gin.HandlerFunc
+	// legacy - пути без префикса версии, nil - не обслуживаются
+	legacy *deprecation
+}
+
+// withMiddleware - middleware для маршрутов API
+func withMiddleware(middleware ...gin.HandlerFunc) func(*routerOptions) {
+	return func(o *routerOptions) {
+		o.middleware = append(o.middleware, middleware...)
+	}
+}
 
-	r.POST("/users", postUser(us))
-	r.OPTIONS("/users", optionsHandler(http.MethodPost, http.MethodOptions))
+// withLegacy - обслуживать пути без префикса версии с заголовками устаревания d; nil отключает их
+func withLegacy(d *deprecation) func(*routerOptions) {
+	return func(o *routerOptions) {
+		o.legacy = d
+	}
+}
 
-	r.GET("/sensors", getSensor(us))
-	r.HEAD("/sensors", headSensor(us))
-	r.POST("/sensors", postSensor(us))
-	r.OPTIONS("/sensors", optionsHandler(http.MethodHead, http.MethodGet, http.MethodPost, http.MethodOptions))
+// setupRouter - маршруты API. Каждая версия живёт в своей группе и регистрируется своей функцией:
+// /v2 со своими моделями и обработчиками добавляется рядом с setupV1 поверх тех же UseCases.
+// Пути без версии - слой совместимости для старых устройств: только то, что было до /v1, с обработчиками v1
+func setupRouter(r *gin.Engine, us UseCases, wsh *WebSocketHandler, options ...func(*routerOptions)) {
+	o := routerOptions{legacy: &deprecation{}}
+	for _, option := range options {
+		option(&o)
+	}
 
-	r.GET("/sensors/:sensor_id/events", subscribe(us, wsh))
-	r.GET("/sensors/:sensor_id", getSensorByID(us))
-	r.HEAD("/sensors/:sensor_id", headSensorByID(us))
-	r.OPTIONS("/sensors/:sensor_id", optionsHandler(http.MethodHead, http.MethodGet, http.MethodPost, http.MethodOptions))
-	r.GET("/sensors/:sensor_id/history", getHistory(us))
+	r.Use(errorMiddleware())
 
-	r.GET("/users/:user_id/sensors", getUserSensors(us))
-	r.HEAD("/users/:user_id/sensors", headUserSensors(us))
-	r.POST("/users/:user_id/sensors", postUserSensors(us))
-	r.OPTIONS("/users/:user_id/sensors", optionsHandler(http.MethodHead, http.MethodGet, http.MethodPost, http.MethodOptions))
+	r.GET("/ping", func(c *gin.Context) {
+		c.String(200, "pong")
+	})
 
-	r.POST("/events", postEvent(us))
-	r.OPTIONS("/events", optionsHandler(http.MethodPost, http.MethodOptions))
+	setupV1(r.Group("/v1", o.middleware...), us, wsh)
+	if o.legacy != nil {
+		legacy := append(append([]gin.HandlerFunc{}, o.middleware...), deprecationMiddleware(*o.legacy, "/v1"))
+		setupLegacy(r.Group("/", legacy...), us, wsh)
+	}
 
 	r.NoRoute(func(c *gin.Context) {
-		if strings.HasPrefix(c.Request.URL.Path, "/users") ||
-			strings.HasPrefix(c.Request.URL.Path, "/sensors") ||
-			strings.HasPrefix(c.Request.URL.Path, "/events") {
-			c.AbortWithStatus(http.StatusMethodNotAllowed)
+		path := c.Request.URL.Path
+		if v1, versioned := strings.CutPrefix(path, "/v1"); versioned && (strings.HasPrefix(v1, "/users") ||
+			strings.HasPrefix(v1, "/sensors") ||
+			strings.HasPrefix(v1, "/sensor-types") ||
+			strings.HasPrefix(v1, "/events") ||
+			strings.HasPrefix(v1, "/query")) ||
+			o.legacy != nil && (strings.HasPrefix(path, "/users") ||
+				strings.HasPrefix(path, "/sensors") ||
+				strings.HasPrefix(path, "/events")) {
+			abort(c, errMethodNotAllowed)
+			return
 		}
+		abort(c, errRouteNotFound)
 	})
 }
+
+// setupV1 - маршруты первой версии API
+func setupV1(api *gin.RouterGroup, us UseCases, wsh *WebSocketHandler) {
+	api.POST("/users", postUser(us))
+	api.OPTIONS("/users", optionsHandler(http.MethodPost, http.MethodOptions))
+
+	api.GET("/sensors", getSensor(us))
+	api.HEAD("/sensors", headSensor(us))
+	api.POST("/sensors", postSensor(us))
+	api.OPTIONS("/sensors", optionsHandler(http.MethodHead, http.MethodGet, http.MethodPost, http.MethodOptions))
+
+	api.GET("/sensors/:sensor_id/events", subscribe(us, wsh))
+	api.GET("/sensors/:sensor_id", getSensorByID(us))
+	api.HEAD("/sensors/:sensor_id", headSensorByID(us))
+	api.PATCH("/sensors/:sensor_id", patchSensor(us))
+	api.OPTIONS("/sensors/:sensor_id", optionsHandler(http.MethodHead, http.MethodGet, http.MethodPatch, http.MethodOptions))
+	api.GET("/sensors/:sensor_id/history", getHistory(us))
+	api.GET("/sensors/:sensor_id/transitions", getTransitions(us))
+	api.GET("/sensors/:sensor_id/openings", getOpenings(us))
+	api.PUT("/sensors/:sensor_id/calibration", putCalibration(us))
+	api.DELETE("/sensors/:sensor_id/calibration", deleteCalibration(us))
+	api.OPTIONS("/sensors/:sensor_id/calibration", optionsHandler(http.MethodPut, http.MethodDelete, http.MethodOptions))
+	api.GET("/sensors/:sensor_id/calibration/history", getCalibrationHistory(us))
+	api.POST("/sensors/:sensor_id/calibration/recompute", postRecalibration(us))
+
+	api.GET("/sensor-types", getSensorTypes(us))
+	api.OPTIONS("/sensor-types", optionsHandler(http.MethodGet, http.MethodOptions))
+
+	api.GET("/users/:user_id/sensors", getUserSensors(us))
+	api.HEAD("/users/:user_id/sensors", headUserSensors(us))
+	api.POST("/users/:user_id/sensors", postUserSensors(us))
+	api.OPTIONS("/users/:user_id/sensors", optionsHandler(http.MethodHead, http.MethodGet, http.MethodPost, http.MethodOptions))
+
+	api.POST("/events", postEvent(us))
+	api.OPTIONS("/events", optionsHandler(http.MethodPost, http.MethodOptions))
+
+	api.POST("/query", postSeriesQuery(us))
+	api.OPTIONS("/query", optionsHandler(http.MethodPost, http.MethodOptions))
+}
+
+// setupLegacy - маршруты, которые обслуживались до появления /v1. Новые эндпоинты доступны только
+// с префиксом версии: старые устройства о них не знают
+func setupLegacy(api *gin.RouterGroup, us UseCases, wsh *WebSocketHandler) {
+	api.POST("/users", postUser(us))
+	api.OPTIONS("/users", optionsHandler(http.MethodPost, http.MethodOptions))
+
+	api.GET("/sensors", getSensor(us))
+	api.HEAD("/sensors", headSensor(us))
+	api.POST("/sensors", postSensor(us))
+	api.OPTIONS("/sensors", optionsHandler(http.MethodHead, http.MethodGet, http.MethodPost, http.MethodOptions))
+
+	api.GET("/sensors/:sensor_id/events", subscribe(us, wsh))
+	api.GET("/sensors/:sensor_id", getSensorByID(us))
+	api.HEAD("/sensors/:sensor_id", headSensorByID(us))
+	api.OPTIONS("/sensors/:sensor_id", optionsHandler(http.MethodHead, http.MethodGet, http.MethodOptions))
+	api.GET("/sensors/:sensor_id/history", getHistory(us))
+
+	api.GET("/users/:user_id/sensors", getUserSensors(us))
+	api.HEAD("/users/:user_id/sensors", headUserSensors(us))
+	api.POST("/users/:user_id/sensors", postUserSensors(us))
+	api.OPTIONS("/users/:user_id/sensors", optionsHandler(http.MethodHead, http.MethodGet, http.MethodPost, http.MethodOptions))
+
+	api.POST("/events", postEvent(us))
+	api.OPTIONS("/events", optionsHandler(http.MethodPost, http.MethodOptions))
+}
@@ -433,7 +433,6 @@ func TestSensorsRoutes(t *testing.T) {
 			{http.MethodPost, http.MethodPost, http.StatusMethodNotAllowed},
 			{http.MethodPut, http.MethodPut, http.StatusMethodNotAllowed},
 			{http.MethodDelete, http.MethodDelete, http.StatusMethodNotAllowed},
-			{http.MethodPatch, http.MethodPatch, http.StatusMethodNotAllowed},
 			{http.MethodConnect, http.MethodConnect, http.StatusMethodNotAllowed},
 			{http.MethodTrace, http.MethodTrace, http.StatusMethodNotAllowed},
 		}
@@ -656,7 +655,7 @@ func TestEventsRoutes(t *testing.T) {
 
 			body := `{
 				"sensor_serial_number": "1234567890",
-				"payload": 10
+				"payload": 1
 			}`
 			req, _ := http.NewRequest(http.MethodPost, "/events", bytes.NewReader([]byte(body)))
 			req.Header.Add("Content-Type", "application/json")
@@ -761,7 +760,7 @@ func TestSensorHistory(t *testing.T) {
 			var events []models.HistoryOfEvents
 			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
 			assert.Len(t, events, 1)
-			assert.Equal(t, *events[0].Payload, int64(10))
+			assert.Equal(t, *events[0].Payload, float64(1))
 			assert.Equal(t, http.StatusOK, w.Code, "Получили в ответ не тот код")
 			assert.True(t, json.Valid(w.Body.Bytes()), "В ответе не json")
 		})
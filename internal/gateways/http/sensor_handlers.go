@@ -1,23 +1,21 @@
 package http
 
 import (
-	"errors"
 	"homework/internal/domain"
+	"homework/internal/logging"
 	"homework/internal/models"
-	"homework/internal/usecase"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-openapi/strfmt"
-	"github.com/go-openapi/swag"
 )
 
 func makeSens(sens *domain.Sensor) models.Sensor {
 	sensorType := string(sens.Type)
 	lastActivity := strfmt.DateTime(sens.LastActivity)
 	registeredAt := strfmt.DateTime(sens.RegisteredAt)
+	scale := int64(sens.Scale)
 	sensor := models.Sensor{
 		ID:           &sens.ID,
 		Description:  &sens.Description,
@@ -27,108 +25,102 @@ func makeSens(sens *domain.Sensor) models.Sensor {
 		IsActive:     &sens.IsActive,
 		LastActivity: &lastActivity,
 		RegisteredAt: &registeredAt,
+		Unit:         &sens.Unit,
+		Scale:        &scale,
+		Calibration:  makeCalibration(sens.Calibration),
+	}
+	for i := range sens.Channels {
+		sensor.Channels = append(sensor.Channels, makeChannel(&sens.Channels[i]))
 	}
 	return sensor
 }
 
-func checkHeader(ctx *gin.Context, err error) {
-	if ctx.GetHeader("Accept") != "application/json" {
-		str := err.Error()
-		ctx.AbortWithStatusJSON(http.StatusNotAcceptable, models.Error{Reason: &str})
+func makeChannel(channel *domain.Channel) *models.SensorChannel {
+	scale := int64(channel.Scale)
+	lastActivity := strfmt.DateTime(channel.LastActivity)
+	return &models.SensorChannel{
+		Name:         &channel.Name,
+		Unit:         &channel.Unit,
+		Scale:        &scale,
+		CurrentState: &channel.CurrentState,
+		LastActivity: &lastActivity,
 	}
 }
 
 func getSensor(us UseCases) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		checkHeader(ctx, errors.New("accept header must be application/json"))
-
-		if ctx.IsAborted() {
+		if err := checkAccept(ctx); err != nil {
+			abort(ctx, err)
 			return
 		}
 
 		sens, err := us.Sensor.GetSensors(ctx)
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			abort(ctx, err)
 			return
 		}
 
 		sensors := make([]models.Sensor, 0, len(sens))
-		for i, sensor := range sens {
-			sensors[i] = makeSens(&sensor)
+		for i := range sens {
+			sensors = append(sensors, makeSens(&sens[i]))
 		}
-		ctx.JSON(http.StatusOK, sensors)
+		writeConditional(ctx, sensors, "")
 	}
 }
 
-func headSensor(_ UseCases) gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		checkHeader(ctx, errors.New("accept header must be application/json"))
-		if ctx.IsAborted() {
-			return
-		}
-		ctx.Header("Content-Length", "1")
-		ctx.Status(http.StatusOK)
-	}
+// headSensor - заголовки ответа GET /sensors; обработчик тот же, тело не пишется
+func headSensor(us UseCases) gin.HandlerFunc {
+	return getSensor(us)
 }
 
 func postSensor(us UseCases) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		toCreate := &models.SensorToCreate{}
-
-		validateSensor(ctx, toCreate)
-
-		if ctx.IsAborted() {
+		if err := validate(ctx, toCreate); err != nil {
+			abort(ctx, err)
 			return
 		}
 
+		logging.AddFields(ctx, "sensor_serial", *toCreate.SerialNumber)
+		var channels []domain.Channel
+		for _, channel := range toCreate.Channels {
+			if channel != nil {
+				channels = append(channels, domain.Channel{Name: *channel.Name, Unit: channel.Unit, Scale: int(channel.Scale)})
+			}
+		}
 		sensor, err := us.Sensor.RegisterSensor(ctx, &domain.Sensor{
 			Description:  *toCreate.Description,
 			SerialNumber: *toCreate.SerialNumber,
 			Type:         domain.SensorType(*toCreate.Type),
 			IsActive:     *toCreate.IsActive,
+			Unit:         toCreate.Unit,
+			Scale:        int(toCreate.Scale),
+			Channels:     channels,
 		})
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			abort(ctx, err)
 			return
 		}
 
+		logging.AddFields(ctx, "sensor_id", sensor.ID)
 		ctx.JSON(http.StatusOK, makeSens(sensor))
 	}
 }
 
-func checkContentType(ctx *gin.Context) {
-	if ctx.GetHeader("content-type") != "application/json" {
-		ctx.AbortWithStatus(http.StatusUnsupportedMediaType)
-	}
-}
-
-func validateSensor(ctx *gin.Context, toCreate *models.SensorToCreate) {
-	checkContentType(ctx)
-	if err := ctx.ShouldBindJSON(toCreate); err != nil {
-		ctx.AbortWithStatus(http.StatusBadRequest)
-	}
-	if err := toCreate.Validate(nil); err != nil {
-		ctx.AbortWithStatus(http.StatusUnprocessableEntity)
-	}
-}
-
 func commonGet(ctx *gin.Context, us UseCases) *domain.Sensor {
-	checkHeader(ctx, errors.New("accept header must be application/json"))
-
-	sensorID, err := strconv.Atoi(ctx.Param("sensor_id"))
-	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, models.Error{Reason: swag.String("sensor_id must be a number")})
-	}
-
-	if ctx.IsAborted() {
+	if err := checkAccept(ctx); err != nil {
+		abort(ctx, err)
 		return nil
 	}
-	var sensor *domain.Sensor
-	if sensor, err = us.Sensor.GetSensorByID(ctx, int64(sensorID)); errors.Is(err, usecase.ErrSensorNotFound) {
-		ctx.AbortWithStatusJSON(http.StatusNotFound, models.Error{Reason: swag.String("sensor not found")})
+	sensorID, err := pathID(ctx, "sensor_id")
+	if err != nil {
+		abort(ctx, err)
 		return nil
-	} else if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, models.Error{Reason: swag.String("internal server error")})
+	}
+
+	sensor, err := us.Sensor.GetSensorByID(ctx, sensorID)
+	if err != nil {
+		abort(ctx, err)
 		return nil
 	}
 	return sensor
@@ -138,44 +130,79 @@ func getSensorByID(us UseCases) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		sensor := commonGet(ctx, us)
 		if !ctx.IsAborted() {
-			ctx.JSON(http.StatusOK, makeSens(sensor))
+			writeConditional(ctx, makeSens(sensor), sensorETag(sensor))
 		}
 	}
 }
 
+// headSensorByID - заголовки ответа GET /sensors/:sensor_id; обработчик тот же, тело не пишется
 func headSensorByID(us UseCases) gin.HandlerFunc {
+	return getSensorByID(us)
+}
+
+// patchSensor - меняет описание, активность и единицы измерения датчика. Клиент передаёт в If-Match ETag, полученный
+// при чтении: если датчик с тех пор изменился, ответ 412 и изменения не применяются
+func patchSensor(us UseCases) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		_ = commonGet(ctx, us)
-		if !ctx.IsAborted() {
-			ctx.Header("Content-Length", "1")
-			ctx.Status(http.StatusOK)
+		if err := checkAccept(ctx); err != nil {
+			abort(ctx, err)
+			return
 		}
+		sensorID, err := pathID(ctx, "sensor_id")
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		precondition, err := ifMatch(ctx)
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		patch := &models.SensorPatch{}
+		if err := validate(ctx, patch); err != nil {
+			abort(ctx, err)
+			return
+		}
+
+		var scale *int
+		if patch.Scale != nil {
+			scale = new(int)
+			*scale = int(*patch.Scale)
+		}
+		sensor, err := us.Sensor.UpdateSensor(ctx, sensorID, domain.SensorPatch{
+			Description: patch.Description,
+			IsActive:    patch.IsActive,
+			Unit:        patch.Unit,
+			Scale:       scale,
+		}, precondition)
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		ctx.Header("ETag", sensorETag(sensor))
+		ctx.JSON(http.StatusOK, makeSens(sensor))
 	}
 }
 
 func subscribe(us UseCases, wsh *WebSocketHandler) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		sensorID, err := strconv.Atoi(ctx.Param("sensor_id"))
+		sensorID, err := pathID(ctx, "sensor_id")
 		if err != nil {
-			ctx.JSON(http.StatusUnprocessableEntity, models.Error{Reason: swag.String("sensor_id is required")})
+			abort(ctx, err)
 			return
 		}
-		sensor, err := us.Sensor.GetSensorByID(ctx, int64(sensorID))
+		sensor, err := us.Sensor.GetSensorByID(ctx, sensorID)
 		if err != nil {
-			if errors.Is(err, usecase.ErrSensorNotFound) {
-				ctx.JSON(http.StatusNotFound, models.Error{Reason: swag.String("sensor not found")})
-				return
-			}
-			ctx.JSON(http.StatusInternalServerError, models.Error{Reason: swag.String(err.Error())})
+			abort(ctx, err)
 			return
 		}
-		err = wsh.Handle(ctx, sensor.ID)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, models.Error{Reason: swag.String(err.Error())})
+		if err := wsh.Handle(ctx, sensor.ID); err != nil {
+			abort(ctx, err)
 		}
 	}
 }
 
+// getHistory - события датчика за период из start_date и end_date или last; время в ответе - в поясе time_zone, по умолчанию UTC
 func getHistory(us UseCases) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		sensor := commonGet(ctx, us)
@@ -183,41 +210,91 @@ func getHistory(us UseCases) gin.HandlerFunc {
 			return
 		}
 
-		start := ctx.Query("start_date")
-		end := ctx.Query("end_date")
-
-		if start == "" || end == "" {
-			ctx.JSON(http.StatusBadRequest, models.Error{Reason: swag.String("start and end query parameters are required")})
+		location, err := queryLocation(ctx, time.UTC)
+		if err != nil {
+			abort(ctx, err)
 			return
 		}
-
-		startTime, err := time.Parse(time.RFC1123, start)
+		startTime, endTime, err := queryPeriod(ctx, location)
 		if err != nil {
-			ctx.JSON(http.StatusBadRequest, models.Error{Reason: swag.String("invalid start_date format")})
+			abort(ctx, err)
 			return
 		}
-		endTime, err := time.Parse(time.RFC1123, end)
-		if err != nil {
-			ctx.JSON(http.StatusBadRequest, models.Error{Reason: swag.String("invalid end_date format")})
+		channel := ctx.Query("channel")
+		if channel != "" && sensor.Channel(channel) == nil {
+			abort(ctx, errInvalidQuery("sensor has no channel "+channel))
+			return
+		}
+		if ctx.Query("fill") != "" {
+			getFilledHistory(ctx, us, sensor, domain.HistoryQuery{Channel: channel, Start: startTime, End: endTime}, location)
 			return
 		}
-		history, err := us.Event.GetEventsBySensorID(ctx, sensor.ID, startTime, endTime)
+		history, err := us.Event.GetEventsBySensorID(ctx, sensor.ID, channel, startTime, endTime)
 		if err != nil {
-			if errors.Is(err, usecase.ErrEventNotFound) {
-				ctx.JSON(http.StatusNotFound, models.Error{Reason: swag.String("event not found")})
-				return
-			}
-			ctx.JSON(http.StatusInternalServerError, models.Error{Reason: swag.String(err.Error())})
+			abort(ctx, err)
 			return
 		}
 		answer := make([]models.HistoryOfEvents, len(history))
 		for i, event := range history {
-			Timestamp := strfmt.DateTime(event.Timestamp)
-			answer[i] = models.HistoryOfEvents{
-				Payload:   &event.Payload,
-				Timestamp: &Timestamp,
-			}
+			answer[i] = makeHistoryOfEvents(event, location)
+		}
+		// события в периоде меняются не только с приходом новых: их пересчитывает калибровка, а относительный
+		// период (last) сдвигается сам. Активность датчика этого не отражает, поэтому только ETag по телу
+		writeConditional(ctx, answer, "")
+	}
+}
+
+// getFilledHistory - история с пропусками и заполнением по параметрам fill и step
+func getFilledHistory(ctx *gin.Context, us UseCases, sensor *domain.Sensor, query domain.HistoryQuery, location *time.Location) {
+	query.Fill = domain.Fill(ctx.Query("fill"))
+	switch query.Fill {
+	case domain.FillNone, domain.FillPrevious, domain.FillLinear, domain.FillNull:
+	default:
+		abort(ctx, errInvalidQuery("fill must be one of none, previous, linear, null"))
+		return
+	}
+	if step := ctx.Query("step"); step != "" {
+		var err error
+		if query.Step, err = parseDuration(step); err != nil || query.Step <= 0 {
+			abort(ctx, errInvalidQuery("step must be a positive duration such as 30s or 5m"))
+			return
+		}
+	}
+
+	history, err := us.Event.GetHistory(ctx, sensor, query)
+	if err != nil {
+		abort(ctx, err)
+		return
+	}
+	answer := models.History{
+		Events: make([]*models.HistoryOfEvents, len(history.Points)),
+		Gaps:   make([]*models.HistoryGap, len(history.Gaps)),
+	}
+	for i, point := range history.Points {
+		event := makeHistoryOfEvents(point.Event, location)
+		event.Filled = point.Filled
+		if point.Null {
+			event.Payload, event.Raw = nil, nil
 		}
-		ctx.JSON(http.StatusOK, answer)
+		answer.Events[i] = &event
+	}
+	for i, gap := range history.Gaps {
+		start, end := strfmt.DateTime(gap.Start.In(location)), strfmt.DateTime(gap.End.In(location))
+		answer.Gaps[i] = &models.HistoryGap{Channel: gap.Channel, Start: &start, End: &end}
+	}
+	// пропуск в конце периода растёт и без новых событий, поэтому только ETag по телу
+	writeConditional(ctx, answer, "")
+}
+
+// makeHistoryOfEvents - событие истории со временем в часовом поясе запроса
+func makeHistoryOfEvents(event *domain.Event, location *time.Location) models.HistoryOfEvents {
+	timestamp := strfmt.DateTime(event.Timestamp.In(location))
+	return models.HistoryOfEvents{
+		Payload:   &event.Payload,
+		Raw:       &event.Raw,
+		Quality:   event.Quality.Flags(),
+		Timestamp: &timestamp,
+		Unit:      &event.Unit,
+		Channel:   event.Channel,
 	}
 }
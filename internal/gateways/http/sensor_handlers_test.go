@@ -0,0 +1,153 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"homework/internal/domain"
+	eventInmemory "homework/internal/repository/event/inmemory"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
+	"homework/internal/usecase"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryFill(t *testing.T) {
+	ctx := context.Background()
+	sensors := sensorInmemory.NewSensorRepository()
+	events := eventInmemory.NewEventRepository()
+	s := NewServer(UseCases{
+		Sensor: usecase.NewSensor(sensors),
+		Event:  usecase.NewEvent(events, sensors),
+	})
+	now := time.Now().Truncate(time.Second)
+	start := now.Add(-10 * time.Minute)
+	require.NoError(t, sensors.SaveSensor(ctx, &domain.Sensor{
+		SerialNumber: "0000000001", Type: domain.SensorTypeADC, Unit: "°C",
+	}))
+	require.NoError(t, sensors.SaveSensor(ctx, &domain.Sensor{
+		SerialNumber: "0000000002", Type: domain.SensorTypeContactClosure,
+	}))
+	// события старше регистрации датчика, например перенесённые из другой системы, тоже проверяются
+	for i, payload := range map[int]float64{0: 10, 1: 10, 5: 50} {
+		require.NoError(t, events.SaveEvent(ctx, &domain.Event{
+			SensorID: 1, SensorSerialNumber: "0000000001", Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Payload: payload, Raw: payload, Unit: "°C",
+		}))
+	}
+
+	history := func(id string, params url.Values) *http.Response {
+		t.Helper()
+		params.Set("start_date", start.UTC().Format(time.RFC1123))
+		params.Set("end_date", now.Add(time.Hour).UTC().Format(time.RFC1123))
+		return serve(s, http.MethodGet, "/v1/sensors/"+id+"/history?"+params.Encode(), nil, "").Result()
+	}
+
+	resp := history("1", url.Values{"fill": {"linear"}})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Last-Modified"))
+	var answer struct {
+		Events []struct {
+			Timestamp time.Time `json:"timestamp"`
+			Payload   *float64  `json:"payload"`
+			Filled    bool      `json:"filled"`
+		} `json:"events"`
+		Gaps []struct {
+			Start time.Time `json:"start"`
+			End   time.Time `json:"end"`
+		} `json:"gaps"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&answer))
+	require.Len(t, answer.Events, 6)
+	for i, want := range []float64{10, 10, 20, 30, 40, 50} {
+		require.NotNil(t, answer.Events[i].Payload)
+		assert.InDelta(t, want, *answer.Events[i].Payload, 1e-9, i)
+		assert.Equal(t, i >= 2 && i <= 4, answer.Events[i].Filled, i)
+	}
+	// второй пропуск тянется от последнего события до текущего момента
+	require.Len(t, answer.Gaps, 2)
+	assert.True(t, answer.Gaps[0].Start.Equal(start.Add(time.Minute)))
+	assert.True(t, answer.Gaps[0].End.Equal(start.Add(5*time.Minute)))
+	assert.True(t, answer.Gaps[1].Start.Equal(start.Add(5*time.Minute)))
+
+	resp = history("1", url.Values{"fill": {"null"}, "step": {"2m"}})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&answer))
+	assert.True(t, answer.Events[2].Filled)
+	assert.Nil(t, answer.Events[2].Payload)
+
+	for _, params := range []url.Values{
+		{"fill": {"zero"}},
+		{"fill": {"previous"}, "step": {"-1m"}},
+		{"fill": {"previous"}, "step": {"minute"}},
+	} {
+		assert.Equal(t, http.StatusBadRequest, history("1", params).StatusCode, params)
+	}
+
+	resp = history("2", url.Values{"fill": {"previous"}})
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	var problem struct {
+		Code string `json:"code"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, "fill_step_required", problem.Code)
+}
+
+func TestHistoryPeriod(t *testing.T) {
+	ctx := context.Background()
+	sensors := sensorInmemory.NewSensorRepository()
+	events := eventInmemory.NewEventRepository()
+	s := NewServer(UseCases{
+		Sensor: usecase.NewSensor(sensors),
+		Event:  usecase.NewEvent(events, sensors),
+	})
+	require.NoError(t, sensors.SaveSensor(ctx, &domain.Sensor{SerialNumber: "0000000001", Type: domain.SensorTypeADC}))
+	now := time.Now().Truncate(time.Second)
+	for _, at := range []time.Time{now.Add(-2 * time.Hour), now.Add(-time.Minute)} {
+		require.NoError(t, events.SaveEvent(ctx, &domain.Event{SensorID: 1, Timestamp: at, Payload: 1}))
+	}
+
+	history := func(params url.Values) *http.Response {
+		t.Helper()
+		return serve(s, http.MethodGet, "/v1/sensors/1/history?"+params.Encode(), nil, "").Result()
+	}
+	timestamps := func(resp *http.Response) []string {
+		t.Helper()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var answer []struct {
+			Timestamp string `json:"timestamp"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&answer))
+		var timestamps []string
+		for _, event := range answer {
+			timestamps = append(timestamps, event.Timestamp)
+		}
+		return timestamps
+	}
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	assert.Equal(t, []string{now.Add(-time.Minute).In(tokyo).Format("2006-01-02T15:04:05.000Z07:00")},
+		timestamps(history(url.Values{"last": {"1h"}, "time_zone": {"Asia/Tokyo"}})))
+
+	epoch := url.Values{
+		"start_date": {strconv.FormatInt(now.Add(-3*time.Hour).Unix(), 10)},
+		"end_date":   {now.Add(-time.Hour).UTC().Format(time.RFC3339)},
+	}
+	assert.Equal(t, []string{now.Add(-2 * time.Hour).UTC().Format("2006-01-02T15:04:05.000Z07:00")}, timestamps(history(epoch)))
+
+	for _, params := range []url.Values{
+		{"last": {"1h"}, "start_date": {"2025-01-01"}},
+		{"last": {"-1h"}},
+		{"start_date": {"2025-01-01"}},
+		{"start_date": {"yesterday"}, "end_date": {"2025-01-01"}},
+		{"last": {"1h"}, "time_zone": {"Mars/Olympus"}},
+	} {
+		assert.Equal(t, http.StatusBadRequest, history(params).StatusCode, params)
+	}
+}
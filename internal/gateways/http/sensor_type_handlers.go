@@ -0,0 +1,44 @@
+package http
+
+import (
+	"homework/internal/models"
+	"homework/internal/sensortype"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func makeSensorType(t *sensortype.Type) models.SensorType {
+	name := string(t.Name)
+	kind := string(t.Kind)
+	aggregation := string(t.Aggregation)
+	reportInterval := int64(t.ReportInterval.Seconds())
+	serialNumber := t.SerialNumberFormat().String()
+	return models.SensorType{
+		Name:                  &name,
+		Description:           t.Description,
+		Kind:                  &kind,
+		Min:                   t.Min,
+		Max:                   t.Max,
+		SerialNumberPattern:   &serialNumber,
+		ReportIntervalSeconds: &reportInterval,
+		Aggregation:           &aggregation,
+	}
+}
+
+// getSensorTypes - типы датчиков, которые можно регистрировать
+func getSensorTypes(us UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if err := checkAccept(ctx); err != nil {
+			abort(ctx, err)
+			return
+		}
+
+		types := us.Sensor.GetSensorTypes()
+		result := make([]models.SensorType, 0, len(types))
+		for i := range types {
+			result = append(result, makeSensorType(&types[i]))
+		}
+		ctx.JSON(http.StatusOK, result)
+	}
+}
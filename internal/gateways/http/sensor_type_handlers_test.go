@@ -0,0 +1,81 @@
+package http
+
+import (
+	"encoding/json"
+	eventInmemory "homework/internal/repository/event/inmemory"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
+	"homework/internal/sensortype"
+	"homework/internal/usecase"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSensorTypes(t *testing.T) {
+	lo, hi := 400.0, 5000.0
+	types, err := sensortype.NewRegistry(append(sensortype.Builtin(), sensortype.Type{
+		Name:        "co2",
+		Kind:        sensortype.KindInteger,
+		Min:         &lo,
+		Max:         &hi,
+		Aggregation: sensortype.AggregationMean,
+	})...)
+	require.NoError(t, err)
+	sensors := sensorInmemory.NewSensorRepository()
+	s := NewServer(UseCases{
+		Sensor: usecase.NewSensor(sensors, usecase.WithSensorTypes(types)),
+		Event:  usecase.NewEvent(eventInmemory.NewEventRepository(), sensors, usecase.WithEventSensorTypes(types)),
+	})
+	headers := map[string]string{"Content-Type": "application/json"}
+
+	w := serve(s, http.MethodGet, "/v1/sensor-types", nil, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list []struct {
+		Name           string   `json:"name"`
+		Kind           string   `json:"kind"`
+		Min            *float64 `json:"min"`
+		SerialNumber   string   `json:"serial_number_pattern"`
+		ReportInterval int64    `json:"report_interval_seconds"`
+		Aggregation    string   `json:"aggregation"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list, 3)
+	assert.Equal(t, "adc", list[0].Name)
+	assert.Equal(t, int64(60), list[0].ReportInterval)
+	assert.Equal(t, "cc", list[1].Name)
+	assert.Equal(t, "binary", list[1].Kind)
+	assert.Equal(t, "last", list[1].Aggregation)
+	assert.Equal(t, `^\d{10}$`, list[1].SerialNumber)
+	assert.Equal(t, "co2", list[2].Name)
+	assert.Equal(t, &lo, list[2].Min)
+
+	for _, body := range []string{
+		`{"serial_number":"0000000001","type":"cc","description":"door","is_active":true}`,
+		`{"serial_number":"0000000002","type":"co2","description":"air","is_active":true,"unit":"ppm"}`,
+	} {
+		w = serve(s, http.MethodPost, "/v1/sensors", headers, body)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	w = serve(s, http.MethodPost, "/v1/sensors", headers, `{"serial_number":"0000000003","type":"motion","description":"hall","is_active":true}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "wrong_sensor_type")
+
+	for _, tt := range []struct {
+		body string
+		code int
+	}{
+		{`{"sensor_serial_number":"0000000001","payload":1}`, http.StatusCreated},
+		{`{"sensor_serial_number":"0000000001","payload":2}`, http.StatusUnprocessableEntity},
+		{`{"sensor_serial_number":"0000000002","payload":800}`, http.StatusCreated},
+		{`{"sensor_serial_number":"0000000002","payload":800.5}`, http.StatusUnprocessableEntity},
+		{`{"sensor_serial_number":"0000000002","payload":100}`, http.StatusUnprocessableEntity},
+	} {
+		w = serve(s, http.MethodPost, "/v1/events", headers, tt.body)
+		assert.Equal(t, tt.code, w.Code, tt.body)
+		if tt.code == http.StatusUnprocessableEntity {
+			assert.Contains(t, w.Body.String(), "invalid_payload", tt.body)
+		}
+	}
+}
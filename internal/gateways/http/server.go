@@ -4,10 +4,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"homework/api"
+	"homework/internal/health"
+	"homework/internal/metrics"
+	"homework/internal/ratelimit"
 	"homework/internal/usecase"
+	"homework/pkg/tlsreload"
+	"log/slog"
+	"net"
 	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Server struct {
@@ -15,6 +25,44 @@ type Server struct {
 	port           uint16
 	router         *gin.Engine
 	shutdownRouter func() error
+
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	idleTimeout     time.Duration
+	shutdownTimeout time.Duration
+	shutdownDelay   time.Duration
+	certFile        string
+	keyFile         string
+	// clientCAFile - корневые сертификаты устройств; непустой включает проверку сертификата на POST /events
+	clientCAFile      string
+	clientAuth        tlsreload.ClientAuth
+	tlsReloadInterval time.Duration
+
+	wsPollInterval time.Duration
+	wsWriteTimeout time.Duration
+
+	adminToken  string
+	adminConfig any
+
+	metrics *metrics.Metrics
+	// tracingService - имя сервиса для спанов HTTP, пустое отключает трассировку запросов
+	tracingService string
+
+	logger *slog.Logger
+
+	// readLimit - ограничение частоты чтений, nil - без ограничения; apiKeys - тарифы API-ключей
+	readLimit *ratelimit.Scope
+	apiKeys   map[string]string
+
+	// trustedProxies - прокси, которым доверяется X-Forwarded-For при определении адреса клиента
+	trustedProxies []string
+
+	// legacy - устаревание путей API без версии, nil - такие пути не обслуживаются
+	legacy *deprecation
+
+	health *health.Checker
+	// draining - сервер останавливается, /readyz должен отвечать ошибкой
+	draining atomic.Bool
 }
 
 type UseCases struct {
@@ -24,16 +72,62 @@ type UseCases struct {
 }
 
 func NewServer(useCases UseCases, options ...func(*Server)) *Server {
-	r := gin.Default()
-
-	ws := NewWebSocketHandler(useCases)
-	setupRouter(r, useCases, ws)
-
-	s := &Server{router: r, host: "localhost", port: 8080, shutdownRouter: ws.Shutdown}
+	s := &Server{
+		host:            "localhost",
+		port:            8080,
+		shutdownTimeout: 10 * time.Second,
+		wsPollInterval:  500 * time.Millisecond,
+		wsWriteTimeout:  5 * time.Second,
+		logger:          slog.Default(),
+		legacy:          &deprecation{},
+	}
 	for _, o := range options {
 		o(s)
 	}
 
+	r := gin.New()
+	// по умолчанию gin верит X-Forwarded-For от любого клиента, и тот мог бы подставлять себе
+	// новый адрес в каждом запросе: новую корзину лимита чтений и чужой адрес в логе
+	if err := r.SetTrustedProxies(s.trustedProxies); err != nil {
+		s.logger.Error("invalid trusted proxies, client address is taken from the connection", "err", err)
+		_ = r.SetTrustedProxies(nil)
+	}
+	// обработчики передают *gin.Context в usecase как context.Context, поэтому значения
+	// (например, спан из otelgin) и отмена должны браться из контекста запроса
+	r.ContextWithFallback = true
+	if s.tracingService != "" {
+		r.Use(otelgin.Middleware(s.tracingService))
+	}
+	// после otelgin, чтобы в логгер запроса попал trace_id
+	r.Use(requestIDMiddleware(s.logger), accessLogMiddleware(), recoveryMiddleware())
+	if s.metrics != nil {
+		// до маршрутов, чтобы замерять и запросы, не попавшие ни в один маршрут
+		r.Use(metricsMiddleware(s.metrics))
+	}
+
+	ws := NewWebSocketHandler(useCases)
+	ws.pollInterval = s.wsPollInterval
+	ws.writeTimeout = s.wsWriteTimeout
+	routerOptions := []func(*routerOptions){withLegacy(s.legacy)}
+	if s.readLimit != nil {
+		routerOptions = append(routerOptions, withMiddleware(readRateLimitMiddleware(s.readLimit, s.apiKeys)))
+	}
+	if s.clientCAFile != "" {
+		routerOptions = append(routerOptions, withMiddleware(deviceAuthMiddleware()))
+	}
+	setupRouter(r, useCases, ws, routerOptions...)
+	if s.metrics != nil {
+		s.metrics.ObserveWebSocketConnections(ws.connections)
+		setupMetricsRouter(r, s.metrics)
+	}
+	setupHealthRouter(r, s.health, &s.draining)
+	setupDocsRouter(r, api.OpenAPI)
+	if s.adminConfig != nil {
+		setupAdminRouter(r, useCases, s.adminToken, s.adminConfig)
+	}
+
+	s.router = r
+	s.shutdownRouter = ws.Shutdown
 	return s
 }
 
@@ -49,24 +143,189 @@ func WithPort(port uint16) func(*Server) {
 	}
 }
 
+// WithTimeouts - таймауты http.Server, 0 означает отсутствие таймаута
+func WithTimeouts(read, write, idle time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.readTimeout = read
+		s.writeTimeout = write
+		s.idleTimeout = idle
+	}
+}
+
+// WithShutdownTimeout - сколько ждать завершения запросов при остановке
+func WithShutdownTimeout(timeout time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// WithShutdownDelay - при остановке сначала delay отвечать ошибкой на /readyz, продолжая обслуживать
+// запросы, чтобы балансировщик успел вывести сервер из ротации
+func WithShutdownDelay(delay time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.shutdownDelay = delay
+	}
+}
+
+// WithTLS - обслуживать запросы по HTTPS с указанными сертификатом и ключом.
+// Файлы перечитываются при изменении не чаще раза в reloadInterval, 0 - по умолчанию tlsreload
+func WithTLS(certFile, keyFile string, reloadInterval time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.certFile = certFile
+		s.keyFile = keyFile
+		s.tlsReloadInterval = reloadInterval
+	}
+}
+
+// WithClientCA - проверять клиентские сертификаты по корневым из caFile (работает вместе с WithTLS).
+// Событие на POST /events принимается только от устройства, в сертификате которого (CN или DNS SAN)
+// указан серийный номер датчика; auth определяет, нужен ли сертификат остальным клиентам
+func WithClientCA(caFile string, auth tlsreload.ClientAuth) func(*Server) {
+	return func(s *Server) {
+		s.clientCAFile = caFile
+		s.clientAuth = auth
+	}
+}
+
+// WithWebSocket - как часто проверять новые события и сколько ждать отправки клиенту
+func WithWebSocket(pollInterval, writeTimeout time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.wsPollInterval = pollInterval
+		s.wsWriteTimeout = writeTimeout
+	}
+}
+
+// WithAdminConfig - отдавать cfg на GET /admin/config и обслуживать остальные служебные эндпоинты /admin;
+// cfg должен быть уже без секретов. Запрос должен содержать заголовок Authorization: Bearer <token>,
+// с пустым token все эндпоинты /admin отвечают 403
+func WithAdminConfig(token string, cfg any) func(*Server) {
+	return func(s *Server) {
+		s.adminToken = token
+		s.adminConfig = cfg
+	}
+}
+
+// WithMetrics - собирать метрики HTTP-запросов и WebSocket-подписок и отдавать их на GET /metrics
+func WithMetrics(m *metrics.Metrics) func(*Server) {
+	return func(s *Server) {
+		s.metrics = m
+	}
+}
+
+// WithTracing - открывать спан на каждый запрос и принимать контекст трассы из заголовков W3C traceparent
+func WithTracing(service string) func(*Server) {
+	return func(s *Server) {
+		s.tracingService = service
+	}
+}
+
+// WithLogger - логгер, от которого порождаются логгеры запросов
+func WithLogger(logger *slog.Logger) func(*Server) {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithLegacyRoutes - обслуживать ли пути API, существовавшие до префикса /v1. Ответы на них содержат
+// заголовки Deprecation и Sunset, если соответствующие даты не нулевые; сами даты задаёт конфигурация
+func WithLegacyRoutes(enabled bool, since, sunset time.Time) func(*Server) {
+	return func(s *Server) {
+		if !enabled {
+			s.legacy = nil
+			return
+		}
+		s.legacy = &deprecation{since: since, sunset: sunset}
+	}
+}
+
+// WithTrustedProxies - адреса и подсети обратных прокси, от которых принимается X-Forwarded-For.
+// Без них адрес клиента для лимитов и логов всегда берётся из соединения
+func WithTrustedProxies(proxies []string) func(*Server) {
+	return func(s *Server) {
+		s.trustedProxies = proxies
+	}
+}
+
+// WithHealth - проверки зависимостей для /readyz
+func WithHealth(checker *health.Checker) func(*Server) {
+	return func(s *Server) {
+		s.health = checker
+	}
+}
+
+// WithReadRateLimit - ограничивать частоту GET- и HEAD-запросов к API. apiKeys сопоставляет
+// API-ключам из заголовка X-API-Key тарифы - классы лимитов в limit
+func WithReadRateLimit(limit *ratelimit.Scope, apiKeys map[string]string) func(*Server) {
+	return func(s *Server) {
+		s.readLimit = limit
+		s.apiKeys = apiKeys
+	}
+}
+
 func (s *Server) Run(ctx context.Context, cancel context.CancelFunc) error {
 	serv := &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", s.host, s.port),
-		Handler: s.router,
+		Addr:         fmt.Sprintf("%s:%d", s.host, s.port),
+		Handler:      s.router,
+		ReadTimeout:  s.readTimeout,
+		WriteTimeout: s.writeTimeout,
+		IdleTimeout:  s.idleTimeout,
 	}
 	defer cancel()
 
+	if s.certFile != "" {
+		options := []func(*tlsreload.Reloader){tlsreload.WithLogger(s.logger)}
+		if s.clientCAFile != "" {
+			options = append(options, tlsreload.WithClientCA(s.clientCAFile, s.clientAuth))
+		}
+		if s.tlsReloadInterval > 0 {
+			options = append(options, tlsreload.WithInterval(s.tlsReloadInterval))
+		}
+		certs, err := tlsreload.New(s.certFile, s.keyFile, options...)
+		if err != nil {
+			return fmt.Errorf("load tls certificates: %w", err)
+		}
+		serv.TLSConfig = certs.TLSConfig()
+	}
+
+	// слушаем заранее, чтобы ошибка вроде занятого порта вернулась из Run сразу
+	ln, err := net.Listen("tcp", serv.Addr)
+	if err != nil {
+		return err
+	}
+	serveErr := make(chan error, 1)
 	go func() {
-		_ = serv.ListenAndServe()
+		var err error
+		if serv.TLSConfig != nil {
+			// сертификаты уже в TLSConfig, ServeTLS только добавит HTTP/2
+			err = serv.ServeTLS(ln, "", "")
+		} else {
+			err = serv.Serve(ln)
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("http server failed", "addr", serv.Addr, "err", err)
+			serveErr <- err
+		}
 	}()
+	s.logger.Info("http server started", "addr", ln.Addr().String(), "tls", s.certFile != "")
 
-	<-ctx.Done()
-	errs := make([]error, 1)
-	if err := serv.Shutdown(ctx); err != nil {
+	var errs []error
+	select {
+	case <-ctx.Done():
+		s.draining.Store(true)
+		if s.shutdownDelay > 0 {
+			s.logger.Info("http server draining", "delay", s.shutdownDelay)
+			time.Sleep(s.shutdownDelay)
+		}
+	case err := <-serveErr:
 		errs = append(errs, err)
 	}
-	err := s.shutdownRouter()
-	if err != nil {
+	// ctx может быть уже отменён, поэтому на завершение запросов отводим отдельный таймаут
+	shutdownCtx, stop := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer stop()
+	if err := serv.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, err)
+	}
+	if err := s.shutdownRouter(); err != nil {
 		errs = append(errs, err)
 	}
 	return errors.Join(errs...)
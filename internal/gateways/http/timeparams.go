@@ -0,0 +1,94 @@
+package http
+
+import (
+	"errors"
+	"homework/internal/usecase"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// localLayouts - форматы времени без часового пояса, они читаются в поясе запроса
+var localLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+var errTimeFormat = errors.New("time must be RFC 3339, RFC 1123, Unix seconds or a local date such as 2006-01-02T15:04")
+
+// parseTime - момент времени из параметра запроса: RFC 3339, RFC 1123, как раньше принимала история,
+// Unix-время в секундах или дата и время без пояса, которые читаются в location с учётом перехода на летнее время
+func parseTime(value string, location *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC1123, value); err == nil {
+		return t, nil
+	}
+	if seconds, fraction, ok := strings.Cut(value, "."); seconds != "" && strings.Trim(seconds, "0123456789") == "" &&
+		(!ok || fraction != "" && len(fraction) <= 9 && strings.Trim(fraction, "0123456789") == "") {
+		sec, err := strconv.ParseInt(seconds, 10, 64)
+		if err != nil {
+			return time.Time{}, errTimeFormat
+		}
+		nsec, _ := strconv.ParseInt((fraction + "000000000")[:9], 10, 64)
+		return time.Unix(sec, nsec), nil
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errTimeFormat
+}
+
+// parseDuration - длительность вида 90m или 1h30m, а также в сутках: 7d
+func parseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n > int(time.Duration(1<<63-1)/(24*time.Hour)) {
+			return 0, errors.New("invalid duration " + strconv.Quote(value))
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// queryLocation - часовой пояс из параметра time_zone, без него - fallback
+func queryLocation(ctx *gin.Context, fallback *time.Location) (*time.Location, error) {
+	name := ctx.Query("time_zone")
+	if name == "" {
+		return fallback, nil
+	}
+	location, err := usecase.LoadTimeZone(name)
+	if err != nil {
+		return nil, errInvalidQuery("time_zone must be an IANA name such as Europe/Moscow")
+	}
+	return location, nil
+}
+
+// queryPeriod - период из параметров start_date и end_date или из last - длительности, отсчитанной назад
+// от текущего момента. Время без пояса читается в location
+func queryPeriod(ctx *gin.Context, location *time.Location) (start, end time.Time, err error) {
+	startDate, endDate, last := ctx.Query("start_date"), ctx.Query("end_date"), ctx.Query("last")
+	if last != "" {
+		if startDate != "" || endDate != "" {
+			return start, end, errInvalidQuery("last cannot be combined with start_date and end_date")
+		}
+		d, err := parseDuration(last)
+		if err != nil || d <= 0 {
+			return start, end, errInvalidQuery("last must be a positive duration such as 24h or 7d")
+		}
+		end = time.Now()
+		return end.Add(-d), end, nil
+	}
+	if startDate == "" || endDate == "" {
+		return start, end, errInvalidQuery("start_date and end_date or last query parameters are required")
+	}
+	if start, err = parseTime(startDate, location); err != nil {
+		return start, end, errInvalidQuery("invalid start_date: " + err.Error())
+	}
+	if end, err = parseTime(endDate, location); err != nil {
+		return start, end, errInvalidQuery("invalid end_date: " + err.Error())
+	}
+	return start, end, nil
+}
@@ -0,0 +1,58 @@
+package http
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	want := time.Date(2025, 3, 30, 1, 30, 0, 0, time.UTC)
+
+	for _, value := range []string{
+		"2025-03-30T01:30:00Z",
+		"2025-03-30T03:30:00+02:00",
+		"Sun, 30 Mar 2025 01:30:00 UTC",
+		"1743298200",
+		"1743298200.000",
+		// 03:30 по Берлину - уже летнее время
+		"2025-03-30T03:30",
+		"2025-03-30T03:30:00",
+	} {
+		actual, err := parseTime(value, berlin)
+		require.NoError(t, err, value)
+		assert.True(t, want.Equal(actual), "%s: %s", value, actual)
+	}
+
+	midnight, err := parseTime("2025-03-30", berlin)
+	require.NoError(t, err)
+	assert.True(t, time.Date(2025, 3, 29, 23, 0, 0, 0, time.UTC).Equal(midnight))
+	fraction, err := parseTime("1743298200.25", berlin)
+	require.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, fraction.Sub(want))
+
+	for _, value := range []string{"", "yesterday", "2025-03-30 03:30", "17432.", ".5", "1743298200.0000000001", "-1"} {
+		_, err := parseTime(value, berlin)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for value, want := range map[string]time.Duration{
+		"90m":  90 * time.Minute,
+		"1h5s": time.Hour + 5*time.Second,
+		"7d":   7 * 24 * time.Hour,
+	} {
+		actual, err := parseDuration(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, actual, value)
+	}
+	for _, value := range []string{"", "d", "1.5d", "1d12h", "week", "999999999d"} {
+		_, err := parseDuration(value)
+		assert.Error(t, err, value)
+	}
+}
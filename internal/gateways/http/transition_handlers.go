@@ -0,0 +1,121 @@
+package http
+
+import (
+	"homework/internal/domain"
+	"homework/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-openapi/strfmt"
+)
+
+// maxTransitions - сколько смен состояния отдаётся одним запросом
+const maxTransitions = 1000
+
+// getTransitions - журнал смен состояния датчика вида binary за период с фильтрами channel, to,
+// min_duration и limit
+func getTransitions(us UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		sensor := commonGet(ctx, us)
+		if ctx.IsAborted() {
+			return
+		}
+
+		location, err := queryLocation(ctx, time.UTC)
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		filter := domain.TransitionFilter{Channel: ctx.Query("channel"), Limit: maxTransitions}
+		if filter.Start, filter.End, err = queryPeriod(ctx, location); err != nil {
+			abort(ctx, err)
+			return
+		}
+		if filter.Channel != "" && sensor.Channel(filter.Channel) == nil {
+			abort(ctx, errInvalidQuery("sensor has no channel "+filter.Channel))
+			return
+		}
+		switch to := ctx.Query("to"); to {
+		case "":
+		case "0", "1":
+			state := float64(to[0] - '0')
+			filter.To = &state
+		default:
+			abort(ctx, errInvalidQuery("to must be 0 or 1"))
+			return
+		}
+		if value := ctx.Query("min_duration"); value != "" {
+			if filter.MinLasted, err = parseDuration(value); err != nil || filter.MinLasted < 0 {
+				abort(ctx, errInvalidQuery("min_duration must be a non-negative duration such as 30s or 5m"))
+				return
+			}
+		}
+		if value := ctx.Query("limit"); value != "" {
+			if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit <= 0 || filter.Limit > maxTransitions {
+				abort(ctx, errInvalidQuery("limit must be an integer from 1 to "+strconv.Itoa(maxTransitions)))
+				return
+			}
+		}
+
+		transitions, err := us.Event.GetTransitions(ctx, sensor, filter)
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		answer := make([]models.Transition, len(transitions))
+		for i := range transitions {
+			answer[i] = makeTransition(&transitions[i], location)
+		}
+		// период бывает относительным (last=24h) и сдвигается без новых событий, поэтому только ETag по телу
+		writeConditional(ctx, answer, "")
+	}
+}
+
+// getOpenings - сколько раз датчик вида binary переходил в состояние 1 за каждые сутки периода в time_zone
+func getOpenings(us UseCases) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		sensor := commonGet(ctx, us)
+		if ctx.IsAborted() {
+			return
+		}
+
+		location, err := queryLocation(ctx, time.UTC)
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		start, end, err := queryPeriod(ctx, location)
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+
+		counts, err := us.Event.CountOpenings(ctx, sensor, start, end, location)
+		if err != nil {
+			abort(ctx, err)
+			return
+		}
+		answer := make([]models.DayCount, len(counts))
+		for i, count := range counts {
+			day := strfmt.DateTime(count.Day)
+			answer[i] = models.DayCount{Day: &day, Count: &count.Count}
+		}
+		writeConditional(ctx, answer, "")
+	}
+}
+
+func makeTransition(transition *domain.Transition, location *time.Location) models.Transition {
+	timestamp := strfmt.DateTime(transition.Timestamp.In(location))
+	result := models.Transition{
+		Channel:   transition.Channel,
+		Timestamp: &timestamp,
+		From:      &transition.From,
+		To:        &transition.To,
+	}
+	if transition.Lasted > 0 {
+		seconds := transition.Lasted.Seconds()
+		result.PreviousDurationSeconds = &seconds
+	}
+	return result
+}
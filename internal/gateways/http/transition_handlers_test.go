@@ -0,0 +1,104 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"homework/internal/domain"
+	eventInmemory "homework/internal/repository/event/inmemory"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
+	"homework/internal/usecase"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransitions(t *testing.T) {
+	ctx := context.Background()
+	sensors := sensorInmemory.NewSensorRepository()
+	events := usecase.NewEvent(eventInmemory.NewEventRepository(), sensors)
+	s := NewServer(UseCases{Sensor: usecase.NewSensor(sensors), Event: events})
+	require.NoError(t, sensors.SaveSensor(ctx, &domain.Sensor{SerialNumber: "0000000001", Type: domain.SensorTypeContactClosure}))
+	require.NoError(t, sensors.SaveSensor(ctx, &domain.Sensor{SerialNumber: "0000000002", Type: domain.SensorTypeADC}))
+
+	// дверь закрыта в 10:00, открыта в 10:05 и 23:30 по Москве, закрыта в 10:10 и на следующий день
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	day := time.Date(2025, 6, 1, 0, 0, 0, 0, moscow)
+	at := func(hours, minutes int) time.Time {
+		return day.Add(time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute)
+	}
+	for _, event := range []struct {
+		at      time.Time
+		payload float64
+	}{{at(10, 0), 0}, {at(10, 5), 1}, {at(10, 6), 1}, {at(10, 10), 0}, {at(23, 30), 1}, {at(24, 30), 0}} {
+		require.NoError(t, events.ReceiveEvent(ctx, &domain.Event{
+			SensorSerialNumber: "0000000001", Timestamp: event.at, Payload: event.payload,
+		}))
+	}
+
+	period := func(params url.Values) url.Values {
+		params.Set("start_date", "2025-06-01")
+		params.Set("end_date", "2025-06-03")
+		params.Set("time_zone", "Europe/Moscow")
+		return params
+	}
+	transitions := func(t *testing.T, params url.Values) []map[string]any {
+		t.Helper()
+		resp := serve(s, http.MethodGet, "/v1/sensors/1/transitions?"+period(params).Encode(), nil, "").Result()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var answer []map[string]any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&answer))
+		return answer
+	}
+
+	answer := transitions(t, url.Values{})
+	require.Len(t, answer, 4)
+	assert.Equal(t, map[string]any{"timestamp": "2025-06-01T10:05:00.000+03:00", "from": 0.0, "to": 1.0}, answer[0])
+	assert.Equal(t, map[string]any{"timestamp": "2025-06-01T10:10:00.000+03:00", "from": 1.0, "to": 0.0, "previous_duration_seconds": 300.0}, answer[1])
+
+	// относительный период сдвигается без новых событий, поэтому ответ сверяется только по ETag
+	for _, path := range []string{"/v1/sensors/1/transitions?last=24h", "/v1/sensors/1/openings?last=24h"} {
+		resp := serve(s, http.MethodGet, path, map[string]string{"If-Modified-Since": time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}, "").Result()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Empty(t, resp.Header.Get("Last-Modified"), path)
+	}
+
+	answer = transitions(t, url.Values{"to": {"1"}, "min_duration": {"1h"}})
+	require.Len(t, answer, 1)
+	assert.Equal(t, "2025-06-01T23:30:00.000+03:00", answer[0]["timestamp"])
+	assert.Len(t, transitions(t, url.Values{"limit": {"3"}}), 3)
+
+	resp := serve(s, http.MethodGet, "/v1/sensors/1/openings?"+period(url.Values{}).Encode(), nil, "").Result()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var openings []struct {
+		Day   string `json:"day"`
+		Count int64  `json:"count"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&openings))
+	require.Len(t, openings, 2)
+	assert.Equal(t, "2025-06-01T00:00:00.000+03:00", openings[0].Day)
+	assert.Equal(t, []int64{2, 0}, []int64{openings[0].Count, openings[1].Count})
+
+	for _, params := range []url.Values{
+		{"to": {"open"}},
+		{"min_duration": {"-1m"}},
+		{"limit": {"0"}},
+		{"limit": {"1001"}},
+		{"channel": {"leak"}},
+	} {
+		resp := serve(s, http.MethodGet, "/v1/sensors/1/transitions?"+period(params).Encode(), nil, "").Result()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, params)
+	}
+
+	resp = serve(s, http.MethodGet, "/v1/sensors/2/openings?"+period(url.Values{}).Encode(), nil, "").Result()
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	var problem struct {
+		Code string `json:"code"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, "not_binary_sensor", problem.Code)
+}
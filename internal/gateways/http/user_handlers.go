@@ -1,65 +1,54 @@
 package http
 
 import (
-	"errors"
 	"homework/internal/domain"
+	"homework/internal/logging"
 	"homework/internal/models"
-	"homework/internal/usecase"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-openapi/swag"
 )
 
 func postUser(us UseCases) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		toCreate := &models.UserToCreate{}
-
-		validate(ctx, toCreate)
-
-		if ctx.IsAborted() {
+		if err := validate(ctx, toCreate); err != nil {
+			abort(ctx, err)
 			return
 		}
 
-		user, err := us.User.RegisterUser(ctx, &domain.User{Name: *toCreate.Name})
+		user, err := us.User.RegisterUser(ctx, &domain.User{Name: *toCreate.Name, TimeZone: toCreate.TimeZone})
 		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			abort(ctx, err)
 			return
 		}
 
+		logging.AddFields(ctx, "user_id", user.ID)
 		ctx.JSON(http.StatusOK, models.User{
-			ID:   &user.ID,
-			Name: &user.Name,
+			ID:       &user.ID,
+			Name:     &user.Name,
+			TimeZone: &user.TimeZone,
 		})
 	}
 }
 
 func commonGetUserSensors(ctx *gin.Context, us UseCases) []domain.Sensor {
-	userID, err := strconv.Atoi(ctx.Param("user_id"))
+	userID, err := pathID(ctx, "user_id")
 	if err != nil {
-		ctx.JSON(http.StatusUnprocessableEntity, models.Error{Reason: swag.String("user_id is required")})
+		abort(ctx, err)
 		return nil
 	}
-
-	if ctx.GetHeader("Accept") != "application/json" {
-		ctx.AbortWithStatus(http.StatusNotAcceptable)
+	if err := checkAccept(ctx); err != nil {
+		abort(ctx, err)
 		return nil
 	}
 
-	sensors, err := us.User.GetUserSensors(ctx, int64(userID))
-	if errors.Is(err, usecase.ErrUserNotFound) {
-		ctx.JSON(http.StatusNotFound, models.Error{Reason: swag.String("user not found")})
-		return nil
-	} else if errors.Is(err, usecase.ErrSensorNotFound) {
-		ctx.JSON(http.StatusNotFound, models.Error{Reason: swag.String("sensor not found")})
-		return nil
-	}
+	sensors, err := us.User.GetUserSensors(ctx, userID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, models.Error{Reason: swag.String(err.Error())})
+		abort(ctx, err)
 		return nil
 	}
-
 	return sensors
 }
 
@@ -84,29 +73,21 @@ func headUserSensors(us UseCases) gin.HandlerFunc {
 
 func postUserSensors(us UseCases) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		userID, err := strconv.Atoi(ctx.Param("user_id"))
+		userID, err := pathID(ctx, "user_id")
 		if err != nil {
-			ctx.JSON(http.StatusUnprocessableEntity, models.Error{Reason: swag.String("user_id is required")})
+			abort(ctx, err)
 			return
 		}
 
 		var sensor models.SensorToUserBinding
-		validate(ctx, &sensor)
-
-		if ctx.IsAborted() {
+		if err := validate(ctx, &sensor); err != nil {
+			abort(ctx, err)
 			return
 		}
 
-		err = us.User.AttachSensorToUser(ctx, int64(userID), *sensor.SensorID)
-		if errors.Is(err, usecase.ErrUserNotFound) {
-			ctx.JSON(http.StatusNotFound, models.Error{Reason: swag.String("user not found")})
-			return
-		} else if errors.Is(err, usecase.ErrSensorNotFound) {
-			ctx.JSON(http.StatusNotFound, models.Error{Reason: swag.String("sensor not found")})
-			return
-		}
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, models.Error{Reason: swag.String(err.Error())})
+		logging.AddFields(ctx, "sensor_id", *sensor.SensorID)
+		if err := us.User.AttachSensorToUser(ctx, userID, *sensor.SensorID); err != nil {
+			abort(ctx, err)
 			return
 		}
 
@@ -1,11 +1,11 @@
 package http
 
 import (
+	"context"
 	"errors"
-	"homework/internal/models"
+	"homework/internal/logging"
 	"homework/internal/usecase"
-	"log"
-	"net/http"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -13,34 +13,42 @@ import (
 	"github.com/coder/websocket/wsjson"
 	"github.com/deckarep/golang-set/v2"
 	"github.com/gin-gonic/gin"
-	"github.com/go-openapi/swag"
 )
 
 type WebSocketHandler struct {
-	useCases   UseCases
-	websockets mapset.Set[*websocket.Conn]
-	mutex      sync.Mutex
+	useCases     UseCases
+	websockets   mapset.Set[*websocket.Conn]
+	mutex        sync.Mutex
+	pollInterval time.Duration
+	writeTimeout time.Duration
 }
 
 func NewWebSocketHandler(useCases UseCases) *WebSocketHandler {
 	return &WebSocketHandler{
-		useCases:   useCases,
-		websockets: mapset.NewSet[*websocket.Conn](),
-		mutex:      sync.Mutex{},
+		useCases:     useCases,
+		websockets:   mapset.NewSet[*websocket.Conn](),
+		mutex:        sync.Mutex{},
+		pollInterval: 500 * time.Millisecond,
+		writeTimeout: 5 * time.Second,
 	}
 }
 
 func (h *WebSocketHandler) Handle(c *gin.Context, id int64) error {
 	conn, err := websocket.Accept(c.Writer, c.Request, nil)
+	logger := logging.FromContext(c).With("sensor_id", id)
 	if err != nil {
-		c.AbortWithStatusJSON(http.StatusInternalServerError, models.Error{Reason: swag.String("websocket accept error")})
+		// Accept сам отвечает клиенту при неудачном рукопожатии
+		logger.Warn("websocket accept failed", "err", err)
+		return nil
 	}
 	h.mutex.Lock()
 	h.websockets.Add(conn)
 	h.mutex.Unlock()
 
-	ticker := time.NewTicker(time.Millisecond * 500)
+	ticker := time.NewTicker(h.pollInterval)
 	ctx := conn.CloseRead(c)
+	// после рукопожатия HTTP-ответ уже отправлен, ошибки передаются кодом закрытия
+	status, reason := websocket.StatusNormalClosure, "connection closed"
 
 	select {
 	case <-ctx.Done():
@@ -48,33 +56,46 @@ func (h *WebSocketHandler) Handle(c *gin.Context, id int64) error {
 	case <-ticker.C:
 		event, err := h.useCases.Event.GetLastEventBySensorID(c, id)
 		if errors.Is(err, usecase.ErrEventNotFound) {
-			c.JSON(http.StatusNotFound, models.Error{Reason: swag.String("not enough events")})
+			status, reason = websocket.StatusTryAgainLater, "not enough events"
 			break
 		} else if err != nil {
-			c.JSON(http.StatusInternalServerError, models.Error{Reason: swag.String("websocket accept error")})
+			logger.Error("websocket: can't get last event", "err", err)
+			status, reason = websocket.StatusInternalError, "internal server error"
 			break
 		}
-		err = wsjson.Write(c, conn, event)
+		writeCtx, cancel := context.WithTimeout(c, h.writeTimeout)
+		err = wsjson.Write(writeCtx, conn, event)
+		cancel()
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, models.Error{Reason: swag.String("failed to write message")})
+			logger.Warn("websocket write failed", "err", err)
+			status, reason = websocket.StatusInternalError, "failed to write message"
 			break
 		}
 	}
 	h.mutex.Lock()
 	h.websockets.Remove(conn)
 	h.mutex.Unlock()
-	_ = conn.Close(websocket.StatusNormalClosure, "connection closed")
+	if err := conn.Close(status, reason); err != nil {
+		logger.Debug("websocket close failed", "err", err)
+	}
 	ticker.Stop()
 	return nil
 }
 
+// connections - число открытых подписок
+func (h *WebSocketHandler) connections() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.websockets.Cardinality()
+}
+
 func (h *WebSocketHandler) Shutdown() error {
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 	for conn := range h.websockets.Iter() {
 		err := conn.Close(websocket.StatusNormalClosure, "server shutting down")
 		if err != nil {
-			log.Printf("failed to close websocket: %v", err)
+			slog.Warn("failed to close websocket", "err", err)
 		}
 	}
 	return nil
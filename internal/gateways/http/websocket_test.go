@@ -66,7 +66,7 @@ func (t *testSuite) TestWebSocketConnection() {
 	require.NoError(t.T(), json.Unmarshal(msg, &event))
 
 	require.Equal(t.T(), int64(1), event.SensorID)
-	require.Equal(t.T(), int64(100), event.Payload)
+	require.Equal(t.T(), float64(100), event.Payload)
 }
 
 func (t *testSuite) TestWebSocketConnectionFail() {
@@ -0,0 +1,129 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+)
+
+// Pinger - то, что умеет проверять соединение, например *pgxpool.Pool
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping - проверка соединения с базой
+func Ping(p Pinger) CheckFunc {
+	return p.Ping
+}
+
+// MigrationVersion - текущая версия схемы и признак незавершённой миграции
+type MigrationVersion func(ctx context.Context) (version uint, dirty bool, err error)
+
+// migrationQuery - таблица, которую ведёт golang-migrate
+const migrationQuery = "SELECT version, dirty FROM schema_migrations LIMIT 1"
+
+// PgxMigrationVersion - версия схемы postgres
+func PgxMigrationVersion(db interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}) MigrationVersion {
+	return func(ctx context.Context) (uint, bool, error) {
+		var version int64
+		var dirty bool
+		if err := db.QueryRow(ctx, migrationQuery).Scan(&version, &dirty); err != nil {
+			return 0, false, err
+		}
+		return uint(version), dirty, nil
+	}
+}
+
+// SQLMigrationVersion - версия схемы базы database/sql, например sqlite
+func SQLMigrationVersion(db *sql.DB) MigrationVersion {
+	return func(ctx context.Context) (uint, bool, error) {
+		var version int64
+		var dirty bool
+		if err := db.QueryRowContext(ctx, migrationQuery).Scan(&version, &dirty); err != nil {
+			return 0, false, err
+		}
+		return uint(version), dirty, nil
+	}
+}
+
+// Migrations - проверка, что схема базы не старее want и последняя миграция завершилась
+func Migrations(current MigrationVersion, want uint) CheckFunc {
+	return func(ctx context.Context) error {
+		version, dirty, err := current(ctx)
+		if err != nil {
+			return fmt.Errorf("read migration version: %w", err)
+		}
+		if dirty {
+			return fmt.Errorf("migration %d is dirty", version)
+		}
+		if version < want {
+			return fmt.Errorf("schema version %d, want %d", version, want)
+		}
+		return nil
+	}
+}
+
+// LatestMigration - номер последней миграции в fsys в формате golang-migrate
+func LatestMigration(fsys fs.FS) (uint, error) {
+	source, err := iofs.New(fsys, ".")
+	if err != nil {
+		return 0, err
+	}
+	defer source.Close()
+
+	version, err := source.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := source.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
+// Heartbeat - отметка жизни фоновой задачи; задача зовёт Beat на каждой итерации
+type Heartbeat struct {
+	maxAge time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// NewHeartbeat - задача считается живой, если Beat вызывался не позже maxAge назад.
+// Отсчёт идёт с момента создания, чтобы задача успела сделать первую итерацию.
+func NewHeartbeat(maxAge time.Duration) *Heartbeat {
+	return &Heartbeat{maxAge: maxAge, now: time.Now, last: time.Now()}
+}
+
+func (h *Heartbeat) Beat() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last = h.now()
+}
+
+// Check - проверка для Checker.Add
+func (h *Heartbeat) Check(context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if age := h.now().Sub(h.last); age > h.maxAge {
+		return fmt.Errorf("no heartbeat for %s", age.Round(time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Package health - проверки готовности сервиса к приёму трафика.
+//
+// Проверки регистрируются по имени в Checker и выполняются параллельно с общим таймаутом.
+// Сами проверки - обычные функции, поэтому новый компонент (например, шлюз к брокеру)
+// подключается одним вызовом Add без изменений в этом пакете.
+package health
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// CheckFunc - проверка зависимости; nil означает, что зависимость в порядке
+type CheckFunc func(ctx context.Context) error
+
+// Result - результат одной проверки
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report - результат всех проверок; Status равен StatusFail, если не прошла хотя бы одна
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Fail - добавляет в отчёт непройденную проверку
+func (r *Report) Fail(name string, err error) {
+	r.Status = StatusFail
+	r.Checks[name] = Result{Status: StatusFail, Error: err.Error(), Duration: "0s"}
+}
+
+type namedCheck struct {
+	name  string
+	check CheckFunc
+}
+
+// Checker - набор проверок готовности
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks []namedCheck
+}
+
+// NewChecker - набор проверок, каждая из которых должна уложиться в timeout
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add - регистрирует проверку; имя попадает в ответ /readyz
+func (c *Checker) Add(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Names - имена зарегистрированных проверок по алфавиту
+func (c *Checker) Names() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := make([]string, 0, len(c.checks))
+	for _, nc := range c.checks {
+		names = append(names, nc.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run - выполняет все проверки параллельно
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := runCheck(ctx, nc.check)
+			result := Result{Status: StatusOK, Duration: time.Since(start).String()}
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[nc.name] = result
+			if err != nil {
+				report.Status = StatusFail
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// runCheck - выполняет проверку, не дожидаясь её дольше, чем позволяет ctx
+func runCheck(ctx context.Context, check CheckFunc) error {
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return errors.New("check timed out")
+	}
+}
@@ -0,0 +1,116 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"homework/migrations"
+	sqliteMigrations "homework/migrations/sqlite"
+	"homework/pkg/sqlite"
+)
+
+func TestChecker_Run(t *testing.T) {
+	c := NewChecker(50 * time.Millisecond)
+	c.Add("ok", func(context.Context) error { return nil })
+	c.Add("broken", func(context.Context) error { return errors.New("connection refused") })
+	c.Add("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	assert.Equal(t, []string{"broken", "ok", "slow"}, c.Names())
+
+	start := time.Now()
+	report := c.Run(context.Background())
+	assert.Less(t, time.Since(start), time.Second, "медленная проверка не должна задерживать ответ")
+
+	assert.Equal(t, StatusFail, report.Status)
+	require.Len(t, report.Checks, 3)
+	assert.Equal(t, StatusOK, report.Checks["ok"].Status)
+	assert.Equal(t, Result{Status: StatusFail, Error: "connection refused", Duration: report.Checks["broken"].Duration},
+		report.Checks["broken"])
+	assert.Equal(t, "check timed out", report.Checks["slow"].Error)
+}
+
+func TestChecker_Run_Empty(t *testing.T) {
+	report := NewChecker(time.Second).Run(context.Background())
+	assert.Equal(t, Report{Status: StatusOK, Checks: map[string]Result{}}, report)
+}
+
+func TestMigrations(t *testing.T) {
+	version := func(v uint, dirty bool, err error) MigrationVersion {
+		return func(context.Context) (uint, bool, error) { return v, dirty, err }
+	}
+
+	tests := []struct {
+		name    string
+		current MigrationVersion
+		wantErr string
+	}{
+		{"up to date", version(4, false, nil), ""},
+		{"newer than binary", version(5, false, nil), ""},
+		{"behind", version(3, false, nil), "schema version 3, want 4"},
+		{"dirty", version(4, true, nil), "migration 4 is dirty"},
+		{"no table", version(0, false, errors.New("relation does not exist")), "read migration version: relation does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Migrations(tt.current, 4)(context.Background())
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestLatestMigration(t *testing.T) {
+	version, err := LatestMigration(fstest.MapFS{
+		"000001_a.up.sql":   {},
+		"000001_a.down.sql": {},
+		"000010_b.up.sql":   {},
+		"000002_c.up.sql":   {},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint(10), version)
+
+	// встроенные миграции postgres и sqlite должны идти в ногу
+	pg, err := LatestMigration(migrations.FS)
+	require.NoError(t, err)
+	lite, err := LatestMigration(sqliteMigrations.FS)
+	require.NoError(t, err)
+	assert.Equal(t, pg, lite)
+}
+
+func TestSQLMigrationVersion(t *testing.T) {
+	db, err := sqlite.Open(context.Background(), ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	want, err := LatestMigration(sqliteMigrations.FS)
+	require.NoError(t, err)
+	assert.NoError(t, Migrations(SQLMigrationVersion(db), want)(context.Background()))
+	assert.Error(t, Migrations(SQLMigrationVersion(db), want+1)(context.Background()))
+}
+
+func TestHeartbeat(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	h := NewHeartbeat(time.Minute)
+	h.now = func() time.Time { return now }
+	h.Beat()
+
+	now = now.Add(30 * time.Second)
+	assert.NoError(t, h.Check(context.Background()))
+
+	now = now.Add(time.Minute)
+	assert.EqualError(t, h.Check(context.Background()), "no heartbeat for 1m30s")
+
+	h.Beat()
+	assert.NoError(t, h.Check(context.Background()))
+}
@@ -0,0 +1,92 @@
+// Package logging - структурное логирование на log/slog.
+//
+// Логгер запроса кладётся в контекст и достаётся через FromContext в обработчиках, usecase и
+// репозиториях, поэтому каждая запись несёт request_id. AddFields дописывает поля в запись
+// access-лога, например серийный номер датчика, известный только обработчику.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// New - логгер с обработчиком text или json, пишущий записи не ниже level
+func New(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case "", FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// ParseLevel - уровень по имени: debug, info, warn или error
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.ToUpper(name))); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+	return level, nil
+}
+
+type loggerKey struct{}
+
+// WithLogger - контекст с логгером запроса
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext - логгер запроса или slog.Default(), если в контексте его нет
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+type fieldsKey struct{}
+
+// fields - поля access-лога, которые дописываются по ходу обработки запроса
+type fields struct {
+	mu    sync.Mutex
+	attrs []any
+}
+
+// WithFields - контекст, в который AddFields сможет дописывать поля
+func WithFields(ctx context.Context) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, &fields{})
+}
+
+// AddFields - дописывает пары ключ-значение в access-лог запроса; без WithFields ничего не делает
+func AddFields(ctx context.Context, args ...any) {
+	f, ok := ctx.Value(fieldsKey{}).(*fields)
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attrs = append(f.attrs, args...)
+}
+
+// Fields - поля, добавленные через AddFields
+func Fields(ctx context.Context) []any {
+	f, ok := ctx.Value(fieldsKey{}).(*fields)
+	if !ok {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]any(nil), f.attrs...)
+}
@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/jackc/pgx/v5/tracelog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := New(&buf, FormatJSON, slog.LevelInfo)
+		require.NoError(t, err)
+
+		logger.Debug("hidden")
+		logger.Info("shown", "sensor_id", 1)
+
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		assert.Equal(t, "shown", record["msg"])
+		assert.Equal(t, float64(1), record["sensor_id"])
+	})
+
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger, err := New(&buf, FormatText, slog.LevelDebug)
+		require.NoError(t, err)
+
+		logger.Debug("shown", "sensor_id", 1)
+		assert.Contains(t, buf.String(), "msg=shown sensor_id=1")
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := New(&bytes.Buffer{}, "xml", slog.LevelInfo)
+		assert.Error(t, err)
+	})
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"debug", slog.LevelDebug, false},
+		{"info", slog.LevelInfo, false},
+		{"WARN", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"verbose", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLevel(tt.name)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Same(t, slog.Default(), FromContext(context.Background()))
+
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	assert.Same(t, logger, FromContext(WithLogger(context.Background(), logger)))
+}
+
+func TestFields(t *testing.T) {
+	AddFields(context.Background(), "ignored", true)
+	assert.Nil(t, Fields(context.Background()))
+
+	ctx := WithFields(context.Background())
+	AddFields(ctx, "sensor.serial_number", "0000000001")
+	AddFields(ctx, "user.id", int64(1))
+	assert.Equal(t, []any{"sensor.serial_number", "0000000001", "user.id", int64(1)}, Fields(ctx))
+}
+
+func TestPgxTracer(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, FormatJSON, slog.LevelDebug)
+	require.NoError(t, err)
+	ctx := WithLogger(context.Background(), logger.With("request_id", "abc"))
+
+	pgxLog(ctx, tracelog.LogLevelInfo, "Query", map[string]any{"sql": "SELECT 1"})
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "DEBUG", record["level"])
+	assert.Equal(t, "pgx: Query", record["msg"])
+	assert.Equal(t, "SELECT 1", record["sql"])
+	assert.Equal(t, "abc", record["request_id"])
+}
@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/tracelog"
+)
+
+// NewPgxTracer - pgx-трассировщик, пишущий запросы в логгер из контекста.
+// Успешные запросы пишутся на уровне debug, ошибки - на уровне error.
+func NewPgxTracer() *tracelog.TraceLog {
+	return &tracelog.TraceLog{
+		Logger:   tracelog.LoggerFunc(pgxLog),
+		LogLevel: tracelog.LogLevelInfo,
+	}
+}
+
+func pgxLog(ctx context.Context, level tracelog.LogLevel, msg string, data map[string]any) {
+	attrs := make([]any, 0, 2*len(data))
+	for k, v := range data {
+		attrs = append(attrs, k, v)
+	}
+	FromContext(ctx).Log(ctx, pgxLevel(level), "pgx: "+msg, attrs...)
+}
+
+// pgxLevel - уровень slog для уровня pgx; обычные запросы pgx пишет как info, для сервиса это debug
+func pgxLevel(level tracelog.LogLevel) slog.Level {
+	switch level {
+	case tracelog.LogLevelError:
+		return slog.LevelError
+	case tracelog.LogLevelWarn:
+		return slog.LevelWarn
+	default:
+		return slog.LevelDebug
+	}
+}
@@ -0,0 +1,185 @@
+// Package metrics - метрики Prometheus сервера.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/ratelimit"
+	"homework/internal/usecase"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "smart_home"
+
+// Metrics - набор метрик сервера со своим реестром
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpDuration    *prometheus.HistogramVec
+	eventsIngested  *prometheus.CounterVec
+	eventsFlagged   *prometheus.CounterVec
+	receiveFailures *prometheus.CounterVec
+	repoDuration    *prometheus.HistogramVec
+	rateLimit       *prometheus.CounterVec
+	cacheLookups    *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Длительность обработки HTTP-запросов по маршрутам.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "code"}),
+		eventsIngested: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_ingested_total",
+			Help:      "Количество принятых событий по типам датчиков.",
+		}, []string{"sensor_type"}),
+		eventsFlagged: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_flagged_total",
+			Help:      "Количество принятых событий с признаками недостоверности по типам датчиков и признакам.",
+		}, []string{"sensor_type", "flag"}),
+		receiveFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "event_receive_failures_total",
+			Help:      "Количество отклонённых событий по видам ошибок.",
+		}, []string{"kind"}),
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "repository",
+			Name:      "operation_duration_seconds",
+			Help:      "Длительность вызовов методов репозиториев.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"repository", "method", "outcome"}),
+		rateLimit: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "rate_limit",
+			Name:      "decisions_total",
+			Help:      "Решения ограничителя частоты запросов по видам запросов.",
+		}, []string{"scope", "result"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "lookups_total",
+			Help:      "Обращения к кэшам репозиториев: попадания и промахи.",
+		}, []string{"cache", "result"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpDuration,
+		m.eventsIngested,
+		m.eventsFlagged,
+		m.receiveFailures,
+		m.repoDuration,
+		m.rateLimit,
+		m.cacheLookups,
+	)
+	return m
+}
+
+// Registry - реестр, в котором зарегистрированы метрики
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler - обработчик для /metrics
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveHTTPRequest - учитывает обработанный HTTP-запрос; route - шаблон маршрута, а не путь
+func (m *Metrics) ObserveHTTPRequest(method, route string, code int, duration time.Duration) {
+	m.httpDuration.WithLabelValues(method, route, strconv.Itoa(code)).Observe(duration.Seconds())
+}
+
+// ObserveRepository - учитывает вызов метода репозитория
+func (m *Metrics) ObserveRepository(repository, method string, start time.Time, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	m.repoDuration.WithLabelValues(repository, method, outcome).Observe(time.Since(start).Seconds())
+}
+
+// ObserveWebSocketConnections - публикует число активных WebSocket-соединений, count вызывается при сборе
+func (m *Metrics) ObserveWebSocketConnections(count func() int) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "websocket",
+		Name:      "active_connections",
+		Help:      "Количество открытых WebSocket-подписок.",
+	}, func() float64 {
+		return float64(count())
+	}))
+}
+
+// ObservePgxPool - публикует статистику пула соединений postgres
+func (m *Metrics) ObservePgxPool(pool *pgxpool.Pool) {
+	m.registry.MustRegister(newPgxPoolCollector(pool))
+}
+
+// RateLimitDecision - реализует ratelimit.Observer
+func (m *Metrics) RateLimitDecision(scope string, allowed bool) {
+	result := "allowed"
+	if !allowed {
+		result = "limited"
+	}
+	m.rateLimit.WithLabelValues(scope, result).Inc()
+}
+
+// CacheLookup - реализует cached.Observer
+func (m *Metrics) CacheLookup(cache string, hit bool) {
+	result := "hit"
+	if !hit {
+		result = "miss"
+	}
+	m.cacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+// EventReceived - реализует usecase.EventObserver
+func (m *Metrics) EventReceived(sensor *domain.Sensor, event *domain.Event) {
+	m.eventsIngested.WithLabelValues(string(sensor.Type)).Inc()
+	for _, flag := range event.Quality.Flags() {
+		m.eventsFlagged.WithLabelValues(string(sensor.Type), flag).Inc()
+	}
+}
+
+// EventRejected - реализует usecase.EventObserver
+func (m *Metrics) EventRejected(err error) {
+	m.receiveFailures.WithLabelValues(errorKind(err)).Inc()
+}
+
+// errorKind - вид ошибки приёма события с ограниченным набором значений
+func errorKind(err error) string {
+	var limited *ratelimit.Error
+	switch {
+	case errors.Is(err, usecase.ErrInvalidEventTimestamp):
+		return "invalid_timestamp"
+	case errors.Is(err, usecase.ErrSensorNotFound):
+		return "sensor_not_found"
+	case errors.Is(err, usecase.ErrInvalidPayload):
+		return "invalid_payload"
+	case errors.As(err, &limited):
+		return "rate_limited"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "storage"
+	}
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/ratelimit"
+	"homework/internal/usecase"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_errorKind(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{usecase.ErrInvalidEventTimestamp, "invalid_timestamp"},
+		{fmt.Errorf("get sensor: %w", usecase.ErrSensorNotFound), "sensor_not_found"},
+		{usecase.ErrInvalidPayload, "invalid_payload"},
+		{&ratelimit.Error{Scope: "events"}, "rate_limited"},
+		{context.Canceled, "canceled"},
+		{context.DeadlineExceeded, "timeout"},
+		{errors.New("connection refused"), "storage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, errorKind(tt.err))
+		})
+	}
+}
+
+func TestMetrics_Events(t *testing.T) {
+	m := New()
+
+	m.EventReceived(&domain.Sensor{Type: domain.SensorTypeADC}, &domain.Event{})
+	m.EventReceived(&domain.Sensor{Type: domain.SensorTypeADC}, &domain.Event{Quality: domain.QualityOutlier | domain.QualityRate})
+	m.EventReceived(&domain.Sensor{Type: domain.SensorTypeContactClosure}, &domain.Event{})
+	m.EventRejected(usecase.ErrSensorNotFound)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.eventsIngested.WithLabelValues("adc")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.eventsIngested.WithLabelValues("cc")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.eventsFlagged.WithLabelValues("adc", "outlier")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.eventsFlagged.WithLabelValues("adc", "rate")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.receiveFailures.WithLabelValues("sensor_not_found")))
+}
+
+func TestMetrics_RateLimit(t *testing.T) {
+	m := New()
+
+	m.RateLimitDecision("events", true)
+	m.RateLimitDecision("events", false)
+	m.RateLimitDecision("events", false)
+	m.RateLimitDecision("reads", true)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.rateLimit.WithLabelValues("events", "allowed")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.rateLimit.WithLabelValues("events", "limited")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.rateLimit.WithLabelValues("reads", "allowed")))
+}
+
+func TestMetrics_CacheLookup(t *testing.T) {
+	m := New()
+
+	m.CacheLookup("sensor_by_serial", false)
+	m.CacheLookup("sensor_by_serial", true)
+	m.CacheLookup("sensor_by_serial", true)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.cacheLookups.WithLabelValues("sensor_by_serial", "hit")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.cacheLookups.WithLabelValues("sensor_by_serial", "miss")))
+}
+
+func TestMetrics_Gather(t *testing.T) {
+	m := New()
+	connections := 3
+	m.ObserveWebSocketConnections(func() int { return connections })
+	m.ObserveHTTPRequest("GET", "/sensors/:sensor_id", 200, 10*time.Millisecond)
+	m.ObserveRepository("sensor", "GetSensorByID", time.Now(), nil)
+	m.ObserveRepository("sensor", "GetSensorByID", time.Now(), usecase.ErrSensorNotFound)
+
+	expected := `
+# HELP smart_home_websocket_active_connections Количество открытых WebSocket-подписок.
+# TYPE smart_home_websocket_active_connections gauge
+smart_home_websocket_active_connections 3
+`
+	require.NoError(t, testutil.GatherAndCompare(m.Registry(), strings.NewReader(expected), "smart_home_websocket_active_connections"))
+
+	count, err := testutil.GatherAndCount(m.Registry(),
+		"smart_home_http_request_duration_seconds", "smart_home_repository_operation_duration_seconds")
+	require.NoError(t, err)
+	// один ряд HTTP и два ряда репозитория: ok и error
+	assert.Equal(t, 3, count)
+}
@@ -0,0 +1,204 @@
+package models
+
+import (
+	"context"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// CalibrationChange CalibrationChange
+//
+// Запись журнала изменений калибровки датчика
+// Example: {"calibration":{"gain":1,"offset":-0.5},"changed_at":"2024-05-01T10:00:00Z","reason":"поверка 2024-05","request_id":"4bf92f3577b34da6","version":3}
+//
+// swagger:model CalibrationChange
+type CalibrationChange struct {
+
+	// Новый профиль, отсутствует, если калибровка снята
+	Calibration *Calibration `json:"calibration,omitempty"`
+
+	// Время изменения
+	// Required: true
+	// Format: date-time
+	ChangedAt *strfmt.DateTime `json:"changed_at"`
+
+	// Профиль до изменения, отсутствует, если калибровки не было
+	Previous *Calibration `json:"previous,omitempty"`
+
+	// Причина изменения
+	Reason string `json:"reason,omitempty"`
+
+	// ID запроса, которым сделано изменение (X-Request-ID)
+	RequestID string `json:"request_id,omitempty"`
+
+	// Версия датчика после изменения
+	// Required: true
+	Version *int64 `json:"version"`
+}
+
+// Validate validates this calibration change
+func (m *CalibrationChange) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateCalibration(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateChangedAt(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validatePrevious(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateVersion(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *CalibrationChange) validateCalibration(formats strfmt.Registry) error {
+	if swag.IsZero(m.Calibration) { // not required
+		return nil
+	}
+
+	if m.Calibration != nil {
+		if err := m.Calibration.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("calibration")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("calibration")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *CalibrationChange) validateChangedAt(formats strfmt.Registry) error {
+
+	if err := validate.Required("changed_at", "body", m.ChangedAt); err != nil {
+		return err
+	}
+
+	if err := validate.FormatOf("changed_at", "body", "date-time", m.ChangedAt.String(), formats); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *CalibrationChange) validatePrevious(formats strfmt.Registry) error {
+	if swag.IsZero(m.Previous) { // not required
+		return nil
+	}
+
+	if m.Previous != nil {
+		if err := m.Previous.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("previous")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("previous")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *CalibrationChange) validateVersion(formats strfmt.Registry) error {
+
+	if err := validate.Required("version", "body", m.Version); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validate this calibration change based on the context it is used
+func (m *CalibrationChange) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.contextValidateCalibration(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidatePrevious(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *CalibrationChange) contextValidateCalibration(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.Calibration != nil {
+
+		if swag.IsZero(m.Calibration) { // not required
+			return nil
+		}
+
+		if err := m.Calibration.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("calibration")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("calibration")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *CalibrationChange) contextValidatePrevious(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.Previous != nil {
+
+		if swag.IsZero(m.Previous) { // not required
+			return nil
+		}
+
+		if err := m.Previous.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("previous")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("previous")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *CalibrationChange) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *CalibrationChange) UnmarshalBinary(b []byte) error {
+	var res CalibrationChange
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"context"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// CalibrationPoint CalibrationPoint
+//
+// Точка таблицы линеаризации
+// Example: {"raw":512,"value":25}
+//
+// swagger:model CalibrationPoint
+type CalibrationPoint struct {
+
+	// Показание датчика с учётом десятичного масштаба
+	// Required: true
+	Raw *float64 `json:"raw"`
+
+	// Значение, соответствующее показанию
+	// Required: true
+	Value *float64 `json:"value"`
+}
+
+// Validate validates this calibration point
+func (m *CalibrationPoint) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateRaw(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateValue(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *CalibrationPoint) validateRaw(formats strfmt.Registry) error {
+
+	if err := validate.Required("raw", "body", m.Raw); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *CalibrationPoint) validateValue(formats strfmt.Registry) error {
+
+	if err := validate.Required("value", "body", m.Value); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validates this calibration point based on context it is used
+func (m *CalibrationPoint) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *CalibrationPoint) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *CalibrationPoint) UnmarshalBinary(b []byte) error {
+	var res CalibrationPoint
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
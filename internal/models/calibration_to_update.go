@@ -0,0 +1,167 @@
+package models
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// CalibrationToUpdate CalibrationToUpdate
+//
+// Новый профиль калибровки датчика и причина изменения для журнала
+// Example: {"gain":1,"offset":-0.5,"reason":"поверка 2024-05","table":[{"raw":0,"value":-40},{"raw":1023,"value":125}]}
+//
+// swagger:model CalibrationToUpdate
+type CalibrationToUpdate struct {
+
+	// Коэффициент усиления
+	// Required: true
+	Gain *float64 `json:"gain"`
+
+	// Смещение, прибавляемое после умножения на gain
+	Offset float64 `json:"offset,omitempty"`
+
+	// Причина изменения, попадает в журнал калибровки
+	// Max Length: 256
+	Reason string `json:"reason,omitempty"`
+
+	// Таблица линеаризации по возрастанию raw, между точками значение интерполируется линейно
+	// Max Items: 64
+	Table []*CalibrationPoint `json:"table,omitempty"`
+}
+
+// Validate validates this calibration to update
+func (m *CalibrationToUpdate) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateGain(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateReason(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateTable(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *CalibrationToUpdate) validateGain(formats strfmt.Registry) error {
+
+	if err := validate.Required("gain", "body", m.Gain); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *CalibrationToUpdate) validateReason(formats strfmt.Registry) error {
+	if swag.IsZero(m.Reason) { // not required
+		return nil
+	}
+
+	if err := validate.MaxLength("reason", "body", m.Reason, 256); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *CalibrationToUpdate) validateTable(formats strfmt.Registry) error {
+	if swag.IsZero(m.Table) { // not required
+		return nil
+	}
+
+	iTableSize := int64(len(m.Table))
+
+	if err := validate.MaxItems("table", "body", iTableSize, 64); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(m.Table); i++ {
+		if swag.IsZero(m.Table[i]) { // not required
+			continue
+		}
+
+		if m.Table[i] != nil {
+			if err := m.Table[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("table" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("table" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// ContextValidate validate this calibration to update based on the context it is used
+func (m *CalibrationToUpdate) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.contextValidateTable(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *CalibrationToUpdate) contextValidateTable(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Table); i++ {
+
+		if m.Table[i] != nil {
+
+			if swag.IsZero(m.Table[i]) { // not required
+				return nil
+			}
+
+			if err := m.Table[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("table" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("table" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *CalibrationToUpdate) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *CalibrationToUpdate) UnmarshalBinary(b []byte) error {
+	var res CalibrationToUpdate
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
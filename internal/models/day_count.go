@@ -0,0 +1,96 @@
+package models
+
+import (
+	"context"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// DayCount DayCount
+//
+// Число событий за календарные сутки
+// Example: {"count":3,"day":"2018-01-01T00:00:00+03:00"}
+//
+// swagger:model DayCount
+type DayCount struct {
+
+	// Число событий за сутки
+	// Required: true
+	// Minimum: 0
+	Count *int64 `json:"count"`
+
+	// Полночь суток в часовом поясе запроса
+	// Required: true
+	// Format: date-time
+	Day *strfmt.DateTime `json:"day"`
+}
+
+// Validate validates this day count
+func (m *DayCount) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateCount(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateDay(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *DayCount) validateCount(formats strfmt.Registry) error {
+
+	if err := validate.Required("count", "body", m.Count); err != nil {
+		return err
+	}
+
+	if err := validate.MinimumInt("count", "body", *m.Count, 0, false); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *DayCount) validateDay(formats strfmt.Registry) error {
+
+	if err := validate.Required("day", "body", m.Day); err != nil {
+		return err
+	}
+
+	if err := validate.FormatOf("day", "body", "date-time", m.Day.String(), formats); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validates this day count based on context it is used
+func (m *DayCount) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *DayCount) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *DayCount) UnmarshalBinary(b []byte) error {
+	var res DayCount
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// History History
+//
+// История событий от датчика с найденными пропусками и подставленными в них точками
+// Example: {"events":[{"payload":21.5,"raw":215,"timestamp":"2018-01-01T00:00:00Z","unit":"°C"},{"filled":true,"payload":21.5,"raw":215,"timestamp":"2018-01-01T00:02:00Z","unit":"°C"}],"gaps":[{"end":"2018-01-01T00:10:00Z","start":"2018-01-01T00:00:00Z"}]}
+//
+// swagger:model History
+type History struct {
+
+	// События и подставленные точки по времени, затем по каналу
+	// Required: true
+	Events []*HistoryOfEvents `json:"events"`
+
+	// Пропуски по времени, затем по каналу; ищутся только у датчиков, тип которых задаёт интервал отчётов
+	// Required: true
+	Gaps []*HistoryGap `json:"gaps"`
+}
+
+// Validate validates this history
+func (m *History) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateEvents(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateGaps(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *History) validateEvents(formats strfmt.Registry) error {
+
+	if err := validate.Required("events", "body", m.Events); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(m.Events); i++ {
+		if swag.IsZero(m.Events[i]) { // not required
+			continue
+		}
+
+		if m.Events[i] != nil {
+			if err := m.Events[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("events" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("events" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *History) validateGaps(formats strfmt.Registry) error {
+
+	if err := validate.Required("gaps", "body", m.Gaps); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(m.Gaps); i++ {
+		if swag.IsZero(m.Gaps[i]) { // not required
+			continue
+		}
+
+		if m.Gaps[i] != nil {
+			if err := m.Gaps[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("gaps" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("gaps" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// ContextValidate validate this history based on the context it is used
+func (m *History) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.contextValidateEvents(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateGaps(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *History) contextValidateEvents(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Events); i++ {
+
+		if m.Events[i] != nil {
+
+			if swag.IsZero(m.Events[i]) { // not required
+				return nil
+			}
+
+			if err := m.Events[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("events" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("events" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+func (m *History) contextValidateGaps(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Gaps); i++ {
+
+		if m.Gaps[i] != nil {
+
+			if swag.IsZero(m.Gaps[i]) { // not required
+				return nil
+			}
+
+			if err := m.Gaps[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("gaps" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("gaps" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *History) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *History) UnmarshalBinary(b []byte) error {
+	var res History
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"context"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// HistoryGap HistoryGap
+//
+// Промежуток, за который от датчика не пришло ожидаемых событий
+// Example: {"end":"2018-01-01T00:10:00Z","start":"2018-01-01T00:01:00Z"}
+//
+// swagger:model HistoryGap
+type HistoryGap struct {
+
+	// Канал многоканального датчика
+	Channel string `json:"channel,omitempty"`
+
+	// Время первого события после пропуска или конец запрошенного периода
+	// Required: true
+	// Format: date-time
+	End *strfmt.DateTime `json:"end"`
+
+	// Время последнего события перед пропуском или начало запрошенного периода
+	// Required: true
+	// Format: date-time
+	Start *strfmt.DateTime `json:"start"`
+}
+
+// Validate validates this history gap
+func (m *HistoryGap) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateEnd(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateStart(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *HistoryGap) validateEnd(formats strfmt.Registry) error {
+
+	if err := validate.Required("end", "body", m.End); err != nil {
+		return err
+	}
+
+	if err := validate.FormatOf("end", "body", "date-time", m.End.String(), formats); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *HistoryGap) validateStart(formats strfmt.Registry) error {
+
+	if err := validate.Required("start", "body", m.Start); err != nil {
+		return err
+	}
+
+	if err := validate.FormatOf("start", "body", "date-time", m.Start.String(), formats); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validates this history gap based on context it is used
+func (m *HistoryGap) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *HistoryGap) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *HistoryGap) UnmarshalBinary(b []byte) error {
+	var res HistoryGap
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -1,12 +1,9 @@
-// Code generated by go-swagger; DO NOT EDIT.
-
 package models
 
-// This file was generated by the swagger tool.
-// Editing this file might prove futile when you re-run the swagger generate command
-
 import (
 	"context"
+	"encoding/json"
+	"strconv"
 
 	"github.com/go-openapi/errors"
 	"github.com/go-openapi/strfmt"
@@ -17,19 +14,36 @@ import (
 // HistoryOfEvents HistoryOfEvents
 //
 // История событий от датчика
-// Example: {"payload":10,"timestamp":"2018-01-01T00:00:00Z"}
+// Example: {"payload":21.5,"quality":["rate"],"raw":215,"timestamp":"2018-01-01T00:00:00Z","unit":"°C"}
 //
 // swagger:model HistoryOfEvents
 type HistoryOfEvents struct {
 
-	// Информация от датчика
+	// Канал многоканального датчика
+	Channel string `json:"channel,omitempty"`
+
+	// Точка подставлена в пропуск по параметру fill, а не пришла от датчика
+	Filled bool `json:"filled,omitempty"`
+
+	// Значение измерения в единицах unit; null у точек, подставленных при fill=null
+	// Required: true
+	Payload *float64 `json:"payload"`
+
+	// Признаки недостоверности значения, найденные при приёме: outlier - выброс относительно последних значений, rate - слишком быстрое изменение, stuck - значение не меняется слишком долго
+	Quality []string `json:"quality,omitempty"`
+
+	// Значение до калибровки датчика с учётом десятичного масштаба, равно payload у неоткалиброванных датчиков; null у точек, подставленных при fill=null
 	// Required: true
-	Payload *int64 `json:"payload"`
+	Raw *float64 `json:"raw"`
 
 	// Дата/время события
 	// Required: true
 	// Format: date-time
 	Timestamp *strfmt.DateTime `json:"timestamp"`
+
+	// Единица измерения payload, пустая для безразмерных значений
+	// Required: true
+	Unit *string `json:"unit"`
 }
 
 // Validate validates this history of events
@@ -40,10 +54,22 @@ func (m *HistoryOfEvents) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateQuality(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateRaw(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateTimestamp(formats); err != nil {
 		res = append(res, err)
 	}
 
+	if err := m.validateUnit(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
@@ -59,6 +85,51 @@ func (m *HistoryOfEvents) validatePayload(formats strfmt.Registry) error {
 	return nil
 }
 
+var historyOfEventsQualityItemsEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["outlier","rate","stuck"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		historyOfEventsQualityItemsEnum = append(historyOfEventsQualityItemsEnum, v)
+	}
+}
+
+func (m *HistoryOfEvents) validateQualityItemsEnum(path, location string, value string) error {
+	if err := validate.EnumCase(path, location, value, historyOfEventsQualityItemsEnum, true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *HistoryOfEvents) validateQuality(formats strfmt.Registry) error {
+	if swag.IsZero(m.Quality) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Quality); i++ {
+
+		// value enum
+		if err := m.validateQualityItemsEnum("quality"+"."+strconv.Itoa(i), "body", m.Quality[i]); err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+}
+
+func (m *HistoryOfEvents) validateRaw(formats strfmt.Registry) error {
+
+	if err := validate.Required("raw", "body", m.Raw); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (m *HistoryOfEvents) validateTimestamp(formats strfmt.Registry) error {
 
 	if err := validate.Required("timestamp", "body", m.Timestamp); err != nil {
@@ -72,6 +143,15 @@ func (m *HistoryOfEvents) validateTimestamp(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *HistoryOfEvents) validateUnit(formats strfmt.Registry) error {
+
+	if err := validate.Required("unit", "body", m.Unit); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // ContextValidate validates this history of events based on context it is used
 func (m *HistoryOfEvents) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
 	return nil
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"context"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// Problem Problem
+//
+// Ошибка исполнения запроса в формате RFC 7807 (application/problem+json)
+// Example: {"code":"sensor_not_found","detail":"sensor not found","instance":"/v1/sensors/42","status":404,"title":"Not Found","type":"about:blank"}
+//
+// swagger:model Problem
+type Problem struct {
+
+	// Стабильный машиночитаемый код ошибки
+	// Required: true
+	// Min Length: 1
+	Code *string `json:"code"`
+
+	// Описание конкретного случая
+	Detail string `json:"detail,omitempty"`
+
+	// Путь запроса, на котором возникла ошибка
+	Instance string `json:"instance,omitempty"`
+
+	// HTTP-код ответа
+	// Required: true
+	Status *int64 `json:"status"`
+
+	// Краткое описание класса ошибки
+	// Required: true
+	Title *string `json:"title"`
+
+	// URI типа ошибки
+	Type string `json:"type,omitempty"`
+}
+
+// Validate validates this problem
+func (m *Problem) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateCode(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateStatus(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateTitle(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *Problem) validateCode(formats strfmt.Registry) error {
+
+	if err := validate.Required("code", "body", m.Code); err != nil {
+		return err
+	}
+
+	if err := validate.MinLength("code", "body", *m.Code, 1); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *Problem) validateStatus(formats strfmt.Registry) error {
+
+	if err := validate.Required("status", "body", m.Status); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *Problem) validateTitle(formats strfmt.Registry) error {
+
+	if err := validate.Required("title", "body", m.Title); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validates this problem based on context it is used
+func (m *Problem) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *Problem) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *Problem) UnmarshalBinary(b []byte) error {
+	var res Problem
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"context"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// RecalibrationResult RecalibrationResult
+//
+// Результат пересчёта событий
+// Example: {"updated":1440}
+//
+// swagger:model RecalibrationResult
+type RecalibrationResult struct {
+
+	// Число событий, значение которых изменилось
+	// Required: true
+	Updated *int64 `json:"updated"`
+}
+
+// Validate validates this recalibration result
+func (m *RecalibrationResult) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateUpdated(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *RecalibrationResult) validateUpdated(formats strfmt.Registry) error {
+
+	if err := validate.Required("updated", "body", m.Updated); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validates this recalibration result based on context it is used
+func (m *RecalibrationResult) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *RecalibrationResult) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *RecalibrationResult) UnmarshalBinary(b []byte) error {
+	var res RecalibrationResult
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
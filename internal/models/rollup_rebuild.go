@@ -0,0 +1,99 @@
+package models
+
+import (
+	"context"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// RollupRebuild RollupRebuild
+//
+// Период и датчики, сводки событий которых надо пересобрать
+// Example: {"end_date":"2024-05-01T00:00:00Z","sensor_ids":[1,2],"start_date":"2024-04-01T00:00:00Z"}
+//
+// swagger:model RollupRebuild
+type RollupRebuild struct {
+
+	// Конец периода, не включительно
+	// Required: true
+	// Format: date-time
+	EndDate *strfmt.DateTime `json:"end_date"`
+
+	// Идентификаторы датчиков, без них - все датчики
+	SensorIds []int64 `json:"sensor_ids,omitempty"`
+
+	// Начало периода
+	// Required: true
+	// Format: date-time
+	StartDate *strfmt.DateTime `json:"start_date"`
+}
+
+// Validate validates this rollup rebuild
+func (m *RollupRebuild) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateEndDate(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateStartDate(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *RollupRebuild) validateEndDate(formats strfmt.Registry) error {
+
+	if err := validate.Required("end_date", "body", m.EndDate); err != nil {
+		return err
+	}
+
+	if err := validate.FormatOf("end_date", "body", "date-time", m.EndDate.String(), formats); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *RollupRebuild) validateStartDate(formats strfmt.Registry) error {
+
+	if err := validate.Required("start_date", "body", m.StartDate); err != nil {
+		return err
+	}
+
+	if err := validate.FormatOf("start_date", "body", "date-time", m.StartDate.String(), formats); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validates this rollup rebuild based on context it is used
+func (m *RollupRebuild) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *RollupRebuild) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *RollupRebuild) UnmarshalBinary(b []byte) error {
+	var res RollupRebuild
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"context"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// RollupRebuildResult RollupRebuildResult
+//
+// Результат пересборки сводок событий
+// Example: {"rebuilt":1500}
+//
+// swagger:model RollupRebuildResult
+type RollupRebuildResult struct {
+
+	// Число записанных сводок
+	// Required: true
+	Rebuilt *int64 `json:"rebuilt"`
+}
+
+// Validate validates this rollup rebuild result
+func (m *RollupRebuildResult) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateRebuilt(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *RollupRebuildResult) validateRebuilt(formats strfmt.Registry) error {
+
+	if err := validate.Required("rebuilt", "body", m.Rebuilt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validates this rollup rebuild result based on context it is used
+func (m *RollupRebuildResult) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *RollupRebuildResult) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *RollupRebuildResult) UnmarshalBinary(b []byte) error {
+	var res RollupRebuildResult
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
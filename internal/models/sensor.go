@@ -1,13 +1,8 @@
-// Code generated by go-swagger; DO NOT EDIT.
-
 package models
 
-// This file was generated by the swagger tool.
-// Editing this file might prove futile when you re-run the swagger generate command
-
 import (
 	"context"
-	"encoding/json"
+	"strconv"
 
 	"github.com/go-openapi/errors"
 	"github.com/go-openapi/strfmt"
@@ -18,14 +13,20 @@ import (
 // Sensor Sensor
 //
 // Датчик умного дома
-// Example: {"current_state":1,"description":"Датчик температуры","id":1,"is_active":true,"last_activity":"2018-01-01T00:00:00Z","registered_at":"2018-01-01T00:00:00Z","serial_number":"1234567890","type":"cc"}
+// Example: {"current_state":21.5,"description":"Датчик температуры","id":1,"is_active":true,"last_activity":"2018-01-01T00:00:00Z","registered_at":"2018-01-01T00:00:00Z","scale":1,"serial_number":"1234567890","type":"adc","unit":"°C"}
 //
 // swagger:model Sensor
 type Sensor struct {
 
-	// Состояние датчика, соответствует значению в payload последнего обработанного события.
+	// Калибровка показаний, отсутствует у неоткалиброванных датчиков
+	Calibration *Calibration `json:"calibration,omitempty"`
+
+	// Каналы многоканального датчика
+	Channels []*SensorChannel `json:"channels,omitempty"`
+
+	// Состояние датчика, соответствует значению в payload последнего обработанного события в единицах unit.
 	// Required: true
-	CurrentState *int64 `json:"current_state"`
+	CurrentState *float64 `json:"current_state"`
 
 	// Описание
 	// Required: true
@@ -50,21 +51,40 @@ type Sensor struct {
 	// Format: date-time
 	RegisteredAt *strfmt.DateTime `json:"registered_at"`
 
+	// Десятичный масштаб показаний: датчик присылает значение, умноженное на 10^scale
+	// Required: true
+	// Maximum: 9
+	// Minimum: 0
+	Scale *int64 `json:"scale"`
+
 	// Серийный номер
 	// Required: true
-	// Pattern: ^\d{10}$
+	// Pattern: ^[0-9A-Za-z_-]{1,32}$
 	SerialNumber *string `json:"serial_number"`
 
 	// Тип
 	// Required: true
-	// Enum: ["cc","adc"]
+	// Pattern: ^[a-z][a-z0-9_]{0,31}$
 	Type *string `json:"type"`
+
+	// Единица измерения показаний, пустая для безразмерных значений
+	// Required: true
+	// Max Length: 16
+	Unit *string `json:"unit"`
 }
 
 // Validate validates this sensor
 func (m *Sensor) Validate(formats strfmt.Registry) error {
 	var res []error
 
+	if err := m.validateCalibration(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateChannels(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateCurrentState(formats); err != nil {
 		res = append(res, err)
 	}
@@ -89,6 +109,10 @@ func (m *Sensor) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateScale(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateSerialNumber(formats); err != nil {
 		res = append(res, err)
 	}
@@ -97,12 +121,61 @@ func (m *Sensor) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateUnit(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
 	return nil
 }
 
+func (m *Sensor) validateCalibration(formats strfmt.Registry) error {
+	if swag.IsZero(m.Calibration) { // not required
+		return nil
+	}
+
+	if m.Calibration != nil {
+		if err := m.Calibration.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("calibration")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("calibration")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Sensor) validateChannels(formats strfmt.Registry) error {
+	if swag.IsZero(m.Channels) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Channels); i++ {
+		if swag.IsZero(m.Channels[i]) { // not required
+			continue
+		}
+
+		if m.Channels[i] != nil {
+			if err := m.Channels[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("channels" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("channels" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
 func (m *Sensor) validateCurrentState(formats strfmt.Registry) error {
 
 	if err := validate.Required("current_state", "body", m.CurrentState); err != nil {
@@ -169,64 +242,123 @@ func (m *Sensor) validateRegisteredAt(formats strfmt.Registry) error {
 	return nil
 }
 
-func (m *Sensor) validateSerialNumber(formats strfmt.Registry) error {
+func (m *Sensor) validateScale(formats strfmt.Registry) error {
 
-	if err := validate.Required("serial_number", "body", m.SerialNumber); err != nil {
+	if err := validate.Required("scale", "body", m.Scale); err != nil {
+		return err
+	}
+
+	if err := validate.MinimumInt("scale", "body", *m.Scale, 0, false); err != nil {
 		return err
 	}
 
-	if err := validate.Pattern("serial_number", "body", *m.SerialNumber, `^\d{10}$`); err != nil {
+	if err := validate.MaximumInt("scale", "body", *m.Scale, 9, false); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-var sensorTypeTypePropEnum []interface{}
+func (m *Sensor) validateSerialNumber(formats strfmt.Registry) error {
 
-func init() {
-	var res []string
-	if err := json.Unmarshal([]byte(`["cc","adc"]`), &res); err != nil {
-		panic(err)
+	if err := validate.Required("serial_number", "body", m.SerialNumber); err != nil {
+		return err
 	}
-	for _, v := range res {
-		sensorTypeTypePropEnum = append(sensorTypeTypePropEnum, v)
+
+	if err := validate.Pattern("serial_number", "body", *m.SerialNumber, `^[0-9A-Za-z_-]{1,32}$`); err != nil {
+		return err
 	}
-}
 
-const (
+	return nil
+}
 
-	// SensorTypeCc captures enum value "cc"
-	SensorTypeCc string = "cc"
+func (m *Sensor) validateType(formats strfmt.Registry) error {
 
-	// SensorTypeAdc captures enum value "adc"
-	SensorTypeAdc string = "adc"
-)
+	if err := validate.Required("type", "body", m.Type); err != nil {
+		return err
+	}
 
-// prop value enum
-func (m *Sensor) validateTypeEnum(path, location string, value string) error {
-	if err := validate.EnumCase(path, location, value, sensorTypeTypePropEnum, true); err != nil {
+	if err := validate.Pattern("type", "body", *m.Type, `^[a-z][a-z0-9_]{0,31}$`); err != nil {
 		return err
 	}
+
 	return nil
 }
 
-func (m *Sensor) validateType(formats strfmt.Registry) error {
+func (m *Sensor) validateUnit(formats strfmt.Registry) error {
 
-	if err := validate.Required("type", "body", m.Type); err != nil {
+	if err := validate.Required("unit", "body", m.Unit); err != nil {
 		return err
 	}
 
-	// value enum
-	if err := m.validateTypeEnum("type", "body", *m.Type); err != nil {
+	if err := validate.MaxLength("unit", "body", *m.Unit, 16); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// ContextValidate validates this sensor based on context it is used
+// ContextValidate validate this sensor based on the context it is used
 func (m *Sensor) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.contextValidateCalibration(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidateChannels(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *Sensor) contextValidateCalibration(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.Calibration != nil {
+
+		if swag.IsZero(m.Calibration) { // not required
+			return nil
+		}
+
+		if err := m.Calibration.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("calibration")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("calibration")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Sensor) contextValidateChannels(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Channels); i++ {
+
+		if m.Channels[i] != nil {
+
+			if swag.IsZero(m.Channels[i]) { // not required
+				return nil
+			}
+
+			if err := m.Channels[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("channels" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("channels" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
 	return nil
 }
 
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"context"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// SensorChannel SensorChannel
+//
+// Канал многоканального датчика
+// Example: {"current_state":21.5,"last_activity":"2018-01-01T00:00:00Z","name":"temperature","scale":1,"unit":"°C"}
+//
+// swagger:model SensorChannel
+type SensorChannel struct {
+
+	// Последнее значение канала в единицах unit
+	// Required: true
+	CurrentState *float64 `json:"current_state"`
+
+	// Время последнего значения канала
+	// Required: true
+	// Format: date-time
+	LastActivity *strfmt.DateTime `json:"last_activity"`
+
+	// Имя канала
+	// Required: true
+	// Pattern: ^[a-z][a-z0-9_]{0,31}$
+	Name *string `json:"name"`
+
+	// Десятичный масштаб значений канала
+	// Required: true
+	// Maximum: 9
+	// Minimum: 0
+	Scale *int64 `json:"scale"`
+
+	// Единица измерения значений канала
+	// Required: true
+	// Max Length: 16
+	Unit *string `json:"unit"`
+}
+
+// Validate validates this sensor channel
+func (m *SensorChannel) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateCurrentState(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateLastActivity(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateName(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateScale(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateUnit(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *SensorChannel) validateCurrentState(formats strfmt.Registry) error {
+
+	if err := validate.Required("current_state", "body", m.CurrentState); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *SensorChannel) validateLastActivity(formats strfmt.Registry) error {
+
+	if err := validate.Required("last_activity", "body", m.LastActivity); err != nil {
+		return err
+	}
+
+	if err := validate.FormatOf("last_activity", "body", "date-time", m.LastActivity.String(), formats); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *SensorChannel) validateName(formats strfmt.Registry) error {
+
+	if err := validate.Required("name", "body", m.Name); err != nil {
+		return err
+	}
+
+	if err := validate.Pattern("name", "body", *m.Name, `^[a-z][a-z0-9_]{0,31}$`); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *SensorChannel) validateScale(formats strfmt.Registry) error {
+
+	if err := validate.Required("scale", "body", m.Scale); err != nil {
+		return err
+	}
+
+	if err := validate.MinimumInt("scale", "body", *m.Scale, 0, false); err != nil {
+		return err
+	}
+
+	if err := validate.MaximumInt("scale", "body", *m.Scale, 9, false); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *SensorChannel) validateUnit(formats strfmt.Registry) error {
+
+	if err := validate.Required("unit", "body", m.Unit); err != nil {
+		return err
+	}
+
+	if err := validate.MaxLength("unit", "body", *m.Unit, 16); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validates this sensor channel based on context it is used
+func (m *SensorChannel) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *SensorChannel) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *SensorChannel) UnmarshalBinary(b []byte) error {
+	var res SensorChannel
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
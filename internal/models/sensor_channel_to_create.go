@@ -0,0 +1,119 @@
+package models
+
+import (
+	"context"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// SensorChannelToCreate SensorChannelToCreate
+//
+// Канал многоканального датчика, который надо создать
+// Example: {"name":"temperature","scale":1,"unit":"°C"}
+//
+// swagger:model SensorChannelToCreate
+type SensorChannelToCreate struct {
+
+	// Имя канала
+	// Required: true
+	// Pattern: ^[a-z][a-z0-9_]{0,31}$
+	Name *string `json:"name"`
+
+	// Десятичный масштаб значений канала
+	// Maximum: 9
+	// Minimum: 0
+	Scale int64 `json:"scale,omitempty"`
+
+	// Единица измерения значений канала
+	// Max Length: 16
+	Unit string `json:"unit,omitempty"`
+}
+
+// Validate validates this sensor channel to create
+func (m *SensorChannelToCreate) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateName(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateScale(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateUnit(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *SensorChannelToCreate) validateName(formats strfmt.Registry) error {
+
+	if err := validate.Required("name", "body", m.Name); err != nil {
+		return err
+	}
+
+	if err := validate.Pattern("name", "body", *m.Name, `^[a-z][a-z0-9_]{0,31}$`); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *SensorChannelToCreate) validateScale(formats strfmt.Registry) error {
+	if swag.IsZero(m.Scale) { // not required
+		return nil
+	}
+
+	if err := validate.MinimumInt("scale", "body", m.Scale, 0, false); err != nil {
+		return err
+	}
+
+	if err := validate.MaximumInt("scale", "body", m.Scale, 9, false); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *SensorChannelToCreate) validateUnit(formats strfmt.Registry) error {
+	if swag.IsZero(m.Unit) { // not required
+		return nil
+	}
+
+	if err := validate.MaxLength("unit", "body", m.Unit, 16); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validates this sensor channel to create based on context it is used
+func (m *SensorChannelToCreate) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *SensorChannelToCreate) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *SensorChannelToCreate) UnmarshalBinary(b []byte) error {
+	var res SensorChannelToCreate
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
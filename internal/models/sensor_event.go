@@ -1,10 +1,5 @@
-// Code generated by go-swagger; DO NOT EDIT.
-
 package models
 
-// This file was generated by the swagger tool.
-// Editing this file might prove futile when you re-run the swagger generate command
-
 import (
 	"context"
 
@@ -17,19 +12,28 @@ import (
 // SensorEvent SensorEvent
 //
 // Событие датчика
-// Example: {"payload":10,"sensor_serial_number":"1234567890"}
+// Example: {"payload":21.5,"sensor_serial_number":"1234567890","unit":"°C"}
+// Example: {"sensor_serial_number":"1234567890","values":{"battery":87,"humidity":45.5,"temperature":21.5}}
 //
 // swagger:model SensorEvent
 type SensorEvent struct {
 
-	// Информация от датчика
-	// Required: true
-	Payload *int64 `json:"payload"`
+	// Значение измерения; целые значения датчиков с масштабом делятся на 10^scale датчика.
+	// Обязательно, если нет values
+	Payload *float64 `json:"payload,omitempty"`
 
 	// Серийный номер датчика
 	// Required: true
-	// Pattern: ^\d{10}$
+	// Pattern: ^[0-9A-Za-z_-]{1,32}$
 	SensorSerialNumber *string `json:"sensor_serial_number"`
+
+	// Единица измерения payload; если указана, должна совпадать с единицей датчика
+	// Max Length: 16
+	Unit string `json:"unit,omitempty"`
+
+	// Значения каналов многоканального датчика по имени канала; обязательно, если нет payload
+	// Max Properties: 16
+	Values map[string]float64 `json:"values,omitempty"`
 }
 
 // Validate validates this sensor event
@@ -44,6 +48,14 @@ func (m *SensorEvent) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateUnit(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateValues(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
@@ -51,6 +63,12 @@ func (m *SensorEvent) Validate(formats strfmt.Registry) error {
 }
 
 func (m *SensorEvent) validatePayload(formats strfmt.Registry) error {
+	if m.Values != nil {
+		if m.Payload != nil {
+			return errors.New(errors.InvalidTypeCode, "payload and values are mutually exclusive")
+		}
+		return nil
+	}
 
 	if err := validate.Required("payload", "body", m.Payload); err != nil {
 		return err
@@ -65,13 +83,43 @@ func (m *SensorEvent) validateSensorSerialNumber(formats strfmt.Registry) error
 		return err
 	}
 
-	if err := validate.Pattern("sensor_serial_number", "body", *m.SensorSerialNumber, `^\d{10}$`); err != nil {
+	if err := validate.Pattern("sensor_serial_number", "body", *m.SensorSerialNumber, `^[0-9A-Za-z_-]{1,32}$`); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *SensorEvent) validateUnit(formats strfmt.Registry) error {
+	if swag.IsZero(m.Unit) { // not required
+		return nil
+	}
+
+	if err := validate.MaxLength("unit", "body", m.Unit, 16); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+func (m *SensorEvent) validateValues(formats strfmt.Registry) error {
+	if m.Values == nil { // not required
+		return nil
+	}
+
+	nprops := len(m.Values)
+
+	if nprops < 1 {
+		return errors.TooFewProperties("values", "body", 1)
+	}
+
+	if nprops > 16 {
+		return errors.TooManyProperties("values", "body", 16)
+	}
+
+	return nil
+}
+
 // ContextValidate validates this sensor event based on context it is used
 func (m *SensorEvent) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
 	return nil
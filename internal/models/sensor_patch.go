@@ -0,0 +1,86 @@
+package models
+
+import (
+	"context"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// SensorPatch SensorPatch
+//
+// Изменение настроек датчика, отсутствующие поля не меняются
+// Example: {"description":"Датчик температуры в спальне","is_active":false,"unit":"°C"}
+//
+// swagger:model SensorPatch
+type SensorPatch struct {
+
+	// Описание
+	Description *string `json:"description,omitempty"`
+
+	// Флаг активности датчика
+	IsActive *bool `json:"is_active,omitempty"`
+
+	// Десятичный масштаб показаний
+	// Maximum: 9
+	// Minimum: 0
+	Scale *int64 `json:"scale,omitempty"`
+
+	// Единица измерения показаний
+	// Max Length: 16
+	Unit *string `json:"unit,omitempty"`
+}
+
+// Validate validates this sensor patch
+func (m *SensorPatch) Validate(formats strfmt.Registry) error {
+	if m.Description == nil && m.IsActive == nil && m.Scale == nil && m.Unit == nil {
+		return errors.TooFewProperties("", "body", 1)
+	}
+
+	var res []error
+
+	if m.Scale != nil {
+		if err := validate.MinimumInt("scale", "body", *m.Scale, 0, false); err != nil {
+			res = append(res, err)
+		}
+		if err := validate.MaximumInt("scale", "body", *m.Scale, 9, false); err != nil {
+			res = append(res, err)
+		}
+	}
+
+	if m.Unit != nil {
+		if err := validate.MaxLength("unit", "body", *m.Unit, 16); err != nil {
+			res = append(res, err)
+		}
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+// ContextValidate validates this sensor patch based on context it is used
+func (m *SensorPatch) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *SensorPatch) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *SensorPatch) UnmarshalBinary(b []byte) error {
+	var res SensorPatch
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
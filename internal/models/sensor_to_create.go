@@ -1,13 +1,8 @@
-// Code generated by go-swagger; DO NOT EDIT.
-
 package models
 
-// This file was generated by the swagger tool.
-// Editing this file might prove futile when you re-run the swagger generate command
-
 import (
 	"context"
-	"encoding/json"
+	"strconv"
 
 	"github.com/go-openapi/errors"
 	"github.com/go-openapi/strfmt"
@@ -18,11 +13,15 @@ import (
 // SensorToCreate SensorToCreate
 //
 // Датчик умного дома, который надо создать
-// Example: {"description":"Датчик температуры","is_active":true,"serial_number":"1234567890","type":"cc"}
+// Example: {"description":"Датчик температуры","is_active":true,"scale":1,"serial_number":"1234567890","type":"adc","unit":"°C"}
 //
 // swagger:model SensorToCreate
 type SensorToCreate struct {
 
+	// Каналы многоканального датчика: события такого датчика передают значения в values
+	// Max Items: 16
+	Channels []*SensorChannelToCreate `json:"channels,omitempty"`
+
 	// Описание
 	// Required: true
 	Description *string `json:"description"`
@@ -31,21 +30,34 @@ type SensorToCreate struct {
 	// Required: true
 	IsActive *bool `json:"is_active"`
 
-	// Серийный номер
+	// Десятичный масштаб показаний: датчик присылает значение, умноженное на 10^scale
+	// Maximum: 9
+	// Minimum: 0
+	Scale int64 `json:"scale,omitempty"`
+
+	// Серийный номер в формате serial_number_pattern типа датчика
 	// Required: true
-	// Pattern: ^\d{10}$
+	// Pattern: ^[0-9A-Za-z_-]{1,32}$
 	SerialNumber *string `json:"serial_number"`
 
 	// Тип
 	// Required: true
-	// Enum: ["cc","adc"]
+	// Pattern: ^[a-z][a-z0-9_]{0,31}$
 	Type *string `json:"type"`
+
+	// Единица измерения показаний, например °C; пустая для безразмерных значений
+	// Max Length: 16
+	Unit string `json:"unit,omitempty"`
 }
 
 // Validate validates this sensor to create
 func (m *SensorToCreate) Validate(formats strfmt.Registry) error {
 	var res []error
 
+	if err := m.validateChannels(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateDescription(formats); err != nil {
 		res = append(res, err)
 	}
@@ -54,6 +66,10 @@ func (m *SensorToCreate) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateScale(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateSerialNumber(formats); err != nil {
 		res = append(res, err)
 	}
@@ -62,12 +78,48 @@ func (m *SensorToCreate) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateUnit(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
 	return nil
 }
 
+func (m *SensorToCreate) validateChannels(formats strfmt.Registry) error {
+	if swag.IsZero(m.Channels) { // not required
+		return nil
+	}
+
+	iChannelsSize := int64(len(m.Channels))
+
+	if err := validate.MaxItems("channels", "body", iChannelsSize, 16); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(m.Channels); i++ {
+		if swag.IsZero(m.Channels[i]) { // not required
+			continue
+		}
+
+		if m.Channels[i] != nil {
+			if err := m.Channels[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("channels" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("channels" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
 func (m *SensorToCreate) validateDescription(formats strfmt.Registry) error {
 
 	if err := validate.Required("description", "body", m.Description); err != nil {
@@ -86,45 +138,32 @@ func (m *SensorToCreate) validateIsActive(formats strfmt.Registry) error {
 	return nil
 }
 
-func (m *SensorToCreate) validateSerialNumber(formats strfmt.Registry) error {
+func (m *SensorToCreate) validateScale(formats strfmt.Registry) error {
+	if swag.IsZero(m.Scale) { // not required
+		return nil
+	}
 
-	if err := validate.Required("serial_number", "body", m.SerialNumber); err != nil {
+	if err := validate.MinimumInt("scale", "body", m.Scale, 0, false); err != nil {
 		return err
 	}
 
-	if err := validate.Pattern("serial_number", "body", *m.SerialNumber, `^\d{10}$`); err != nil {
+	if err := validate.MaximumInt("scale", "body", m.Scale, 9, false); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-var sensorToCreateTypeTypePropEnum []interface{}
+func (m *SensorToCreate) validateSerialNumber(formats strfmt.Registry) error {
 
-func init() {
-	var res []string
-	if err := json.Unmarshal([]byte(`["cc","adc"]`), &res); err != nil {
-		panic(err)
-	}
-	for _, v := range res {
-		sensorToCreateTypeTypePropEnum = append(sensorToCreateTypeTypePropEnum, v)
+	if err := validate.Required("serial_number", "body", m.SerialNumber); err != nil {
+		return err
 	}
-}
-
-const (
 
-	// SensorToCreateTypeCc captures enum value "cc"
-	SensorToCreateTypeCc string = "cc"
-
-	// SensorToCreateTypeAdc captures enum value "adc"
-	SensorToCreateTypeAdc string = "adc"
-)
-
-// prop value enum
-func (m *SensorToCreate) validateTypeEnum(path, location string, value string) error {
-	if err := validate.EnumCase(path, location, value, sensorToCreateTypeTypePropEnum, true); err != nil {
+	if err := validate.Pattern("serial_number", "body", *m.SerialNumber, `^[0-9A-Za-z_-]{1,32}$`); err != nil {
 		return err
 	}
+
 	return nil
 }
 
@@ -134,16 +173,61 @@ func (m *SensorToCreate) validateType(formats strfmt.Registry) error {
 		return err
 	}
 
-	// value enum
-	if err := m.validateTypeEnum("type", "body", *m.Type); err != nil {
+	if err := validate.Pattern("type", "body", *m.Type, `^[a-z][a-z0-9_]{0,31}$`); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// ContextValidate validates this sensor to create based on context it is used
+func (m *SensorToCreate) validateUnit(formats strfmt.Registry) error {
+	if swag.IsZero(m.Unit) { // not required
+		return nil
+	}
+
+	if err := validate.MaxLength("unit", "body", m.Unit, 16); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validate this sensor to create based on the context it is used
 func (m *SensorToCreate) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.contextValidateChannels(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *SensorToCreate) contextValidateChannels(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Channels); i++ {
+
+		if m.Channels[i] != nil {
+
+			if swag.IsZero(m.Channels[i]) { // not required
+				return nil
+			}
+
+			if err := m.Channels[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("channels" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("channels" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
 	return nil
 }
 
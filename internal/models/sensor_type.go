@@ -0,0 +1,234 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// SensorType SensorType
+//
+// Тип датчика из реестра
+// Example: {"aggregation":"last","description":"Датчик замыкания контакта: двери, окна, протечки","kind":"binary","name":"cc","report_interval_seconds":0,"serial_number_pattern":"^\\d{10}$"}
+//
+// swagger:model SensorType
+type SensorType struct {
+
+	// Как значения сводятся за интервал
+	// Required: true
+	// Enum: ["mean","last","min","max","sum"]
+	Aggregation *string `json:"aggregation"`
+
+	// Описание
+	Description string `json:"description,omitempty"`
+
+	// Вид значений: binary - 0 или 1, integer - целые, number - любые числа
+	// Required: true
+	// Enum: ["binary","integer","number"]
+	Kind *string `json:"kind"`
+
+	// Наибольшее допустимое значение, отсутствует - без ограничения
+	Max *float64 `json:"max,omitempty"`
+
+	// Наименьшее допустимое значение, отсутствует - без ограничения
+	Min *float64 `json:"min,omitempty"`
+
+	// Имя типа, значение поля type датчика
+	// Required: true
+	Name *string `json:"name"`
+
+	// Как часто датчик обычно присылает события, в секундах; 0 - только при изменении состояния
+	// Required: true
+	// Minimum: 0
+	ReportIntervalSeconds *int64 `json:"report_interval_seconds"`
+
+	// Регулярное выражение для серийного номера датчика
+	// Required: true
+	SerialNumberPattern *string `json:"serial_number_pattern"`
+}
+
+// Validate validates this sensor type
+func (m *SensorType) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateAggregation(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateKind(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateName(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateReportIntervalSeconds(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateSerialNumberPattern(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+var sensorTypeTypeAggregationPropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["mean","last","min","max","sum"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		sensorTypeTypeAggregationPropEnum = append(sensorTypeTypeAggregationPropEnum, v)
+	}
+}
+
+const (
+
+	// SensorTypeAggregationMean captures enum value "mean"
+	SensorTypeAggregationMean string = "mean"
+
+	// SensorTypeAggregationLast captures enum value "last"
+	SensorTypeAggregationLast string = "last"
+
+	// SensorTypeAggregationMin captures enum value "min"
+	SensorTypeAggregationMin string = "min"
+
+	// SensorTypeAggregationMax captures enum value "max"
+	SensorTypeAggregationMax string = "max"
+
+	// SensorTypeAggregationSum captures enum value "sum"
+	SensorTypeAggregationSum string = "sum"
+)
+
+// prop value enum
+func (m *SensorType) validateAggregationEnum(path, location string, value string) error {
+	if err := validate.EnumCase(path, location, value, sensorTypeTypeAggregationPropEnum, true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *SensorType) validateAggregation(formats strfmt.Registry) error {
+
+	if err := validate.Required("aggregation", "body", m.Aggregation); err != nil {
+		return err
+	}
+
+	// value enum
+	if err := m.validateAggregationEnum("aggregation", "body", *m.Aggregation); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var sensorTypeTypeKindPropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["binary","integer","number"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		sensorTypeTypeKindPropEnum = append(sensorTypeTypeKindPropEnum, v)
+	}
+}
+
+const (
+
+	// SensorTypeKindBinary captures enum value "binary"
+	SensorTypeKindBinary string = "binary"
+
+	// SensorTypeKindInteger captures enum value "integer"
+	SensorTypeKindInteger string = "integer"
+
+	// SensorTypeKindNumber captures enum value "number"
+	SensorTypeKindNumber string = "number"
+)
+
+// prop value enum
+func (m *SensorType) validateKindEnum(path, location string, value string) error {
+	if err := validate.EnumCase(path, location, value, sensorTypeTypeKindPropEnum, true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *SensorType) validateKind(formats strfmt.Registry) error {
+
+	if err := validate.Required("kind", "body", m.Kind); err != nil {
+		return err
+	}
+
+	// value enum
+	if err := m.validateKindEnum("kind", "body", *m.Kind); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *SensorType) validateName(formats strfmt.Registry) error {
+
+	if err := validate.Required("name", "body", m.Name); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *SensorType) validateReportIntervalSeconds(formats strfmt.Registry) error {
+
+	if err := validate.Required("report_interval_seconds", "body", m.ReportIntervalSeconds); err != nil {
+		return err
+	}
+
+	if err := validate.MinimumInt("report_interval_seconds", "body", *m.ReportIntervalSeconds, 0, false); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *SensorType) validateSerialNumberPattern(formats strfmt.Registry) error {
+
+	if err := validate.Required("serial_number_pattern", "body", m.SerialNumberPattern); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validates this sensor type based on context it is used
+func (m *SensorType) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *SensorType) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *SensorType) UnmarshalBinary(b []byte) error {
+	var res SensorType
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
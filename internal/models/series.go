@@ -0,0 +1,171 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// Series Series
+//
+// Значения датчика или его канала по интервалам запроса
+// Example: {"aggregation":"mean","counts":[12,0,11],"sensor_id":1,"unit":"°C","values":[21.5,null,22]}
+//
+// swagger:model Series
+type Series struct {
+
+	// Как значения сведены за интервал
+	// Required: true
+	// Enum: ["mean","last","min","max","sum"]
+	Aggregation *string `json:"aggregation"`
+
+	// Канал многоканального датчика
+	Channel string `json:"channel,omitempty"`
+
+	// Число событий в каждом интервале; у заполненных интервалов 0
+	// Required: true
+	Counts []int64 `json:"counts"`
+
+	// Идентификатор датчика
+	// Required: true
+	SensorID *int64 `json:"sensor_id"`
+
+	// Единица измерения
+	Unit string `json:"unit,omitempty"`
+
+	// Значение каждого интервала из buckets; null - событий нет и интервал не заполнен
+	// Required: true
+	Values []*float64 `json:"values"`
+}
+
+// Validate validates this series
+func (m *Series) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateAggregation(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateCounts(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateSensorID(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateValues(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+var seriesTypeAggregationPropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["mean","last","min","max","sum"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		seriesTypeAggregationPropEnum = append(seriesTypeAggregationPropEnum, v)
+	}
+}
+
+const (
+
+	// SeriesAggregationMean captures enum value "mean"
+	SeriesAggregationMean string = "mean"
+
+	// SeriesAggregationLast captures enum value "last"
+	SeriesAggregationLast string = "last"
+
+	// SeriesAggregationMin captures enum value "min"
+	SeriesAggregationMin string = "min"
+
+	// SeriesAggregationMax captures enum value "max"
+	SeriesAggregationMax string = "max"
+
+	// SeriesAggregationSum captures enum value "sum"
+	SeriesAggregationSum string = "sum"
+)
+
+// prop value enum
+func (m *Series) validateAggregationEnum(path, location string, value string) error {
+	if err := validate.EnumCase(path, location, value, seriesTypeAggregationPropEnum, true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *Series) validateAggregation(formats strfmt.Registry) error {
+
+	if err := validate.Required("aggregation", "body", m.Aggregation); err != nil {
+		return err
+	}
+
+	// value enum
+	if err := m.validateAggregationEnum("aggregation", "body", *m.Aggregation); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *Series) validateCounts(formats strfmt.Registry) error {
+
+	if err := validate.Required("counts", "body", m.Counts); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *Series) validateSensorID(formats strfmt.Registry) error {
+
+	if err := validate.Required("sensor_id", "body", m.SensorID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *Series) validateValues(formats strfmt.Registry) error {
+
+	if err := validate.Required("values", "body", m.Values); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validates this series based on context it is used
+func (m *Series) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *Series) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *Series) UnmarshalBinary(b []byte) error {
+	var res Series
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
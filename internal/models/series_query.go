@@ -0,0 +1,309 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// SeriesQuery SeriesQuery
+//
+// Запрос значений нескольких датчиков, сведённых по одинаковым интервалам
+// Example: {"aggregation":"mean","bucket":"1h","end_date":"2024-05-02T00:00:00Z","fill":"previous","sensor_ids":[1,2],"start_date":"2024-05-01T00:00:00Z","time_zone":"Europe/Moscow","user_id":1}
+//
+// swagger:model SeriesQuery
+type SeriesQuery struct {
+
+	// Как значения сводятся за интервал; отсутствует - функцией типа каждого датчика
+	// Enum: ["mean","last","min","max","sum"]
+	Aggregation string `json:"aggregation,omitempty"`
+
+	// Длина интервала, например 15m, 1h или 1d; интервал из целых суток начинается в местную полночь
+	// Required: true
+	Bucket *string `json:"bucket"`
+
+	// Конец периода, не включается
+	// Required: true
+	// Format: date-time
+	EndDate *strfmt.DateTime `json:"end_date"`
+
+	// Чем заполнять интервалы без событий, как в истории датчика
+	// Enum: ["none","previous","linear","null"]
+	Fill string `json:"fill,omitempty"`
+
+	// Датчики для сравнения, все должны быть привязаны к пользователю
+	// Required: true
+	// Min Items: 1
+	// Unique: true
+	SensorIds []int64 `json:"sensor_ids"`
+
+	// Начало периода и первого интервала
+	// Required: true
+	// Format: date-time
+	StartDate *strfmt.DateTime `json:"start_date"`
+
+	// Часовой пояс из базы IANA для суточных интервалов и времени в ответе; по умолчанию - пояс пользователя
+	// Max Length: 64
+	TimeZone string `json:"time_zone,omitempty"`
+
+	// Пользователь, от имени которого выполняется запрос
+	// Required: true
+	// Minimum: 1
+	UserID *int64 `json:"user_id"`
+}
+
+// Validate validates this series query
+func (m *SeriesQuery) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateAggregation(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateBucket(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateEndDate(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateFill(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateSensorIds(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateStartDate(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateTimeZone(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateUserID(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+var seriesQueryTypeAggregationPropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["mean","last","min","max","sum"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		seriesQueryTypeAggregationPropEnum = append(seriesQueryTypeAggregationPropEnum, v)
+	}
+}
+
+const (
+
+	// SeriesQueryAggregationMean captures enum value "mean"
+	SeriesQueryAggregationMean string = "mean"
+
+	// SeriesQueryAggregationLast captures enum value "last"
+	SeriesQueryAggregationLast string = "last"
+
+	// SeriesQueryAggregationMin captures enum value "min"
+	SeriesQueryAggregationMin string = "min"
+
+	// SeriesQueryAggregationMax captures enum value "max"
+	SeriesQueryAggregationMax string = "max"
+
+	// SeriesQueryAggregationSum captures enum value "sum"
+	SeriesQueryAggregationSum string = "sum"
+)
+
+// prop value enum
+func (m *SeriesQuery) validateAggregationEnum(path, location string, value string) error {
+	if err := validate.EnumCase(path, location, value, seriesQueryTypeAggregationPropEnum, true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *SeriesQuery) validateAggregation(formats strfmt.Registry) error {
+	if swag.IsZero(m.Aggregation) { // not required
+		return nil
+	}
+
+	// value enum
+	if err := m.validateAggregationEnum("aggregation", "body", m.Aggregation); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *SeriesQuery) validateBucket(formats strfmt.Registry) error {
+
+	if err := validate.Required("bucket", "body", m.Bucket); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *SeriesQuery) validateEndDate(formats strfmt.Registry) error {
+
+	if err := validate.Required("end_date", "body", m.EndDate); err != nil {
+		return err
+	}
+
+	if err := validate.FormatOf("end_date", "body", "date-time", m.EndDate.String(), formats); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var seriesQueryTypeFillPropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["none","previous","linear","null"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		seriesQueryTypeFillPropEnum = append(seriesQueryTypeFillPropEnum, v)
+	}
+}
+
+const (
+
+	// SeriesQueryFillNone captures enum value "none"
+	SeriesQueryFillNone string = "none"
+
+	// SeriesQueryFillPrevious captures enum value "previous"
+	SeriesQueryFillPrevious string = "previous"
+
+	// SeriesQueryFillLinear captures enum value "linear"
+	SeriesQueryFillLinear string = "linear"
+
+	// SeriesQueryFillNull captures enum value "null"
+	SeriesQueryFillNull string = "null"
+)
+
+// prop value enum
+func (m *SeriesQuery) validateFillEnum(path, location string, value string) error {
+	if err := validate.EnumCase(path, location, value, seriesQueryTypeFillPropEnum, true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *SeriesQuery) validateFill(formats strfmt.Registry) error {
+	if swag.IsZero(m.Fill) { // not required
+		return nil
+	}
+
+	// value enum
+	if err := m.validateFillEnum("fill", "body", m.Fill); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *SeriesQuery) validateSensorIds(formats strfmt.Registry) error {
+
+	if err := validate.Required("sensor_ids", "body", m.SensorIds); err != nil {
+		return err
+	}
+
+	iSensorIdsSize := int64(len(m.SensorIds))
+
+	if err := validate.MinItems("sensor_ids", "body", iSensorIdsSize, 1); err != nil {
+		return err
+	}
+
+	if err := validate.UniqueItems("sensor_ids", "body", m.SensorIds); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(m.SensorIds); i++ {
+
+		if err := validate.MinimumInt("sensor_ids"+"."+strconv.Itoa(i), "body", m.SensorIds[i], 1, false); err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+}
+
+func (m *SeriesQuery) validateStartDate(formats strfmt.Registry) error {
+
+	if err := validate.Required("start_date", "body", m.StartDate); err != nil {
+		return err
+	}
+
+	if err := validate.FormatOf("start_date", "body", "date-time", m.StartDate.String(), formats); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *SeriesQuery) validateTimeZone(formats strfmt.Registry) error {
+	if swag.IsZero(m.TimeZone) { // not required
+		return nil
+	}
+
+	if err := validate.MaxLength("time_zone", "body", m.TimeZone, 64); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *SeriesQuery) validateUserID(formats strfmt.Registry) error {
+
+	if err := validate.Required("user_id", "body", m.UserID); err != nil {
+		return err
+	}
+
+	if err := validate.MinimumInt("user_id", "body", *m.UserID, 1, false); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validates this series query based on context it is used
+func (m *SeriesQuery) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *SeriesQuery) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *SeriesQuery) UnmarshalBinary(b []byte) error {
+	var res SeriesQuery
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -0,0 +1,147 @@
+package models
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// SeriesResult SeriesResult
+//
+// Ряды датчиков, выровненные по общим интервалам
+// Example: {"buckets":["2024-05-01T00:00:00Z","2024-05-01T01:00:00Z"],"series":[{"aggregation":"mean","counts":[12,11],"sensor_id":1,"unit":"°C","values":[21.5,22]},{"aggregation":"last","counts":[1,0],"sensor_id":2,"values":[1,null]}]}
+//
+// swagger:model SeriesResult
+type SeriesResult struct {
+
+	// Начала интервалов; i-е значение каждого ряда относится к i-му интервалу
+	// Required: true
+	Buckets []strfmt.DateTime `json:"buckets"`
+
+	// Ряды в порядке sensor_ids запроса, внутри датчика - по каналам
+	// Required: true
+	Series []*Series `json:"series"`
+}
+
+// Validate validates this series result
+func (m *SeriesResult) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateBuckets(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateSeries(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *SeriesResult) validateBuckets(formats strfmt.Registry) error {
+
+	if err := validate.Required("buckets", "body", m.Buckets); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(m.Buckets); i++ {
+
+		if err := validate.FormatOf("buckets"+"."+strconv.Itoa(i), "body", "date-time", m.Buckets[i].String(), formats); err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+}
+
+func (m *SeriesResult) validateSeries(formats strfmt.Registry) error {
+
+	if err := validate.Required("series", "body", m.Series); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(m.Series); i++ {
+		if swag.IsZero(m.Series[i]) { // not required
+			continue
+		}
+
+		if m.Series[i] != nil {
+			if err := m.Series[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("series" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("series" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// ContextValidate validate this series result based on the context it is used
+func (m *SeriesResult) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.contextValidateSeries(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *SeriesResult) contextValidateSeries(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Series); i++ {
+
+		if m.Series[i] != nil {
+
+			if swag.IsZero(m.Series[i]) { // not required
+				return nil
+			}
+
+			if err := m.Series[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("series" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("series" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *SeriesResult) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *SeriesResult) UnmarshalBinary(b []byte) error {
+	var res SeriesResult
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
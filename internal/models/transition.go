@@ -0,0 +1,114 @@
+package models
+
+import (
+	"context"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
+)
+
+// Transition Transition
+//
+// Смена состояния датчика вида binary
+// Example: {"from":0,"previous_duration_seconds":3600,"timestamp":"2018-01-01T00:00:00Z","to":1}
+//
+// swagger:model Transition
+type Transition struct {
+
+	// Канал многоканального датчика
+	Channel string `json:"channel,omitempty"`
+
+	// Прежнее состояние
+	// Required: true
+	From *float64 `json:"from"`
+
+	// Сколько секунд длилось прежнее состояние, от прошлой смены; нет, если это первая известная смена
+	PreviousDurationSeconds *float64 `json:"previous_duration_seconds,omitempty"`
+
+	// Время события с новым состоянием
+	// Required: true
+	// Format: date-time
+	Timestamp *strfmt.DateTime `json:"timestamp"`
+
+	// Новое состояние
+	// Required: true
+	To *float64 `json:"to"`
+}
+
+// Validate validates this transition
+func (m *Transition) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateFrom(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateTimestamp(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateTo(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *Transition) validateFrom(formats strfmt.Registry) error {
+
+	if err := validate.Required("from", "body", m.From); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *Transition) validateTimestamp(formats strfmt.Registry) error {
+
+	if err := validate.Required("timestamp", "body", m.Timestamp); err != nil {
+		return err
+	}
+
+	if err := validate.FormatOf("timestamp", "body", "date-time", m.Timestamp.String(), formats); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *Transition) validateTo(formats strfmt.Registry) error {
+
+	if err := validate.Required("to", "body", m.To); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ContextValidate validates this transition based on context it is used
+func (m *Transition) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	return nil
+}
+
+// MarshalBinary interface implementation
+func (m *Transition) MarshalBinary() ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return swag.WriteJSON(m)
+}
+
+// UnmarshalBinary interface implementation
+func (m *Transition) UnmarshalBinary(b []byte) error {
+	var res Transition
+	if err := swag.ReadJSON(b, &res); err != nil {
+		return err
+	}
+	*m = res
+	return nil
+}
@@ -1,10 +1,5 @@
-// Code generated by go-swagger; DO NOT EDIT.
-
 package models
 
-// This file was generated by the swagger tool.
-// Editing this file might prove futile when you re-run the swagger generate command
-
 import (
 	"context"
 
@@ -17,7 +12,7 @@ import (
 // User User
 //
 // Пользователь умного дома
-// Example: {"id":1,"name":"Иван Иваныч Иванов"}
+// Example: {"id":1,"name":"Иван Иваныч Иванов","time_zone":"Europe/Moscow"}
 //
 // swagger:model User
 type User struct {
@@ -31,6 +26,10 @@ type User struct {
 	// Required: true
 	// Min Length: 1
 	Name *string `json:"name"`
+
+	// Часовой пояс дома из базы IANA
+	// Required: true
+	TimeZone *string `json:"time_zone"`
 }
 
 // Validate validates this user
@@ -45,6 +44,10 @@ func (m *User) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateTimeZone(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
@@ -77,6 +80,15 @@ func (m *User) validateName(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *User) validateTimeZone(formats strfmt.Registry) error {
+
+	if err := validate.Required("time_zone", "body", m.TimeZone); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // ContextValidate validates this user based on context it is used
 func (m *User) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
 	return nil
@@ -1,10 +1,5 @@
-// Code generated by go-swagger; DO NOT EDIT.
-
 package models
 
-// This file was generated by the swagger tool.
-// Editing this file might prove futile when you re-run the swagger generate command
-
 import (
 	"context"
 
@@ -17,7 +12,7 @@ import (
 // UserToCreate UserToCreate
 //
 // Пользователь умного дома, которого надо создать
-// Example: {"name":"Иван Иваныч Иванов"}
+// Example: {"name":"Иван Иваныч Иванов","time_zone":"Europe/Moscow"}
 //
 // swagger:model UserToCreate
 type UserToCreate struct {
@@ -26,6 +21,10 @@ type UserToCreate struct {
 	// Required: true
 	// Min Length: 1
 	Name *string `json:"name"`
+
+	// Часовой пояс дома из базы IANA, по нему история и сравнение датчиков делятся на сутки; по умолчанию UTC
+	// Max Length: 64
+	TimeZone string `json:"time_zone,omitempty"`
 }
 
 // Validate validates this user to create
@@ -36,6 +35,10 @@ func (m *UserToCreate) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateTimeZone(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if len(res) > 0 {
 		return errors.CompositeValidationError(res...)
 	}
@@ -55,6 +58,18 @@ func (m *UserToCreate) validateName(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *UserToCreate) validateTimeZone(formats strfmt.Registry) error {
+	if swag.IsZero(m.TimeZone) { // not required
+		return nil
+	}
+
+	if err := validate.MaxLength("time_zone", "body", m.TimeZone, 64); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // ContextValidate validates this user to create based on context it is used
 func (m *UserToCreate) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
 	return nil
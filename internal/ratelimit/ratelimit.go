@@ -0,0 +1,124 @@
+// Package ratelimit - ограничение частоты запросов корзиной токенов.
+//
+// Limiter хранит корзины по ключам; MemoryLimiter держит их в памяти процесса, общее хранилище
+// для нескольких реплик можно подставить, реализовав тот же интерфейс. Scope связывает хранилище
+// с набором лимитов для одного вида запросов (например, приём событий) и учитывает решения в метриках.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"homework/internal/logging"
+	"math"
+	"time"
+)
+
+// Limit - параметры корзины: Rate токенов в секунду, не больше Burst подряд.
+// Нулевой Rate означает отсутствие ограничения
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited - лимит не ограничивает запросы
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0
+}
+
+// Result - решение по одному запросу
+type Result struct {
+	Allowed bool
+	// Remaining - сколько целых токенов осталось в корзине
+	Remaining int
+	// RetryAfter - через сколько появится токен, если запрос отклонён
+	RetryAfter time.Duration
+}
+
+// Limiter - хранилище корзин токенов
+type Limiter interface {
+	// Allow - забирает токен из корзины key, заводя её по limit при первом обращении
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Error - запрос отклонён ограничителем
+type Error struct {
+	Scope      string
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s rate limit exceeded, retry after %s", e.Scope, e.RetryAfter)
+}
+
+// RetryAfterSeconds - значение заголовка Retry-After: целые секунды, не меньше одной
+func (e *Error) RetryAfterSeconds() int {
+	return max(1, int(math.Ceil(e.RetryAfter.Seconds())))
+}
+
+// Limits - лимит по умолчанию и отдельные лимиты для классов ключей (типов датчиков, тарифов)
+type Limits struct {
+	Default Limit
+	Classes map[string]Limit
+}
+
+// For - лимит класса class или лимит по умолчанию
+func (l Limits) For(class string) Limit {
+	if limit, ok := l.Classes[class]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// Observer - получает каждое решение ограничителя
+type Observer interface {
+	RateLimitDecision(scope string, allowed bool)
+}
+
+// Scope - ограничение одного вида запросов поверх общего Limiter
+type Scope struct {
+	name     string
+	limiter  Limiter
+	limits   Limits
+	observer Observer
+}
+
+func NewScope(name string, limiter Limiter, limits Limits, options ...func(*Scope)) *Scope {
+	s := &Scope{name: name, limiter: limiter, limits: limits}
+	for _, o := range options {
+		o(s)
+	}
+	return s
+}
+
+// WithObserver - сообщать observer о каждом решении
+func WithObserver(observer Observer) func(*Scope) {
+	return func(s *Scope) {
+		s.observer = observer
+	}
+}
+
+// Name - имя вида запросов, оно же префикс ключей в Limiter
+func (s *Scope) Name() string {
+	return s.name
+}
+
+// Allow - пропускает запрос с ключом key и классом class или возвращает *Error.
+// Ошибки хранилища не должны останавливать приём данных, поэтому запрос в этом случае пропускается
+func (s *Scope) Allow(ctx context.Context, key, class string) error {
+	limit := s.limits.For(class)
+	if limit.Unlimited() {
+		return nil
+	}
+	res, err := s.limiter.Allow(ctx, s.name+":"+key, limit)
+	if err != nil {
+		logging.FromContext(ctx).Warn("rate limiter failed, request allowed", "scope", s.name, "err", err)
+		return nil
+	}
+	if s.observer != nil {
+		s.observer.RateLimitDecision(s.name, res.Allowed)
+	}
+	if !res.Allowed {
+		return &Error{Scope: s.name, RetryAfter: res.RetryAfter}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter() (*MemoryLimiter, *time.Time) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemoryLimiter()
+	m.now = func() time.Time { return now }
+	m.lastSweep = now
+	return m, &now
+}
+
+func TestMemoryLimiter_Allow(t *testing.T) {
+	m, now := newTestLimiter()
+	ctx := context.Background()
+	limit := Limit{Rate: 2, Burst: 3}
+
+	for i := 2; i >= 0; i-- {
+		res, err := m.Allow(ctx, "a", limit)
+		require.NoError(t, err)
+		assert.Equal(t, Result{Allowed: true, Remaining: i}, res)
+	}
+	res, err := m.Allow(ctx, "a", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+	// у другого ключа своя корзина
+	res, _ = m.Allow(ctx, "b", limit)
+	assert.True(t, res.Allowed)
+
+	*now = now.Add(250 * time.Millisecond)
+	res, _ = m.Allow(ctx, "a", limit)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 250*time.Millisecond, res.RetryAfter)
+
+	*now = now.Add(250 * time.Millisecond)
+	res, _ = m.Allow(ctx, "a", limit)
+	assert.True(t, res.Allowed)
+
+	// корзина не копит токенов больше Burst
+	*now = now.Add(time.Hour)
+	for range 3 {
+		res, _ = m.Allow(ctx, "a", limit)
+		assert.True(t, res.Allowed)
+	}
+	res, _ = m.Allow(ctx, "a", limit)
+	assert.False(t, res.Allowed)
+}
+
+func TestMemoryLimiter_Unlimited(t *testing.T) {
+	m, _ := newTestLimiter()
+	for range 100 {
+		res, err := m.Allow(context.Background(), "a", Limit{})
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+	}
+	assert.Equal(t, 0, m.Len())
+}
+
+func TestMemoryLimiter_Sweep(t *testing.T) {
+	m, now := newTestLimiter()
+	ctx := context.Background()
+	_, _ = m.Allow(ctx, "idle", Limit{Rate: 1, Burst: 1})
+	_, _ = m.Allow(ctx, "busy", Limit{Rate: 0.001, Burst: 1})
+	assert.Equal(t, 2, m.Len())
+
+	*now = now.Add(time.Minute)
+	_, _ = m.Allow(ctx, "new", Limit{Rate: 1, Burst: 1})
+	// idle заполнилась и удалена, busy ещё пуста
+	assert.Equal(t, 2, m.Len())
+}
+
+type observer map[bool]int
+
+func (o observer) RateLimitDecision(scope string, allowed bool) {
+	o[allowed]++
+}
+
+type brokenLimiter struct{}
+
+func (brokenLimiter) Allow(context.Context, string, Limit) (Result, error) {
+	return Result{}, errors.New("connection refused")
+}
+
+func TestScope_Allow(t *testing.T) {
+	m, _ := newTestLimiter()
+	obs := observer{}
+	s := NewScope("events", m, Limits{
+		Default: Limit{Rate: 1, Burst: 1},
+		Classes: map[string]Limit{"adc": {Rate: 1, Burst: 2}, "cc": {}},
+	}, WithObserver(obs))
+	ctx := context.Background()
+
+	assert.NoError(t, s.Allow(ctx, "0000000001", ""))
+	err := s.Allow(ctx, "0000000001", "")
+	var limited *Error
+	require.ErrorAs(t, err, &limited)
+	assert.Equal(t, "events", limited.Scope)
+	assert.Equal(t, 1, limited.RetryAfterSeconds())
+
+	assert.NoError(t, s.Allow(ctx, "0000000002", "adc"))
+	assert.NoError(t, s.Allow(ctx, "0000000002", "adc"))
+	assert.Error(t, s.Allow(ctx, "0000000002", "adc"))
+
+	// класс без ограничения не доходит до хранилища и не учитывается
+	for range 10 {
+		assert.NoError(t, s.Allow(ctx, "0000000003", "cc"))
+	}
+	assert.Equal(t, observer{true: 3, false: 2}, obs)
+
+	// отказ хранилища не блокирует запросы
+	assert.NoError(t, NewScope("events", brokenLimiter{}, Limits{Default: Limit{Rate: 1, Burst: 1}}).Allow(ctx, "a", ""))
+}
+
+func TestError_RetryAfterSeconds(t *testing.T) {
+	assert.Equal(t, 1, (&Error{RetryAfter: 10 * time.Millisecond}).RetryAfterSeconds())
+	assert.Equal(t, 3, (&Error{RetryAfter: 2100 * time.Millisecond}).RetryAfterSeconds())
+}
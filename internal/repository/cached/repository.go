@@ -0,0 +1,243 @@
+// Package cached - кэширующий декоратор репозитория датчиков.
+//
+// Приём события ищет датчик по серийному номеру и затем сохраняет его новое состояние. Кэш держит
+// датчики по серийному номеру, и сохранение через декоратор обновляет уже закэшированную запись,
+// поэтому на поток событий от одного датчика хранилище читается один раз за ttl.
+//
+// Изменения, сделанные в обход декоратора (другой репликой сервиса), видны при чтении не позже чем
+// через ttl. Приём события их не затирает: состояние записывается через UpdateSensor с версией
+// закэшированной записи, и при ErrSensorModified запись удаляется из кэша, а usecase перечитывает
+// датчик из хранилища.
+package cached
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"sync"
+	"time"
+)
+
+// sensorBySerial - имя кэша в метриках
+const sensorBySerial = "sensor_by_serial"
+
+// Observer - получатель попаданий и промахов кэша, например metrics.Metrics
+type Observer interface {
+	CacheLookup(cache string, hit bool)
+}
+
+type nopObserver struct{}
+
+func (nopObserver) CacheLookup(string, bool) {}
+
+type SensorRepository struct {
+	inner    usecase.SensorRepository
+	ttl      time.Duration
+	observer Observer
+	now      func() time.Time
+
+	mu sync.Mutex
+	// bySerial - закэшированные датчики
+	bySerial map[string]*entry
+	// serialByID - серийный номер закэшированного датчика, чтобы найти запись при сохранении по ID
+	serialByID map[int64]string
+	// loads - чтения из хранилища, которые ещё не завершились; запись датчика помечает их устаревшими,
+	// чтобы прочитанное до записи значение не попало в кэш
+	loads map[string]*load
+
+	// sweepInterval - как часто удалять просроченные записи
+	sweepInterval time.Duration
+	lastSweep     time.Time
+}
+
+type entry struct {
+	sensor  domain.Sensor
+	expires time.Time
+}
+
+type load struct {
+	inflight int
+	stale    bool
+}
+
+// NewSensorRepository - кэш поверх inner, записи живут ttl
+func NewSensorRepository(inner usecase.SensorRepository, ttl time.Duration, options ...func(*SensorRepository)) *SensorRepository {
+	r := &SensorRepository{
+		inner:         inner,
+		ttl:           ttl,
+		observer:      nopObserver{},
+		now:           time.Now,
+		bySerial:      make(map[string]*entry),
+		serialByID:    make(map[int64]string),
+		loads:         make(map[string]*load),
+		sweepInterval: time.Minute,
+	}
+	for _, o := range options {
+		o(r)
+	}
+	return r
+}
+
+// WithObserver - сообщать observer о каждом попадании и промахе
+func WithObserver(observer Observer) func(*SensorRepository) {
+	return func(r *SensorRepository) {
+		r.observer = observer
+	}
+}
+
+func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) error {
+	if sensor == nil {
+		return r.inner.SaveSensor(ctx, sensor)
+	}
+	id := sensor.ID
+	err := r.inner.SaveSensor(ctx, sensor)
+	r.stored(id, sensor, err)
+	return err
+}
+
+func (r *SensorRepository) UpdateSensor(ctx context.Context, sensor *domain.Sensor, version int64) error {
+	if sensor == nil {
+		return r.inner.UpdateSensor(ctx, sensor, version)
+	}
+	err := r.inner.UpdateSensor(ctx, sensor, version)
+	r.stored(sensor.ID, sensor, err)
+	return err
+}
+
+// UpdateSensorCalibration - передаёт вызов, если обёрнутое хранилище ведёт журнал калибровки,
+// и обновляет кэш, как UpdateSensor
+func (r *SensorRepository) UpdateSensorCalibration(ctx context.Context, sensor *domain.Sensor, version int64, change *domain.CalibrationChange) error {
+	calibrations, ok := r.inner.(usecase.SensorCalibrationRepository)
+	if !ok {
+		return usecase.ErrCalibrationNotSupported
+	}
+	if sensor == nil {
+		return calibrations.UpdateSensorCalibration(ctx, sensor, version, change)
+	}
+	err := calibrations.UpdateSensorCalibration(ctx, sensor, version, change)
+	r.stored(sensor.ID, sensor, err)
+	return err
+}
+
+// GetCalibrationChanges - передаёт вызов, если обёрнутое хранилище ведёт журнал калибровки
+func (r *SensorRepository) GetCalibrationChanges(ctx context.Context, sensorID int64) ([]domain.CalibrationChange, error) {
+	calibrations, ok := r.inner.(usecase.SensorCalibrationRepository)
+	if !ok {
+		return nil, usecase.ErrCalibrationNotSupported
+	}
+	return calibrations.GetCalibrationChanges(ctx, sensorID)
+}
+
+func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
+	return r.inner.GetSensors(ctx)
+}
+
+func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
+	return r.inner.GetSensorByID(ctx, id)
+}
+
+func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	now := r.now()
+	if now.Sub(r.lastSweep) >= r.sweepInterval {
+		r.sweep(now)
+	}
+	if e, ok := r.bySerial[sn]; ok && now.Before(e.expires) {
+		sensor := e.sensor.Clone()
+		r.mu.Unlock()
+		r.observer.CacheLookup(sensorBySerial, true)
+		return &sensor, nil
+	}
+	l, ok := r.loads[sn]
+	if !ok {
+		l = &load{}
+		r.loads[sn] = l
+	}
+	l.inflight++
+	r.mu.Unlock()
+	r.observer.CacheLookup(sensorBySerial, false)
+
+	sensor, err := r.inner.GetSensorBySerialNumber(ctx, sn)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil && sensor != nil && !l.stale {
+		r.put(sensor.Clone(), r.now())
+	}
+	if l.inflight--; l.inflight == 0 {
+		delete(r.loads, sn)
+	}
+	return sensor, err
+}
+
+// Len - число записей в кэше
+func (r *SensorRepository) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.bySerial)
+}
+
+// stored - обновляет кэш после записи датчика, которому до записи был присвоен id.
+// Обновлённый датчик кладётся в кэш, только если там уже была его запись: дата регистрации
+// при обновлении не меняется, а остальные поля - ровно то, что записано. После вставки
+// или ошибки записи запись удаляется, датчик будет прочитан из хранилища
+func (r *SensorRepository) stored(id int64, sensor *domain.Sensor, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.invalidateLoads(sensor.SerialNumber)
+	serial, cached := r.serialByID[id]
+	if !cached {
+		r.remove(sensor.SerialNumber)
+		return
+	}
+	existing := r.bySerial[serial].sensor
+	if err == nil && existing.Version > sensor.Version {
+		// параллельная запись успела положить более новую версию
+		return
+	}
+	r.invalidateLoads(serial)
+	r.remove(serial)
+	r.remove(sensor.SerialNumber)
+	if err != nil {
+		return
+	}
+	saved := sensor.Clone()
+	saved.RegisteredAt = existing.RegisteredAt
+	r.put(saved, r.now())
+}
+
+func (r *SensorRepository) invalidateLoads(serial string) {
+	if l, ok := r.loads[serial]; ok {
+		l.stale = true
+	}
+}
+
+func (r *SensorRepository) put(sensor domain.Sensor, now time.Time) {
+	if old, ok := r.bySerial[sensor.SerialNumber]; ok && old.sensor.ID != sensor.ID {
+		delete(r.serialByID, old.sensor.ID)
+	}
+	r.bySerial[sensor.SerialNumber] = &entry{sensor: sensor, expires: now.Add(r.ttl)}
+	r.serialByID[sensor.ID] = sensor.SerialNumber
+}
+
+func (r *SensorRepository) remove(serial string) {
+	if e, ok := r.bySerial[serial]; ok {
+		delete(r.bySerial, serial)
+		delete(r.serialByID, e.sensor.ID)
+	}
+}
+
+// sweep - удаляет просроченные записи, чтобы кэш не рос за счёт датчиков, которые замолчали
+func (r *SensorRepository) sweep(now time.Time) {
+	for serial, e := range r.bySerial {
+		if !now.Before(e.expires) {
+			r.remove(serial)
+		}
+	}
+	r.lastSweep = now
+}
@@ -0,0 +1,279 @@
+package cached
+
+import (
+	"context"
+	"fmt"
+	"homework/internal/domain"
+	eventInmemory "homework/internal/repository/event/inmemory"
+	"homework/internal/repository/repotest"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
+	"homework/internal/usecase"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// counting - хранилище, считающее чтения по серийному номеру; block задерживает чтение до закрытия канала
+type counting struct {
+	usecase.SensorRepository
+	reads atomic.Int64
+	block chan struct{}
+}
+
+func (c *counting) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
+	c.reads.Add(1)
+	sensor, err := c.SensorRepository.GetSensorBySerialNumber(ctx, sn)
+	if c.block != nil {
+		<-c.block
+	}
+	return sensor, err
+}
+
+type lookups struct {
+	mu     sync.Mutex
+	hits   int
+	misses int
+}
+
+func (l *lookups) CacheLookup(cache string, hit bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if hit {
+		l.hits++
+	} else {
+		l.misses++
+	}
+}
+
+func newSensor(t *testing.T, repo usecase.SensorRepository, serial string) *domain.Sensor {
+	sensor := &domain.Sensor{SerialNumber: serial, Type: domain.SensorTypeADC, Description: "sensor", IsActive: true}
+	require.NoError(t, repo.SaveSensor(context.Background(), sensor))
+	return sensor
+}
+
+func TestSensorRepository_Conformance(t *testing.T) {
+	repotest.TestSensorRepository(t, func(*testing.T) usecase.SensorRepository {
+		return NewSensorRepository(sensorInmemory.NewSensorRepository(), time.Minute)
+	})
+}
+
+func TestSensorRepository_Ingestion(t *testing.T) {
+	ctx := context.Background()
+	inner := &counting{SensorRepository: sensorInmemory.NewSensorRepository()}
+	observer := &lookups{}
+	repo := NewSensorRepository(inner, time.Minute, WithObserver(observer))
+	registered := newSensor(t, repo, "0000000001")
+
+	for i := 1; i <= 5; i++ {
+		sensor, err := repo.GetSensorBySerialNumber(ctx, "0000000001")
+		require.NoError(t, err)
+		sensor.CurrentState = float64(i)
+		sensor.LastActivity = time.Now()
+		require.NoError(t, repo.SaveSensor(ctx, sensor))
+	}
+
+	assert.Equal(t, int64(1), inner.reads.Load(), "хранилище читается только при первом промахе")
+	assert.Equal(t, 4, observer.hits)
+	assert.Equal(t, 1, observer.misses)
+
+	cached, err := repo.GetSensorBySerialNumber(ctx, "0000000001")
+	require.NoError(t, err)
+	stored, err := inner.GetSensorByID(ctx, registered.ID)
+	require.NoError(t, err)
+	assert.Equal(t, *stored, *cached)
+	assert.Equal(t, float64(5), cached.CurrentState)
+
+	// вызывающий получает копию
+	cached.CurrentState = 100500
+	cached, err = repo.GetSensorBySerialNumber(ctx, "0000000001")
+	require.NoError(t, err)
+	assert.Equal(t, float64(5), cached.CurrentState)
+}
+
+func TestSensorRepository_StaleIngestion(t *testing.T) {
+	ctx := context.Background()
+	inner := sensorInmemory.NewSensorRepository()
+	repo := NewSensorRepository(inner, time.Minute)
+	events := usecase.NewEvent(eventInmemory.NewEventRepository(), repo)
+	sensor := newSensor(t, repo, "0000000001")
+	receive := func(payload float64) {
+		t.Helper()
+		require.NoError(t, events.ReceiveEvent(ctx, &domain.Event{
+			SensorSerialNumber: sensor.SerialNumber, Timestamp: time.Now(), Payload: payload,
+		}))
+	}
+	receive(1)
+
+	// другая реплика меняет описание, пока в кэше старая запись
+	elsewhere, err := inner.GetSensorByID(ctx, sensor.ID)
+	require.NoError(t, err)
+	elsewhere.Description = "changed elsewhere"
+	require.NoError(t, inner.UpdateSensor(ctx, elsewhere, elsewhere.Version))
+
+	receive(2)
+	stored, err := inner.GetSensorByID(ctx, sensor.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "changed elsewhere", stored.Description, "приём события не возвращает старое описание")
+	assert.Equal(t, float64(2), stored.CurrentState)
+	cached, err := repo.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+	require.NoError(t, err)
+	assert.Equal(t, *stored, *cached)
+}
+
+// missing - хранилище, которое не находит датчик, но и не возвращает ошибку
+type missing struct {
+	usecase.SensorRepository
+}
+
+func (missing) GetSensorBySerialNumber(context.Context, string) (*domain.Sensor, error) {
+	return nil, nil //nolint:nilnil // usecase принимает и такой ответ за ненайденный датчик
+}
+
+func TestSensorRepository_NotFound(t *testing.T) {
+	repo := NewSensorRepository(missing{}, time.Minute)
+	sensor, err := repo.GetSensorBySerialNumber(context.Background(), "0000000001")
+	require.NoError(t, err)
+	assert.Nil(t, sensor)
+	assert.Equal(t, 0, repo.Len())
+}
+
+func TestSensorRepository_TTL(t *testing.T) {
+	ctx := context.Background()
+	inner := &counting{SensorRepository: sensorInmemory.NewSensorRepository()}
+	repo := NewSensorRepository(inner, time.Minute)
+	now := time.Now()
+	repo.now = func() time.Time { return now }
+	sensor := newSensor(t, inner, "0000000001")
+
+	_, err := repo.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+	require.NoError(t, err)
+
+	// изменение в обход кэша, например другой репликой
+	sensor.Description = "changed elsewhere"
+	require.NoError(t, inner.SaveSensor(ctx, sensor))
+
+	now = now.Add(59 * time.Second)
+	cached, err := repo.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+	require.NoError(t, err)
+	assert.Equal(t, "sensor", cached.Description)
+
+	now = now.Add(time.Second)
+	cached, err = repo.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+	require.NoError(t, err)
+	assert.Equal(t, "changed elsewhere", cached.Description)
+	assert.Equal(t, int64(2), inner.reads.Load())
+
+	// просроченные записи удаляются и без обращения к ним
+	now = now.Add(2 * time.Minute)
+	_, err = repo.GetSensorBySerialNumber(ctx, "0000000002")
+	require.ErrorIs(t, err, usecase.ErrSensorNotFound)
+	assert.Equal(t, 0, repo.Len())
+}
+
+func TestSensorRepository_Invalidation(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("update", func(t *testing.T) {
+		repo := NewSensorRepository(sensorInmemory.NewSensorRepository(), time.Minute)
+		newSensor(t, repo, "0000000001")
+		sensor, err := repo.GetSensorBySerialNumber(ctx, "0000000001")
+		require.NoError(t, err)
+
+		sensor.Description = "updated"
+		require.NoError(t, repo.UpdateSensor(ctx, sensor, sensor.Version))
+		cached, err := repo.GetSensorBySerialNumber(ctx, "0000000001")
+		require.NoError(t, err)
+		assert.Equal(t, "updated", cached.Description)
+		assert.Equal(t, sensor.Version, cached.Version)
+	})
+
+	t.Run("serial number changed", func(t *testing.T) {
+		repo := NewSensorRepository(sensorInmemory.NewSensorRepository(), time.Minute)
+		newSensor(t, repo, "0000000001")
+		sensor, err := repo.GetSensorBySerialNumber(ctx, "0000000001")
+		require.NoError(t, err)
+
+		sensor.SerialNumber = "0000000002"
+		require.NoError(t, repo.SaveSensor(ctx, sensor))
+		_, err = repo.GetSensorBySerialNumber(ctx, "0000000001")
+		assert.ErrorIs(t, err, usecase.ErrSensorNotFound)
+		cached, err := repo.GetSensorBySerialNumber(ctx, "0000000002")
+		require.NoError(t, err)
+		assert.Equal(t, sensor.ID, cached.ID)
+	})
+
+	t.Run("failed write", func(t *testing.T) {
+		repo := NewSensorRepository(sensorInmemory.NewSensorRepository(), time.Minute)
+		newSensor(t, repo, "0000000001")
+		sensor, err := repo.GetSensorBySerialNumber(ctx, "0000000001")
+		require.NoError(t, err)
+
+		stale := *sensor
+		stale.Description = "stale"
+		require.NoError(t, repo.UpdateSensor(ctx, sensor, sensor.Version))
+		require.ErrorIs(t, repo.UpdateSensor(ctx, &stale, stale.Version), usecase.ErrSensorModified)
+		cached, err := repo.GetSensorBySerialNumber(ctx, "0000000001")
+		require.NoError(t, err)
+		assert.Equal(t, "sensor", cached.Description)
+	})
+
+	t.Run("write during load", func(t *testing.T) {
+		inner := &counting{SensorRepository: sensorInmemory.NewSensorRepository()}
+		repo := NewSensorRepository(inner, time.Minute)
+		sensor := newSensor(t, inner, "0000000001")
+
+		release := make(chan struct{})
+		inner.block = release
+		loaded := make(chan *domain.Sensor)
+		go func() {
+			s, _ := repo.GetSensorBySerialNumber(ctx, "0000000001")
+			loaded <- s
+		}()
+		require.Eventually(t, func() bool { return inner.reads.Load() == 1 }, time.Second, time.Millisecond)
+
+		// пока идёт чтение, датчик меняется: прочитанная копия не должна остаться в кэше
+		sensor.Description = "written during load"
+		require.NoError(t, repo.SaveSensor(ctx, sensor))
+		close(release)
+		assert.Equal(t, "sensor", (<-loaded).Description)
+
+		cached, err := repo.GetSensorBySerialNumber(ctx, "0000000001")
+		require.NoError(t, err)
+		assert.Equal(t, "written during load", cached.Description)
+	})
+}
+
+func TestSensorRepository_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	inner := sensorInmemory.NewSensorRepository()
+	repo := NewSensorRepository(inner, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		serial := fmt.Sprintf("%010d", i%10)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sensor, err := repo.GetSensorBySerialNumber(ctx, serial)
+			if err != nil {
+				sensor = &domain.Sensor{SerialNumber: serial, Type: domain.SensorTypeADC}
+			}
+			sensor.CurrentState++
+			assert.NoError(t, repo.SaveSensor(ctx, sensor))
+		}()
+	}
+	wg.Wait()
+
+	sensors, err := inner.GetSensors(ctx)
+	require.NoError(t, err)
+	assert.Len(t, sensors, 10)
+	for _, stored := range sensors {
+		cached, err := repo.GetSensorBySerialNumber(ctx, stored.SerialNumber)
+		require.NoError(t, err)
+		assert.Equal(t, stored, *cached, "кэш разошёлся с хранилищем")
+	}
+}
@@ -0,0 +1,301 @@
+package durable
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"time"
+
+	eventInmemory "homework/internal/repository/event/inmemory"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
+	userInmemory "homework/internal/repository/user/inmemory"
+)
+
+type SensorRepository struct {
+	store *Store
+	inner *sensorInmemory.SensorRepository
+}
+
+// SaveSensor - сохраняет датчик так же, как inmemory-репозиторий, но сначала пишет результат в журнал
+func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) error {
+	if sensor == nil {
+		return errors.New("sensor is nil")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, err := r.inner.GetSensorByID(ctx, sensor.ID)
+	if err != nil && !errors.Is(err, usecase.ErrSensorNotFound) {
+		return err
+	}
+	if existing != nil {
+		saved, err := r.updated(ctx, sensor, existing.Version)
+		if err != nil {
+			return err
+		}
+		return r.commit(sensor, saved)
+	}
+	if sensor.ID == 0 {
+		if known, err := r.inner.GetSensorBySerialNumber(ctx, sensor.SerialNumber); err == nil {
+			sensor.ID = known.ID
+			sensor.Version = known.Version
+			return nil
+		}
+	}
+
+	saved := sensor.Clone()
+	if saved.ID == 0 {
+		saved.ID = r.inner.NextID()
+	}
+	saved.RegisteredAt = time.Now()
+	saved.Version = 1
+	if err := r.commit(sensor, saved); err != nil {
+		return err
+	}
+	sensor.ID = saved.ID
+	sensor.RegisteredAt = saved.RegisteredAt
+	return nil
+}
+
+func (r *SensorRepository) UpdateSensor(ctx context.Context, sensor *domain.Sensor, version int64) error {
+	if sensor == nil {
+		return errors.New("sensor is nil")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	saved, err := r.updated(ctx, sensor, version)
+	if err != nil {
+		return err
+	}
+	return r.commit(sensor, saved)
+}
+
+func (r *SensorRepository) UpdateSensorCalibration(ctx context.Context, sensor *domain.Sensor, version int64, change *domain.CalibrationChange) error {
+	if sensor == nil || change == nil {
+		return errors.New("sensor or calibration change is nil")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	saved, err := r.updated(ctx, sensor, version)
+	if err != nil {
+		return err
+	}
+	logged := *change
+	logged.SensorID = saved.ID
+	logged.Version = saved.Version
+	err = r.store.commit(record{Op: opCalibration, Sensor: &saved, CalibrationChange: &logged}, func() error {
+		r.inner.Restore(saved)
+		r.inner.RestoreCalibrationChanges(logged)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sensor.Version = saved.Version
+	change.SensorID = logged.SensorID
+	change.Version = logged.Version
+	return nil
+}
+
+// updated - датчик в том виде, в каком он ляжет в хранилище после обновления версии version;
+// дата регистрации не меняется, как и в inmemory-репозитории. Вызывается под store.mu.
+func (r *SensorRepository) updated(ctx context.Context, sensor *domain.Sensor, version int64) (domain.Sensor, error) {
+	existing, err := r.inner.GetSensorByID(ctx, sensor.ID)
+	if err != nil {
+		return domain.Sensor{}, err
+	}
+	if existing.Version != version {
+		return domain.Sensor{}, usecase.ErrSensorModified
+	}
+	saved := sensor.Clone()
+	saved.RegisteredAt = existing.RegisteredAt
+	saved.Version = existing.Version + 1
+	return saved, nil
+}
+
+// commit - пишет датчик в журнал, затем в хранилище, и отдаёт вызывающему новую версию
+func (r *SensorRepository) commit(sensor *domain.Sensor, saved domain.Sensor) error {
+	err := r.store.commit(record{Op: opSensor, Sensor: &saved}, func() error {
+		r.inner.Restore(saved)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sensor.Version = saved.Version
+	return nil
+}
+
+func (r *SensorRepository) GetCalibrationChanges(ctx context.Context, sensorID int64) ([]domain.CalibrationChange, error) {
+	return r.inner.GetCalibrationChanges(ctx, sensorID)
+}
+
+func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
+	return r.inner.GetSensors(ctx)
+}
+
+func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
+	return r.inner.GetSensorByID(ctx, id)
+}
+
+func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
+	return r.inner.GetSensorBySerialNumber(ctx, sn)
+}
+
+type EventRepository struct {
+	store *Store
+	inner *eventInmemory.EventRepository
+}
+
+func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) error {
+	if event == nil {
+		return errors.New("event is nil")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	saved := *event
+	return r.store.commit(record{Op: opEvent, Event: &saved}, func() error {
+		r.inner.Restore(saved)
+		return nil
+	})
+}
+
+func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
+	return r.inner.GetLastEventBySensorID(ctx, id)
+}
+
+func (r *EventRepository) GetEventsBySensorID(ctx context.Context, id int64, channel string, start, end time.Time) ([]*domain.Event, error) {
+	return r.inner.GetEventsBySensorID(ctx, id, channel, start, end)
+}
+
+// DeleteEventsBefore - удаляет события старше before. Число удалённых известно только после
+// удаления, поэтому запись попадает в журнал, даже если удалять нечего.
+func (r *EventRepository) DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var deleted int64
+	err := r.store.commit(record{Op: opDeleteEvents, Before: &before}, func() error {
+		var err error
+		deleted, err = r.inner.DeleteEventsBefore(context.WithoutCancel(ctx), before)
+		return err
+	})
+	return deleted, err
+}
+
+func (r *EventRepository) UpdateEventPayloads(ctx context.Context, events []*domain.Event) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var updated int64
+	err := r.store.commit(record{Op: opEventPayload, Events: events}, func() error {
+		var err error
+		updated, err = r.inner.UpdateEventPayloads(context.WithoutCancel(ctx), events)
+		return err
+	})
+	return updated, err
+}
+
+func (r *EventRepository) SaveTransition(ctx context.Context, transition *domain.Transition) error {
+	if transition == nil {
+		return errors.New("transition is nil")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	saved := *transition
+	return r.store.commit(record{Op: opTransition, Transition: &saved}, func() error {
+		r.inner.RestoreTransitions(saved)
+		return nil
+	})
+}
+
+func (r *EventRepository) GetLastTransition(ctx context.Context, sensorID int64, channel string) (*domain.Transition, error) {
+	return r.inner.GetLastTransition(ctx, sensorID, channel)
+}
+
+func (r *EventRepository) GetTransitions(ctx context.Context, sensorID int64, filter domain.TransitionFilter) ([]domain.Transition, error) {
+	return r.inner.GetTransitions(ctx, sensorID, filter)
+}
+
+type UserRepository struct {
+	store *Store
+	inner *userInmemory.UserRepository
+}
+
+func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) error {
+	if user == nil {
+		return errors.New("user is nil")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	saved := *user
+	saved.ID = r.inner.NextID()
+	err := r.store.commit(record{Op: opUser, User: &saved}, func() error {
+		r.inner.Restore(saved)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	user.ID = saved.ID
+	return nil
+}
+
+func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
+	return r.inner.GetUserByID(ctx, id)
+}
+
+type SensorOwnerRepository struct {
+	store *Store
+	inner *userInmemory.SensorOwnerRepository
+}
+
+func (r *SensorOwnerRepository) SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return r.store.commit(record{Op: opSensorOwner, SensorOwner: &sensorOwner}, func() error {
+		r.inner.Restore(sensorOwner)
+		return nil
+	})
+}
+
+func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) ([]domain.SensorOwner, error) {
+	return r.inner.GetSensorsByUserID(ctx, userID)
+}
@@ -0,0 +1,242 @@
+// Package durable делает inmemory-репозитории переживающими перезапуск:
+// каждая мутация сначала пишется в журнал упреждающей записи и только потом применяется,
+// а состояние периодически сохраняется снапшотом. При открытии хранилище восстанавливается
+// из снапшота и журнала.
+package durable
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework/internal/domain"
+	"log/slog"
+	"sync"
+	"time"
+
+	eventInmemory "homework/internal/repository/event/inmemory"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
+	userInmemory "homework/internal/repository/user/inmemory"
+	"homework/pkg/wal"
+)
+
+const (
+	opSensor       = "sensor"
+	opEvent        = "event"
+	opUser         = "user"
+	opSensorOwner  = "sensor_owner"
+	opDeleteEvents = "delete_events"
+	opCalibration  = "calibration"
+	opEventPayload = "event_payloads"
+	opTransition   = "transition"
+)
+
+// record - одна мутация в журнале
+type record struct {
+	Op          string              `json:"op"`
+	Sensor      *domain.Sensor      `json:"sensor,omitempty"`
+	Event       *domain.Event       `json:"event,omitempty"`
+	User        *domain.User        `json:"user,omitempty"`
+	SensorOwner *domain.SensorOwner `json:"sensor_owner,omitempty"`
+	// Before - граница удаления событий для opDeleteEvents
+	Before *time.Time `json:"before,omitempty"`
+	// CalibrationChange - запись журнала калибровки для opCalibration, вместе с ней пишется Sensor
+	CalibrationChange *domain.CalibrationChange `json:"calibration_change,omitempty"`
+	// Events - события с новыми значениями для opEventPayload
+	Events []*domain.Event `json:"events,omitempty"`
+	// Transition - смена состояния датчика для opTransition
+	Transition *domain.Transition `json:"transition,omitempty"`
+}
+
+// snapshot - полное состояние всех репозиториев
+type snapshot struct {
+	Sensors      []domain.Sensor            `json:"sensors"`
+	Events       []domain.Event             `json:"events"`
+	Users        []domain.User              `json:"users"`
+	SensorOwners []domain.SensorOwner       `json:"sensor_owners"`
+	Calibrations []domain.CalibrationChange `json:"calibrations,omitempty"`
+	Transitions  []domain.Transition        `json:"transitions,omitempty"`
+}
+
+// Options - настройки хранилища
+type Options struct {
+	// WAL - настройки журнала
+	WAL wal.Options
+	// SnapshotInterval - период снапшотов в Run, 0 отключает периодические снапшоты
+	SnapshotInterval time.Duration
+	// SnapshotSize - размер журнала в байтах, после которого снапшот делается сразу, 0 отключает
+	SnapshotSize int64
+}
+
+// Store - набор inmemory-репозиториев с общим журналом
+type Store struct {
+	// mu упорядочивает мутации с записями журнала и не даёт снапшоту разорвать пару
+	mu   sync.Mutex
+	log  *wal.Log
+	opts Options
+
+	sensors      *sensorInmemory.SensorRepository
+	events       *eventInmemory.EventRepository
+	users        *userInmemory.UserRepository
+	sensorOwners *userInmemory.SensorOwnerRepository
+}
+
+// Open - открывает хранилище в каталоге dir и восстанавливает состояние
+func Open(dir string, opts Options) (*Store, error) {
+	l, err := wal.Open(dir, opts.WAL)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{
+		log:          l,
+		opts:         opts,
+		sensors:      sensorInmemory.NewSensorRepository(),
+		events:       eventInmemory.NewEventRepository(),
+		users:        userInmemory.NewUserRepository(),
+		sensorOwners: userInmemory.NewSensorOwnerRepository(),
+	}
+
+	if err := l.Load(s.applySnapshot, s.applyRecord); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("restore durable store: %w", err)
+	}
+	return s, nil
+}
+
+// SensorRepository - репозиторий датчиков
+func (s *Store) SensorRepository() *SensorRepository {
+	return &SensorRepository{store: s, inner: s.sensors}
+}
+
+// EventRepository - репозиторий событий
+func (s *Store) EventRepository() *EventRepository {
+	return &EventRepository{store: s, inner: s.events}
+}
+
+// UserRepository - репозиторий пользователей
+func (s *Store) UserRepository() *UserRepository {
+	return &UserRepository{store: s, inner: s.users}
+}
+
+// SensorOwnerRepository - репозиторий привязок датчиков к пользователям
+func (s *Store) SensorOwnerRepository() *SensorOwnerRepository {
+	return &SensorOwnerRepository{store: s, inner: s.sensorOwners}
+}
+
+// Run - делает снапшоты раз в Options.SnapshotInterval, пока не отменён ctx
+func (s *Store) Run(ctx context.Context) {
+	if s.opts.SnapshotInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.opts.SnapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Snapshot(); err != nil {
+				slog.Error("durable: snapshot failed", "err", err)
+			}
+		}
+	}
+}
+
+// Snapshot - сохраняет снапшот текущего состояния и очищает журнал
+func (s *Store) Snapshot() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshot()
+}
+
+// Close - делает финальный снапшот и закрывает журнал
+func (s *Store) Close() error {
+	err := s.Snapshot()
+	if errors.Is(err, wal.ErrClosed) {
+		return nil
+	}
+	return errors.Join(err, s.log.Close())
+}
+
+func (s *Store) snapshot() error {
+	data, err := json.Marshal(snapshot{
+		Sensors:      s.sensors.Dump(),
+		Events:       s.events.Dump(),
+		Users:        s.users.Dump(),
+		SensorOwners: s.sensorOwners.Dump(),
+		Calibrations: s.sensors.DumpCalibrationChanges(),
+		Transitions:  s.events.DumpTransitions(),
+	})
+	if err != nil {
+		return err
+	}
+	return s.log.Snapshot(data)
+}
+
+// commit - пишет мутацию в журнал и только после этого применяет её к inmemory-репозиториям,
+// чтобы состояние в памяти не опережало журнал; вызывается под s.mu
+func (s *Store) commit(rec record, apply func() error) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if err := s.log.Append(data); err != nil {
+		return err
+	}
+	if err := apply(); err != nil {
+		return err
+	}
+	if s.opts.SnapshotSize > 0 && s.log.Size() >= s.opts.SnapshotSize {
+		if err := s.snapshot(); err != nil {
+			// запись уже в журнале, так что ошибка снапшота не теряет данные
+			slog.Error("durable: snapshot failed", "err", err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) applySnapshot(data []byte) error {
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return err
+	}
+	s.sensors.Restore(snap.Sensors...)
+	s.events.Restore(snap.Events...)
+	s.users.Restore(snap.Users...)
+	s.sensorOwners.Restore(snap.SensorOwners...)
+	s.sensors.RestoreCalibrationChanges(snap.Calibrations...)
+	s.events.RestoreTransitions(snap.Transitions...)
+	return nil
+}
+
+func (s *Store) applyRecord(data []byte) error {
+	var rec record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return err
+	}
+	switch {
+	case rec.Op == opSensor && rec.Sensor != nil:
+		s.sensors.Restore(*rec.Sensor)
+	case rec.Op == opEvent && rec.Event != nil:
+		s.events.Restore(*rec.Event)
+	case rec.Op == opUser && rec.User != nil:
+		s.users.Restore(*rec.User)
+	case rec.Op == opSensorOwner && rec.SensorOwner != nil:
+		s.sensorOwners.Restore(*rec.SensorOwner)
+	case rec.Op == opDeleteEvents && rec.Before != nil:
+		_, err := s.events.DeleteEventsBefore(context.Background(), *rec.Before)
+		return err
+	case rec.Op == opCalibration && rec.Sensor != nil && rec.CalibrationChange != nil:
+		s.sensors.Restore(*rec.Sensor)
+		s.sensors.RestoreCalibrationChanges(*rec.CalibrationChange)
+	case rec.Op == opEventPayload:
+		_, err := s.events.UpdateEventPayloads(context.Background(), rec.Events)
+		return err
+	case rec.Op == opTransition && rec.Transition != nil:
+		s.events.RestoreTransitions(*rec.Transition)
+	default:
+		return fmt.Errorf("unknown wal record %q", rec.Op)
+	}
+	return nil
+}
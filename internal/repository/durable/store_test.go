@@ -0,0 +1,334 @@
+package durable
+
+import (
+	"context"
+	"fmt"
+	"homework/internal/domain"
+	"homework/internal/repository/repotest"
+	"homework/internal/usecase"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"homework/pkg/wal"
+)
+
+func fill(t *testing.T, s *Store) (*domain.Sensor, *domain.User, domain.Event) {
+	t.Helper()
+	ctx := context.Background()
+
+	sensor := &domain.Sensor{
+		SerialNumber: "0123456789",
+		Type:         domain.SensorTypeADC,
+		Description:  "sensor description",
+		IsActive:     true,
+	}
+	require.NoError(t, s.SensorRepository().SaveSensor(ctx, sensor))
+
+	user := &domain.User{Name: "user"}
+	require.NoError(t, s.UserRepository().SaveUser(ctx, user))
+	require.NoError(t, s.SensorOwnerRepository().SaveSensorOwner(ctx, domain.SensorOwner{UserID: user.ID, SensorID: sensor.ID}))
+
+	event := domain.Event{
+		Timestamp:          time.Now().UTC().Truncate(time.Microsecond),
+		SensorSerialNumber: sensor.SerialNumber,
+		SensorID:           sensor.ID,
+		Payload:            42,
+	}
+	require.NoError(t, s.EventRepository().SaveEvent(ctx, &event))
+	return sensor, user, event
+}
+
+func assertRestored(t *testing.T, s *Store, sensor *domain.Sensor, user *domain.User, event domain.Event) {
+	t.Helper()
+	ctx := context.Background()
+
+	actualSensor, err := s.SensorRepository().GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+	require.NoError(t, err)
+	assert.Equal(t, sensor.ID, actualSensor.ID)
+	assert.Equal(t, sensor.Description, actualSensor.Description)
+	assert.True(t, sensor.RegisteredAt.Equal(actualSensor.RegisteredAt))
+
+	actualUser, err := s.UserRepository().GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, *user, *actualUser)
+
+	owners, err := s.SensorOwnerRepository().GetSensorsByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.SensorOwner{{UserID: user.ID, SensorID: sensor.ID}}, owners)
+
+	actualEvent, err := s.EventRepository().GetLastEventBySensorID(ctx, sensor.ID)
+	require.NoError(t, err)
+	assert.Equal(t, event, *actualEvent)
+
+	// новые ID продолжают последовательность, а не начинаются заново
+	newUser := &domain.User{Name: "another user"}
+	require.NoError(t, s.UserRepository().SaveUser(ctx, newUser))
+	assert.Greater(t, newUser.ID, user.ID)
+}
+
+func TestStore_ReplayWAL(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, Options{})
+	require.NoError(t, err)
+	sensor, user, event := fill(t, s)
+	// закрываем только журнал, чтобы не было финального снапшота
+	require.NoError(t, s.log.Close())
+
+	s, err = Open(dir, Options{})
+	require.NoError(t, err)
+	defer s.Close()
+	assertRestored(t, s, sensor, user, event)
+}
+
+func TestStore_ReplaySnapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, Options{})
+	require.NoError(t, err)
+	sensor, user, event := fill(t, s)
+	require.NoError(t, s.Close())
+
+	info, err := os.Stat(filepath.Join(dir, "wal.log"))
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+
+	s, err = Open(dir, Options{})
+	require.NoError(t, err)
+	defer s.Close()
+	assertRestored(t, s, sensor, user, event)
+}
+
+func TestStore_ReplayDeleteEvents(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	s, err := Open(dir, Options{})
+	require.NoError(t, err)
+	sensor, _, event := fill(t, s)
+	deleted, err := s.EventRepository().DeleteEventsBefore(ctx, event.Timestamp.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	require.NoError(t, s.log.Close())
+
+	s, err = Open(dir, Options{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	_, err = s.EventRepository().GetLastEventBySensorID(ctx, sensor.ID)
+	assert.ErrorIs(t, err, usecase.ErrEventNotFound)
+}
+
+func TestStore_ReplayCalibration(t *testing.T) {
+	for _, snapshot := range []bool{false, true} {
+		t.Run(fmt.Sprintf("snapshot %v", snapshot), func(t *testing.T) {
+			dir := t.TempDir()
+			ctx := context.Background()
+
+			s, err := Open(dir, Options{})
+			require.NoError(t, err)
+			sensor, _, event := fill(t, s)
+			profile := &domain.Calibration{Offset: 1, Gain: 2}
+			sensor.Calibration = profile
+			change := &domain.CalibrationChange{Calibration: profile, Reason: "installed", ChangedAt: time.Now().UTC()}
+			require.NoError(t, s.SensorRepository().UpdateSensorCalibration(ctx, sensor, sensor.Version, change))
+			event.Payload = 85
+			updated, err := s.EventRepository().UpdateEventPayloads(ctx, []*domain.Event{&event})
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), updated)
+			if snapshot {
+				require.NoError(t, s.Close())
+			} else {
+				require.NoError(t, s.log.Close())
+			}
+
+			s, err = Open(dir, Options{})
+			require.NoError(t, err)
+			defer s.Close()
+			actual, err := s.SensorRepository().GetSensorByID(ctx, sensor.ID)
+			require.NoError(t, err)
+			assert.Equal(t, profile, actual.Calibration)
+			changes, err := s.SensorRepository().GetCalibrationChanges(ctx, sensor.ID)
+			require.NoError(t, err)
+			require.Len(t, changes, 1)
+			assert.Equal(t, "installed", changes[0].Reason)
+			assert.Equal(t, actual.Version, changes[0].Version)
+			actualEvent, err := s.EventRepository().GetLastEventBySensorID(ctx, sensor.ID)
+			require.NoError(t, err)
+			assert.Equal(t, event, *actualEvent)
+		})
+	}
+}
+
+func TestStore_ReplayTransitions(t *testing.T) {
+	for _, snapshot := range []bool{false, true} {
+		t.Run(fmt.Sprintf("snapshot %v", snapshot), func(t *testing.T) {
+			dir := t.TempDir()
+			ctx := context.Background()
+
+			s, err := Open(dir, Options{})
+			require.NoError(t, err)
+			at := time.Now().UTC().Truncate(time.Microsecond)
+			transitions := []domain.Transition{
+				{SensorID: 1, Timestamp: at, From: 0, To: 1},
+				{SensorID: 1, Timestamp: at.Add(time.Minute), From: 1, To: 0, Lasted: time.Minute},
+			}
+			for i := range transitions {
+				require.NoError(t, s.EventRepository().SaveTransition(ctx, &transitions[i]))
+			}
+			if snapshot {
+				require.NoError(t, s.Close())
+			} else {
+				require.NoError(t, s.log.Close())
+			}
+
+			s, err = Open(dir, Options{})
+			require.NoError(t, err)
+			defer s.Close()
+			actual, err := s.EventRepository().GetTransitions(ctx, 1, domain.TransitionFilter{Start: at, End: at.Add(time.Hour)})
+			require.NoError(t, err)
+			assert.Equal(t, transitions, actual)
+		})
+	}
+}
+
+func TestStore_SensorStateUpdate(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	s, err := Open(dir, Options{WAL: wal.Options{Sync: wal.SyncNever}})
+	require.NoError(t, err)
+	sensor, _, _ := fill(t, s)
+
+	sr := s.SensorRepository()
+	e := usecase.NewEvent(s.EventRepository(), sr)
+	require.NoError(t, e.ReceiveEvent(ctx, &domain.Event{
+		Timestamp:          time.Now(),
+		SensorSerialNumber: sensor.SerialNumber,
+		Payload:            7,
+	}))
+	require.NoError(t, s.log.Close())
+
+	s, err = Open(dir, Options{})
+	require.NoError(t, err)
+	defer s.Close()
+
+	actual, err := s.SensorRepository().GetSensorByID(ctx, sensor.ID)
+	require.NoError(t, err)
+	assert.Equal(t, float64(7), actual.CurrentState)
+}
+
+func TestStore_SnapshotSize(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	s, err := Open(dir, Options{SnapshotSize: 512})
+	require.NoError(t, err)
+
+	ur := s.UserRepository()
+	wg := sync.WaitGroup{}
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, ur.SaveUser(ctx, &domain.User{Name: fmt.Sprintf("user #%d", i)}))
+		}()
+	}
+	wg.Wait()
+	assert.Less(t, s.log.Size(), int64(512))
+	require.NoError(t, s.log.Close())
+
+	s, err = Open(dir, Options{})
+	require.NoError(t, err)
+	defer s.Close()
+	for id := int64(1); id <= 100; id++ {
+		_, err := s.UserRepository().GetUserByID(ctx, id)
+		assert.NoError(t, err)
+	}
+}
+
+func TestStore_Run(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := Open(dir, Options{SnapshotInterval: time.Millisecond})
+	require.NoError(t, err)
+	defer s.Close()
+	fill(t, s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return s.log.Size() == 0 }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+}
+
+func TestStore_FailedAppendKeepsState(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(t.TempDir(), Options{})
+	require.NoError(t, err)
+	sensor, user, event := fill(t, s)
+
+	// журнал больше не принимает записи: мутации не должны доходить до памяти
+	require.NoError(t, s.log.Close())
+
+	updated := *sensor
+	updated.Description = "changed"
+	assert.ErrorIs(t, s.SensorRepository().UpdateSensor(ctx, &updated, sensor.Version), wal.ErrClosed)
+	assert.Equal(t, sensor.Version, updated.Version)
+	actual, err := s.SensorRepository().GetSensorByID(ctx, sensor.ID)
+	require.NoError(t, err)
+	assert.Equal(t, sensor.Description, actual.Description)
+	assert.Equal(t, sensor.Version, actual.Version)
+
+	assert.ErrorIs(t, s.UserRepository().SaveUser(ctx, &domain.User{Name: "lost"}), wal.ErrClosed)
+	_, err = s.UserRepository().GetUserByID(ctx, user.ID+1)
+	assert.ErrorIs(t, err, usecase.ErrUserNotFound)
+
+	_, err = s.EventRepository().DeleteEventsBefore(ctx, event.Timestamp.Add(time.Second))
+	assert.ErrorIs(t, err, wal.ErrClosed)
+	last, err := s.EventRepository().GetLastEventBySensorID(ctx, sensor.ID)
+	require.NoError(t, err)
+	assert.Equal(t, event, *last)
+}
+
+func openStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(t.TempDir(), Options{WAL: wal.Options{Sync: wal.SyncNever}})
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, s.Close()) })
+	return s
+}
+
+func TestStore_Conformance(t *testing.T) {
+	t.Run("sensor", func(t *testing.T) {
+		repotest.TestSensorRepository(t, func(t *testing.T) usecase.SensorRepository {
+			return openStore(t).SensorRepository()
+		})
+	})
+	t.Run("event", func(t *testing.T) {
+		repotest.TestEventRepository(t, func(t *testing.T) usecase.EventRepository {
+			return openStore(t).EventRepository()
+		})
+	})
+	t.Run("user", func(t *testing.T) {
+		repotest.TestUserRepository(t, func(t *testing.T) usecase.UserRepository {
+			return openStore(t).UserRepository()
+		})
+	})
+	t.Run("sensor owner", func(t *testing.T) {
+		repotest.TestSensorOwnerRepository(t, func(t *testing.T) usecase.SensorOwnerRepository {
+			return openStore(t).SensorOwnerRepository()
+		})
+	})
+}
@@ -1,22 +1,39 @@
 package inmemory
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"homework/internal/domain"
 	"homework/internal/usecase"
+	"maps"
+	"slices"
 	"sync"
 	"time"
 )
 
 type EventRepository struct {
 	mu     sync.Mutex
-	events map[int64]map[time.Time]*domain.Event
+	events map[int64]map[eventKey]*domain.Event
+	// transitions - журналы смен состояния по id датчика, по времени
+	transitions map[int64][]domain.Transition
+}
+
+// eventKey - событие датчика определяется временем и каналом: пакет многоканального датчика
+// даёт несколько событий с одним временем
+type eventKey struct {
+	timestamp time.Time
+	channel   string
+}
+
+func keyOf(event *domain.Event) eventKey {
+	return eventKey{timestamp: event.Timestamp, channel: event.Channel}
 }
 
 func NewEventRepository() *EventRepository {
 	return &EventRepository{
-		events: make(map[int64]map[time.Time]*domain.Event),
+		events:      make(map[int64]map[eventKey]*domain.Event),
+		transitions: make(map[int64][]domain.Transition),
 	}
 }
 
@@ -32,9 +49,10 @@ func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) er
 		defer r.mu.Unlock()
 
 		if _, exists := r.events[event.SensorID]; !exists {
-			r.events[event.SensorID] = make(map[time.Time]*domain.Event)
+			r.events[event.SensorID] = make(map[eventKey]*domain.Event)
 		}
-		r.events[event.SensorID][event.Timestamp] = event
+		saved := *event
+		r.events[event.SensorID][keyOf(event)] = &saved
 		return nil
 	}
 }
@@ -58,11 +76,12 @@ func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64)
 				latestEvent = event
 			}
 		}
-		return latestEvent, nil
+		found := *latestEvent
+		return &found, nil
 	}
 }
 
-func (r *EventRepository) GetEventsBySensorID(ctx context.Context, id int64, start, end time.Time) ([]*domain.Event, error) {
+func (r *EventRepository) GetEventsBySensorID(ctx context.Context, id int64, channel string, start, end time.Time) ([]*domain.Event, error) {
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
@@ -73,17 +92,176 @@ func (r *EventRepository) GetEventsBySensorID(ctx context.Context, id int64, sta
 			return nil, usecase.ErrInvalidEventTimestamp
 		}
 
-		events, exists := r.events[id]
-		if !exists {
-			return nil, usecase.ErrSensorNotFound
+		var result []*domain.Event
+		for _, event := range r.events[id] {
+			if channel != "" && event.Channel != channel {
+				continue
+			}
+			if !event.Timestamp.Before(start) && !event.Timestamp.After(end) {
+				found := *event
+				result = append(result, &found)
+			}
 		}
+		slices.SortFunc(result, func(a, b *domain.Event) int {
+			return cmp.Or(a.Timestamp.Compare(b.Timestamp), cmp.Compare(a.Channel, b.Channel))
+		})
+		return result, nil
+	}
+}
 
-		var result []*domain.Event
+func (r *EventRepository) DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		var deleted int64
+		for id, events := range r.events {
+			for key := range events {
+				if key.timestamp.Before(before) {
+					delete(events, key)
+					deleted++
+				}
+			}
+			if len(events) == 0 {
+				delete(r.events, id)
+			}
+		}
+		return deleted, nil
+	}
+}
+
+func (r *EventRepository) UpdateEventPayloads(ctx context.Context, events []*domain.Event) (int64, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		var updated int64
 		for _, event := range events {
-			if event.Timestamp.After(start) && event.Timestamp.Before(end) {
-				result = append(result, event)
+			if saved, ok := r.events[event.SensorID][keyOf(event)]; ok {
+				saved.Payload = event.Payload
+				updated++
+			}
+		}
+		return updated, nil
+	}
+}
+
+func (r *EventRepository) SaveTransition(ctx context.Context, transition *domain.Transition) error {
+	if transition == nil {
+		return errors.New("transition is nil")
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.addTransition(*transition)
+		return nil
+	}
+}
+
+// addTransition - вставляет смену состояния, сохраняя порядок журнала; вызывается под r.mu
+func (r *EventRepository) addTransition(transition domain.Transition) {
+	log := r.transitions[transition.SensorID]
+	i, _ := slices.BinarySearchFunc(log, transition, compareTransitions)
+	for i < len(log) && compareTransitions(log[i], transition) == 0 {
+		i++
+	}
+	r.transitions[transition.SensorID] = slices.Insert(log, i, transition)
+}
+
+func compareTransitions(a, b domain.Transition) int {
+	return cmp.Or(a.Timestamp.Compare(b.Timestamp), cmp.Compare(a.Channel, b.Channel))
+}
+
+func (r *EventRepository) GetLastTransition(ctx context.Context, sensorID int64, channel string) (*domain.Transition, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		log := r.transitions[sensorID]
+		for i := len(log) - 1; i >= 0; i-- {
+			if log[i].Channel == channel {
+				found := log[i]
+				return &found, nil
+			}
+		}
+		return nil, usecase.ErrNoTransition
+	}
+}
+
+func (r *EventRepository) GetTransitions(ctx context.Context, sensorID int64, filter domain.TransitionFilter) ([]domain.Transition, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		var result []domain.Transition
+		for _, transition := range r.transitions[sensorID] {
+			if filter.Limit > 0 && len(result) == filter.Limit {
+				break
+			}
+			if filter.Match(&transition) {
+				result = append(result, transition)
 			}
 		}
 		return result, nil
 	}
 }
+
+// Dump - возвращает копию всех событий, используется для снапшотов
+func (r *EventRepository) Dump() []domain.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []domain.Event
+	for _, sensorEvents := range r.events {
+		for _, event := range sensorEvents {
+			events = append(events, *event)
+		}
+	}
+	return events
+}
+
+// Restore - кладёт события в хранилище, используется при восстановлении состояния
+func (r *EventRepository) Restore(events ...domain.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, event := range events {
+		if _, exists := r.events[event.SensorID]; !exists {
+			r.events[event.SensorID] = make(map[eventKey]*domain.Event)
+		}
+		r.events[event.SensorID][keyOf(&event)] = &event
+	}
+}
+
+// DumpTransitions - возвращает копию журналов смен состояния всех датчиков, используется для снапшотов
+func (r *EventRepository) DumpTransitions() []domain.Transition {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var transitions []domain.Transition
+	for _, id := range slices.Sorted(maps.Keys(r.transitions)) {
+		transitions = append(transitions, r.transitions[id]...)
+	}
+	return transitions
+}
+
+// RestoreTransitions - добавляет смены состояния в журналы, используется при восстановлении состояния
+func (r *EventRepository) RestoreTransitions(transitions ...domain.Transition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, transition := range transitions {
+		r.addTransition(transition)
+	}
+}
@@ -3,6 +3,7 @@ package inmemory
 import (
 	"context"
 	"homework/internal/domain"
+	"homework/internal/repository/repotest"
 	"homework/internal/usecase"
 	"sync"
 	"testing"
@@ -156,7 +157,7 @@ func TestEventRepository_GetEventsBySensorID(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		cancel()
 
-		_, err := er.GetEventsBySensorID(ctx, 0, time.Time{}, time.Time{})
+		_, err := er.GetEventsBySensorID(ctx, 0, "", time.Time{}, time.Time{})
 		assert.ErrorIs(t, err, context.Canceled)
 	})
 
@@ -165,7 +166,7 @@ func TestEventRepository_GetEventsBySensorID(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
 		defer cancel()
 
-		_, err := er.GetEventsBySensorID(ctx, 0, time.Time{}, time.Time{})
+		_, err := er.GetEventsBySensorID(ctx, 0, "", time.Time{}, time.Time{})
 		assert.ErrorIs(t, err, context.DeadlineExceeded)
 	})
 
@@ -179,13 +180,13 @@ func TestEventRepository_GetEventsBySensorID(t *testing.T) {
 			lastEvent = &domain.Event{
 				Timestamp: time.Now(),
 				SensorID:  sensorID,
-				Payload:   int64(100 + i),
+				Payload:   float64(100 + i),
 			}
 			time.Sleep(10 * time.Millisecond)
 			assert.NoError(t, er.SaveEvent(ctx, lastEvent))
 		}
 
-		actualEvents, err := er.GetEventsBySensorID(ctx, sensorID, time.Now().Add(-time.Second), time.Now())
+		actualEvents, err := er.GetEventsBySensorID(ctx, sensorID, "", time.Now().Add(-time.Second), time.Now())
 		assert.NoError(t, err)
 		assert.Equal(t, len(actualEvents), 10)
 	})
@@ -207,7 +208,7 @@ func FuzzGetEventsBySensorID(f *testing.F) {
 		event := &domain.Event{
 			Timestamp: time.Now(),
 			SensorID:  sensorID,
-			Payload:   int64(i),
+			Payload:   float64(i),
 		}
 		_ = er.SaveEvent(ctx, event)
 	}
@@ -219,16 +220,15 @@ func FuzzGetEventsBySensorID(f *testing.F) {
 		end, err2 := time.Parse(time.RFC3339, endDate)
 
 		if err1 != nil || err2 != nil || start.IsZero() || end.IsZero() || start.After(end) {
-			_, err := er.GetEventsBySensorID(ctx, id, start, end)
+			_, err := er.GetEventsBySensorID(ctx, id, "", start, end)
 			assert.Error(t, err)
 			return
 		}
 
-		_, err := er.GetEventsBySensorID(ctx, id, start, end)
-		if id == sensorID {
-			assert.NoError(t, err)
-		} else {
-			assert.Error(t, err)
+		events, err := er.GetEventsBySensorID(ctx, id, "", start, end)
+		assert.NoError(t, err)
+		if id != sensorID {
+			assert.Empty(t, events)
 		}
 	})
 }
@@ -242,7 +242,7 @@ func TestEventRepository_GetEventsBySensorID_TableDriven(t *testing.T) {
 		event := &domain.Event{
 			Timestamp: time.Now().Add(time.Duration(i) * time.Minute),
 			SensorID:  sensorID,
-			Payload:   int64(i),
+			Payload:   float64(i),
 		}
 		_ = er.SaveEvent(ctx, event)
 	}
@@ -272,18 +272,26 @@ func TestEventRepository_GetEventsBySensorID_TableDriven(t *testing.T) {
 			wantErr:   nil,
 		},
 		{
-			name:      "invalid sensor ID",
+			name:      "unknown sensor ID",
 			sensorID:  -1,
 			startTime: time.Now().Add(-time.Minute),
 			endTime:   time.Now().Add(10 * time.Minute),
 			wantCount: 0,
-			wantErr:   usecase.ErrSensorNotFound,
+			wantErr:   nil,
+		},
+		{
+			name:      "invalid range",
+			sensorID:  sensorID,
+			startTime: time.Now().Add(10 * time.Minute),
+			endTime:   time.Now().Add(-time.Minute),
+			wantCount: 0,
+			wantErr:   usecase.ErrInvalidEventTimestamp,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			events, err := er.GetEventsBySensorID(ctx, tt.sensorID, tt.startTime, tt.endTime)
+			events, err := er.GetEventsBySensorID(ctx, tt.sensorID, "", tt.startTime, tt.endTime)
 
 			if tt.wantErr != nil {
 				assert.ErrorIs(t, err, tt.wantErr)
@@ -295,3 +303,9 @@ func TestEventRepository_GetEventsBySensorID_TableDriven(t *testing.T) {
 		})
 	}
 }
+
+func TestEventRepository_Conformance(t *testing.T) {
+	repotest.TestEventRepository(t, func(*testing.T) usecase.EventRepository {
+		return NewEventRepository()
+	})
+}
@@ -4,14 +4,13 @@ import (
 	"context"
 	"errors"
 	"homework/internal/domain"
+	"homework/internal/usecase"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-var ErrEventNotFound = errors.New("event not found")
-
 type EventRepository struct {
 	pool *pgxpool.Pool
 }
@@ -22,8 +21,12 @@ func NewEventRepository(pool *pgxpool.Pool) *EventRepository {
 	}
 }
 
-func (r *EventRepository) GetEventsBySensorID(ctx context.Context, id int64, start, end time.Time) ([]*domain.Event, error) {
-	rows, err := r.pool.Query(ctx, `SELECT timestamp, sensor_serial_number, sensor_id, payload FROM events WHERE sensor_id = $1 AND timestamp BETWEEN $2 AND $3`, id, start, end)
+func (r *EventRepository) GetEventsBySensorID(ctx context.Context, id int64, channel string, start, end time.Time) ([]*domain.Event, error) {
+	if start.IsZero() || end.IsZero() || start.After(end) {
+		return nil, usecase.ErrInvalidEventTimestamp
+	}
+	rows, err := r.pool.Query(ctx, `SELECT timestamp, sensor_serial_number, sensor_id, payload, raw, unit, channel, quality FROM events
+		WHERE sensor_id = $1 AND ($2 = '' OR channel = $2) AND timestamp BETWEEN $3 AND $4 ORDER BY timestamp, channel`, id, channel, start.UTC(), end.UTC())
 	if err != nil {
 		return nil, err
 	}
@@ -31,30 +34,213 @@ func (r *EventRepository) GetEventsBySensorID(ctx context.Context, id int64, sta
 	var events []*domain.Event
 	for rows.Next() {
 		event := &domain.Event{}
-		if err := rows.Scan(&event.Timestamp, &event.SensorSerialNumber, &event.SensorID, &event.Payload); err != nil {
+		if err := rows.Scan(&event.Timestamp, &event.SensorSerialNumber, &event.SensorID, &event.Payload, &event.Raw, &event.Unit, &event.Channel, &event.Quality); err != nil {
 			return nil, err
 		}
 		events = append(events, event)
 	}
-	return events, nil
+	return events, rows.Err()
 }
 
 func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) error {
-	_, err := r.pool.Exec(ctx, `INSERT INTO events (timestamp, sensor_serial_number, sensor_id, payload) VALUES ($1, $2, $3, $4)`, event.Timestamp, event.SensorSerialNumber, event.SensorID, event.Payload)
-	if err != nil {
-		return err
+	if event == nil {
+		return errors.New("event is nil")
 	}
-	return nil
+	// время без пояса pgx пишет по часам пояса значения, поэтому всё время приводится к UTC
+	timestamp := event.Timestamp.UTC()
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		batch := &pgx.Batch{}
+		batch.Queue(`INSERT INTO events (timestamp, sensor_serial_number, sensor_id, payload, raw, unit, channel, quality) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			timestamp, event.SensorSerialNumber, event.SensorID, event.Payload, event.Raw, event.Unit, event.Channel, int16(event.Quality))
+		for _, resolution := range domain.RollupResolutions {
+			batch.Queue(`INSERT INTO event_rollups AS r (resolution, sensor_id, channel, bucket, count, total, minimum, maximum, latest, latest_at)
+				VALUES ($1, $2, $3, date_bin($1::bigint * interval '1 second', $4::timestamp, timestamp '1970-01-01'), 1, $5, $5, $5, $5, $4)
+				ON CONFLICT (resolution, sensor_id, channel, bucket) DO UPDATE SET count = r.count + 1, total = r.total + excluded.total,
+				minimum = least(r.minimum, excluded.minimum), maximum = greatest(r.maximum, excluded.maximum),
+				latest = CASE WHEN excluded.latest_at >= r.latest_at THEN excluded.latest ELSE r.latest END,
+				latest_at = greatest(r.latest_at, excluded.latest_at)`,
+				int64(resolution/time.Second), event.SensorID, event.Channel, timestamp, event.Payload)
+		}
+		return tx.SendBatch(ctx, batch).Close()
+	})
 }
 
 func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
-	row := r.pool.QueryRow(ctx, `SELECT timestamp, sensor_serial_number, sensor_id, payload FROM events WHERE sensor_id = $1 ORDER BY timestamp DESC LIMIT 1`, id)
+	row := r.pool.QueryRow(ctx, `SELECT timestamp, sensor_serial_number, sensor_id, payload, raw, unit, channel, quality FROM events WHERE sensor_id = $1 ORDER BY timestamp DESC LIMIT 1`, id)
 	event := &domain.Event{}
-	if err := row.Scan(&event.Timestamp, &event.SensorSerialNumber, &event.SensorID, &event.Payload); err != nil {
+	if err := row.Scan(&event.Timestamp, &event.SensorSerialNumber, &event.SensorID, &event.Payload, &event.Raw, &event.Unit, &event.Channel, &event.Quality); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrEventNotFound
+			return nil, usecase.ErrEventNotFound
 		}
 		return nil, err
 	}
 	return event, nil
 }
+
+// DeleteEventsBefore - удаляет события старше before и сводки, целиком лежащие до before
+func (r *EventRepository) DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `DELETE FROM events WHERE timestamp < $1`, before.UTC())
+		if err != nil {
+			return err
+		}
+		deleted = tag.RowsAffected()
+		_, err = tx.Exec(ctx, `DELETE FROM event_rollups WHERE bucket + resolution * interval '1 second' <= $1`, before.UTC())
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+func (r *EventRepository) UpdateEventPayloads(ctx context.Context, events []*domain.Event) (int64, error) {
+	var updated int64
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		batch := &pgx.Batch{}
+		for _, event := range events {
+			batch.Queue(`UPDATE events SET payload = $4 WHERE sensor_id = $1 AND timestamp = $2 AND channel = $3`,
+				event.SensorID, event.Timestamp.UTC(), event.Channel, event.Payload)
+		}
+		results := tx.SendBatch(ctx, batch)
+		defer results.Close()
+		for range events {
+			tag, err := results.Exec()
+			if err != nil {
+				return err
+			}
+			updated += tag.RowsAffected()
+		}
+		return results.Close()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
+
+// AggregateEvents - сводит интервалы из самых крупных подходящих сводок event_rollups, а хвост периода
+// после последней целой сводки - из событий
+func (r *EventRepository) AggregateEvents(ctx context.Context, ids []int64, start, end time.Time, bucket time.Duration) ([]domain.Aggregate, error) {
+	if start.IsZero() || !end.After(start) || bucket <= 0 {
+		return nil, usecase.ErrWrongSeriesQuery
+	}
+	start, end = start.UTC(), end.UTC()
+	resolution, split := domain.RollupResolution(start, bucket), start
+	if resolution > 0 && end.Truncate(resolution).After(start) {
+		split = end.Truncate(resolution)
+	}
+	rows, err := r.pool.Query(ctx, `WITH parts AS (
+			SELECT sensor_id, channel, bucket AS timestamp, count, total, minimum, maximum, latest, latest_at FROM event_rollups
+			WHERE resolution = $5 AND sensor_id = ANY($1) AND bucket >= $3 AND bucket < $6
+			UNION ALL
+			SELECT sensor_id, channel, timestamp, 1, payload, payload, payload, payload, timestamp FROM events
+			WHERE sensor_id = ANY($1) AND timestamp >= $6 AND timestamp < $4)
+		SELECT sensor_id, channel, date_bin($2::bigint * interval '1 microsecond', timestamp, $3) AS bucket, sum(count)::bigint,
+		sum(total) / sum(count)::double precision, min(minimum), max(maximum), sum(total), (array_agg(latest ORDER BY latest_at DESC))[1]
+		FROM parts GROUP BY sensor_id, channel, bucket ORDER BY sensor_id, channel, bucket`,
+		ids, bucket.Microseconds(), start, end, int64(resolution/time.Second), split)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var aggregates []domain.Aggregate
+	for rows.Next() {
+		var a domain.Aggregate
+		if err := rows.Scan(&a.SensorID, &a.Channel, &a.Start, &a.Count, &a.Mean, &a.Min, &a.Max, &a.Sum, &a.Last); err != nil {
+			return nil, err
+		}
+		aggregates = append(aggregates, a)
+	}
+	return aggregates, rows.Err()
+}
+
+func (r *EventRepository) RebuildRollups(ctx context.Context, ids []int64, start, end time.Time) (int64, error) {
+	if start.IsZero() || !end.After(start) {
+		return 0, usecase.ErrInvalidEventTimestamp
+	}
+	start, end = domain.RollupPeriod(start.UTC(), end.UTC())
+	if ids == nil {
+		// nil pgx передаёт как NULL, а не пустой массив
+		ids = []int64{}
+	}
+	var rebuilt int64
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `DELETE FROM event_rollups WHERE (cardinality($1::bigint[]) = 0 OR sensor_id = ANY($1)) AND bucket >= $2 AND bucket < $3`,
+			ids, start, end)
+		if err != nil {
+			return err
+		}
+		for _, resolution := range domain.RollupResolutions {
+			tag, err := tx.Exec(ctx, `INSERT INTO event_rollups (resolution, sensor_id, channel, bucket, count, total, minimum, maximum, latest, latest_at)
+				SELECT $1::bigint, sensor_id, channel, date_bin($1::bigint * interval '1 second', timestamp, timestamp '1970-01-01') AS bucket, count(*),
+				sum(payload), min(payload), max(payload), (array_agg(payload ORDER BY timestamp DESC))[1], max(timestamp)
+				FROM events WHERE (cardinality($2::bigint[]) = 0 OR sensor_id = ANY($2)) AND timestamp >= $3 AND timestamp < $4
+				GROUP BY sensor_id, channel, bucket`, int64(resolution/time.Second), ids, start, end)
+			if err != nil {
+				return err
+			}
+			rebuilt += tag.RowsAffected()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rebuilt, nil
+}
+
+func (r *EventRepository) SaveTransition(ctx context.Context, transition *domain.Transition) error {
+	if transition == nil {
+		return errors.New("transition is nil")
+	}
+	_, err := r.pool.Exec(ctx, `INSERT INTO transitions (sensor_id, channel, timestamp, from_state, to_state, lasted) VALUES ($1, $2, $3, $4, $5, $6)`,
+		transition.SensorID, transition.Channel, transition.Timestamp.UTC(), transition.From, transition.To, transition.Lasted.Microseconds())
+	return err
+}
+
+func (r *EventRepository) GetLastTransition(ctx context.Context, sensorID int64, channel string) (*domain.Transition, error) {
+	row := r.pool.QueryRow(ctx, `SELECT sensor_id, channel, timestamp, from_state, to_state, lasted FROM transitions
+		WHERE sensor_id = $1 AND channel = $2 ORDER BY timestamp DESC LIMIT 1`, sensorID, channel)
+	transition, err := scanTransition(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, usecase.ErrNoTransition
+	}
+	return transition, err
+}
+
+func (r *EventRepository) GetTransitions(ctx context.Context, sensorID int64, filter domain.TransitionFilter) ([]domain.Transition, error) {
+	var limit *int
+	if filter.Limit > 0 {
+		limit = &filter.Limit
+	}
+	rows, err := r.pool.Query(ctx, `SELECT sensor_id, channel, timestamp, from_state, to_state, lasted FROM transitions
+		WHERE sensor_id = $1 AND ($2 = '' OR channel = $2) AND timestamp BETWEEN $3 AND $4
+		AND ($5::double precision IS NULL OR to_state = $5) AND lasted >= $6
+		ORDER BY timestamp, channel LIMIT $7`,
+		sensorID, filter.Channel, filter.Start.UTC(), filter.End.UTC(), filter.To, filter.MinLasted.Microseconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var transitions []domain.Transition
+	for rows.Next() {
+		transition, err := scanTransition(rows)
+		if err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, *transition)
+	}
+	return transitions, rows.Err()
+}
+
+func scanTransition(row pgx.Row) (*domain.Transition, error) {
+	var transition domain.Transition
+	var lasted int64
+	if err := row.Scan(&transition.SensorID, &transition.Channel, &transition.Timestamp, &transition.From, &transition.To, &lasted); err != nil {
+		return nil, err
+	}
+	transition.Lasted = time.Duration(lasted) * time.Microsecond
+	return &transition, nil
+}
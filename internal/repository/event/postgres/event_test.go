@@ -3,6 +3,8 @@ package postgres
 import (
 	"context"
 	"homework/internal/domain"
+	"homework/internal/repository/repotest"
+	"homework/internal/usecase"
 	"homework/pkg/pg_test"
 	"testing"
 	"time"
@@ -32,7 +34,8 @@ func (suite *EventTestSuite) TearDownSuite() {
 }
 
 func (suite *EventTestSuite) TestEventRepository_SaveEvent() {
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint: govet // test stub
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
 	err := suite.repo.SaveEvent(ctx, &domain.Event{
 		Timestamp:          time.Now().In(time.UTC),
@@ -45,7 +48,8 @@ func (suite *EventTestSuite) TestEventRepository_SaveEvent() {
 }
 
 func (suite *EventTestSuite) TestEventRepository_GetLastEventBySensorID() {
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint: govet // test stub
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
 	firstEvent := domain.Event{
 		Timestamp:          time.Now().Truncate(time.Microsecond).In(time.UTC),
@@ -73,6 +77,12 @@ func (suite *EventTestSuite) TestEventRepository_GetLastEventBySensorID() {
 	assert.Equal(suite.T(), secondEvent, *event)
 }
 
+func (suite *EventTestSuite) TestConformance() {
+	repotest.TestEventRepository(suite.T(), func(*testing.T) usecase.EventRepository {
+		return suite.repo
+	})
+}
+
 func TestEventTestSuite(t *testing.T) {
 	suite.Run(t, new(EventTestSuite))
 }
@@ -0,0 +1,312 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"strconv"
+	"strings"
+	"time"
+
+	"homework/pkg/sqlite"
+)
+
+type EventRepository struct {
+	db *sql.DB
+}
+
+func NewEventRepository(db *sql.DB) *EventRepository {
+	return &EventRepository{
+		db: db,
+	}
+}
+
+func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) (err error) {
+	if event == nil {
+		return errors.New("event is nil")
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	_, err = tx.ExecContext(ctx, `INSERT INTO events (timestamp, sensor_serial_number, sensor_id, payload, raw, unit, channel, quality) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		sqlite.TimeValue(event.Timestamp), event.SensorSerialNumber, event.SensorID, event.Payload, event.Raw, event.Unit, event.Channel, int16(event.Quality))
+	if err != nil {
+		return err
+	}
+	for _, resolution := range domain.RollupResolutions {
+		// Truncate отсчитывает от полуночи UTC, поэтому сводки выровнены так же, как в RebuildRollups
+		_, err = tx.ExecContext(ctx, `INSERT INTO event_rollups AS r (resolution, sensor_id, channel, bucket, count, total, minimum, maximum, latest, latest_at)
+			VALUES (?1, ?2, ?3, ?4, 1, ?5, ?5, ?5, ?5, ?6)
+			ON CONFLICT (resolution, sensor_id, channel, bucket) DO UPDATE SET count = r.count + 1, total = r.total + excluded.total,
+			minimum = min(r.minimum, excluded.minimum), maximum = max(r.maximum, excluded.maximum),
+			latest = CASE WHEN excluded.latest_at >= r.latest_at THEN excluded.latest ELSE r.latest END,
+			latest_at = max(r.latest_at, excluded.latest_at)`,
+			int64(resolution/time.Second), event.SensorID, event.Channel, sqlite.TimeValue(event.Timestamp.Truncate(resolution)),
+			event.Payload, sqlite.TimeValue(event.Timestamp))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT timestamp, sensor_serial_number, sensor_id, payload, raw, unit, channel, quality FROM events WHERE sensor_id = ? ORDER BY timestamp DESC LIMIT 1`, id)
+	event := &domain.Event{}
+	if err := row.Scan(sqlite.ScanTime(&event.Timestamp), &event.SensorSerialNumber, &event.SensorID, &event.Payload, &event.Raw, &event.Unit, &event.Channel, &event.Quality); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, usecase.ErrEventNotFound
+		}
+		return nil, err
+	}
+	return event, nil
+}
+
+func (r *EventRepository) GetEventsBySensorID(ctx context.Context, id int64, channel string, start, end time.Time) ([]*domain.Event, error) {
+	if start.IsZero() || end.IsZero() || start.After(end) {
+		return nil, usecase.ErrInvalidEventTimestamp
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT timestamp, sensor_serial_number, sensor_id, payload, raw, unit, channel, quality FROM events
+		WHERE sensor_id = ? AND (? = '' OR channel = ?) AND timestamp BETWEEN ? AND ? ORDER BY timestamp, channel`,
+		id, channel, channel, sqlite.TimeValue(start), sqlite.TimeValue(end))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []*domain.Event
+	for rows.Next() {
+		event := &domain.Event{}
+		if err := rows.Scan(sqlite.ScanTime(&event.Timestamp), &event.SensorSerialNumber, &event.SensorID, &event.Payload, &event.Raw, &event.Unit, &event.Channel, &event.Quality); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// DeleteEventsBefore - удаляет события старше before и сводки, целиком лежащие до before
+func (r *EventRepository) DeleteEventsBefore(ctx context.Context, before time.Time) (_ int64, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	res, err := tx.ExecContext(ctx, `DELETE FROM events WHERE timestamp < ?`, sqlite.TimeValue(before))
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM event_rollups WHERE bucket + resolution * 1000000 <= ?`, sqlite.TimeValue(before)); err != nil {
+		return 0, err
+	}
+	return deleted, tx.Commit()
+}
+
+func (r *EventRepository) UpdateEventPayloads(ctx context.Context, events []*domain.Event) (_ int64, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	stmt, err := tx.PrepareContext(ctx, `UPDATE events SET payload = ? WHERE sensor_id = ? AND timestamp = ? AND channel = ?`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var updated int64
+	for _, event := range events {
+		res, err := stmt.ExecContext(ctx, event.Payload, event.SensorID, sqlite.TimeValue(event.Timestamp), event.Channel)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		updated += n
+	}
+	return updated, tx.Commit()
+}
+
+// AggregateEvents - сводит интервалы из самых крупных подходящих сводок event_rollups, а хвост периода
+// после последней целой сводки - из событий
+func (r *EventRepository) AggregateEvents(ctx context.Context, ids []int64, start, end time.Time, bucket time.Duration) ([]domain.Aggregate, error) {
+	if start.IsZero() || !end.After(start) || bucket <= 0 {
+		return nil, usecase.ErrWrongSeriesQuery
+	}
+	resolution, split := domain.RollupResolution(start, bucket), start
+	if resolution > 0 && end.Truncate(resolution).After(start) {
+		split = end.Truncate(resolution)
+	}
+	// время хранится в микросекундах, поэтому интервал - целочисленное деление; последнее значение
+	// интервала берётся оконной функцией, своего array_agg у sqlite нет
+	args := []any{sqlite.TimeValue(start), bucket.Microseconds(), sqlite.TimeValue(end), int64(resolution / time.Second), sqlite.TimeValue(split)}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	in := placeholders(6, len(ids))
+	rows, err := r.db.QueryContext(ctx, `WITH parts AS (
+			SELECT sensor_id, channel, bucket AS timestamp, count, total, minimum, maximum, latest, latest_at FROM event_rollups
+			WHERE resolution = ?4 AND sensor_id IN (`+in+`) AND bucket >= ?1 AND bucket < ?5
+			UNION ALL
+			SELECT sensor_id, channel, timestamp, 1, payload, payload, payload, payload, timestamp FROM events
+			WHERE sensor_id IN (`+in+`) AND timestamp >= ?5 AND timestamp < ?3)
+		SELECT sensor_id, channel, bucket, sum(count), sum(total) / sum(count), min(minimum), max(maximum), sum(total), max(last)
+		FROM (SELECT sensor_id, channel, count, total, minimum, maximum, (timestamp - ?1) / ?2 AS bucket,
+			last_value(latest) OVER (PARTITION BY sensor_id, channel, (timestamp - ?1) / ?2 ORDER BY latest_at
+				ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING) AS last
+			FROM parts)
+		GROUP BY sensor_id, channel, bucket ORDER BY sensor_id, channel, bucket`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var aggregates []domain.Aggregate
+	for rows.Next() {
+		var a domain.Aggregate
+		var n int64
+		if err := rows.Scan(&a.SensorID, &a.Channel, &n, &a.Count, &a.Mean, &a.Min, &a.Max, &a.Sum, &a.Last); err != nil {
+			return nil, err
+		}
+		a.Start = start.Add(time.Duration(n) * bucket)
+		aggregates = append(aggregates, a)
+	}
+	return aggregates, rows.Err()
+}
+
+func (r *EventRepository) RebuildRollups(ctx context.Context, ids []int64, start, end time.Time) (_ int64, err error) {
+	if start.IsZero() || !end.After(start) {
+		return 0, usecase.ErrInvalidEventTimestamp
+	}
+	start, end = domain.RollupPeriod(start, end)
+	args := []any{nil, sqlite.TimeValue(start), sqlite.TimeValue(end)}
+	sensors := "1"
+	if len(ids) > 0 {
+		sensors = "sensor_id IN (" + placeholders(4, len(ids)) + ")"
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if _, err = tx.ExecContext(ctx, `DELETE FROM event_rollups WHERE `+sensors+` AND bucket >= ?2 AND bucket < ?3`, args...); err != nil {
+		return 0, err
+	}
+	var rebuilt int64
+	for _, resolution := range domain.RollupResolutions {
+		// начало сводки - время события, округлённое вниз до её длины и от эпохи Unix
+		args[0] = resolution.Microseconds()
+		res, err := tx.ExecContext(ctx, `INSERT INTO event_rollups (resolution, sensor_id, channel, bucket, count, total, minimum, maximum, latest, latest_at)
+			SELECT ?1 / 1000000, sensor_id, channel, bucket, count(*), sum(payload), min(payload), max(payload), max(latest), max(timestamp)
+			FROM (SELECT sensor_id, channel, payload, timestamp, timestamp - (timestamp % ?1 + ?1) % ?1 AS bucket,
+				last_value(payload) OVER (PARTITION BY sensor_id, channel, timestamp - (timestamp % ?1 + ?1) % ?1 ORDER BY timestamp
+					ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING) AS latest
+				FROM events WHERE `+sensors+` AND timestamp >= ?2 AND timestamp < ?3)
+			GROUP BY sensor_id, channel, bucket`, args...)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		rebuilt += n
+	}
+	return rebuilt, tx.Commit()
+}
+
+// placeholders - n нумерованных параметров запроса, начиная с first
+func placeholders(first, n int) string {
+	var b strings.Builder
+	for i := range n {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("?" + strconv.Itoa(first+i))
+	}
+	return b.String()
+}
+
+func (r *EventRepository) SaveTransition(ctx context.Context, transition *domain.Transition) error {
+	if transition == nil {
+		return errors.New("transition is nil")
+	}
+	_, err := r.db.ExecContext(ctx, `INSERT INTO transitions (sensor_id, channel, timestamp, from_state, to_state, lasted) VALUES (?, ?, ?, ?, ?, ?)`,
+		transition.SensorID, transition.Channel, sqlite.TimeValue(transition.Timestamp), transition.From, transition.To, transition.Lasted.Microseconds())
+	return err
+}
+
+func (r *EventRepository) GetLastTransition(ctx context.Context, sensorID int64, channel string) (*domain.Transition, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT sensor_id, channel, timestamp, from_state, to_state, lasted FROM transitions
+		WHERE sensor_id = ? AND channel = ? ORDER BY timestamp DESC LIMIT 1`, sensorID, channel)
+	transition, err := scanTransition(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, usecase.ErrNoTransition
+	}
+	return transition, err
+}
+
+func (r *EventRepository) GetTransitions(ctx context.Context, sensorID int64, filter domain.TransitionFilter) ([]domain.Transition, error) {
+	// отрицательный LIMIT в sqlite снимает ограничение
+	limit := -1
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT sensor_id, channel, timestamp, from_state, to_state, lasted FROM transitions
+		WHERE sensor_id = ?1 AND (?2 = '' OR channel = ?2) AND timestamp BETWEEN ?3 AND ?4
+		AND (?5 IS NULL OR to_state = ?5) AND lasted >= ?6
+		ORDER BY timestamp, channel LIMIT ?7`,
+		sensorID, filter.Channel, sqlite.TimeValue(filter.Start), sqlite.TimeValue(filter.End), filter.To, filter.MinLasted.Microseconds(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var transitions []domain.Transition
+	for rows.Next() {
+		transition, err := scanTransition(rows)
+		if err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, *transition)
+	}
+	return transitions, rows.Err()
+}
+
+// scanTransition - читает смену состояния из строки *sql.Row или *sql.Rows
+func scanTransition(row interface{ Scan(dest ...any) error }) (*domain.Transition, error) {
+	var transition domain.Transition
+	var lasted int64
+	if err := row.Scan(&transition.SensorID, &transition.Channel, sqlite.ScanTime(&transition.Timestamp), &transition.From, &transition.To, &lasted); err != nil {
+		return nil, err
+	}
+	transition.Lasted = time.Duration(lasted) * time.Microsecond
+	return &transition, nil
+}
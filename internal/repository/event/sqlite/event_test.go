@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"homework/internal/domain"
+	"homework/internal/repository/repotest"
+	"homework/internal/usecase"
+	"homework/pkg/sqlite_test"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type EventTestSuite struct {
+	suite.Suite
+	testDbInstance *sql.DB
+	testDB         *sqlite_test.TestDatabase
+
+	repo *EventRepository
+}
+
+func (suite *EventTestSuite) SetupSuite() {
+	suite.testDB = sqlite_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewEventRepository(suite.testDbInstance)
+}
+
+func (suite *EventTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *EventTestSuite) TestEventRepository_SaveEvent() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := suite.repo.SaveEvent(ctx, &domain.Event{
+		Timestamp:          time.Now().In(time.UTC),
+		SensorSerialNumber: "1234567890",
+		SensorID:           1,
+		Payload:            1,
+	})
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *EventTestSuite) TestEventRepository_GetLastEventBySensorID() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	firstEvent := domain.Event{
+		Timestamp:          time.Now().Truncate(time.Microsecond).In(time.UTC),
+		SensorSerialNumber: "0987654321",
+		SensorID:           2,
+		Payload:            1,
+	}
+
+	secondEvent := domain.Event{
+		Timestamp:          time.Now().Truncate(time.Microsecond).Add(time.Minute * 10).In(time.UTC),
+		SensorSerialNumber: "0987654321",
+		SensorID:           2,
+		Payload:            2,
+	}
+
+	err := suite.repo.SaveEvent(ctx, &firstEvent)
+	assert.Nil(suite.T(), err)
+
+	err = suite.repo.SaveEvent(ctx, &secondEvent)
+	assert.Nil(suite.T(), err)
+
+	event, err := suite.repo.GetLastEventBySensorID(ctx, 2)
+
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), secondEvent, *event)
+}
+
+func (suite *EventTestSuite) TestConformance() {
+	repotest.TestEventRepository(suite.T(), func(*testing.T) usecase.EventRepository {
+		return suite.repo
+	})
+}
+
+func TestEventTestSuite(t *testing.T) {
+	suite.Run(t, new(EventTestSuite))
+}
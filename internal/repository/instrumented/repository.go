@@ -0,0 +1,219 @@
+// Package instrumented - декораторы репозиториев, замеряющие длительность каждого вызова.
+//
+// Декораторы оборачивают любые реализации интерфейсов usecase и не меняют их поведение,
+// в том числе сохраняют необязательные возможности вроде usecase.EventRetentionRepository.
+package instrumented
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"time"
+)
+
+// Observer - получатель замеров, например metrics.Metrics
+type Observer interface {
+	ObserveRepository(repository, method string, start time.Time, err error)
+}
+
+type SensorRepository struct {
+	inner    usecase.SensorRepository
+	observer Observer
+}
+
+func NewSensorRepository(inner usecase.SensorRepository, observer Observer) *SensorRepository {
+	return &SensorRepository{inner: inner, observer: observer}
+}
+
+func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) (err error) {
+	defer r.observe("SaveSensor", time.Now(), &err)
+	return r.inner.SaveSensor(ctx, sensor)
+}
+
+func (r *SensorRepository) GetSensors(ctx context.Context) (_ []domain.Sensor, err error) {
+	defer r.observe("GetSensors", time.Now(), &err)
+	return r.inner.GetSensors(ctx)
+}
+
+func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (_ *domain.Sensor, err error) {
+	defer r.observe("GetSensorByID", time.Now(), &err)
+	return r.inner.GetSensorByID(ctx, id)
+}
+
+func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (_ *domain.Sensor, err error) {
+	defer r.observe("GetSensorBySerialNumber", time.Now(), &err)
+	return r.inner.GetSensorBySerialNumber(ctx, sn)
+}
+
+func (r *SensorRepository) UpdateSensor(ctx context.Context, sensor *domain.Sensor, version int64) (err error) {
+	defer r.observe("UpdateSensor", time.Now(), &err)
+	return r.inner.UpdateSensor(ctx, sensor, version)
+}
+
+// UpdateSensorCalibration - передаёт вызов, если обёрнутое хранилище ведёт журнал калибровки
+func (r *SensorRepository) UpdateSensorCalibration(ctx context.Context, sensor *domain.Sensor, version int64, change *domain.CalibrationChange) (err error) {
+	calibrations, ok := r.inner.(usecase.SensorCalibrationRepository)
+	if !ok {
+		return usecase.ErrCalibrationNotSupported
+	}
+	defer r.observe("UpdateSensorCalibration", time.Now(), &err)
+	return calibrations.UpdateSensorCalibration(ctx, sensor, version, change)
+}
+
+// GetCalibrationChanges - передаёт вызов, если обёрнутое хранилище ведёт журнал калибровки
+func (r *SensorRepository) GetCalibrationChanges(ctx context.Context, sensorID int64) (_ []domain.CalibrationChange, err error) {
+	calibrations, ok := r.inner.(usecase.SensorCalibrationRepository)
+	if !ok {
+		return nil, usecase.ErrCalibrationNotSupported
+	}
+	defer r.observe("GetCalibrationChanges", time.Now(), &err)
+	return calibrations.GetCalibrationChanges(ctx, sensorID)
+}
+
+func (r *SensorRepository) observe(method string, start time.Time, err *error) {
+	r.observer.ObserveRepository("sensor", method, start, *err)
+}
+
+type EventRepository struct {
+	inner    usecase.EventRepository
+	observer Observer
+}
+
+func NewEventRepository(inner usecase.EventRepository, observer Observer) *EventRepository {
+	return &EventRepository{inner: inner, observer: observer}
+}
+
+func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) (err error) {
+	defer r.observe("SaveEvent", time.Now(), &err)
+	return r.inner.SaveEvent(ctx, event)
+}
+
+func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (_ *domain.Event, err error) {
+	defer r.observe("GetLastEventBySensorID", time.Now(), &err)
+	return r.inner.GetLastEventBySensorID(ctx, id)
+}
+
+func (r *EventRepository) GetEventsBySensorID(ctx context.Context, id int64, channel string, start, end time.Time) (_ []*domain.Event, err error) {
+	defer r.observe("GetEventsBySensorID", time.Now(), &err)
+	return r.inner.GetEventsBySensorID(ctx, id, channel, start, end)
+}
+
+// DeleteEventsBefore - передаёт вызов, если обёрнутое хранилище умеет удалять события
+func (r *EventRepository) DeleteEventsBefore(ctx context.Context, before time.Time) (_ int64, err error) {
+	retention, ok := r.inner.(usecase.EventRetentionRepository)
+	if !ok {
+		return 0, usecase.ErrRetentionNotSupported
+	}
+	defer r.observe("DeleteEventsBefore", time.Now(), &err)
+	return retention.DeleteEventsBefore(ctx, before)
+}
+
+// UpdateEventPayloads - передаёт вызов, если обёрнутое хранилище умеет менять значения событий
+func (r *EventRepository) UpdateEventPayloads(ctx context.Context, events []*domain.Event) (_ int64, err error) {
+	recalibration, ok := r.inner.(usecase.EventRecalibrationRepository)
+	if !ok {
+		return 0, usecase.ErrRecalibrationNotSupported
+	}
+	defer r.observe("UpdateEventPayloads", time.Now(), &err)
+	return recalibration.UpdateEventPayloads(ctx, events)
+}
+
+// AggregateEvents - передаёт вызов, если обёрнутое хранилище умеет сводить события
+func (r *EventRepository) AggregateEvents(ctx context.Context, ids []int64, start, end time.Time, bucket time.Duration) (_ []domain.Aggregate, err error) {
+	aggregation, ok := r.inner.(usecase.EventAggregationRepository)
+	if !ok {
+		return nil, usecase.ErrAggregationNotSupported
+	}
+	defer r.observe("AggregateEvents", time.Now(), &err)
+	return aggregation.AggregateEvents(ctx, ids, start, end, bucket)
+}
+
+// RebuildRollups - передаёт вызов, если обёрнутое хранилище ведёт сводки событий
+func (r *EventRepository) RebuildRollups(ctx context.Context, ids []int64, start, end time.Time) (_ int64, err error) {
+	rollups, ok := r.inner.(usecase.EventRollupRepository)
+	if !ok {
+		return 0, usecase.ErrRollupsNotSupported
+	}
+	defer r.observe("RebuildRollups", time.Now(), &err)
+	return rollups.RebuildRollups(ctx, ids, start, end)
+}
+
+// SaveTransition - передаёт вызов, если обёрнутое хранилище ведёт журнал смен состояния
+func (r *EventRepository) SaveTransition(ctx context.Context, transition *domain.Transition) (err error) {
+	transitions, ok := r.inner.(usecase.EventTransitionRepository)
+	if !ok {
+		return usecase.ErrTransitionsNotSupported
+	}
+	defer r.observe("SaveTransition", time.Now(), &err)
+	return transitions.SaveTransition(ctx, transition)
+}
+
+// GetLastTransition - передаёт вызов, если обёрнутое хранилище ведёт журнал смен состояния
+func (r *EventRepository) GetLastTransition(ctx context.Context, sensorID int64, channel string) (_ *domain.Transition, err error) {
+	transitions, ok := r.inner.(usecase.EventTransitionRepository)
+	if !ok {
+		return nil, usecase.ErrTransitionsNotSupported
+	}
+	defer r.observe("GetLastTransition", time.Now(), &err)
+	return transitions.GetLastTransition(ctx, sensorID, channel)
+}
+
+// GetTransitions - передаёт вызов, если обёрнутое хранилище ведёт журнал смен состояния
+func (r *EventRepository) GetTransitions(ctx context.Context, sensorID int64, filter domain.TransitionFilter) (_ []domain.Transition, err error) {
+	transitions, ok := r.inner.(usecase.EventTransitionRepository)
+	if !ok {
+		return nil, usecase.ErrTransitionsNotSupported
+	}
+	defer r.observe("GetTransitions", time.Now(), &err)
+	return transitions.GetTransitions(ctx, sensorID, filter)
+}
+
+func (r *EventRepository) observe(method string, start time.Time, err *error) {
+	r.observer.ObserveRepository("event", method, start, *err)
+}
+
+type UserRepository struct {
+	inner    usecase.UserRepository
+	observer Observer
+}
+
+func NewUserRepository(inner usecase.UserRepository, observer Observer) *UserRepository {
+	return &UserRepository{inner: inner, observer: observer}
+}
+
+func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) (err error) {
+	defer r.observe("SaveUser", time.Now(), &err)
+	return r.inner.SaveUser(ctx, user)
+}
+
+func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (_ *domain.User, err error) {
+	defer r.observe("GetUserByID", time.Now(), &err)
+	return r.inner.GetUserByID(ctx, id)
+}
+
+func (r *UserRepository) observe(method string, start time.Time, err *error) {
+	r.observer.ObserveRepository("user", method, start, *err)
+}
+
+type SensorOwnerRepository struct {
+	inner    usecase.SensorOwnerRepository
+	observer Observer
+}
+
+func NewSensorOwnerRepository(inner usecase.SensorOwnerRepository, observer Observer) *SensorOwnerRepository {
+	return &SensorOwnerRepository{inner: inner, observer: observer}
+}
+
+func (r *SensorOwnerRepository) SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) (err error) {
+	defer r.observe("SaveSensorOwner", time.Now(), &err)
+	return r.inner.SaveSensorOwner(ctx, sensorOwner)
+}
+
+func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) (_ []domain.SensorOwner, err error) {
+	defer r.observe("GetSensorsByUserID", time.Now(), &err)
+	return r.inner.GetSensorsByUserID(ctx, userID)
+}
+
+func (r *SensorOwnerRepository) observe(method string, start time.Time, err *error) {
+	r.observer.ObserveRepository("sensor_owner", method, start, *err)
+}
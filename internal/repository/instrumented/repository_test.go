@@ -0,0 +1,74 @@
+package instrumented
+
+import (
+	"context"
+	"homework/internal/repository/repotest"
+	"homework/internal/usecase"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	eventInmemory "homework/internal/repository/event/inmemory"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
+	userInmemory "homework/internal/repository/user/inmemory"
+)
+
+type call struct {
+	repository, method string
+	failed             bool
+}
+
+type recordingObserver struct {
+	mu    sync.Mutex
+	calls []call
+}
+
+func (o *recordingObserver) ObserveRepository(repository, method string, _ time.Time, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.calls = append(o.calls, call{repository, method, err != nil})
+}
+
+func TestRepositories_Conformance(t *testing.T) {
+	observer := &recordingObserver{}
+
+	t.Run("sensor", func(t *testing.T) {
+		repotest.TestSensorRepository(t, func(t *testing.T) usecase.SensorRepository {
+			return NewSensorRepository(sensorInmemory.NewSensorRepository(), observer)
+		})
+	})
+	t.Run("event", func(t *testing.T) {
+		repotest.TestEventRepository(t, func(t *testing.T) usecase.EventRepository {
+			return NewEventRepository(eventInmemory.NewEventRepository(), observer)
+		})
+	})
+	t.Run("user", func(t *testing.T) {
+		repotest.TestUserRepository(t, func(t *testing.T) usecase.UserRepository {
+			return NewUserRepository(userInmemory.NewUserRepository(), observer)
+		})
+	})
+	t.Run("sensor owner", func(t *testing.T) {
+		repotest.TestSensorOwnerRepository(t, func(t *testing.T) usecase.SensorOwnerRepository {
+			return NewSensorOwnerRepository(userInmemory.NewSensorOwnerRepository(), observer)
+		})
+	})
+
+	assert.Contains(t, observer.calls, call{"sensor", "SaveSensor", false})
+	assert.Contains(t, observer.calls, call{"sensor", "GetSensorByID", true})
+	assert.Contains(t, observer.calls, call{"event", "DeleteEventsBefore", false})
+	assert.Contains(t, observer.calls, call{"user", "GetUserByID", true})
+	assert.Contains(t, observer.calls, call{"sensor_owner", "GetSensorsByUserID", false})
+}
+
+func TestEventRepository_DeleteEventsBefore_NotSupported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	observer := &recordingObserver{}
+
+	repo := NewEventRepository(usecase.NewMockEventRepository(ctrl), observer)
+	_, err := repo.DeleteEventsBefore(context.Background(), time.Now())
+	assert.ErrorIs(t, err, usecase.ErrRetentionNotSupported)
+	assert.Empty(t, observer.calls)
+}
@@ -0,0 +1,451 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEventRepository - контрактные тесты usecase.EventRepository
+func TestEventRepository(t *testing.T, newRepo func(t *testing.T) usecase.EventRepository) {
+	t.Run("err, event is nil", func(t *testing.T) {
+		assert.Error(t, newRepo(t).SaveEvent(testContext(t), nil))
+	})
+
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := cancelledContext()
+
+		assert.ErrorIs(t, repo.SaveEvent(ctx, &domain.Event{Timestamp: now(), SensorID: uniqueID()}), context.Canceled)
+		_, err := repo.GetLastEventBySensorID(ctx, 1)
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = repo.GetEventsBySensorID(ctx, 1, "", now().Add(-time.Hour), now())
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("fail, last event not found", func(t *testing.T) {
+		_, err := newRepo(t).GetLastEventBySensorID(testContext(t), uniqueID())
+		assert.ErrorIs(t, err, usecase.ErrEventNotFound)
+	})
+
+	t.Run("ok, last event", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		events := saveEvents(t, repo, uniqueID(), now(), time.Minute, 3)
+		// последнее по времени, а не по порядку сохранения
+		late := *events[1]
+		late.Timestamp = late.Timestamp.Add(time.Hour)
+		late.Payload = 100
+		require.NoError(t, repo.SaveEvent(ctx, &late))
+		saveEvents(t, repo, uniqueID(), now().Add(2*time.Hour), time.Minute, 1)
+
+		actual, err := repo.GetLastEventBySensorID(ctx, late.SensorID)
+		require.NoError(t, err)
+		assertEvent(t, &late, actual)
+	})
+
+	t.Run("ok, events in range", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		start := now()
+		id := uniqueID()
+		saved := saveEvents(t, repo, id, start, time.Minute, 5)
+		saveEvents(t, repo, uniqueID(), start, time.Minute, 5)
+
+		tests := []struct {
+			name       string
+			start, end time.Time
+			want       []*domain.Event
+		}{
+			{"all", start.Add(-time.Hour), start.Add(time.Hour), saved},
+			{"bounds are inclusive", saved[1].Timestamp, saved[3].Timestamp, saved[1:4]},
+			{"single point", saved[2].Timestamp, saved[2].Timestamp, saved[2:3]},
+			{"nothing in range", start.Add(-time.Hour), start.Add(-time.Minute), nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				events, err := repo.GetEventsBySensorID(ctx, id, "", tt.start, tt.end)
+				require.NoError(t, err)
+				require.Len(t, events, len(tt.want))
+				for i := range tt.want {
+					assertEvent(t, tt.want[i], events[i])
+				}
+			})
+		}
+	})
+
+	t.Run("ok, events are sorted by time", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		start := now()
+		id := uniqueID()
+		for _, offset := range []time.Duration{3, 1, 2} {
+			require.NoError(t, repo.SaveEvent(ctx, &domain.Event{
+				Timestamp:          start.Add(offset * time.Minute),
+				SensorSerialNumber: "0123456789",
+				SensorID:           id,
+				Payload:            float64(offset),
+			}))
+		}
+
+		events, err := repo.GetEventsBySensorID(ctx, id, "", start, start.Add(time.Hour))
+		require.NoError(t, err)
+		require.Len(t, events, 3)
+		for i, event := range events {
+			assert.Equal(t, float64(i+1), event.Payload)
+		}
+	})
+
+	t.Run("ok, fractional payload with unit", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		event := &domain.Event{
+			Timestamp:          now(),
+			SensorSerialNumber: "0123456789",
+			SensorID:           uniqueID(),
+			Payload:            21.5,
+			Unit:               "°C",
+		}
+		require.NoError(t, repo.SaveEvent(ctx, event))
+
+		actual, err := repo.GetLastEventBySensorID(ctx, event.SensorID)
+		require.NoError(t, err)
+		assertEvent(t, event, actual)
+	})
+
+	t.Run("ok, channels", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		id := uniqueID()
+		start := now()
+		var saved []*domain.Event
+		for i := 0; i < 2; i++ {
+			for _, channel := range []string{"temperature", "humidity"} {
+				event := &domain.Event{
+					Timestamp:          start.Add(time.Duration(i) * time.Minute),
+					SensorSerialNumber: "0123456789",
+					SensorID:           id,
+					Channel:            channel,
+					Payload:            float64(i),
+				}
+				require.NoError(t, repo.SaveEvent(ctx, event))
+				saved = append(saved, event)
+			}
+		}
+
+		// события одного пакета с одним временем не затирают друг друга
+		events, err := repo.GetEventsBySensorID(ctx, id, "", start, start.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Len(t, events, 4)
+
+		events, err = repo.GetEventsBySensorID(ctx, id, "humidity", start, start.Add(time.Hour))
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assertEvent(t, saved[1], events[0])
+		assertEvent(t, saved[3], events[1])
+	})
+
+	t.Run("ok, unknown sensor has no events", func(t *testing.T) {
+		events, err := newRepo(t).GetEventsBySensorID(testContext(t), uniqueID(), "", now().Add(-time.Hour), now())
+		assert.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("fail, invalid range", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		_, err := repo.GetEventsBySensorID(ctx, 1, "", now(), now().Add(-time.Hour))
+		assert.ErrorIs(t, err, usecase.ErrInvalidEventTimestamp)
+		_, err = repo.GetEventsBySensorID(ctx, 1, "", time.Time{}, now())
+		assert.ErrorIs(t, err, usecase.ErrInvalidEventTimestamp)
+		_, err = repo.GetEventsBySensorID(ctx, 1, "", now(), time.Time{})
+		assert.ErrorIs(t, err, usecase.ErrInvalidEventTimestamp)
+	})
+
+	t.Run("ok, delete events before", func(t *testing.T) {
+		repo := newRepo(t)
+		retention, ok := repo.(usecase.EventRetentionRepository)
+		if !ok {
+			t.Skip("retention is not supported")
+		}
+		ctx := testContext(t)
+
+		// события из далёкого прошлого, чтобы не задеть события других тестов
+		start := time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC)
+		id := uniqueID()
+		saved := saveEvents(t, repo, id, start, time.Hour, 4)
+
+		deleted, err := retention.DeleteEventsBefore(ctx, saved[2].Timestamp)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, deleted, int64(2))
+
+		events, err := repo.GetEventsBySensorID(ctx, id, "", start, start.Add(24*time.Hour))
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		assertEvent(t, saved[2], events[0])
+		assertEvent(t, saved[3], events[1])
+	})
+
+	t.Run("ok, update event payloads", func(t *testing.T) {
+		testEventRecalibration(t, newRepo(t))
+	})
+
+	t.Run("ok, aggregate events", func(t *testing.T) {
+		testEventAggregation(t, newRepo(t))
+	})
+
+	t.Run("ok, rollups", func(t *testing.T) {
+		testEventRollups(t, newRepo(t))
+	})
+
+	t.Run("ok, transitions", func(t *testing.T) {
+		testEventTransitions(t, newRepo(t))
+	})
+}
+
+// testEventRecalibration - контрактные тесты usecase.EventRecalibrationRepository
+func testEventRecalibration(t *testing.T, repo usecase.EventRepository) {
+	recalibration, ok := repo.(usecase.EventRecalibrationRepository)
+	if !ok {
+		t.Skip("recalibration is not supported")
+	}
+	ctx := testContext(t)
+
+	start := now()
+	id := uniqueID()
+	saved := saveEvents(t, repo, id, start, time.Minute, 3)
+	other := saveEvents(t, repo, uniqueID(), start, time.Minute, 1)
+
+	changed := []*domain.Event{saved[0], saved[2]}
+	for _, event := range changed {
+		event.Payload = event.Raw*2 + 1
+	}
+	missing := *saved[1]
+	missing.Timestamp = start.Add(time.Hour)
+	updated, err := recalibration.UpdateEventPayloads(ctx, append(changed, &missing))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated)
+
+	events, err := repo.GetEventsBySensorID(ctx, id, "", start, start.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	for i := range saved {
+		assertEvent(t, saved[i], events[i])
+	}
+	events, err = repo.GetEventsBySensorID(ctx, other[0].SensorID, "", start, start)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assertEvent(t, other[0], events[0])
+}
+
+// testEventAggregation - контрактные тесты usecase.EventAggregationRepository
+func testEventAggregation(t *testing.T, repo usecase.EventRepository) {
+	aggregation, ok := repo.(usecase.EventAggregationRepository)
+	if !ok {
+		t.Skip("aggregation is not supported")
+	}
+	ctx := testContext(t)
+
+	start := now().Truncate(time.Second)
+	first, second := uniqueID(), uniqueID()
+	// седьмое событие приходится на конец периода и в интервалы не входит
+	saveEvents(t, repo, first, start, 10*time.Minute, 7)
+	require.NoError(t, repo.SaveEvent(ctx, &domain.Event{
+		Timestamp: start.Add(5 * time.Minute), SensorSerialNumber: "0123456789", SensorID: second, Channel: "humidity", Payload: 7, Raw: 7,
+	}))
+	saveEvents(t, repo, uniqueID(), start, time.Minute, 1)
+
+	aggregates, err := aggregation.AggregateEvents(ctx, []int64{second, first}, start, start.Add(time.Hour), 30*time.Minute)
+	if errors.Is(err, usecase.ErrAggregationNotSupported) {
+		// обёртка над хранилищем, которое не сводит события само
+		t.Skip("aggregation is not supported")
+	}
+	require.NoError(t, err)
+	require.Len(t, aggregates, 3)
+	for i := range aggregates {
+		assert.True(t, start.Add(time.Duration(i%2)*30*time.Minute).Equal(aggregates[i].Start), "start of %d: %v", i, aggregates[i].Start)
+		aggregates[i].Start = time.Time{}
+	}
+	assert.Equal(t, []domain.Aggregate{
+		{SensorID: first, Count: 3, Mean: 1, Min: 0, Max: 2, Sum: 3, Last: 2},
+		{SensorID: first, Count: 3, Mean: 4, Min: 3, Max: 5, Sum: 12, Last: 5},
+		{SensorID: second, Channel: "humidity", Count: 1, Mean: 7, Min: 7, Max: 7, Sum: 7, Last: 7},
+	}, aggregates)
+}
+
+// testEventRollups - контрактные тесты usecase.EventRollupRepository: сводки по выровненным интервалам
+// собираются из event_rollups и хвоста событий и совпадают со сводками самих событий
+func testEventRollups(t *testing.T, repo usecase.EventRepository) {
+	rollups, ok := repo.(usecase.EventRollupRepository)
+	aggregation, aggregates := repo.(usecase.EventAggregationRepository)
+	if !ok || !aggregates {
+		t.Skip("rollups are not supported")
+	}
+	ctx := testContext(t)
+
+	// вчерашние сутки UTC, чтобы все события были в прошлом
+	start := now().Truncate(24 * time.Hour).Add(-24 * time.Hour)
+	id := uniqueID()
+	save := func(at time.Duration, payload float64) *domain.Event {
+		event := &domain.Event{Timestamp: start.Add(at), SensorSerialNumber: "0123456789", SensorID: id, Payload: payload, Raw: payload}
+		require.NoError(t, repo.SaveEvent(ctx, event))
+		return event
+	}
+	// последнее значение часа - по времени события, а не по порядку сохранения
+	late := save(59*time.Minute, 3)
+	save(0, 1)
+	save(30*time.Second, 2)
+	save(61*time.Minute, 5)
+	save(2*time.Hour+10*time.Second, 7)
+
+	aggregate := func(t *testing.T) []domain.Aggregate {
+		t.Helper()
+		aggregates, err := aggregation.AggregateEvents(ctx, []int64{id}, start, start.Add(2*time.Hour+time.Minute), time.Hour)
+		if errors.Is(err, usecase.ErrAggregationNotSupported) {
+			t.Skip("aggregation is not supported")
+		}
+		require.NoError(t, err)
+		for i := range aggregates {
+			assert.True(t, start.Add(time.Duration(i)*time.Hour).Equal(aggregates[i].Start), "start of %d: %v", i, aggregates[i].Start)
+			aggregates[i].Start = time.Time{}
+		}
+		return aggregates
+	}
+	assert.Equal(t, []domain.Aggregate{
+		{SensorID: id, Count: 3, Mean: 2, Min: 1, Max: 3, Sum: 6, Last: 3},
+		{SensorID: id, Count: 1, Mean: 5, Min: 5, Max: 5, Sum: 5, Last: 5},
+		{SensorID: id, Count: 1, Mean: 7, Min: 7, Max: 7, Sum: 7, Last: 7},
+	}, aggregate(t))
+
+	recalibration, ok := repo.(usecase.EventRecalibrationRepository)
+	if !ok {
+		return
+	}
+	late.Payload = 9
+	_, err := recalibration.UpdateEventPayloads(ctx, []*domain.Event{late})
+	require.NoError(t, err)
+	rebuilt, err := rollups.RebuildRollups(ctx, []int64{id}, start.Add(time.Minute), start.Add(time.Hour))
+	if errors.Is(err, usecase.ErrRollupsNotSupported) {
+		t.Skip("rollups are not supported")
+	}
+	require.NoError(t, err)
+	// сутки целиком: 4 минуты, 3 часа и сами сутки
+	assert.Equal(t, int64(8), rebuilt)
+	assert.Equal(t, domain.Aggregate{SensorID: id, Count: 3, Mean: 4, Min: 1, Max: 9, Sum: 12, Last: 9}, aggregate(t)[0])
+}
+
+// testEventTransitions - контрактные тесты usecase.EventTransitionRepository
+func testEventTransitions(t *testing.T, repo usecase.EventRepository) {
+	transitions, ok := repo.(usecase.EventTransitionRepository)
+	if !ok {
+		t.Skip("transitions are not supported")
+	}
+	ctx := testContext(t)
+
+	start := now().Truncate(time.Second)
+	id := uniqueID()
+	last, err := transitions.GetLastTransition(ctx, id, "")
+	if errors.Is(err, usecase.ErrTransitionsNotSupported) {
+		t.Skip("transitions are not supported")
+	}
+	assert.ErrorIs(t, err, usecase.ErrNoTransition)
+	assert.Nil(t, last)
+
+	saved := []domain.Transition{
+		{SensorID: id, Timestamp: start.Add(2 * time.Minute), From: 1, To: 0, Lasted: 2 * time.Minute},
+		{SensorID: id, Timestamp: start, From: 0, To: 1},
+		{SensorID: id, Channel: "leak", Timestamp: start.Add(time.Minute), From: 0, To: 1},
+		{SensorID: id, Timestamp: start.Add(10 * time.Minute), From: 0, To: 1, Lasted: 8 * time.Minute},
+		{SensorID: uniqueID(), Timestamp: start, From: 0, To: 1},
+	}
+	for i := range saved {
+		require.NoError(t, transitions.SaveTransition(ctx, &saved[i]))
+	}
+	assert.Error(t, transitions.SaveTransition(ctx, nil))
+
+	assertTransitions := func(t *testing.T, expected, actual []domain.Transition) {
+		t.Helper()
+		require.Len(t, actual, len(expected))
+		for i := range expected {
+			assert.True(t, expected[i].Timestamp.Equal(actual[i].Timestamp), "timestamp of %d: %v", i, actual[i].Timestamp)
+			actual[i].Timestamp = expected[i].Timestamp
+		}
+		assert.Equal(t, expected, actual)
+	}
+
+	// последняя по времени, а не по порядку сохранения, и только своего канала
+	last, err = transitions.GetLastTransition(ctx, id, "")
+	require.NoError(t, err)
+	require.NotNil(t, last)
+	assertTransitions(t, saved[3:4], []domain.Transition{*last})
+	last, err = transitions.GetLastTransition(ctx, id, "leak")
+	require.NoError(t, err)
+	require.NotNil(t, last)
+	assertTransitions(t, saved[2:3], []domain.Transition{*last})
+
+	all := domain.TransitionFilter{Start: start, End: start.Add(10 * time.Minute)}
+	actual, err := transitions.GetTransitions(ctx, id, all)
+	require.NoError(t, err)
+	assertTransitions(t, []domain.Transition{saved[1], saved[2], saved[0], saved[3]}, actual)
+
+	opened := 1.0
+	actual, err = transitions.GetTransitions(ctx, id, domain.TransitionFilter{
+		Start: start, End: start.Add(time.Hour), To: &opened, MinLasted: time.Minute,
+	})
+	require.NoError(t, err)
+	assertTransitions(t, saved[3:4], actual)
+
+	actual, err = transitions.GetTransitions(ctx, id, domain.TransitionFilter{
+		Channel: "leak", Start: start.Add(time.Minute), End: start.Add(time.Minute),
+	})
+	require.NoError(t, err)
+	assertTransitions(t, saved[2:3], actual)
+
+	all.Limit = 2
+	actual, err = transitions.GetTransitions(ctx, id, all)
+	require.NoError(t, err)
+	assertTransitions(t, []domain.Transition{saved[1], saved[2]}, actual)
+}
+
+func saveEvents(t *testing.T, repo usecase.EventRepository, id int64, start time.Time, step time.Duration, n int) []*domain.Event {
+	t.Helper()
+	events := make([]*domain.Event, 0, n)
+	for i := 0; i < n; i++ {
+		event := &domain.Event{
+			Timestamp:          start.Add(time.Duration(i) * step),
+			SensorSerialNumber: "0123456789",
+			SensorID:           id,
+			Payload:            float64(i),
+			Raw:                float64(i),
+			Quality:            domain.Quality(i % 8),
+		}
+		require.NoError(t, repo.SaveEvent(testContext(t), event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func assertEvent(t *testing.T, expected, actual *domain.Event) {
+	t.Helper()
+	require.NotNil(t, actual)
+	assert.True(t, expected.Timestamp.Equal(actual.Timestamp), "timestamp: expected %v, got %v", expected.Timestamp, actual.Timestamp)
+	assert.Equal(t, expected.SensorSerialNumber, actual.SensorSerialNumber)
+	assert.Equal(t, expected.SensorID, actual.SensorID)
+	assert.Equal(t, expected.Payload, actual.Payload)
+	assert.Equal(t, expected.Raw, actual.Raw)
+	assert.Equal(t, expected.Unit, actual.Unit)
+	assert.Equal(t, expected.Channel, actual.Channel)
+	assert.Equal(t, expected.Quality, actual.Quality)
+}
@@ -0,0 +1,47 @@
+// Package repotest - общий набор контрактных тестов для всех реализаций репозиториев.
+//
+// Каждый бэкенд (inmemory, postgres, sqlite, ...) вызывает функции этого пакета из своих
+// тестов, чтобы гарантировать одинаковое поведение. Тесты не рассчитывают на пустое
+// хранилище: фабрика может каждый раз возвращать репозиторий над одной и той же базой.
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// unknownID - ID, которого заведомо нет ни в одном тестовом хранилище
+const unknownID = int64(1) << 40
+
+var counter atomic.Int64
+
+// serialNumber - уникальный в пределах процесса серийный номер из 10 цифр
+func serialNumber() string {
+	return fmt.Sprintf("%010d", uniqueID()%1e10)
+}
+
+// uniqueID - уникальный в пределах процесса ID, не пересекающийся с другими тестами
+func uniqueID() int64 {
+	return time.Now().UnixNano()/1000 + counter.Add(1)
+}
+
+// now - текущее время с точностью, которую сохраняют все бэкенды
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func testContext(t *testing.T) context.Context {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func cancelledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
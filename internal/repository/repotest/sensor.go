@@ -0,0 +1,339 @@
+package repotest
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSensorRepository - контрактные тесты usecase.SensorRepository
+func TestSensorRepository(t *testing.T, newRepo func(t *testing.T) usecase.SensorRepository) {
+	t.Run("err, sensor is nil", func(t *testing.T) {
+		assert.Error(t, newRepo(t).SaveSensor(testContext(t), nil))
+	})
+
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := cancelledContext()
+
+		assert.ErrorIs(t, repo.SaveSensor(ctx, newSensor()), context.Canceled)
+		_, err := repo.GetSensors(ctx)
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = repo.GetSensorByID(ctx, 1)
+		assert.ErrorIs(t, err, context.Canceled)
+		_, err = repo.GetSensorBySerialNumber(ctx, "0123456789")
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, repo.UpdateSensor(ctx, &domain.Sensor{ID: 1}, 1), context.Canceled)
+	})
+
+	t.Run("fail, not found", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		_, err := repo.GetSensorByID(ctx, unknownID)
+		assert.ErrorIs(t, err, usecase.ErrSensorNotFound)
+		_, err = repo.GetSensorBySerialNumber(ctx, serialNumber())
+		assert.ErrorIs(t, err, usecase.ErrSensorNotFound)
+		assert.ErrorIs(t, repo.UpdateSensor(ctx, &domain.Sensor{ID: unknownID, SerialNumber: serialNumber(), Type: domain.SensorTypeADC}, 1),
+			usecase.ErrSensorNotFound)
+	})
+
+	t.Run("ok, save assigns id and registration time", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		sensor := newSensor()
+		require.NoError(t, repo.SaveSensor(ctx, sensor))
+		assert.Positive(t, sensor.ID)
+
+		other := newSensor()
+		require.NoError(t, repo.SaveSensor(ctx, other))
+		assert.NotEqual(t, sensor.ID, other.ID)
+
+		actual, err := repo.GetSensorByID(ctx, sensor.ID)
+		require.NoError(t, err)
+		assert.False(t, actual.RegisteredAt.IsZero())
+		assertSensor(t, sensor, actual)
+	})
+
+	t.Run("ok, get by serial number", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		sensor := newSensor()
+		require.NoError(t, repo.SaveSensor(ctx, sensor))
+
+		actual, err := repo.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+		require.NoError(t, err)
+		assertSensor(t, sensor, actual)
+	})
+
+	t.Run("ok, save with id updates sensor", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		sensor := newSensor()
+		require.NoError(t, repo.SaveSensor(ctx, sensor))
+		saved, err := repo.GetSensorByID(ctx, sensor.ID)
+		require.NoError(t, err)
+
+		updated := *saved
+		updated.CurrentState = 42
+		updated.Description = "updated description"
+		updated.IsActive = false
+		updated.LastActivity = now()
+		require.NoError(t, repo.SaveSensor(ctx, &updated))
+		assert.Equal(t, saved.ID, updated.ID)
+
+		actual, err := repo.GetSensorByID(ctx, sensor.ID)
+		require.NoError(t, err)
+		assertSensor(t, &updated, actual)
+		assert.True(t, saved.RegisteredAt.Equal(actual.RegisteredAt), "registered_at must not change on update")
+	})
+
+	t.Run("ok, unit and scale", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		sensor := newSensor()
+		sensor.Unit = "°C"
+		sensor.Scale = 1
+		sensor.CurrentState = 21.5
+		require.NoError(t, repo.SaveSensor(ctx, sensor))
+
+		actual, err := repo.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+		require.NoError(t, err)
+		assertSensor(t, sensor, actual)
+
+		actual.Unit = "%"
+		actual.Scale = 0
+		require.NoError(t, repo.UpdateSensor(ctx, actual, actual.Version))
+		updated, err := repo.GetSensorByID(ctx, sensor.ID)
+		require.NoError(t, err)
+		assertSensor(t, actual, updated)
+	})
+
+	t.Run("ok, channels", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		sensor := newSensor()
+		sensor.Channels = []domain.Channel{
+			{Name: "temperature", Unit: "°C", Scale: 1},
+			{Name: "humidity", Unit: "%"},
+		}
+		require.NoError(t, repo.SaveSensor(ctx, sensor))
+
+		actual, err := repo.GetSensorByID(ctx, sensor.ID)
+		require.NoError(t, err)
+		assertChannels(t, sensor.Channels, actual.Channels)
+
+		actual.Channels[0].CurrentState = 21.5
+		actual.Channels[0].LastActivity = now()
+		require.NoError(t, repo.SaveSensor(ctx, actual))
+		updated, err := repo.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+		require.NoError(t, err)
+		assertChannels(t, actual.Channels, updated.Channels)
+
+		sensors, err := repo.GetSensors(ctx)
+		require.NoError(t, err)
+		for _, listed := range sensors {
+			if listed.ID == sensor.ID {
+				assertChannels(t, actual.Channels, listed.Channels)
+			}
+		}
+	})
+
+	t.Run("ok, calibration", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		sensor := newSensor()
+		sensor.Calibration = &domain.Calibration{Offset: -40, Gain: 0.5}
+		require.NoError(t, repo.SaveSensor(ctx, sensor))
+		actual, err := repo.GetSensorByID(ctx, sensor.ID)
+		require.NoError(t, err)
+		assertSensor(t, sensor, actual)
+
+		actual.Calibration = nil
+		require.NoError(t, repo.UpdateSensor(ctx, actual, actual.Version))
+		updated, err := repo.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+		require.NoError(t, err)
+		assert.Nil(t, updated.Calibration)
+	})
+
+	t.Run("ok, calibration log", func(t *testing.T) {
+		repo := newRepo(t)
+		calibrations, ok := repo.(usecase.SensorCalibrationRepository)
+		if !ok {
+			t.Skip("calibration log is not supported")
+		}
+		ctx := testContext(t)
+
+		sensor := newSensor()
+		require.NoError(t, repo.SaveSensor(ctx, sensor))
+		profile := &domain.Calibration{Offset: 1, Gain: 2, Table: []domain.CalibrationPoint{{Raw: 0, Value: 0}, {Raw: 10, Value: 20}}}
+		first := &domain.CalibrationChange{Calibration: profile, Reason: "installed", RequestID: "req-1", ChangedAt: now()}
+		version := sensor.Version
+		sensor.Calibration = profile
+		require.NoError(t, calibrations.UpdateSensorCalibration(ctx, sensor, version, first))
+		assert.Equal(t, version+1, sensor.Version)
+		assert.Equal(t, sensor.Version, first.Version)
+
+		// устаревшая версия не меняет ни датчик, ни журнал
+		assert.ErrorIs(t, calibrations.UpdateSensorCalibration(ctx, sensor, version, &domain.CalibrationChange{ChangedAt: now()}),
+			usecase.ErrSensorModified)
+		assert.ErrorIs(t, calibrations.UpdateSensorCalibration(ctx, &domain.Sensor{ID: unknownID, SerialNumber: serialNumber(), Type: domain.SensorTypeADC}, 1,
+			&domain.CalibrationChange{ChangedAt: now()}), usecase.ErrSensorNotFound)
+
+		second := &domain.CalibrationChange{Previous: profile, Reason: "removed", ChangedAt: now().Add(time.Second)}
+		sensor.Calibration = nil
+		require.NoError(t, calibrations.UpdateSensorCalibration(ctx, sensor, sensor.Version, second))
+
+		actual, err := repo.GetSensorByID(ctx, sensor.ID)
+		require.NoError(t, err)
+		assert.Nil(t, actual.Calibration)
+		changes, err := calibrations.GetCalibrationChanges(ctx, sensor.ID)
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+		for i, expected := range []*domain.CalibrationChange{first, second} {
+			assert.Equal(t, sensor.ID, changes[i].SensorID)
+			assert.Equal(t, expected.Version, changes[i].Version)
+			assert.Equal(t, expected.Calibration, changes[i].Calibration)
+			assert.Equal(t, expected.Previous, changes[i].Previous)
+			assert.Equal(t, expected.Reason, changes[i].Reason)
+			assert.Equal(t, expected.RequestID, changes[i].RequestID)
+			assert.True(t, expected.ChangedAt.Equal(changes[i].ChangedAt), "changed_at: expected %v, got %v", expected.ChangedAt, changes[i].ChangedAt)
+		}
+
+		changes, err = calibrations.GetCalibrationChanges(ctx, unknownID)
+		require.NoError(t, err)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("ok, every save increments version", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		sensor := newSensor()
+		require.NoError(t, repo.SaveSensor(ctx, sensor))
+		assert.Equal(t, int64(1), sensor.Version)
+
+		sensor.CurrentState = 2
+		require.NoError(t, repo.SaveSensor(ctx, sensor))
+		assert.Equal(t, int64(2), sensor.Version)
+
+		actual, err := repo.GetSensorByID(ctx, sensor.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), actual.Version)
+	})
+
+	t.Run("ok, update checks version", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		sensor := newSensor()
+		require.NoError(t, repo.SaveSensor(ctx, sensor))
+		first, err := repo.GetSensorByID(ctx, sensor.ID)
+		require.NoError(t, err)
+		second := *first
+
+		first.Description = "first admin"
+		require.NoError(t, repo.UpdateSensor(ctx, first, first.Version))
+		assert.Equal(t, second.Version+1, first.Version)
+
+		// второй читал датчик до первого изменения и не должен его затереть
+		second.Description = "second admin"
+		assert.ErrorIs(t, repo.UpdateSensor(ctx, &second, second.Version), usecase.ErrSensorModified)
+
+		actual, err := repo.GetSensorByID(ctx, sensor.ID)
+		require.NoError(t, err)
+		assertSensor(t, first, actual)
+		assert.Equal(t, first.Version, actual.Version)
+	})
+
+	t.Run("ok, returned sensor is a copy", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		sensor := newSensor()
+		sensor.Channels = []domain.Channel{{Name: "temperature"}}
+		require.NoError(t, repo.SaveSensor(ctx, sensor))
+
+		actual, err := repo.GetSensorByID(ctx, sensor.ID)
+		require.NoError(t, err)
+		actual.CurrentState = 100500
+		actual.Channels[0].CurrentState = 100500
+
+		actual, err = repo.GetSensorByID(ctx, sensor.ID)
+		require.NoError(t, err)
+		assert.Equal(t, sensor.CurrentState, actual.CurrentState)
+		assert.Zero(t, actual.Channels[0].CurrentState)
+	})
+
+	t.Run("ok, get list", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		saved := make(map[int64]*domain.Sensor)
+		for i := 0; i < 3; i++ {
+			sensor := newSensor()
+			require.NoError(t, repo.SaveSensor(ctx, sensor))
+			saved[sensor.ID] = sensor
+		}
+
+		sensors, err := repo.GetSensors(ctx)
+		require.NoError(t, err)
+		for _, actual := range sensors {
+			if expected, ok := saved[actual.ID]; ok {
+				assertSensor(t, expected, &actual)
+				delete(saved, actual.ID)
+			}
+		}
+		assert.Empty(t, saved, "not all saved sensors are listed")
+	})
+}
+
+func newSensor() *domain.Sensor {
+	return &domain.Sensor{
+		SerialNumber: serialNumber(),
+		Type:         domain.SensorTypeADC,
+		CurrentState: 1,
+		Description:  "sensor description",
+		IsActive:     true,
+	}
+}
+
+func assertSensor(t *testing.T, expected, actual *domain.Sensor) {
+	t.Helper()
+	require.NotNil(t, actual)
+	assert.Equal(t, expected.ID, actual.ID)
+	assert.Equal(t, expected.SerialNumber, actual.SerialNumber)
+	assert.Equal(t, expected.Type, actual.Type)
+	assert.Equal(t, expected.CurrentState, actual.CurrentState)
+	assert.Equal(t, expected.Description, actual.Description)
+	assert.Equal(t, expected.IsActive, actual.IsActive)
+	assert.Equal(t, expected.Unit, actual.Unit)
+	assert.Equal(t, expected.Scale, actual.Scale)
+	assert.True(t, expected.LastActivity.Equal(actual.LastActivity), "last_activity: expected %v, got %v", expected.LastActivity, actual.LastActivity)
+	assertChannels(t, expected.Channels, actual.Channels)
+	assert.Equal(t, expected.Calibration, actual.Calibration)
+}
+
+func assertChannels(t *testing.T, expected, actual []domain.Channel) {
+	t.Helper()
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		assert.Equal(t, expected[i].Name, actual[i].Name)
+		assert.Equal(t, expected[i].Unit, actual[i].Unit)
+		assert.Equal(t, expected[i].Scale, actual[i].Scale)
+		assert.Equal(t, expected[i].CurrentState, actual[i].CurrentState)
+		assert.True(t, expected[i].LastActivity.Equal(actual[i].LastActivity),
+			"channel %s last_activity: expected %v, got %v", expected[i].Name, expected[i].LastActivity, actual[i].LastActivity)
+	}
+}
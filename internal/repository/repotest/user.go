@@ -0,0 +1,83 @@
+package repotest
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUserRepository - контрактные тесты usecase.UserRepository
+func TestUserRepository(t *testing.T, newRepo func(t *testing.T) usecase.UserRepository) {
+	t.Run("err, user is nil", func(t *testing.T) {
+		assert.Error(t, newRepo(t).SaveUser(testContext(t), nil))
+	})
+
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := cancelledContext()
+
+		assert.ErrorIs(t, repo.SaveUser(ctx, &domain.User{Name: "user"}), context.Canceled)
+		_, err := repo.GetUserByID(ctx, 1)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("fail, not found", func(t *testing.T) {
+		_, err := newRepo(t).GetUserByID(testContext(t), unknownID)
+		assert.ErrorIs(t, err, usecase.ErrUserNotFound)
+	})
+
+	t.Run("ok, save and get", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		first := &domain.User{Name: "first user", TimeZone: "Europe/Moscow"}
+		require.NoError(t, repo.SaveUser(ctx, first))
+		second := &domain.User{Name: "second user"}
+		require.NoError(t, repo.SaveUser(ctx, second))
+		assert.Positive(t, first.ID)
+		assert.NotEqual(t, first.ID, second.ID)
+
+		actual, err := repo.GetUserByID(ctx, first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, *first, *actual)
+	})
+}
+
+// TestSensorOwnerRepository - контрактные тесты usecase.SensorOwnerRepository
+func TestSensorOwnerRepository(t *testing.T, newRepo func(t *testing.T) usecase.SensorOwnerRepository) {
+	t.Run("fail, ctx cancelled", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := cancelledContext()
+
+		assert.ErrorIs(t, repo.SaveSensorOwner(ctx, domain.SensorOwner{UserID: 1, SensorID: 1}), context.Canceled)
+		_, err := repo.GetSensorsByUserID(ctx, 1)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("ok, unknown user has no sensors", func(t *testing.T) {
+		sensors, err := newRepo(t).GetSensorsByUserID(testContext(t), unknownID)
+		assert.NoError(t, err)
+		assert.Empty(t, sensors)
+	})
+
+	t.Run("ok, save and get", func(t *testing.T) {
+		repo := newRepo(t)
+		ctx := testContext(t)
+
+		userID, otherUserID := uniqueID(), uniqueID()
+		require.NoError(t, repo.SaveSensorOwner(ctx, domain.SensorOwner{UserID: userID, SensorID: 1}))
+		require.NoError(t, repo.SaveSensorOwner(ctx, domain.SensorOwner{UserID: userID, SensorID: 2}))
+		require.NoError(t, repo.SaveSensorOwner(ctx, domain.SensorOwner{UserID: otherUserID, SensorID: 3}))
+
+		sensors, err := repo.GetSensorsByUserID(ctx, userID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []domain.SensorOwner{
+			{UserID: userID, SensorID: 1},
+			{UserID: userID, SensorID: 2},
+		}, sensors)
+	})
+}
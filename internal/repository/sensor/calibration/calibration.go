@@ -0,0 +1,47 @@
+// Package calibration - хранение профилей калибровки датчиков в JSON-колонках SQL-хранилищ.
+package calibration
+
+import (
+	"encoding/json"
+	"homework/internal/domain"
+)
+
+// profile - профиль калибровки в JSON-колонке
+type profile struct {
+	Offset float64 `json:"offset"`
+	Gain   float64 `json:"gain"`
+	Table  []point `json:"table,omitempty"`
+}
+
+type point struct {
+	Raw   float64 `json:"raw"`
+	Value float64 `json:"value"`
+}
+
+// Marshal - профиль для записи в колонку; nil для датчика без калибровки, то есть NULL
+func Marshal(calibration *domain.Calibration) ([]byte, error) {
+	if calibration == nil {
+		return nil, nil
+	}
+	stored := profile{Offset: calibration.Offset, Gain: calibration.Gain}
+	for _, p := range calibration.Table {
+		stored.Table = append(stored.Table, point(p))
+	}
+	return json.Marshal(stored)
+}
+
+// Unmarshal - профиль из колонки, nil для NULL
+func Unmarshal(data []byte) (*domain.Calibration, error) {
+	if data == nil {
+		return nil, nil
+	}
+	var stored profile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	calibration := &domain.Calibration{Offset: stored.Offset, Gain: stored.Gain}
+	for _, p := range stored.Table {
+		calibration.Table = append(calibration.Table, domain.CalibrationPoint(p))
+	}
+	return calibration, nil
+}
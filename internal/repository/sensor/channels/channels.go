@@ -0,0 +1,42 @@
+// Package channels - хранение каналов многоканальных датчиков в JSON-колонке SQL-хранилищ.
+package channels
+
+import (
+	"encoding/json"
+	"homework/internal/domain"
+	"time"
+)
+
+// channel - канал датчика в JSON-колонке channels
+type channel struct {
+	Name         string    `json:"name"`
+	Unit         string    `json:"unit,omitempty"`
+	Scale        int       `json:"scale,omitempty"`
+	CurrentState float64   `json:"current_state"`
+	LastActivity time.Time `json:"last_activity"`
+}
+
+// Marshal - каналы датчика для записи в колонку channels
+func Marshal(channels []domain.Channel) ([]byte, error) {
+	stored := make([]channel, 0, len(channels))
+	for _, c := range channels {
+		stored = append(stored, channel(c))
+	}
+	return json.Marshal(stored)
+}
+
+// Unmarshal - каналы датчика из колонки channels, nil для датчиков без каналов
+func Unmarshal(data []byte) ([]domain.Channel, error) {
+	var stored []channel
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	if len(stored) == 0 {
+		return nil, nil
+	}
+	channels := make([]domain.Channel, 0, len(stored))
+	for _, c := range stored {
+		channels = append(channels, domain.Channel(c))
+	}
+	return channels, nil
+}
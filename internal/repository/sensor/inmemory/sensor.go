@@ -1,10 +1,13 @@
 package inmemory
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"homework/internal/domain"
 	"homework/internal/usecase"
+	"maps"
+	"slices"
 	"sync"
 	"time"
 )
@@ -14,13 +17,16 @@ type SensorRepository struct {
 	serialToId map[string]int64
 	sensors    map[int64]*domain.Sensor
 	nextID     int64
+	// calibrations - журналы калибровки по id датчика
+	calibrations map[int64][]domain.CalibrationChange
 }
 
 func NewSensorRepository() *SensorRepository {
 	return &SensorRepository{
-		serialToId: make(map[string]int64),
-		sensors:    make(map[int64]*domain.Sensor),
-		nextID:     1,
+		serialToId:   make(map[string]int64),
+		sensors:      make(map[int64]*domain.Sensor),
+		nextID:       1,
+		calibrations: make(map[int64][]domain.CalibrationChange),
 	}
 }
 
@@ -34,18 +40,110 @@ func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor
 	default:
 		r.mu.Lock()
 		defer r.mu.Unlock()
-		if _, ok := r.serialToId[sensor.SerialNumber]; ok {
+		if existing, ok := r.sensors[sensor.ID]; ok {
+			// обновление: дата регистрации не меняется, как и в postgres
+			r.update(existing, sensor)
 			return nil
 		}
-		sensor.ID = r.nextID
+		if id, ok := r.serialToId[sensor.SerialNumber]; ok && sensor.ID == 0 {
+			sensor.ID = id
+			sensor.Version = r.sensors[id].Version
+			return nil
+		}
+		if sensor.ID == 0 {
+			sensor.ID = r.nextID
+		}
+		if sensor.ID >= r.nextID {
+			r.nextID = sensor.ID + 1
+		}
 		sensor.RegisteredAt = time.Now()
-		r.serialToId[sensor.SerialNumber] = r.nextID
-		r.sensors[r.nextID] = sensor
-		r.nextID++
+		sensor.Version = 1
+		saved := sensor.Clone()
+		r.serialToId[sensor.SerialNumber] = sensor.ID
+		r.sensors[sensor.ID] = &saved
 	}
 	return nil
 }
 
+func (r *SensorRepository) UpdateSensor(ctx context.Context, sensor *domain.Sensor, version int64) error {
+	if sensor == nil {
+		return errors.New("sensor is nil")
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		existing, ok := r.sensors[sensor.ID]
+		if !ok {
+			return usecase.ErrSensorNotFound
+		}
+		if existing.Version != version {
+			return usecase.ErrSensorModified
+		}
+		r.update(existing, sensor)
+		return nil
+	}
+}
+
+func (r *SensorRepository) UpdateSensorCalibration(ctx context.Context, sensor *domain.Sensor, version int64, change *domain.CalibrationChange) error {
+	if sensor == nil || change == nil {
+		return errors.New("sensor or calibration change is nil")
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		existing, ok := r.sensors[sensor.ID]
+		if !ok {
+			return usecase.ErrSensorNotFound
+		}
+		if existing.Version != version {
+			return usecase.ErrSensorModified
+		}
+		r.update(existing, sensor)
+		change.SensorID = sensor.ID
+		change.Version = sensor.Version
+		r.calibrations[sensor.ID] = append(r.calibrations[sensor.ID], cloneChange(*change))
+		return nil
+	}
+}
+
+func (r *SensorRepository) GetCalibrationChanges(ctx context.Context, sensorID int64) ([]domain.CalibrationChange, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		changes := make([]domain.CalibrationChange, 0, len(r.calibrations[sensorID]))
+		for _, change := range r.calibrations[sensorID] {
+			changes = append(changes, cloneChange(change))
+		}
+		return changes, nil
+	}
+}
+
+func cloneChange(change domain.CalibrationChange) domain.CalibrationChange {
+	change.Calibration = change.Calibration.Clone()
+	change.Previous = change.Previous.Clone()
+	return change
+}
+
+// update - записывает sensor поверх existing и увеличивает версию; вызывается под r.mu
+func (r *SensorRepository) update(existing, sensor *domain.Sensor) {
+	// дата регистрации не меняется, как и в postgres
+	registeredAt := existing.RegisteredAt
+	sensor.Version = existing.Version + 1
+	delete(r.serialToId, existing.SerialNumber)
+	*existing = sensor.Clone()
+	existing.RegisteredAt = registeredAt
+	r.serialToId[sensor.SerialNumber] = sensor.ID
+}
+
 func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
 	select {
 	case <-ctx.Done():
@@ -55,8 +153,11 @@ func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, err
 		defer r.mu.RUnlock()
 		sensors := make([]domain.Sensor, 0, len(r.sensors))
 		for _, sensor := range r.sensors {
-			sensors = append(sensors, *sensor)
+			sensors = append(sensors, sensor.Clone())
 		}
+		slices.SortFunc(sensors, func(a, b domain.Sensor) int {
+			return cmp.Compare(a.ID, b.ID)
+		})
 		return sensors, nil
 	}
 }
@@ -68,14 +169,94 @@ func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (*domain
 	default:
 		r.mu.RLock()
 		defer r.mu.RUnlock()
-		sensor, exists := r.sensors[id]
-		if !exists {
+		return r.getSensorByID(id)
+	}
+}
+
+func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		id, ok := r.serialToId[sn]
+		if !ok {
 			return nil, usecase.ErrSensorNotFound
 		}
-		return sensor, nil
+		return r.getSensorByID(id)
 	}
 }
 
-func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
-	return r.GetSensorByID(ctx, r.serialToId[sn])
+// getSensorByID - возвращает копию датчика, чтобы вызывающий не мог изменить хранилище в обход SaveSensor
+func (r *SensorRepository) getSensorByID(id int64) (*domain.Sensor, error) {
+	sensor, exists := r.sensors[id]
+	if !exists {
+		return nil, usecase.ErrSensorNotFound
+	}
+	found := sensor.Clone()
+	return &found, nil
+}
+
+// NextID - id, который получит следующий новый датчик
+func (r *SensorRepository) NextID() int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.nextID
+}
+
+// Dump - возвращает копию всех датчиков, используется для снапшотов
+func (r *SensorRepository) Dump() []domain.Sensor {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sensors := make([]domain.Sensor, 0, len(r.sensors))
+	for _, sensor := range r.sensors {
+		sensors = append(sensors, sensor.Clone())
+	}
+	slices.SortFunc(sensors, func(a, b domain.Sensor) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return sensors
+}
+
+// Restore - кладёт датчики в хранилище с их ID, заменяя существующие.
+// Используется при восстановлении состояния из снапшота и журнала.
+func (r *SensorRepository) Restore(sensors ...domain.Sensor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, sensor := range sensors {
+		sensor = sensor.Clone()
+		if existing, ok := r.sensors[sensor.ID]; ok {
+			delete(r.serialToId, existing.SerialNumber)
+			*existing = sensor
+		} else {
+			r.sensors[sensor.ID] = &sensor
+		}
+		r.serialToId[sensor.SerialNumber] = sensor.ID
+		if sensor.ID >= r.nextID {
+			r.nextID = sensor.ID + 1
+		}
+	}
+}
+
+// DumpCalibrationChanges - возвращает копию журналов калибровки всех датчиков, используется для снапшотов
+func (r *SensorRepository) DumpCalibrationChanges() []domain.CalibrationChange {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var changes []domain.CalibrationChange
+	for _, id := range slices.Sorted(maps.Keys(r.calibrations)) {
+		for _, change := range r.calibrations[id] {
+			changes = append(changes, cloneChange(change))
+		}
+	}
+	return changes
+}
+
+// RestoreCalibrationChanges - дописывает записи в журналы калибровки, используется при восстановлении состояния
+func (r *SensorRepository) RestoreCalibrationChanges(changes ...domain.CalibrationChange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, change := range changes {
+		r.calibrations[change.SensorID] = append(r.calibrations[change.SensorID], cloneChange(change))
+	}
 }
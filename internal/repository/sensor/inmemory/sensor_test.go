@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"homework/internal/domain"
+	"homework/internal/repository/repotest"
 	"homework/internal/usecase"
 	"math/rand/v2"
 	"strings"
@@ -270,3 +271,9 @@ func TestGenerateRandomNumbersString(t *testing.T) {
 		}
 	}
 }
+
+func TestSensorRepository_Conformance(t *testing.T) {
+	repotest.TestSensorRepository(t, func(*testing.T) usecase.SensorRepository {
+		return NewSensorRepository()
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"homework/internal/domain"
+	"homework/internal/repository/sensor/calibration"
+	"homework/internal/repository/sensor/channels"
 	"homework/internal/usecase"
 	"strings"
 	"time"
@@ -24,8 +26,19 @@ func NewSensorRepository(pool *pgxpool.Pool) *SensorRepository {
 }
 
 func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) error {
+	if sensor == nil {
+		return errors.New("sensor is nil")
+	}
+	stored, err := channels.Marshal(sensor.Channels)
+	if err != nil {
+		return err
+	}
+	profile, err := calibration.Marshal(sensor.Calibration)
+	if err != nil {
+		return err
+	}
 	//goland:noinspection SqlInsertValues
-	query := `INSERT INTO sensors (%s) VALUES (%s) %s RETURNING id`
+	query := `INSERT INTO sensors (%s) VALUES (%s) %s RETURNING id, version`
 
 	var columns []string
 	var placeholders []string
@@ -52,6 +65,10 @@ func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor
 		{"is_active", sensor.IsActive},
 		{"registered_at", time.Now()},
 		{"last_activity", sensor.LastActivity},
+		{"unit", sensor.Unit},
+		{"scale", sensor.Scale},
+		{"channels", stored},
+		{"calibration", profile},
 	}
 
 	for _, field := range fields {
@@ -68,7 +85,12 @@ func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor
 			current_state = EXCLUDED.current_state,
 			description = EXCLUDED.description,
 			is_active = EXCLUDED.is_active,
-			last_activity = EXCLUDED.last_activity`
+			last_activity = EXCLUDED.last_activity,
+			unit = EXCLUDED.unit,
+			scale = EXCLUDED.scale,
+			channels = EXCLUDED.channels,
+			calibration = EXCLUDED.calibration,
+			version = sensors.version + 1`
 	} else {
 		conflictClause = ""
 	}
@@ -76,16 +98,128 @@ func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor
 	finalQuery := fmt.Sprintf(query, strings.Join(columns, ", "), strings.Join(placeholders, ", "), conflictClause)
 
 	row := r.pool.QueryRow(ctx, finalQuery, values...)
-	return row.Scan(&sensor.ID)
+	return row.Scan(&sensor.ID, &sensor.Version)
+}
+
+func (r *SensorRepository) UpdateSensor(ctx context.Context, sensor *domain.Sensor, version int64) error {
+	return r.update(ctx, r.pool, sensor, version)
+}
+
+func (r *SensorRepository) UpdateSensorCalibration(ctx context.Context, sensor *domain.Sensor, version int64, change *domain.CalibrationChange) error {
+	if change == nil {
+		return errors.New("calibration change is nil")
+	}
+	profile, err := calibration.Marshal(change.Calibration)
+	if err != nil {
+		return err
+	}
+	previous, err := calibration.Marshal(change.Previous)
+	if err != nil {
+		return err
+	}
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := r.update(ctx, tx, sensor, version); err != nil {
+			return err
+		}
+		change.SensorID = sensor.ID
+		change.Version = sensor.Version
+		_, err := tx.Exec(ctx, `INSERT INTO sensor_calibrations (sensor_id, version, calibration, previous, reason, request_id, changed_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			change.SensorID, change.Version, profile, previous, change.Reason, change.RequestID, change.ChangedAt)
+		return err
+	})
 }
 
+func (r *SensorRepository) GetCalibrationChanges(ctx context.Context, sensorID int64) ([]domain.CalibrationChange, error) {
+	rows, err := r.pool.Query(ctx, `SELECT sensor_id, version, calibration, previous, reason, request_id, changed_at
+		FROM sensor_calibrations WHERE sensor_id = $1 ORDER BY id`, sensorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	changes := []domain.CalibrationChange{}
+	for rows.Next() {
+		var change domain.CalibrationChange
+		var profile, previous []byte
+		if err := rows.Scan(&change.SensorID, &change.Version, &profile, &previous, &change.Reason, &change.RequestID, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+		if change.Calibration, err = calibration.Unmarshal(profile); err != nil {
+			return nil, err
+		}
+		if change.Previous, err = calibration.Unmarshal(previous); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// querier - пул или транзакция
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func (r *SensorRepository) update(ctx context.Context, q querier, sensor *domain.Sensor, version int64) error {
+	if sensor == nil {
+		return errors.New("sensor is nil")
+	}
+	stored, err := channels.Marshal(sensor.Channels)
+	if err != nil {
+		return err
+	}
+	profile, err := calibration.Marshal(sensor.Calibration)
+	if err != nil {
+		return err
+	}
+	row := q.QueryRow(ctx, `UPDATE sensors SET
+			serial_number = $2,
+			type = $3,
+			current_state = $4,
+			description = $5,
+			is_active = $6,
+			last_activity = $7,
+			unit = $8,
+			scale = $9,
+			channels = $10,
+			calibration = $11,
+			version = version + 1
+		WHERE id = $1 AND version = $12
+		RETURNING version`,
+		sensor.ID, sensor.SerialNumber, sensor.Type, sensor.CurrentState, sensor.Description, sensor.IsActive,
+		sensor.LastActivity, sensor.Unit, sensor.Scale, stored, profile, version)
+	err = row.Scan(&sensor.Version)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	// строка не обновилась: датчика нет или его версия уже другая
+	var exists bool
+	if err := q.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM sensors WHERE id = $1)`, sensor.ID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return usecase.ErrSensorNotFound
+	}
+	return usecase.ErrSensorModified
+}
+
+const selectSensor = `SELECT id,
+       serial_number,
+       type,
+       current_state,
+       description,
+       is_active,
+       registered_at,
+       last_activity,
+       version,
+       unit,
+       scale,
+       channels,
+       calibration FROM sensors`
+
 func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
-	rows, err := r.pool.Query(ctx, `SELECT id,
-       									serial_number, 
-       									type, current_state, 
-       									description, is_active, 
-       									registered_at, 
-       									last_activity FROM sensors`)
+	rows, err := r.pool.Query(ctx, selectSensor+` ORDER BY id`)
 	if err != nil {
 		return nil, err
 	}
@@ -93,26 +227,26 @@ func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, err
 	var sensors []domain.Sensor
 
 	for rows.Next() {
-		sensor := &domain.Sensor{}
-		if err := rows.Scan(&sensor.ID, &sensor.SerialNumber, &sensor.Type, &sensor.CurrentState, &sensor.Description, &sensor.IsActive, &sensor.RegisteredAt, &sensor.LastActivity); err != nil {
+		sensor, err := scanSensor(rows)
+		if err != nil {
 			return nil, err
 		}
 		sensors = append(sensors, *sensor)
 	}
-	return sensors, nil
+	return sensors, rows.Err()
 }
 
 func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
-	row := r.pool.QueryRow(ctx, `SELECT id, 
-       								serial_number, 
-       								type, 
-       								current_state, 
-       								description, 
-       								is_active, 
-       								registered_at, 
-       								last_activity FROM sensors WHERE id = $1`, id)
-	sensor := &domain.Sensor{}
-	if err := row.Scan(&sensor.ID, &sensor.SerialNumber, &sensor.Type, &sensor.CurrentState, &sensor.Description, &sensor.IsActive, &sensor.RegisteredAt, &sensor.LastActivity); err != nil {
+	return r.getSensor(ctx, selectSensor+` WHERE id = $1`, id)
+}
+
+func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
+	return r.getSensor(ctx, selectSensor+` WHERE serial_number = $1`, sn)
+}
+
+func (r *SensorRepository) getSensor(ctx context.Context, query string, arg any) (*domain.Sensor, error) {
+	sensor, err := scanSensor(r.pool.QueryRow(ctx, query, arg))
+	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, usecase.ErrSensorNotFound
 		}
@@ -121,20 +255,18 @@ func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (*domain
 	return sensor, nil
 }
 
-func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
-	row := r.pool.QueryRow(ctx, `SELECT id, 
-       								serial_number, 
-       								type, 
-       								current_state, 
-       								description, 
-       								is_active, 
-       								registered_at, 
-       								last_activity FROM sensors WHERE serial_number = $1`, sn)
+func scanSensor(row pgx.Row) (*domain.Sensor, error) {
 	sensor := &domain.Sensor{}
-	if err := row.Scan(&sensor.ID, &sensor.SerialNumber, &sensor.Type, &sensor.CurrentState, &sensor.Description, &sensor.IsActive, &sensor.RegisteredAt, &sensor.LastActivity); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, usecase.ErrSensorNotFound
-		}
+	var stored, profile []byte
+	err := row.Scan(&sensor.ID, &sensor.SerialNumber, &sensor.Type, &sensor.CurrentState, &sensor.Description, &sensor.IsActive,
+		&sensor.RegisteredAt, &sensor.LastActivity, &sensor.Version, &sensor.Unit, &sensor.Scale, &stored, &profile)
+	if err != nil {
+		return nil, err
+	}
+	if sensor.Channels, err = channels.Unmarshal(stored); err != nil {
+		return nil, err
+	}
+	if sensor.Calibration, err = calibration.Unmarshal(profile); err != nil {
 		return nil, err
 	}
 	return sensor, nil
@@ -3,6 +3,8 @@ package postgres
 import (
 	"context"
 	"homework/internal/domain"
+	"homework/internal/repository/repotest"
+	"homework/internal/usecase"
 	"homework/pkg/pg_test"
 	"testing"
 	"time"
@@ -32,7 +34,8 @@ func (suite *SensorTestSuite) TearDownSuite() {
 }
 
 func (suite *SensorTestSuite) TestSensorRepository_SaveSensor() {
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint: govet // test stub
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
 	sn := "1234567890"
 
@@ -81,7 +84,8 @@ func (suite *SensorTestSuite) TestSensorRepository_SaveSensor() {
 }
 
 func (suite *SensorTestSuite) TestSensorRepository_GetSensors() {
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint: govet // test stub
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
 	sn := "0987654321"
 
@@ -112,7 +116,8 @@ func (suite *SensorTestSuite) TestSensorRepository_GetSensors() {
 }
 
 func (suite *SensorTestSuite) TestSensorRepository_GetSensorByID() {
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint: govet // test stub
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
 	sn := "1987654321"
 
@@ -143,7 +148,8 @@ func (suite *SensorTestSuite) TestSensorRepository_GetSensorByID() {
 }
 
 func (suite *SensorTestSuite) TestSensorRepository_GetSensorBySerialNumber() {
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint: govet // test stub
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
 	sn := "2987654321"
 
@@ -169,6 +175,12 @@ func (suite *SensorTestSuite) TestSensorRepository_GetSensorBySerialNumber() {
 	assert.Equal(suite.T(), newSensor, *sensor)
 }
 
+func (suite *SensorTestSuite) TestConformance() {
+	repotest.TestSensorRepository(suite.T(), func(*testing.T) usecase.SensorRepository {
+		return suite.repo
+	})
+}
+
 func TestSensorTestSuite(t *testing.T) {
 	suite.Run(t, new(SensorTestSuite))
 }
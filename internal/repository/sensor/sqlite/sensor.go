@@ -0,0 +1,290 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/repository/sensor/calibration"
+	"homework/internal/repository/sensor/channels"
+	"homework/internal/usecase"
+	"time"
+
+	"homework/pkg/sqlite"
+)
+
+const selectSensor = `SELECT id,
+       serial_number,
+       type,
+       current_state,
+       description,
+       is_active,
+       registered_at,
+       last_activity,
+       version,
+       unit,
+       scale,
+       channels,
+       calibration FROM sensors`
+
+type SensorRepository struct {
+	db *sql.DB
+}
+
+func NewSensorRepository(db *sql.DB) *SensorRepository {
+	return &SensorRepository{
+		db: db,
+	}
+}
+
+func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) error {
+	if sensor == nil {
+		return errors.New("sensor is nil")
+	}
+
+	var id any
+	if sensor.ID != 0 {
+		id = sensor.ID
+	}
+	stored, err := channels.Marshal(sensor.Channels)
+	if err != nil {
+		return err
+	}
+	profile, err := calibration.Marshal(sensor.Calibration)
+	if err != nil {
+		return err
+	}
+
+	row := r.db.QueryRowContext(ctx, `INSERT INTO sensors (id, serial_number, type, current_state, description, is_active, registered_at, last_activity, unit, scale, channels, calibration)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			serial_number = excluded.serial_number,
+			type = excluded.type,
+			current_state = excluded.current_state,
+			description = excluded.description,
+			is_active = excluded.is_active,
+			last_activity = excluded.last_activity,
+			unit = excluded.unit,
+			scale = excluded.scale,
+			channels = excluded.channels,
+			calibration = excluded.calibration,
+			version = sensors.version + 1
+		RETURNING id, version`,
+		id,
+		sensor.SerialNumber,
+		sensor.Type,
+		sensor.CurrentState,
+		sensor.Description,
+		sensor.IsActive,
+		sqlite.TimeValue(time.Now()),
+		sqlite.TimeValue(sensor.LastActivity),
+		sensor.Unit,
+		sensor.Scale,
+		string(stored),
+		nullJSON(profile),
+	)
+	return row.Scan(&sensor.ID, &sensor.Version)
+}
+
+func (r *SensorRepository) UpdateSensor(ctx context.Context, sensor *domain.Sensor, version int64) error {
+	return r.update(ctx, r.db, sensor, version)
+}
+
+func (r *SensorRepository) UpdateSensorCalibration(ctx context.Context, sensor *domain.Sensor, version int64, change *domain.CalibrationChange) (err error) {
+	if change == nil {
+		return errors.New("calibration change is nil")
+	}
+	profile, err := calibration.Marshal(change.Calibration)
+	if err != nil {
+		return err
+	}
+	previous, err := calibration.Marshal(change.Previous)
+	if err != nil {
+		return err
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if err := r.update(ctx, tx, sensor, version); err != nil {
+		return err
+	}
+	change.SensorID = sensor.ID
+	change.Version = sensor.Version
+	_, err = tx.ExecContext(ctx, `INSERT INTO sensor_calibrations (sensor_id, version, calibration, previous, reason, request_id, changed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		change.SensorID, change.Version, nullJSON(profile), nullJSON(previous), change.Reason, change.RequestID, sqlite.TimeValue(change.ChangedAt))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SensorRepository) GetCalibrationChanges(ctx context.Context, sensorID int64) ([]domain.CalibrationChange, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT sensor_id, version, calibration, previous, reason, request_id, changed_at
+		FROM sensor_calibrations WHERE sensor_id = ? ORDER BY id`, sensorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	changes := []domain.CalibrationChange{}
+	for rows.Next() {
+		var change domain.CalibrationChange
+		var profile, previous []byte
+		if err := rows.Scan(&change.SensorID, &change.Version, &profile, &previous, &change.Reason, &change.RequestID, sqlite.ScanTime(&change.ChangedAt)); err != nil {
+			return nil, err
+		}
+		if change.Calibration, err = calibration.Unmarshal(profile); err != nil {
+			return nil, err
+		}
+		if change.Previous, err = calibration.Unmarshal(previous); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+// querier - база или транзакция
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (r *SensorRepository) update(ctx context.Context, q querier, sensor *domain.Sensor, version int64) error {
+	if sensor == nil {
+		return errors.New("sensor is nil")
+	}
+	stored, err := channels.Marshal(sensor.Channels)
+	if err != nil {
+		return err
+	}
+	profile, err := calibration.Marshal(sensor.Calibration)
+	if err != nil {
+		return err
+	}
+	row := q.QueryRowContext(ctx, `UPDATE sensors SET
+			serial_number = ?,
+			type = ?,
+			current_state = ?,
+			description = ?,
+			is_active = ?,
+			last_activity = ?,
+			unit = ?,
+			scale = ?,
+			channels = ?,
+			calibration = ?,
+			version = version + 1
+		WHERE id = ? AND version = ?
+		RETURNING version`,
+		sensor.SerialNumber,
+		sensor.Type,
+		sensor.CurrentState,
+		sensor.Description,
+		sensor.IsActive,
+		sqlite.TimeValue(sensor.LastActivity),
+		sensor.Unit,
+		sensor.Scale,
+		string(stored),
+		nullJSON(profile),
+		sensor.ID,
+		version,
+	)
+	err = row.Scan(&sensor.Version)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// строка не обновилась: датчика нет или его версия уже другая
+	var exists bool
+	if err := q.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM sensors WHERE id = ?)`, sensor.ID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return usecase.ErrSensorNotFound
+	}
+	return usecase.ErrSensorModified
+}
+
+// nullJSON - JSON для текстовой колонки, NULL вместо nil
+func nullJSON(data []byte) any {
+	if data == nil {
+		return nil
+	}
+	return string(data)
+}
+
+func (r *SensorRepository) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
+	rows, err := r.db.QueryContext(ctx, selectSensor+` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sensors []domain.Sensor
+
+	for rows.Next() {
+		sensor, err := scanSensor(rows)
+		if err != nil {
+			return nil, err
+		}
+		sensors = append(sensors, *sensor)
+	}
+	return sensors, rows.Err()
+}
+
+func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
+	return r.getSensor(ctx, selectSensor+` WHERE id = ?`, id)
+}
+
+func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error) {
+	return r.getSensor(ctx, selectSensor+` WHERE serial_number = ?`, sn)
+}
+
+func (r *SensorRepository) getSensor(ctx context.Context, query string, arg any) (*domain.Sensor, error) {
+	sensor, err := scanSensor(r.db.QueryRowContext(ctx, query, arg))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, usecase.ErrSensorNotFound
+		}
+		return nil, err
+	}
+	return sensor, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSensor(row scanner) (*domain.Sensor, error) {
+	sensor := &domain.Sensor{}
+	var stored, profile []byte
+	err := row.Scan(
+		&sensor.ID,
+		&sensor.SerialNumber,
+		&sensor.Type,
+		&sensor.CurrentState,
+		&sensor.Description,
+		&sensor.IsActive,
+		sqlite.ScanTime(&sensor.RegisteredAt),
+		sqlite.ScanTime(&sensor.LastActivity),
+		&sensor.Version,
+		&sensor.Unit,
+		&sensor.Scale,
+		&stored,
+		&profile,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if sensor.Channels, err = channels.Unmarshal(stored); err != nil {
+		return nil, err
+	}
+	if sensor.Calibration, err = calibration.Unmarshal(profile); err != nil {
+		return nil, err
+	}
+	return sensor, nil
+}
@@ -0,0 +1,240 @@
+// Package traced - декораторы репозиториев, открывающие спан на каждый вызов.
+//
+// Спаны репозиториев становятся дочерними для спанов usecase, поэтому в трассе видно,
+// сколько обращений к хранилищу сделал один запрос. Необязательные возможности вроде
+// usecase.EventRetentionRepository сохраняются.
+package traced
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/tracing"
+	"homework/internal/usecase"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("homework/internal/repository")
+
+type SensorRepository struct {
+	inner usecase.SensorRepository
+}
+
+func NewSensorRepository(inner usecase.SensorRepository) *SensorRepository {
+	return &SensorRepository{inner: inner}
+}
+
+func (r *SensorRepository) SaveSensor(ctx context.Context, sensor *domain.Sensor) (err error) {
+	ctx, span := tracer.Start(ctx, "SensorRepository.SaveSensor")
+	defer func() { tracing.End(span, err) }()
+	return r.inner.SaveSensor(ctx, sensor)
+}
+
+func (r *SensorRepository) GetSensors(ctx context.Context) (_ []domain.Sensor, err error) {
+	ctx, span := tracer.Start(ctx, "SensorRepository.GetSensors")
+	defer func() { tracing.End(span, err) }()
+	return r.inner.GetSensors(ctx)
+}
+
+func (r *SensorRepository) GetSensorByID(ctx context.Context, id int64) (_ *domain.Sensor, err error) {
+	ctx, span := tracer.Start(ctx, "SensorRepository.GetSensorByID", trace.WithAttributes(attribute.Int64("sensor.id", id)))
+	defer func() { tracing.End(span, err) }()
+	return r.inner.GetSensorByID(ctx, id)
+}
+
+func (r *SensorRepository) GetSensorBySerialNumber(ctx context.Context, sn string) (_ *domain.Sensor, err error) {
+	ctx, span := tracer.Start(ctx, "SensorRepository.GetSensorBySerialNumber",
+		trace.WithAttributes(attribute.String("sensor.serial_number", sn)))
+	defer func() { tracing.End(span, err) }()
+	return r.inner.GetSensorBySerialNumber(ctx, sn)
+}
+
+func (r *SensorRepository) UpdateSensor(ctx context.Context, sensor *domain.Sensor, version int64) (err error) {
+	var attrs []attribute.KeyValue
+	if sensor != nil {
+		attrs = append(attrs, attribute.Int64("sensor.id", sensor.ID))
+	}
+	ctx, span := tracer.Start(ctx, "SensorRepository.UpdateSensor", trace.WithAttributes(attrs...))
+	defer func() { tracing.End(span, err) }()
+	return r.inner.UpdateSensor(ctx, sensor, version)
+}
+
+// UpdateSensorCalibration - передаёт вызов, если обёрнутое хранилище ведёт журнал калибровки
+func (r *SensorRepository) UpdateSensorCalibration(ctx context.Context, sensor *domain.Sensor, version int64, change *domain.CalibrationChange) (err error) {
+	calibrations, ok := r.inner.(usecase.SensorCalibrationRepository)
+	if !ok {
+		return usecase.ErrCalibrationNotSupported
+	}
+	var attrs []attribute.KeyValue
+	if sensor != nil {
+		attrs = append(attrs, attribute.Int64("sensor.id", sensor.ID))
+	}
+	ctx, span := tracer.Start(ctx, "SensorRepository.UpdateSensorCalibration", trace.WithAttributes(attrs...))
+	defer func() { tracing.End(span, err) }()
+	return calibrations.UpdateSensorCalibration(ctx, sensor, version, change)
+}
+
+// GetCalibrationChanges - передаёт вызов, если обёрнутое хранилище ведёт журнал калибровки
+func (r *SensorRepository) GetCalibrationChanges(ctx context.Context, sensorID int64) (_ []domain.CalibrationChange, err error) {
+	calibrations, ok := r.inner.(usecase.SensorCalibrationRepository)
+	if !ok {
+		return nil, usecase.ErrCalibrationNotSupported
+	}
+	ctx, span := tracer.Start(ctx, "SensorRepository.GetCalibrationChanges", trace.WithAttributes(attribute.Int64("sensor.id", sensorID)))
+	defer func() { tracing.End(span, err) }()
+	return calibrations.GetCalibrationChanges(ctx, sensorID)
+}
+
+type EventRepository struct {
+	inner usecase.EventRepository
+}
+
+func NewEventRepository(inner usecase.EventRepository) *EventRepository {
+	return &EventRepository{inner: inner}
+}
+
+func (r *EventRepository) SaveEvent(ctx context.Context, event *domain.Event) (err error) {
+	ctx, span := tracer.Start(ctx, "EventRepository.SaveEvent")
+	defer func() { tracing.End(span, err) }()
+	return r.inner.SaveEvent(ctx, event)
+}
+
+func (r *EventRepository) GetLastEventBySensorID(ctx context.Context, id int64) (_ *domain.Event, err error) {
+	ctx, span := tracer.Start(ctx, "EventRepository.GetLastEventBySensorID", trace.WithAttributes(attribute.Int64("sensor.id", id)))
+	defer func() { tracing.End(span, err) }()
+	return r.inner.GetLastEventBySensorID(ctx, id)
+}
+
+func (r *EventRepository) GetEventsBySensorID(ctx context.Context, id int64, channel string, start, end time.Time) (_ []*domain.Event, err error) {
+	ctx, span := tracer.Start(ctx, "EventRepository.GetEventsBySensorID",
+		trace.WithAttributes(attribute.Int64("sensor.id", id), attribute.String("event.channel", channel)))
+	defer func() { tracing.End(span, err) }()
+	return r.inner.GetEventsBySensorID(ctx, id, channel, start, end)
+}
+
+// DeleteEventsBefore - передаёт вызов, если обёрнутое хранилище умеет удалять события
+func (r *EventRepository) DeleteEventsBefore(ctx context.Context, before time.Time) (_ int64, err error) {
+	retention, ok := r.inner.(usecase.EventRetentionRepository)
+	if !ok {
+		return 0, usecase.ErrRetentionNotSupported
+	}
+	ctx, span := tracer.Start(ctx, "EventRepository.DeleteEventsBefore")
+	defer func() { tracing.End(span, err) }()
+	return retention.DeleteEventsBefore(ctx, before)
+}
+
+// UpdateEventPayloads - передаёт вызов, если обёрнутое хранилище умеет менять значения событий
+func (r *EventRepository) UpdateEventPayloads(ctx context.Context, events []*domain.Event) (_ int64, err error) {
+	recalibration, ok := r.inner.(usecase.EventRecalibrationRepository)
+	if !ok {
+		return 0, usecase.ErrRecalibrationNotSupported
+	}
+	ctx, span := tracer.Start(ctx, "EventRepository.UpdateEventPayloads", trace.WithAttributes(attribute.Int("events", len(events))))
+	defer func() { tracing.End(span, err) }()
+	return recalibration.UpdateEventPayloads(ctx, events)
+}
+
+// AggregateEvents - передаёт вызов, если обёрнутое хранилище умеет сводить события
+func (r *EventRepository) AggregateEvents(ctx context.Context, ids []int64, start, end time.Time, bucket time.Duration) (_ []domain.Aggregate, err error) {
+	aggregation, ok := r.inner.(usecase.EventAggregationRepository)
+	if !ok {
+		return nil, usecase.ErrAggregationNotSupported
+	}
+	ctx, span := tracer.Start(ctx, "EventRepository.AggregateEvents", trace.WithAttributes(
+		attribute.Int64Slice("sensor.ids", ids), attribute.String("series.bucket", bucket.String())))
+	defer func() { tracing.End(span, err) }()
+	return aggregation.AggregateEvents(ctx, ids, start, end, bucket)
+}
+
+// RebuildRollups - передаёт вызов, если обёрнутое хранилище ведёт сводки событий
+func (r *EventRepository) RebuildRollups(ctx context.Context, ids []int64, start, end time.Time) (_ int64, err error) {
+	rollups, ok := r.inner.(usecase.EventRollupRepository)
+	if !ok {
+		return 0, usecase.ErrRollupsNotSupported
+	}
+	ctx, span := tracer.Start(ctx, "EventRepository.RebuildRollups", trace.WithAttributes(attribute.Int64Slice("sensor.ids", ids)))
+	defer func() { tracing.End(span, err) }()
+	return rollups.RebuildRollups(ctx, ids, start, end)
+}
+
+// SaveTransition - передаёт вызов, если обёрнутое хранилище ведёт журнал смен состояния
+func (r *EventRepository) SaveTransition(ctx context.Context, transition *domain.Transition) (err error) {
+	transitions, ok := r.inner.(usecase.EventTransitionRepository)
+	if !ok {
+		return usecase.ErrTransitionsNotSupported
+	}
+	var attrs []attribute.KeyValue
+	if transition != nil {
+		attrs = append(attrs, attribute.Int64("sensor.id", transition.SensorID))
+	}
+	ctx, span := tracer.Start(ctx, "EventRepository.SaveTransition", trace.WithAttributes(attrs...))
+	defer func() { tracing.End(span, err) }()
+	return transitions.SaveTransition(ctx, transition)
+}
+
+// GetLastTransition - передаёт вызов, если обёрнутое хранилище ведёт журнал смен состояния
+func (r *EventRepository) GetLastTransition(ctx context.Context, sensorID int64, channel string) (_ *domain.Transition, err error) {
+	transitions, ok := r.inner.(usecase.EventTransitionRepository)
+	if !ok {
+		return nil, usecase.ErrTransitionsNotSupported
+	}
+	ctx, span := tracer.Start(ctx, "EventRepository.GetLastTransition",
+		trace.WithAttributes(attribute.Int64("sensor.id", sensorID), attribute.String("event.channel", channel)))
+	defer func() { tracing.End(span, err) }()
+	return transitions.GetLastTransition(ctx, sensorID, channel)
+}
+
+// GetTransitions - передаёт вызов, если обёрнутое хранилище ведёт журнал смен состояния
+func (r *EventRepository) GetTransitions(ctx context.Context, sensorID int64, filter domain.TransitionFilter) (_ []domain.Transition, err error) {
+	transitions, ok := r.inner.(usecase.EventTransitionRepository)
+	if !ok {
+		return nil, usecase.ErrTransitionsNotSupported
+	}
+	ctx, span := tracer.Start(ctx, "EventRepository.GetTransitions",
+		trace.WithAttributes(attribute.Int64("sensor.id", sensorID), attribute.String("event.channel", filter.Channel)))
+	defer func() { tracing.End(span, err) }()
+	return transitions.GetTransitions(ctx, sensorID, filter)
+}
+
+type UserRepository struct {
+	inner usecase.UserRepository
+}
+
+func NewUserRepository(inner usecase.UserRepository) *UserRepository {
+	return &UserRepository{inner: inner}
+}
+
+func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) (err error) {
+	ctx, span := tracer.Start(ctx, "UserRepository.SaveUser")
+	defer func() { tracing.End(span, err) }()
+	return r.inner.SaveUser(ctx, user)
+}
+
+func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (_ *domain.User, err error) {
+	ctx, span := tracer.Start(ctx, "UserRepository.GetUserByID", trace.WithAttributes(attribute.Int64("user.id", id)))
+	defer func() { tracing.End(span, err) }()
+	return r.inner.GetUserByID(ctx, id)
+}
+
+type SensorOwnerRepository struct {
+	inner usecase.SensorOwnerRepository
+}
+
+func NewSensorOwnerRepository(inner usecase.SensorOwnerRepository) *SensorOwnerRepository {
+	return &SensorOwnerRepository{inner: inner}
+}
+
+func (r *SensorOwnerRepository) SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) (err error) {
+	ctx, span := tracer.Start(ctx, "SensorOwnerRepository.SaveSensorOwner")
+	defer func() { tracing.End(span, err) }()
+	return r.inner.SaveSensorOwner(ctx, sensorOwner)
+}
+
+func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) (_ []domain.SensorOwner, err error) {
+	ctx, span := tracer.Start(ctx, "SensorOwnerRepository.GetSensorsByUserID", trace.WithAttributes(attribute.Int64("user.id", userID)))
+	defer func() { tracing.End(span, err) }()
+	return r.inner.GetSensorsByUserID(ctx, userID)
+}
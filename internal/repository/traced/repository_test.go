@@ -0,0 +1,103 @@
+package traced
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/repository/repotest"
+	"homework/internal/usecase"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	eventInmemory "homework/internal/repository/event/inmemory"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
+	userInmemory "homework/internal/repository/user/inmemory"
+)
+
+// recorder - все завершённые спаны; глобальный провайдер можно задать только один раз
+var recorder = tracetest.NewSpanRecorder()
+
+func TestMain(m *testing.M) {
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	os.Exit(m.Run())
+}
+
+func TestRepositories_Conformance(t *testing.T) {
+	t.Run("sensor", func(t *testing.T) {
+		repotest.TestSensorRepository(t, func(t *testing.T) usecase.SensorRepository {
+			return NewSensorRepository(sensorInmemory.NewSensorRepository())
+		})
+	})
+	t.Run("event", func(t *testing.T) {
+		repotest.TestEventRepository(t, func(t *testing.T) usecase.EventRepository {
+			return NewEventRepository(eventInmemory.NewEventRepository())
+		})
+	})
+	t.Run("user", func(t *testing.T) {
+		repotest.TestUserRepository(t, func(t *testing.T) usecase.UserRepository {
+			return NewUserRepository(userInmemory.NewUserRepository())
+		})
+	})
+	t.Run("sensor owner", func(t *testing.T) {
+		repotest.TestSensorOwnerRepository(t, func(t *testing.T) usecase.SensorOwnerRepository {
+			return NewSensorOwnerRepository(userInmemory.NewSensorOwnerRepository())
+		})
+	})
+}
+
+// В трассе GetUserSensors видно по спану на каждый датчик пользователя
+func TestGetUserSensors_Spans(t *testing.T) {
+	ctx := context.Background()
+
+	sr := NewSensorRepository(sensorInmemory.NewSensorRepository())
+	ur := NewUserRepository(userInmemory.NewUserRepository())
+	sor := NewSensorOwnerRepository(userInmemory.NewSensorOwnerRepository())
+	u := usecase.NewUser(ur, sor, sr)
+
+	user, err := u.RegisterUser(ctx, &domain.User{Name: "user"})
+	require.NoError(t, err)
+	for _, sn := range []string{"0000000001", "0000000002", "0000000003"} {
+		sensor := &domain.Sensor{SerialNumber: sn, Type: domain.SensorTypeADC}
+		require.NoError(t, sr.SaveSensor(ctx, sensor))
+		require.NoError(t, u.AttachSensorToUser(ctx, user.ID, sensor.ID))
+	}
+
+	before := len(recorder.Ended())
+	_, err = u.GetUserSensors(ctx, user.ID)
+	require.NoError(t, err)
+	_, err = u.GetUserSensors(ctx, user.ID+1)
+	require.ErrorIs(t, err, usecase.ErrUserNotFound)
+	spans := recorder.Ended()[before:]
+
+	var parent sdktrace.ReadOnlySpan
+	var failed []sdktrace.ReadOnlySpan
+	for _, span := range spans {
+		if span.Name() == "User.GetUserSensors" {
+			if span.Status().Code == codes.Error {
+				failed = append(failed, span)
+				continue
+			}
+			parent = span
+		}
+	}
+	require.NotNil(t, parent)
+	require.Len(t, failed, 1)
+
+	children := make(map[string]int)
+	for _, span := range spans {
+		if span.Parent().SpanID() == parent.SpanContext().SpanID() {
+			children[span.Name()]++
+		}
+	}
+	assert.Equal(t, map[string]int{
+		"UserRepository.GetUserByID":               1,
+		"SensorOwnerRepository.GetSensorsByUserID": 1,
+		"SensorRepository.GetSensorByID":           3,
+	}, children)
+}
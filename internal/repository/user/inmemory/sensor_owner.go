@@ -3,6 +3,7 @@ package inmemory
 import (
 	"context"
 	"homework/internal/domain"
+	"slices"
 	"sync"
 )
 
@@ -37,8 +38,28 @@ func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID i
 		r.mu.RLock()
 		defer r.mu.RUnlock()
 		if sensors, exists := r.sensors[userID]; exists {
-			return sensors, nil
+			return slices.Clone(sensors), nil
 		}
 	}
 	return make([]domain.SensorOwner, 0), nil
 }
+
+// Dump - возвращает копию всех привязок, используется для снапшотов
+func (r *SensorOwnerRepository) Dump() []domain.SensorOwner {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var sensorOwners []domain.SensorOwner
+	for _, owners := range r.sensors {
+		sensorOwners = append(sensorOwners, owners...)
+	}
+	return sensorOwners
+}
+
+// Restore - добавляет привязки в хранилище, используется при восстановлении состояния
+func (r *SensorOwnerRepository) Restore(sensorOwners ...domain.SensorOwner) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, sensorOwner := range sensorOwners {
+		r.sensors[sensorOwner.UserID] = append(r.sensors[sensorOwner.UserID], sensorOwner)
+	}
+}
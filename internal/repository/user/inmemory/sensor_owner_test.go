@@ -3,6 +3,8 @@ package inmemory
 import (
 	"context"
 	"homework/internal/domain"
+	"homework/internal/repository/repotest"
+	"homework/internal/usecase"
 	"sync"
 	"testing"
 	"time"
@@ -120,3 +122,9 @@ func TestSensorOwnerRepository_GetSensorsByUserID(t *testing.T) {
 		assert.Len(t, sensors, 1)
 	})
 }
+
+func TestSensorOwnerRepository_Conformance(t *testing.T) {
+	repotest.TestSensorOwnerRepository(t, func(*testing.T) usecase.SensorOwnerRepository {
+		return NewSensorOwnerRepository()
+	})
+}
@@ -34,7 +34,8 @@ func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) error
 
 		user.ID = r.nextID
 		r.nextID++
-		r.users[user.ID] = user
+		saved := *user
+		r.users[user.ID] = &saved
 		return nil
 	}
 }
@@ -51,6 +52,38 @@ func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (*domain.Use
 		if !exists {
 			return nil, usecase.ErrUserNotFound
 		}
-		return user, nil
+		found := *user
+		return &found, nil
+	}
+}
+
+// NextID - id, который получит следующий новый пользователь
+func (r *UserRepository) NextID() int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.nextID
+}
+
+// Dump - возвращает копию всех пользователей, используется для снапшотов
+func (r *UserRepository) Dump() []domain.User {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	users := make([]domain.User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, *user)
+	}
+	return users
+}
+
+// Restore - кладёт пользователей в хранилище с их ID, заменяя существующих.
+// Используется при восстановлении состояния из снапшота и журнала.
+func (r *UserRepository) Restore(users ...domain.User) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range users {
+		r.users[user.ID] = &user
+		if user.ID >= r.nextID {
+			r.nextID = user.ID + 1
+		}
 	}
 }
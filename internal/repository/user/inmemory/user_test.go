@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"homework/internal/domain"
+	"homework/internal/repository/repotest"
+	"homework/internal/usecase"
 	"sync"
 	"testing"
 	"time"
@@ -69,3 +71,9 @@ func TestUserRepository_SaveUser(t *testing.T) {
 		wg.Wait()
 	})
 }
+
+func TestUserRepository_Conformance(t *testing.T) {
+	repotest.TestUserRepository(t, func(*testing.T) usecase.UserRepository {
+		return NewUserRepository()
+	})
+}
@@ -36,5 +36,5 @@ func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID i
 		}
 		sensorOwners = append(sensorOwners, domain.SensorOwner{SensorID: sensorID, UserID: userID})
 	}
-	return sensorOwners, nil
+	return sensorOwners, rows.Err()
 }
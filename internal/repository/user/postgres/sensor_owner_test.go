@@ -3,6 +3,8 @@ package postgres
 import (
 	"context"
 	"homework/internal/domain"
+	"homework/internal/repository/repotest"
+	"homework/internal/usecase"
 	"homework/pkg/pg_test"
 	"testing"
 	"time"
@@ -32,7 +34,8 @@ func (suite *SensorOwnerTestSuite) TearDownSuite() {
 }
 
 func (suite *SensorOwnerTestSuite) TestSensorOwnerRepository_SaveSensorOwner() {
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint: govet // test stub
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
 	err := suite.repo.SaveSensorOwner(ctx, domain.SensorOwner{
 		UserID:   1,
@@ -43,7 +46,8 @@ func (suite *SensorOwnerTestSuite) TestSensorOwnerRepository_SaveSensorOwner() {
 }
 
 func (suite *SensorOwnerTestSuite) TestSensorOwnerRepository_GetSensorsByUserID() {
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint: govet // test stub
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
 	err := suite.repo.SaveSensorOwner(ctx, domain.SensorOwner{
 		UserID:   2,
@@ -69,6 +73,12 @@ func (suite *SensorOwnerTestSuite) TestSensorOwnerRepository_GetSensorsByUserID(
 	}, sensors)
 }
 
+func (suite *SensorOwnerTestSuite) TestConformance() {
+	repotest.TestSensorOwnerRepository(suite.T(), func(*testing.T) usecase.SensorOwnerRepository {
+		return suite.repo
+	})
+}
+
 func TestSensorOwnerTestSuite(t *testing.T) {
 	suite.Run(t, new(SensorOwnerTestSuite))
 }
@@ -21,14 +21,17 @@ func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
 }
 
 func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) error {
-	row := r.pool.QueryRow(ctx, `INSERT INTO users (name) VALUES ($1) RETURNING id`, user.Name)
+	if user == nil {
+		return errors.New("user is nil")
+	}
+	row := r.pool.QueryRow(ctx, `INSERT INTO users (name, time_zone) VALUES ($1, $2) RETURNING id`, user.Name, user.TimeZone)
 	return row.Scan(&user.ID)
 }
 
 func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
-	row := r.pool.QueryRow(ctx, `SELECT id, name FROM users WHERE id = $1`, id)
+	row := r.pool.QueryRow(ctx, `SELECT id, name, time_zone FROM users WHERE id = $1`, id)
 	user := &domain.User{}
-	if err := row.Scan(&user.ID, &user.Name); err != nil {
+	if err := row.Scan(&user.ID, &user.Name, &user.TimeZone); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, usecase.ErrUserNotFound
 		}
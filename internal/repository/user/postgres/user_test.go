@@ -3,6 +3,8 @@ package postgres
 import (
 	"context"
 	"homework/internal/domain"
+	"homework/internal/repository/repotest"
+	"homework/internal/usecase"
 	"homework/pkg/pg_test"
 	"testing"
 	"time"
@@ -32,7 +34,8 @@ func (suite *UserTestSuite) TearDownSuite() {
 }
 
 func (suite *UserTestSuite) TestUserRepository_SaveUser() {
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint: govet // test stub
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
 	name := "vasya pupkin"
 
@@ -44,23 +47,31 @@ func (suite *UserTestSuite) TestUserRepository_SaveUser() {
 }
 
 func (suite *UserTestSuite) TestUserRepository_GetUserByID() {
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second) //nolint: govet // test stub
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
 	name := "vasya pupkin"
 
-	err := suite.repo.SaveUser(ctx, &domain.User{
+	saved := &domain.User{
 		Name: name,
-	})
+	}
+	err := suite.repo.SaveUser(ctx, saved)
 
 	assert.Nil(suite.T(), err)
 
-	user, err := suite.repo.GetUserByID(ctx, 1)
+	user, err := suite.repo.GetUserByID(ctx, saved.ID)
 
 	assert.Nil(suite.T(), err)
 
 	assert.Equal(suite.T(), name, user.Name)
 }
 
+func (suite *UserTestSuite) TestConformance() {
+	repotest.TestUserRepository(suite.T(), func(*testing.T) usecase.UserRepository {
+		return suite.repo
+	})
+}
+
 func TestUserTestSuite(t *testing.T) {
 	suite.Run(t, new(UserTestSuite))
 }
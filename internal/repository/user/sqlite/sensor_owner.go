@@ -0,0 +1,39 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"homework/internal/domain"
+)
+
+type SensorOwnerRepository struct {
+	db *sql.DB
+}
+
+func NewSensorOwnerRepository(db *sql.DB) *SensorOwnerRepository {
+	return &SensorOwnerRepository{
+		db: db,
+	}
+}
+
+func (r *SensorOwnerRepository) SaveSensorOwner(ctx context.Context, sensorOwner domain.SensorOwner) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO sensors_users (sensor_id, user_id) VALUES (?, ?)`, sensorOwner.SensorID, sensorOwner.UserID)
+	return err
+}
+
+func (r *SensorOwnerRepository) GetSensorsByUserID(ctx context.Context, userID int64) ([]domain.SensorOwner, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT sensor_id FROM sensors_users WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var sensorOwners []domain.SensorOwner
+	for rows.Next() {
+		var sensorID int64
+		if err := rows.Scan(&sensorID); err != nil {
+			return nil, err
+		}
+		sensorOwners = append(sensorOwners, domain.SensorOwner{SensorID: sensorID, UserID: userID})
+	}
+	return sensorOwners, rows.Err()
+}
@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"homework/internal/domain"
+	"homework/internal/repository/repotest"
+	"homework/internal/usecase"
+	"homework/pkg/sqlite_test"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type SensorOwnerTestSuite struct {
+	suite.Suite
+	testDbInstance *sql.DB
+	testDB         *sqlite_test.TestDatabase
+
+	repo *SensorOwnerRepository
+}
+
+func (suite *SensorOwnerTestSuite) SetupSuite() {
+	suite.testDB = sqlite_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewSensorOwnerRepository(suite.testDbInstance)
+}
+
+func (suite *SensorOwnerTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *SensorOwnerTestSuite) TestSensorOwnerRepository_SaveSensorOwner() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := suite.repo.SaveSensorOwner(ctx, domain.SensorOwner{
+		UserID:   1,
+		SensorID: 1,
+	})
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *SensorOwnerTestSuite) TestSensorOwnerRepository_GetSensorsByUserID() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := suite.repo.SaveSensorOwner(ctx, domain.SensorOwner{
+		UserID:   2,
+		SensorID: 2,
+	})
+
+	assert.Nil(suite.T(), err)
+
+	err = suite.repo.SaveSensorOwner(ctx, domain.SensorOwner{
+		UserID:   2,
+		SensorID: 3,
+	})
+
+	assert.Nil(suite.T(), err)
+
+	sensors, err := suite.repo.GetSensorsByUserID(ctx, 2)
+
+	assert.Nil(suite.T(), err)
+
+	assert.ElementsMatch(suite.T(), []domain.SensorOwner{
+		{UserID: 2, SensorID: 2},
+		{UserID: 2, SensorID: 3},
+	}, sensors)
+}
+
+func (suite *SensorOwnerTestSuite) TestConformance() {
+	repotest.TestSensorOwnerRepository(suite.T(), func(*testing.T) usecase.SensorOwnerRepository {
+		return suite.repo
+	})
+}
+
+func TestSensorOwnerTestSuite(t *testing.T) {
+	suite.Run(t, new(SensorOwnerTestSuite))
+}
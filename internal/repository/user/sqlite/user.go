@@ -0,0 +1,39 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+)
+
+type UserRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{
+		db: db,
+	}
+}
+
+func (r *UserRepository) SaveUser(ctx context.Context, user *domain.User) error {
+	if user == nil {
+		return errors.New("user is nil")
+	}
+	row := r.db.QueryRowContext(ctx, `INSERT INTO users (name, time_zone) VALUES (?, ?) RETURNING id`, user.Name, user.TimeZone)
+	return row.Scan(&user.ID)
+}
+
+func (r *UserRepository) GetUserByID(ctx context.Context, id int64) (*domain.User, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT id, name, time_zone FROM users WHERE id = ?`, id)
+	user := &domain.User{}
+	if err := row.Scan(&user.ID, &user.Name, &user.TimeZone); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, usecase.ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
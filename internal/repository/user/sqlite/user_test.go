@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"homework/internal/domain"
+	"homework/internal/repository/repotest"
+	"homework/internal/usecase"
+	"homework/pkg/sqlite_test"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type UserTestSuite struct {
+	suite.Suite
+	testDbInstance *sql.DB
+	testDB         *sqlite_test.TestDatabase
+
+	repo *UserRepository
+}
+
+func (suite *UserTestSuite) SetupSuite() {
+	suite.testDB = sqlite_test.SetupTestDatabase()
+	suite.testDbInstance = suite.testDB.DbInstance
+
+	suite.repo = NewUserRepository(suite.testDbInstance)
+}
+
+func (suite *UserTestSuite) TearDownSuite() {
+	suite.testDB.TearDown()
+}
+
+func (suite *UserTestSuite) TestUserRepository_SaveUser() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	name := "vasya pupkin"
+
+	err := suite.repo.SaveUser(ctx, &domain.User{
+		Name: name,
+	})
+
+	assert.Nil(suite.T(), err)
+}
+
+func (suite *UserTestSuite) TestUserRepository_GetUserByID() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	name := "vasya pupkin"
+
+	saved := &domain.User{
+		Name: name,
+	}
+	err := suite.repo.SaveUser(ctx, saved)
+
+	assert.Nil(suite.T(), err)
+
+	user, err := suite.repo.GetUserByID(ctx, saved.ID)
+
+	assert.Nil(suite.T(), err)
+
+	assert.Equal(suite.T(), name, user.Name)
+}
+
+func (suite *UserTestSuite) TestConformance() {
+	repotest.TestUserRepository(suite.T(), func(*testing.T) usecase.UserRepository {
+		return suite.repo
+	})
+}
+
+func TestUserTestSuite(t *testing.T) {
+	suite.Run(t, new(UserTestSuite))
+}
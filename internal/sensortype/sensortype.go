@@ -0,0 +1,200 @@
+// Package sensortype - реестр типов датчиков.
+//
+// Тип описывает, какие значения присылает датчик, как выглядит его серийный номер, как часто он
+// отчитывается и как его значения сводятся за интервал. Встроенные типы cc и adc регистрируются
+// в Default; новый тип (например, датчик движения или CO2) добавляется одной записью в реестре
+// или в конфигурации, без правки схемы базы и API.
+package sensortype
+
+import (
+	"fmt"
+	"homework/internal/domain"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind - вид значений датчика
+type Kind string
+
+const (
+	// KindBinary - состояние 0 или 1, например контакт двери
+	KindBinary Kind = "binary"
+	// KindInteger - целые значения, например число срабатываний
+	KindInteger Kind = "integer"
+	// KindNumber - любые конечные числа, например температура
+	KindNumber Kind = "number"
+)
+
+// Aggregation - как значения датчика сводятся за интервал
+type Aggregation = domain.Aggregation
+
+const (
+	AggregationMean = domain.AggregationMean
+	AggregationLast = domain.AggregationLast
+	AggregationMin  = domain.AggregationMin
+	AggregationMax  = domain.AggregationMax
+	AggregationSum  = domain.AggregationSum
+)
+
+var (
+	kinds = []Kind{KindBinary, KindInteger, KindNumber}
+	// typeName - допустимое имя типа, совпадает с шаблоном поля type в API
+	typeName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+)
+
+// DefaultSerialNumber - формат серийного номера встроенных типов: десять цифр
+var DefaultSerialNumber = regexp.MustCompile(`^\d{10}$`)
+
+// Type - описание типа датчика
+type Type struct {
+	// Name - имя типа, значение поля type датчика
+	Name domain.SensorType
+	// Description - описание для людей
+	Description string
+	// Kind - вид значений
+	Kind Kind
+	// Min, Max - допустимый диапазон значений в единицах датчика, nil - без ограничения
+	Min, Max *float64
+	// SerialNumber - формат серийного номера, nil - DefaultSerialNumber
+	SerialNumber *regexp.Regexp
+	// ReportInterval - как часто датчик обычно присылает события, 0 - только при изменении состояния
+	ReportInterval time.Duration
+	// Aggregation - как сводить значения за интервал
+	Aggregation Aggregation
+}
+
+// SerialNumberFormat - формат серийного номера с учётом значения по умолчанию
+func (t *Type) SerialNumberFormat() *regexp.Regexp {
+	if t.SerialNumber == nil {
+		return DefaultSerialNumber
+	}
+	return t.SerialNumber
+}
+
+// ValidSerialNumber - серийный номер подходит типу
+func (t *Type) ValidSerialNumber(sn string) bool {
+	return t.SerialNumberFormat().MatchString(sn)
+}
+
+// ValidPayload - значение в единицах датчика допустимо для типа
+func (t *Type) ValidPayload(v float64) bool {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return false
+	}
+	switch t.Kind {
+	case KindBinary:
+		return v == 0 || v == 1
+	case KindInteger:
+		if v != math.Trunc(v) {
+			return false
+		}
+	}
+	return (t.Min == nil || v >= *t.Min) && (t.Max == nil || v <= *t.Max)
+}
+
+// validate - описание типа полное и непротиворечивое
+func (t *Type) validate() error {
+	var problems []string
+	if !typeName.MatchString(string(t.Name)) {
+		problems = append(problems, fmt.Sprintf("name must match %s", typeName))
+	}
+	if !slices.Contains(kinds, t.Kind) {
+		problems = append(problems, fmt.Sprintf("unknown kind %q", t.Kind))
+	}
+	if !slices.Contains(domain.Aggregations, t.Aggregation) {
+		problems = append(problems, fmt.Sprintf("unknown aggregation %q", t.Aggregation))
+	}
+	if t.Min != nil && t.Max != nil && *t.Min > *t.Max {
+		problems = append(problems, "min is greater than max")
+	}
+	if t.ReportInterval < 0 {
+		problems = append(problems, "report interval must not be negative")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("sensor type %q: %s", t.Name, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// Builtin - встроенные типы датчиков
+func Builtin() []Type {
+	return []Type{
+		{
+			Name:        domain.SensorTypeContactClosure,
+			Description: "Датчик замыкания контакта: двери, окна, протечки",
+			Kind:        KindBinary,
+			Aggregation: AggregationLast,
+		},
+		{
+			Name:           domain.SensorTypeADC,
+			Description:    "Аналоговый датчик: термометры, гигрометры",
+			Kind:           KindNumber,
+			ReportInterval: time.Minute,
+			Aggregation:    AggregationMean,
+		},
+	}
+}
+
+// Registry - зарегистрированные типы датчиков, безопасен для конкурентного использования
+type Registry struct {
+	mu    sync.RWMutex
+	types map[domain.SensorType]Type
+}
+
+// NewRegistry - реестр с типами types
+func NewRegistry(types ...Type) (*Registry, error) {
+	r := &Registry{types: make(map[domain.SensorType]Type, len(types))}
+	for _, t := range types {
+		if err := r.Register(t); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Default - реестр со встроенными типами
+func Default() *Registry {
+	r, err := NewRegistry(Builtin()...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Register - добавляет тип; имя должно быть свободно
+func (r *Registry) Register(t Type) error {
+	if err := t.validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.types[t.Name]; ok {
+		return fmt.Errorf("sensor type %q is already registered", t.Name)
+	}
+	r.types[t.Name] = t
+	return nil
+}
+
+// Lookup - тип по имени
+func (r *Registry) Lookup(name domain.SensorType) (Type, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.types[name]
+	return t, ok
+}
+
+// Types - все типы в порядке имён
+func (r *Registry) Types() []Type {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]Type, 0, len(r.types))
+	for _, t := range r.types {
+		types = append(types, t)
+	}
+	slices.SortFunc(types, func(a, b Type) int { return strings.Compare(string(a.Name), string(b.Name)) })
+	return types
+}
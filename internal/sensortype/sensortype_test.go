@@ -0,0 +1,68 @@
+package sensortype
+
+import (
+	"homework/internal/domain"
+	"math"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefault(t *testing.T) {
+	r := Default()
+
+	var names []domain.SensorType
+	for _, typ := range r.Types() {
+		names = append(names, typ.Name)
+	}
+	assert.Equal(t, []domain.SensorType{domain.SensorTypeADC, domain.SensorTypeContactClosure}, names)
+
+	cc, ok := r.Lookup(domain.SensorTypeContactClosure)
+	require.True(t, ok)
+	assert.True(t, cc.ValidPayload(0))
+	assert.True(t, cc.ValidPayload(1))
+	assert.False(t, cc.ValidPayload(2))
+	assert.False(t, cc.ValidPayload(0.5))
+	assert.True(t, cc.ValidSerialNumber("1234567890"))
+	assert.False(t, cc.ValidSerialNumber("123"))
+
+	adc, ok := r.Lookup(domain.SensorTypeADC)
+	require.True(t, ok)
+	assert.True(t, adc.ValidPayload(-21.5))
+	assert.False(t, adc.ValidPayload(math.NaN()))
+	assert.False(t, adc.ValidPayload(math.Inf(1)))
+
+	_, ok = r.Lookup("co2")
+	assert.False(t, ok)
+}
+
+func TestRegistry_Register(t *testing.T) {
+	lo, hi := 400.0, 5000.0
+	r, err := NewRegistry(Type{
+		Name:         "co2",
+		Kind:         KindInteger,
+		Min:          &lo,
+		Max:          &hi,
+		SerialNumber: regexp.MustCompile(`^CO2-\d{6}$`),
+		Aggregation:  AggregationMean,
+	})
+	require.NoError(t, err)
+
+	co2, ok := r.Lookup("co2")
+	require.True(t, ok)
+	assert.True(t, co2.ValidPayload(800))
+	assert.False(t, co2.ValidPayload(800.5))
+	assert.False(t, co2.ValidPayload(300))
+	assert.False(t, co2.ValidPayload(6000))
+	assert.True(t, co2.ValidSerialNumber("CO2-000001"))
+	assert.False(t, co2.ValidSerialNumber("1234567890"))
+
+	assert.ErrorContains(t, r.Register(co2), "already registered")
+	assert.ErrorContains(t, r.Register(Type{Name: "Motion", Kind: KindBinary, Aggregation: AggregationLast}), "name")
+	assert.ErrorContains(t, r.Register(Type{Name: "motion", Kind: "bool", Aggregation: AggregationLast}), "kind")
+	assert.ErrorContains(t, r.Register(Type{Name: "motion", Kind: KindBinary}), "aggregation")
+	assert.ErrorContains(t, r.Register(Type{Name: "motion", Kind: KindNumber, Aggregation: AggregationMean, Min: &hi, Max: &lo}), "min")
+	assert.Len(t, r.Types(), 1)
+}
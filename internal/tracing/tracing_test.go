@@ -0,0 +1,66 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestSetup(t *testing.T) {
+	t.Run("unknown exporter", func(t *testing.T) {
+		_, err := Setup(context.Background(), Options{Exporter: "zipkin"})
+		assert.Error(t, err)
+	})
+
+	t.Run("none only sets propagator", func(t *testing.T) {
+		shutdown, err := Setup(context.Background(), Options{Exporter: ExporterNone})
+		require.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+
+		carrier := propagation.MapCarrier{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+		ctx := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+		out := propagation.MapCarrier{}
+		otel.GetTextMapPropagator().Inject(ctx, out)
+		assert.Equal(t, carrier["traceparent"], out["traceparent"])
+	})
+
+	t.Run("stdout", func(t *testing.T) {
+		var buf bytes.Buffer
+		shutdown, err := Setup(context.Background(), Options{
+			Exporter:    ExporterStdout,
+			SampleRatio: 1,
+			ServiceName: "smart-home-test",
+			Writer:      &buf,
+		})
+		require.NoError(t, err)
+
+		_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+		span.End()
+		require.NoError(t, shutdown(context.Background()))
+
+		assert.Contains(t, buf.String(), "test-span")
+		assert.Contains(t, buf.String(), "smart-home-test")
+	})
+}
+
+func Test_operationName(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT id FROM sensors", "SELECT"},
+		{"  insert into events values ($1)", "INSERT"},
+		{"\n\tDELETE FROM events", "DELETE"},
+		{"", "QUERY"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, operationName(tt.sql))
+		})
+	}
+}
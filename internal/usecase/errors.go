@@ -0,0 +1,77 @@
+package usecase
+
+import "errors"
+
+// Kind - класс ошибки usecase, по которому транспорт выбирает код ответа
+type Kind string
+
+const (
+	// KindInvalid - входные данные не прошли проверку
+	KindInvalid Kind = "invalid"
+	// KindNotFound - запрошенной сущности нет
+	KindNotFound Kind = "not_found"
+	// KindConflict - операция противоречит текущему состоянию
+	KindConflict Kind = "conflict"
+	// KindForbidden - у вызывающего нет прав на операцию
+	KindForbidden Kind = "forbidden"
+	// KindPrecondition - сущность изменилась с тех пор, как её прочитал вызывающий
+	KindPrecondition Kind = "precondition"
+)
+
+// Error - ошибка usecase с классом и стабильным машиночитаемым кодом.
+// Code не меняется между версиями, на него могут опираться клиенты; Message - для людей.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+var (
+	ErrWrongSensorSerialNumber   = &Error{Kind: KindInvalid, Code: "wrong_sensor_serial_number", Message: "wrong sensor serial number"}
+	ErrWrongSensorType           = &Error{Kind: KindInvalid, Code: "wrong_sensor_type", Message: "wrong sensor type"}
+	ErrWrongSensorScale          = &Error{Kind: KindInvalid, Code: "wrong_sensor_scale", Message: "wrong sensor scale"}
+	ErrWrongSensorChannels       = &Error{Kind: KindInvalid, Code: "wrong_sensor_channels", Message: "sensor channels must have unique non-empty names"}
+	ErrInvalidEventTimestamp     = &Error{Kind: KindInvalid, Code: "invalid_event_timestamp", Message: "invalid event timestamp"}
+	ErrUnitMismatch              = &Error{Kind: KindInvalid, Code: "unit_mismatch", Message: "event unit differs from sensor unit"}
+	ErrInvalidPayload            = &Error{Kind: KindInvalid, Code: "invalid_payload", Message: "payload is out of range for sensor type"}
+	ErrChannelRequired           = &Error{Kind: KindInvalid, Code: "channel_required", Message: "multi-channel sensor requires values by channel"}
+	ErrUnknownChannel            = &Error{Kind: KindInvalid, Code: "unknown_channel", Message: "sensor has no such channel"}
+	ErrDuplicateChannel          = &Error{Kind: KindInvalid, Code: "duplicate_channel", Message: "channel is repeated"}
+	ErrEmptyEventPacket          = &Error{Kind: KindInvalid, Code: "empty_event_packet", Message: "event packet is empty"}
+	ErrWrongCalibration          = &Error{Kind: KindInvalid, Code: "wrong_calibration", Message: "calibration needs a finite non-zero gain and a table with increasing raw values"}
+	ErrCalibrationChannels       = &Error{Kind: KindInvalid, Code: "calibration_channels", Message: "multi-channel sensors cannot be calibrated"}
+	ErrFillStepRequired          = &Error{Kind: KindInvalid, Code: "fill_step_required", Message: "step is required to fill history of sensors without report interval"}
+	ErrFillTooLarge              = &Error{Kind: KindInvalid, Code: "fill_too_large", Message: "too many points to fill, use a larger step or a shorter period"}
+	ErrWrongSeriesQuery          = &Error{Kind: KindInvalid, Code: "wrong_series_query", Message: "query needs distinct sensors, a period with end after start and a positive bucket"}
+	ErrSeriesQueryTooLarge       = &Error{Kind: KindInvalid, Code: "series_query_too_large", Message: "query exceeds the limit on sensors, period length or buckets"}
+	ErrNotBinarySensor           = &Error{Kind: KindInvalid, Code: "not_binary_sensor", Message: "sensor type has no on/off states"}
+	ErrWrongTransitionFilter     = &Error{Kind: KindInvalid, Code: "wrong_transition_filter", Message: "filter needs a period with end after start, state 0 or 1 and non-negative duration and limit"}
+	ErrInvalidUserName           = &Error{Kind: KindInvalid, Code: "invalid_user_name", Message: "invalid user name"}
+	ErrInvalidTimeZone           = &Error{Kind: KindInvalid, Code: "invalid_time_zone", Message: "time zone must be an IANA name such as Europe/Moscow"}
+	ErrSensorNotFound            = &Error{Kind: KindNotFound, Code: "sensor_not_found", Message: "sensor not found"}
+	ErrUserNotFound              = &Error{Kind: KindNotFound, Code: "user_not_found", Message: "user not found"}
+	ErrEventNotFound             = &Error{Kind: KindNotFound, Code: "event_not_found", Message: "event not found"}
+	ErrNoTransition              = &Error{Kind: KindNotFound, Code: "transition_not_found", Message: "sensor channel has no transitions"}
+	ErrSensorAccessDenied        = &Error{Kind: KindForbidden, Code: "sensor_access_denied", Message: "sensor is not attached to user"}
+	ErrSensorAlreadyAttached     = &Error{Kind: KindConflict, Code: "sensor_already_attached", Message: "sensor is already attached to user"}
+	ErrSensorModified            = &Error{Kind: KindPrecondition, Code: "sensor_modified", Message: "sensor was modified by another request"}
+	ErrRetentionNotSupported     = errors.New("event retention is not supported by storage")
+	ErrCalibrationNotSupported   = errors.New("calibration log is not supported by storage")
+	ErrRecalibrationNotSupported = errors.New("event recalibration is not supported by storage")
+	ErrTransitionsNotSupported   = errors.New("transition log is not supported by storage")
+	ErrAggregationNotSupported   = errors.New("event aggregation is not supported by storage")
+	ErrRollupsNotSupported       = errors.New("event rollups are not supported by storage")
+)
+
+// KindOf - класс ошибки или пустая строка, если err не ошибка usecase (то есть внутренняя)
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return ""
+}
@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Kind
+	}{
+		{"invalid", ErrWrongSensorType, KindInvalid},
+		{"not found", ErrSensorNotFound, KindNotFound},
+		{"wrapped", fmt.Errorf("get sensor: %w", ErrUserNotFound), KindNotFound},
+		{"conflict", ErrSensorAlreadyAttached, KindConflict},
+		{"internal", errors.New("connection reset"), ""},
+		{"canceled", context.Canceled, ""},
+		{"nil", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, KindOf(tt.err))
+		})
+	}
+}
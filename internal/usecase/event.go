@@ -2,49 +2,384 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"homework/internal/anomaly"
 	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/ratelimit"
+	"homework/internal/sensortype"
+	"math"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// EventObserver - получает уведомления о результате приёма событий, например для метрик
+type EventObserver interface {
+	// EventReceived - событие сохранено и состояние датчика обновлено
+	EventReceived(sensor *domain.Sensor, event *domain.Event)
+	// EventRejected - событие не принято из-за err
+	EventRejected(err error)
+}
+
+type nopObserver struct{}
+
+func (nopObserver) EventReceived(*domain.Sensor, *domain.Event) {}
+
+func (nopObserver) EventRejected(error) {}
+
 type Event struct {
 	eventRepo  EventRepository
 	sensorRepo SensorRepository
+	observer   EventObserver
+	// limit - ограничение частоты событий от одного датчика, nil - без ограничения
+	limit *ratelimit.Scope
+	// limitClasses - тип датчика по серийному номеру, чтобы выбрать лимит до чтения датчика из хранилища
+	limitClasses sync.Map
+	// types - типы датчиков, по которым проверяются значения событий
+	types *sensortype.Registry
+	// detector - помечает недостоверные значения, nil - без проверки
+	detector *anomaly.Detector
+	// seriesLimits - ограничения запросов рядов нескольких датчиков
+	seriesLimits SeriesLimits
+}
+
+func NewEvent(er EventRepository, sr SensorRepository, options ...func(*Event)) *Event {
+	e := &Event{eventRepo: er, sensorRepo: sr, observer: nopObserver{}, types: sensortype.Default(), seriesLimits: DefaultSeriesLimits}
+	for _, o := range options {
+		o(e)
+	}
+	return e
 }
 
-func NewEvent(er EventRepository, sr SensorRepository) *Event {
-	return &Event{eventRepo: er, sensorRepo: sr}
+// WithEventObserver - уведомлять observer о каждом принятом и отклонённом событии
+func WithEventObserver(observer EventObserver) func(*Event) {
+	return func(e *Event) {
+		e.observer = observer
+	}
 }
 
-func (e *Event) ReceiveEvent(ctx context.Context, event *domain.Event) error {
-	if event.Timestamp.IsZero() {
-		return ErrInvalidEventTimestamp
+// WithEventRateLimit - ограничивать частоту событий от каждого датчика; ключ - серийный номер,
+// лимит выбирается по типу датчика
+func WithEventRateLimit(limit *ratelimit.Scope) func(*Event) {
+	return func(e *Event) {
+		e.limit = limit
 	}
+}
+
+// WithEventSensorTypes - проверять значения событий по типам из types; по умолчанию встроенные cc и adc
+func WithEventSensorTypes(types *sensortype.Registry) func(*Event) {
+	return func(e *Event) {
+		e.types = types
+	}
+}
+
+// WithEventAnomalyDetector - проверять значения событий детектором и сохранять найденные признаки
+// в Event.Quality. Состояния датчиков типа вида binary не проверяются
+func WithEventAnomalyDetector(detector *anomaly.Detector) func(*Event) {
+	return func(e *Event) {
+		e.detector = detector
+	}
+}
+
+func (e *Event) ReceiveEvent(ctx context.Context, event *domain.Event) (err error) {
+	ctx, span := tracer.Start(ctx, "Event.ReceiveEvent",
+		trace.WithAttributes(attribute.String("sensor.serial_number", event.SensorSerialNumber)))
+	defer func() { endSpan(span, err) }()
 
-	sensor, err := e.sensorRepo.GetSensorBySerialNumber(ctx, event.SensorSerialNumber)
+	return e.receive(ctx, []*domain.Event{event})
+}
+
+// ReceiveEvents - принимает пакет многоканального датчика: по событию на канал, у всех событий
+// один датчик и одно время. Пакет проходит ограничение частоты как одно событие,
+// а состояние датчика обновляется один раз
+func (e *Event) ReceiveEvents(ctx context.Context, events []*domain.Event) (err error) {
+	if len(events) == 0 {
+		return ErrEmptyEventPacket
+	}
+	ctx, span := tracer.Start(ctx, "Event.ReceiveEvents",
+		trace.WithAttributes(attribute.String("sensor.serial_number", events[0].SensorSerialNumber),
+			attribute.Int("events", len(events))))
+	defer func() { endSpan(span, err) }()
+
+	return e.receive(ctx, events)
+}
+
+func (e *Event) receive(ctx context.Context, events []*domain.Event) error {
+	sensor, err := e.receiveEvents(ctx, events)
 	if err != nil {
+		e.observer.EventRejected(err)
 		return err
 	}
+	for _, event := range events {
+		e.observer.EventReceived(sensor, event)
+	}
+	logging.AddFields(ctx, "sensor_id", sensor.ID)
+	logging.FromContext(ctx).Debug("event received", "sensor_id", sensor.ID, "sensor_serial", sensor.SerialNumber)
+	return nil
+}
+
+// unscale - значение показания, присланного датчиком с десятичным масштабом scale
+func unscale(payload float64, scale int) float64 {
+	if scale == 0 {
+		return payload
+	}
+	// деление, а не умножение на 10^-scale: 215 / 10 даёт ровно 21.5
+	return payload / math.Pow10(scale)
+}
+
+func (e *Event) receiveEvents(ctx context.Context, events []*domain.Event) (*domain.Sensor, error) {
+	first := events[0]
+	for _, event := range events {
+		if event.Timestamp.IsZero() || !event.Timestamp.Equal(first.Timestamp) {
+			return nil, ErrInvalidEventTimestamp
+		}
+		if event.SensorSerialNumber != first.SensorSerialNumber {
+			return nil, ErrWrongSensorSerialNumber
+		}
+	}
+
+	// лимит проверяется до чтения датчика, иначе отклонённые события всё равно нагружают хранилище.
+	// Тип датчика, по которому выбирается лимит, известен после первого чтения; до него серийный номер
+	// ограничивается лимитом по умолчанию в отдельной корзине, чтобы поток событий от незарегистрированного
+	// номера не доходил до хранилища
+	class, known := e.limitClasses.Load(first.SensorSerialNumber)
+	if e.limit != nil {
+		key, sensorType := first.SensorSerialNumber, ""
+		if known {
+			sensorType = class.(string)
+		} else {
+			key = "unknown:" + key
+		}
+		if err := e.limit.Allow(ctx, key, sensorType); err != nil {
+			return nil, err
+		}
+	}
+	sensor, err := e.sensorRepo.GetSensorBySerialNumber(ctx, first.SensorSerialNumber)
+	if err != nil {
+		return nil, err
+	}
 	if sensor == nil {
-		return ErrSensorNotFound
+		return nil, ErrSensorNotFound
+	}
+	if e.limit != nil && !known {
+		if err = e.limit.Allow(ctx, sensor.SerialNumber, string(sensor.Type)); err != nil {
+			return nil, err
+		}
+		e.limitClasses.Store(sensor.SerialNumber, string(sensor.Type))
+	}
+	// датчики типа, убранного из реестра после регистрации, продолжают работать без проверки значений
+	sensorType, typed := e.types.Lookup(sensor.Type)
+	seen := make(map[string]bool, len(events))
+	for _, event := range events {
+		if seen[event.Channel] {
+			return nil, ErrDuplicateChannel
+		}
+		seen[event.Channel] = true
+		if err = measure(sensor, event); err != nil {
+			return nil, err
+		}
+		if typed && !sensorType.ValidPayload(event.Payload) {
+			return nil, ErrInvalidPayload
+		}
+	}
+	binary := typed && sensorType.Kind == sensortype.KindBinary
+	if e.detector != nil && !binary {
+		for _, event := range events {
+			event.Quality = e.detector.Check(ctx, anomaly.Key{SensorID: sensor.ID, Channel: event.Channel},
+				string(sensor.Type), event.Timestamp, event.Payload)
+		}
+	}
+
+	for _, event := range events {
+		if err = e.eventRepo.SaveEvent(ctx, event); err != nil {
+			return nil, err
+		}
+		state, lastActivity := &sensor.CurrentState, &sensor.LastActivity
+		if channel := sensor.Channel(event.Channel); channel != nil {
+			state, lastActivity = &channel.CurrentState, &channel.LastActivity
+		}
+		// опоздавшее событие остаётся в истории, но не откатывает состояние к старому значению
+		if !event.Timestamp.After(*lastActivity) {
+			continue
+		}
+		// до первого события состояние неизвестно, так что и смены нет
+		if binary && !lastActivity.IsZero() && *state != event.Payload {
+			if err = e.saveTransition(ctx, event, *state); err != nil {
+				return nil, err
+			}
+		}
+		*state, *lastActivity = event.Payload, event.Timestamp
+	}
+	if first.Timestamp.After(sensor.LastActivity) {
+		sensor.LastActivity = first.Timestamp
+	}
+	return e.saveState(ctx, sensor, events)
+}
+
+// stateRetries - сколько раз saveState переносит состояние на свежую запись датчика
+const stateRetries = 3
+
+// saveState - записывает состояние датчика после приёма events. Датчик мог быть прочитан из кэша
+// и устареть, а полная запись вернула бы описание, калибровку и каналы, изменённые за это время
+// другой репликой. Поэтому запись условная по версии: если датчик изменился, его состояние
+// и состояние каналов событий переносится на свежую запись
+func (e *Event) saveState(ctx context.Context, sensor *domain.Sensor, events []*domain.Event) (*domain.Sensor, error) {
+	for attempt := 1; ; attempt++ {
+		err := e.sensorRepo.UpdateSensor(ctx, sensor, sensor.Version)
+		if err == nil {
+			return sensor, nil
+		}
+		if !errors.Is(err, ErrSensorModified) || attempt == stateRetries {
+			return nil, err
+		}
+		fresh, err := e.sensorRepo.GetSensorBySerialNumber(ctx, sensor.SerialNumber)
+		if err != nil {
+			return nil, err
+		}
+		if fresh == nil {
+			return nil, ErrSensorNotFound
+		}
+		// другая реплика могла успеть записать состояние по более новому событию
+		if sensor.LastActivity.After(fresh.LastActivity) {
+			fresh.CurrentState, fresh.LastActivity = sensor.CurrentState, sensor.LastActivity
+		}
+		for _, event := range events {
+			from, to := sensor.Channel(event.Channel), fresh.Channel(event.Channel)
+			if from != nil && to != nil && from.LastActivity.After(to.LastActivity) {
+				to.CurrentState, to.LastActivity = from.CurrentState, from.LastActivity
+			}
+		}
+		sensor = fresh
+	}
+}
+
+// measure - проверяет канал и единицу события, приводит значение к единицам датчика или его канала
+// и калибрует его; значение до калибровки остаётся в event.Raw
+func measure(sensor *domain.Sensor, event *domain.Event) error {
+	unit, scale := sensor.Unit, sensor.Scale
+	switch {
+	case event.Channel != "":
+		channel := sensor.Channel(event.Channel)
+		if channel == nil {
+			return ErrUnknownChannel
+		}
+		unit, scale = channel.Unit, channel.Scale
+	case len(sensor.Channels) > 0:
+		return ErrChannelRequired
+	}
+	if event.Unit != "" && event.Unit != unit {
+		return ErrUnitMismatch
 	}
 	event.SensorID = sensor.ID
-	if err = e.eventRepo.SaveEvent(ctx, event); err != nil {
-		return err
+	event.Unit = unit
+	event.Raw = unscale(event.Payload, scale)
+	event.Payload = event.Raw
+	if event.Channel == "" {
+		event.Payload = sensor.Calibration.Apply(event.Raw)
 	}
-	sensor.CurrentState = event.Payload
-	sensor.LastActivity = event.Timestamp
-	err = e.sensorRepo.SaveSensor(ctx, sensor)
-	return err
+	return nil
 }
 
-func (e *Event) GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error) {
+func (e *Event) GetLastEventBySensorID(ctx context.Context, id int64) (_ *domain.Event, err error) {
+	ctx, span := tracer.Start(ctx, "Event.GetLastEventBySensorID", trace.WithAttributes(attribute.Int64("sensor.id", id)))
+	defer func() { endSpan(span, err) }()
+
 	return e.eventRepo.GetLastEventBySensorID(ctx, id)
 }
 
-func (e *Event) GetEventsBySensorID(ctx context.Context, id int64, start, end time.Time) ([]*domain.Event, error) {
-	events, err := e.eventRepo.GetEventsBySensorID(ctx, id, start, end)
+// GetEventsBySensorID - события датчика за [start, end]; channel выбирает канал многоканального
+// датчика, пустой - события всех каналов
+func (e *Event) GetEventsBySensorID(ctx context.Context, id int64, channel string, start, end time.Time) (_ []*domain.Event, err error) {
+	ctx, span := tracer.Start(ctx, "Event.GetEventsBySensorID", trace.WithAttributes(attribute.Int64("sensor.id", id)))
+	defer func() { endSpan(span, err) }()
+
+	events, err := e.eventRepo.GetEventsBySensorID(ctx, id, channel, start, end)
 	if err != nil {
 		return nil, err
 	}
 	return events, nil
 }
+
+// PurgeEventsBefore - удаляет события старше before, если хранилище это поддерживает
+func (e *Event) PurgeEventsBefore(ctx context.Context, before time.Time) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "Event.PurgeEventsBefore")
+	defer func() { endSpan(span, err) }()
+
+	repo, ok := e.eventRepo.(EventRetentionRepository)
+	if !ok {
+		return 0, ErrRetentionNotSupported
+	}
+	deleted, err := repo.DeleteEventsBefore(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	logging.FromContext(ctx).Info("events purged", "before", before, "deleted", deleted)
+	return deleted, nil
+}
+
+// recalibrationWindow - за сколько времени события пересчитываются за один проход
+// в Recalibrate, чтобы не держать в памяти всю историю датчика
+const recalibrationWindow = 24 * time.Hour
+
+// Recalibrate - пересчитывает значения событий датчика за [start, end] из Raw по его текущей калибровке,
+// если хранилище это поддерживает, и пересобирает сводки за этот период; возвращает число изменённых событий
+func (e *Event) Recalibrate(ctx context.Context, id int64, start, end time.Time) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "Event.Recalibrate", trace.WithAttributes(attribute.Int64("sensor.id", id)))
+	defer func() { endSpan(span, err) }()
+
+	repo, ok := e.eventRepo.(EventRecalibrationRepository)
+	if !ok {
+		return 0, ErrRecalibrationNotSupported
+	}
+	if start.IsZero() || end.IsZero() || start.After(end) {
+		return 0, ErrInvalidEventTimestamp
+	}
+	sensor, err := e.sensorRepo.GetSensorByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	var updated int64
+	// окна пересекаются на границах, повторный пересчёт события ничего не меняет
+	for from := start; ; {
+		to := from.Add(recalibrationWindow)
+		if to.After(end) {
+			to = end
+		}
+		events, err := e.eventRepo.GetEventsBySensorID(ctx, sensor.ID, "", from, to)
+		if err != nil {
+			return updated, err
+		}
+		changed := events[:0]
+		for _, event := range events {
+			if event.Channel != "" {
+				continue
+			}
+			if payload := sensor.Calibration.Apply(event.Raw); payload != event.Payload {
+				event.Payload = payload
+				changed = append(changed, event)
+			}
+		}
+		if len(changed) > 0 {
+			n, err := repo.UpdateEventPayloads(ctx, changed)
+			updated += n
+			if err != nil {
+				return updated, err
+			}
+		}
+		if !to.Before(end) {
+			break
+		}
+		from = to
+	}
+	if updated > 0 {
+		if err := e.rebuildRollups(ctx, sensor.ID, start, end); err != nil {
+			return updated, err
+		}
+	}
+	logging.FromContext(ctx).Info("events recalibrated", "sensor_id", sensor.ID, "start", start, "end", end, "updated", updated)
+	return updated, nil
+}
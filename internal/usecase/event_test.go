@@ -3,12 +3,15 @@ package usecase
 import (
 	"context"
 	"errors"
+	"homework/internal/anomaly"
 	"homework/internal/domain"
+	"homework/internal/ratelimit"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_event_ReceiveEvent(t *testing.T) {
@@ -31,7 +34,7 @@ func Test_event_ReceiveEvent(t *testing.T) {
 
 		sr := NewMockSensorRepository(ctrl)
 
-		sr.EXPECT().GetSensorBySerialNumber(ctx, gomock.Any()).Times(1).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), gomock.Any()).Times(1).Return(nil, ErrSensorNotFound)
 
 		e := NewEvent(nil, sr)
 
@@ -47,13 +50,13 @@ func Test_event_ReceiveEvent(t *testing.T) {
 
 		sr := NewMockSensorRepository(ctrl)
 
-		sr.EXPECT().GetSensorBySerialNumber(ctx, "0123456789").Times(1).Return(&domain.Sensor{
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), "0123456789").Times(1).Return(&domain.Sensor{
 			ID: 1,
 		}, nil)
 
 		er := NewMockEventRepository(ctrl)
 		expectedError := errors.New("some error")
-		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(1).Return(expectedError)
+		er.EXPECT().SaveEvent(derivedFrom(ctx), gomock.Any()).Times(1).Return(expectedError)
 
 		e := NewEvent(er, sr)
 
@@ -70,14 +73,14 @@ func Test_event_ReceiveEvent(t *testing.T) {
 
 		sr := NewMockSensorRepository(ctrl)
 
-		sr.EXPECT().GetSensorBySerialNumber(ctx, "0123456789").Times(1).Return(&domain.Sensor{
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), "0123456789").Times(1).Return(&domain.Sensor{
 			ID: 1,
 		}, nil)
 		expectedError := errors.New("some error")
-		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Times(1).Return(expectedError)
+		sr.EXPECT().UpdateSensor(derivedFrom(ctx), gomock.Any(), gomock.Any()).Times(1).Return(expectedError)
 
 		er := NewMockEventRepository(ctrl)
-		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(1).Return(nil)
+		er.EXPECT().SaveEvent(derivedFrom(ctx), gomock.Any()).Times(1).Return(nil)
 
 		e := NewEvent(er, sr)
 
@@ -88,22 +91,69 @@ func Test_event_ReceiveEvent(t *testing.T) {
 		assert.ErrorIs(t, err, expectedError)
 	})
 
+	t.Run("ok, stale sensor", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// датчик из кэша устарел: другая реплика сменила описание и калибровку
+		stale := &domain.Sensor{ID: 1, SerialNumber: "0123456789", Version: 1, Description: "old",
+			Channels: []domain.Channel{{Name: "t"}}}
+		fresh := &domain.Sensor{ID: 1, SerialNumber: "0123456789", Version: 2, Description: "new",
+			Channels: []domain.Channel{{Name: "t", Unit: "°C"}}}
+		sr := NewMockSensorRepository(ctrl)
+		gomock.InOrder(
+			sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), "0123456789").Return(stale, nil),
+			sr.EXPECT().UpdateSensor(derivedFrom(ctx), stale, int64(1)).Return(ErrSensorModified),
+			sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), "0123456789").Return(fresh, nil),
+			sr.EXPECT().UpdateSensor(derivedFrom(ctx), fresh, int64(2)).Return(nil),
+		)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(derivedFrom(ctx), gomock.Any()).Return(nil)
+
+		at := time.Now()
+		require.NoError(t, NewEvent(er, sr).ReceiveEvents(ctx, []*domain.Event{
+			{Timestamp: at, SensorSerialNumber: "0123456789", Channel: "t", Payload: 21},
+		}))
+		assert.Equal(t, "new", fresh.Description)
+		assert.Equal(t, "°C", fresh.Channels[0].Unit)
+		assert.Equal(t, 21.0, fresh.Channels[0].CurrentState)
+		assert.True(t, at.Equal(fresh.Channels[0].LastActivity))
+		assert.True(t, at.Equal(fresh.LastActivity))
+	})
+
+	t.Run("err, sensor keeps changing", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), "0123456789").Times(stateRetries).
+			DoAndReturn(func(context.Context, string) (*domain.Sensor, error) {
+				return &domain.Sensor{ID: 1, SerialNumber: "0123456789"}, nil
+			})
+		sr.EXPECT().UpdateSensor(derivedFrom(ctx), gomock.Any(), gomock.Any()).Times(stateRetries).Return(ErrSensorModified)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(derivedFrom(ctx), gomock.Any()).Return(nil)
+
+		err := NewEvent(er, sr).ReceiveEvent(ctx, &domain.Event{Timestamp: time.Now(), SensorSerialNumber: "0123456789"})
+		assert.ErrorIs(t, err, ErrSensorModified)
+	})
+
 	t.Run("ok, no error", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
 		sr := NewMockSensorRepository(ctrl)
 
-		sr.EXPECT().GetSensorBySerialNumber(ctx, "0123456789").Times(1).Return(&domain.Sensor{
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), "0123456789").Times(1).Return(&domain.Sensor{
 			ID: 1,
 		}, nil)
-		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Do(func(_ context.Context, s *domain.Sensor) {
-			assert.Equal(t, int64(8), s.CurrentState)
+		sr.EXPECT().UpdateSensor(derivedFrom(ctx), gomock.Any(), gomock.Any()).Times(1).Do(func(_ context.Context, s *domain.Sensor, _ int64) {
+			assert.Equal(t, float64(8), s.CurrentState)
 			assert.NotEmpty(t, s.LastActivity)
 		})
 
 		er := NewMockEventRepository(ctrl)
-		er.EXPECT().SaveEvent(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, event *domain.Event) error {
+		er.EXPECT().SaveEvent(derivedFrom(ctx), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, event *domain.Event) error {
 			assert.Equal(t, int64(1), event.SensorID)
 			assert.Equal(t, "0123456789", event.SensorSerialNumber)
 
@@ -118,6 +168,342 @@ func Test_event_ReceiveEvent(t *testing.T) {
 		})
 		assert.NoError(t, err)
 	})
+
+	t.Run("ok, scaled payload gets sensor unit", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), "0123456789").Return(&domain.Sensor{
+			ID: 1, Unit: "°C", Scale: 1,
+		}, nil)
+		sr.EXPECT().UpdateSensor(derivedFrom(ctx), gomock.Any(), gomock.Any()).Do(func(_ context.Context, s *domain.Sensor, _ int64) {
+			assert.Equal(t, 21.5, s.CurrentState)
+		})
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(derivedFrom(ctx), gomock.Any()).DoAndReturn(func(_ context.Context, event *domain.Event) error {
+			assert.Equal(t, 21.5, event.Payload)
+			assert.Equal(t, "°C", event.Unit)
+			return nil
+		})
+
+		err := NewEvent(er, sr).ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "0123456789",
+			Payload:            215,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("ok, calibrated payload keeps raw", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), "0123456789").Return(&domain.Sensor{
+			ID: 1, Scale: 1, Calibration: &domain.Calibration{Gain: 2, Offset: -1, Table: []domain.CalibrationPoint{
+				{Raw: 0, Value: 0}, {Raw: 10, Value: 20}, {Raw: 20, Value: 30},
+			}},
+		}, nil)
+		sr.EXPECT().UpdateSensor(derivedFrom(ctx), gomock.Any(), gomock.Any()).Do(func(_ context.Context, s *domain.Sensor, _ int64) {
+			assert.Equal(t, 49.0, s.CurrentState)
+		})
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(derivedFrom(ctx), gomock.Any()).DoAndReturn(func(_ context.Context, event *domain.Event) error {
+			// 15 между точками 10 и 20 таблицы: 25 × 2 - 1
+			assert.Equal(t, 15.0, event.Raw)
+			assert.Equal(t, 49.0, event.Payload)
+			return nil
+		})
+
+		err := NewEvent(er, sr).ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "0123456789",
+			Payload:            150,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("ok, anomalies flagged", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sensors := map[string]*domain.Sensor{
+			"0123456789": {ID: 1, Type: domain.SensorTypeADC},
+			"0123456780": {ID: 2, Type: domain.SensorTypeContactClosure},
+		}
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), gomock.Any()).AnyTimes().DoAndReturn(
+			func(_ context.Context, sn string) (*domain.Sensor, error) {
+				sensor := sensors[sn].Clone()
+				return &sensor, nil
+			})
+		sr.EXPECT().UpdateSensor(derivedFrom(ctx), gomock.Any(), gomock.Any()).AnyTimes()
+		er := NewMockEventRepository(ctrl)
+		var saved []domain.Quality
+		er.EXPECT().SaveEvent(derivedFrom(ctx), gomock.Any()).AnyTimes().DoAndReturn(func(_ context.Context, event *domain.Event) error {
+			saved = append(saved, event.Quality)
+			return nil
+		})
+
+		detector := anomaly.NewDetector(anomaly.Configs{Default: anomaly.Config{MaxRate: 0.5}})
+		e := NewEvent(er, sr, WithEventAnomalyDetector(detector))
+		start := time.Now()
+		for i, tt := range []struct {
+			sn      string
+			payload float64
+		}{
+			{"0123456789", 20}, {"0123456789", 60}, {"0123456780", 0}, {"0123456780", 1},
+		} {
+			require.NoError(t, e.ReceiveEvent(ctx, &domain.Event{
+				Timestamp:          start.Add(time.Duration(i) * time.Second),
+				SensorSerialNumber: tt.sn,
+				Payload:            tt.payload,
+			}))
+		}
+		// состояния cc не проверяются
+		assert.Equal(t, []domain.Quality{0, domain.QualityRate, 0, 0}, saved)
+	})
+
+	t.Run("err, unit mismatch", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), "0123456789").Return(&domain.Sensor{
+			ID: 1, Unit: "°C",
+		}, nil)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(0)
+
+		err := NewEvent(er, sr).ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "0123456789",
+			Payload:            70,
+			Unit:               "°F",
+		})
+		assert.ErrorIs(t, err, ErrUnitMismatch)
+	})
+
+	t.Run("err, payload invalid for sensor type", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), "0123456789").Return(&domain.Sensor{
+			ID: 1, Type: domain.SensorTypeContactClosure,
+		}, nil)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(0)
+
+		err := NewEvent(er, sr).ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "0123456789",
+			Payload:            2,
+		})
+		assert.ErrorIs(t, err, ErrInvalidPayload)
+	})
+}
+
+func Test_event_ReceiveEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	climate := func() *domain.Sensor {
+		return &domain.Sensor{ID: 1, SerialNumber: "0123456789", CurrentState: 5, Channels: []domain.Channel{
+			{Name: "humidity", Unit: "%"},
+			{Name: "temperature", Unit: "°C", Scale: 1},
+		}}
+	}
+	packet := func(values map[string]float64) []*domain.Event {
+		now := time.Now()
+		var events []*domain.Event
+		for _, channel := range []string{"", "humidity", "temperature", "pressure"} {
+			if value, ok := values[channel]; ok {
+				events = append(events, &domain.Event{
+					Timestamp: now, SensorSerialNumber: "0123456789", Channel: channel, Payload: value,
+				})
+			}
+		}
+		return events
+	}
+
+	t.Run("ok, channels updated", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), "0123456789").Return(climate(), nil)
+		sr.EXPECT().UpdateSensor(derivedFrom(ctx), gomock.Any(), gomock.Any()).Times(1).Do(func(_ context.Context, s *domain.Sensor, _ int64) {
+			assert.Equal(t, 45.5, s.Channel("humidity").CurrentState)
+			assert.Equal(t, 21.5, s.Channel("temperature").CurrentState)
+			assert.False(t, s.Channel("temperature").LastActivity.IsZero())
+			assert.Equal(t, float64(5), s.CurrentState, "состояние одноканального датчика не меняется")
+		})
+		er := NewMockEventRepository(ctrl)
+		var saved []domain.Event
+		er.EXPECT().SaveEvent(derivedFrom(ctx), gomock.Any()).Times(2).DoAndReturn(func(_ context.Context, event *domain.Event) error {
+			saved = append(saved, *event)
+			return nil
+		})
+
+		err := NewEvent(er, sr).ReceiveEvents(ctx, packet(map[string]float64{"humidity": 45.5, "temperature": 215}))
+		assert.NoError(t, err)
+		require.Len(t, saved, 2)
+		assert.Equal(t, domain.Event{Timestamp: saved[0].Timestamp, SensorSerialNumber: "0123456789", SensorID: 1,
+			Channel: "humidity", Payload: 45.5, Raw: 45.5, Unit: "%"}, saved[0])
+		assert.Equal(t, 21.5, saved[1].Payload)
+		assert.Equal(t, "°C", saved[1].Unit)
+	})
+
+	tests := []struct {
+		name   string
+		events []*domain.Event
+		want   error
+	}{
+		{"empty packet", nil, ErrEmptyEventPacket},
+		{"unknown channel", packet(map[string]float64{"pressure": 1000}), ErrUnknownChannel},
+		{"payload without channel", packet(map[string]float64{"": 1}), ErrChannelRequired},
+		{"duplicate channel", func() []*domain.Event {
+			events := packet(map[string]float64{"humidity": 1})
+			repeated := *events[0]
+			return append(events, &repeated)
+		}(), ErrDuplicateChannel},
+		{"different timestamps", func() []*domain.Event {
+			events := packet(map[string]float64{"humidity": 1, "temperature": 2})
+			events[1].Timestamp = events[1].Timestamp.Add(time.Second)
+			return events
+		}(), ErrInvalidEventTimestamp},
+	}
+	for _, tt := range tests {
+		t.Run("err, "+tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sr := NewMockSensorRepository(ctrl)
+			sr.EXPECT().GetSensorBySerialNumber(gomock.Any(), gomock.Any()).AnyTimes().Return(climate(), nil)
+			er := NewMockEventRepository(ctrl)
+			er.EXPECT().SaveEvent(gomock.Any(), gomock.Any()).Times(0)
+
+			assert.ErrorIs(t, NewEvent(er, sr).ReceiveEvents(ctx, tt.events), tt.want)
+		})
+	}
+}
+
+type recordingObserver struct {
+	received []domain.SensorType
+	rejected []error
+}
+
+func (o *recordingObserver) EventReceived(sensor *domain.Sensor, _ *domain.Event) {
+	o.received = append(o.received, sensor.Type)
+}
+
+func (o *recordingObserver) EventRejected(err error) {
+	o.rejected = append(o.rejected, err)
+}
+
+func Test_event_ReceiveEvent_Observer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Run("rejected", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), "0123456789").Times(1).Return(nil, ErrSensorNotFound)
+
+		observer := &recordingObserver{}
+		e := NewEvent(nil, sr, WithEventObserver(observer))
+
+		assert.ErrorIs(t, e.ReceiveEvent(ctx, &domain.Event{}), ErrInvalidEventTimestamp)
+		assert.ErrorIs(t, e.ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "0123456789",
+		}), ErrSensorNotFound)
+
+		assert.Empty(t, observer.received)
+		assert.Len(t, observer.rejected, 2)
+		assert.ErrorIs(t, observer.rejected[0], ErrInvalidEventTimestamp)
+		assert.ErrorIs(t, observer.rejected[1], ErrSensorNotFound)
+	})
+
+	t.Run("received", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), "0123456789").Times(1).Return(&domain.Sensor{
+			ID:   1,
+			Type: domain.SensorTypeContactClosure,
+		}, nil)
+		sr.EXPECT().UpdateSensor(derivedFrom(ctx), gomock.Any(), gomock.Any()).Times(1).Return(nil)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(derivedFrom(ctx), gomock.Any()).Times(1).Return(nil)
+
+		observer := &recordingObserver{}
+		e := NewEvent(er, sr, WithEventObserver(observer))
+
+		assert.NoError(t, e.ReceiveEvent(ctx, &domain.Event{
+			Timestamp:          time.Now(),
+			SensorSerialNumber: "0123456789",
+		}))
+		assert.Equal(t, []domain.SensorType{domain.SensorTypeContactClosure}, observer.received)
+		assert.Empty(t, observer.rejected)
+	})
+}
+
+func Test_event_ReceiveEvent_RateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sensors := map[string]*domain.Sensor{
+		"0000000001": {ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeContactClosure},
+		"0000000002": {ID: 2, SerialNumber: "0000000002", Type: domain.SensorTypeADC},
+	}
+	sr := NewMockSensorRepository(ctrl)
+	lookups := 0
+	sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), gomock.Any()).AnyTimes().DoAndReturn(
+		func(_ context.Context, serial string) (*domain.Sensor, error) {
+			lookups++
+			if sensors[serial] == nil {
+				return nil, ErrSensorNotFound
+			}
+			sensor := *sensors[serial]
+			return &sensor, nil
+		})
+	sr.EXPECT().UpdateSensor(derivedFrom(ctx), gomock.Any(), gomock.Any()).Times(3).Return(nil)
+	er := NewMockEventRepository(ctrl)
+	er.EXPECT().SaveEvent(derivedFrom(ctx), gomock.Any()).Times(3).Return(nil)
+
+	observer := &recordingObserver{}
+	limit := ratelimit.NewScope("events", ratelimit.NewMemoryLimiter(), ratelimit.Limits{
+		Default: ratelimit.Limit{Rate: 0.001, Burst: 1},
+		Classes: map[string]ratelimit.Limit{string(domain.SensorTypeADC): {Rate: 0.001, Burst: 2}},
+	})
+	e := NewEvent(er, sr, WithEventObserver(observer), WithEventRateLimit(limit))
+	receive := func(serial string) error {
+		return e.ReceiveEvent(ctx, &domain.Event{Timestamp: time.Now(), SensorSerialNumber: serial})
+	}
+
+	var limited *ratelimit.Error
+	assert.NoError(t, receive("0000000001"))
+	assert.ErrorAs(t, receive("0000000001"), &limited)
+	// лимит adc больше, и корзины датчиков независимы
+	assert.NoError(t, receive("0000000002"))
+	assert.NoError(t, receive("0000000002"))
+	assert.ErrorAs(t, receive("0000000002"), &limited)
+	// незарегистрированный номер ограничивается лимитом по умолчанию
+	assert.ErrorIs(t, receive("0000000003"), ErrSensorNotFound)
+	assert.ErrorAs(t, receive("0000000003"), &limited)
+
+	assert.Equal(t, 4, lookups, "отклонённые события не читают датчик")
+	assert.Len(t, observer.received, 3)
+	assert.Len(t, observer.rejected, 4)
 }
 
 func Test_event_GetEventsBySensorID(t *testing.T) {
@@ -130,11 +516,11 @@ func Test_event_GetEventsBySensorID(t *testing.T) {
 
 		er := NewMockEventRepository(ctrl)
 
-		er.EXPECT().GetEventsBySensorID(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, ErrEventNotFound)
+		er.EXPECT().GetEventsBySensorID(derivedFrom(ctx), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, ErrEventNotFound)
 
 		e := NewEvent(er, nil)
 
-		event, err := e.GetEventsBySensorID(ctx, 1, time.Now(), time.Now())
+		event, err := e.GetEventsBySensorID(ctx, 1, "", time.Now(), time.Now())
 		assert.ErrorIs(t, err, ErrEventNotFound)
 		assert.Nil(t, event)
 	})
@@ -150,14 +536,73 @@ func Test_event_GetEventsBySensorID(t *testing.T) {
 
 		er := NewMockEventRepository(ctrl)
 
-		er.EXPECT().GetEventsBySensorID(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return([]*domain.Event{event}, nil)
+		er.EXPECT().GetEventsBySensorID(derivedFrom(ctx), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return([]*domain.Event{event}, nil)
 
 		e := NewEvent(er, nil)
 
-		actualEvent, err := e.GetEventsBySensorID(ctx, 1, time.Now(), time.Now())
+		actualEvent, err := e.GetEventsBySensorID(ctx, 1, "", time.Now(), time.Now())
 		assert.NoError(t, err)
 		assert.Len(t, actualEvent, 1)
 		assert.Equal(t, event.Timestamp, actualEvent[0].Timestamp)
 		assert.Equal(t, event.Payload, actualEvent[0].Payload)
 	})
 }
+
+// recalibratedEventRepository - хранилище событий, умеющее менять их значения
+type recalibratedEventRepository struct {
+	*MockEventRepository
+	*MockEventRecalibrationRepository
+}
+
+func Test_event_Recalibrate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sensor := &domain.Sensor{ID: 1, Calibration: &domain.Calibration{Gain: 2}}
+
+	t.Run("ok, windows", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), int64(1)).Return(sensor, nil)
+		er := recalibratedEventRepository{NewMockEventRepository(ctrl), NewMockEventRecalibrationRepository(ctrl)}
+		middle := start.Add(recalibrationWindow)
+		end := start.Add(recalibrationWindow + time.Hour)
+		er.MockEventRepository.EXPECT().GetEventsBySensorID(derivedFrom(ctx), int64(1), "", start, middle).Return([]*domain.Event{
+			{SensorID: 1, Timestamp: start, Raw: 1, Payload: 1},
+			// уже откалибровано
+			{SensorID: 1, Timestamp: start.Add(time.Hour), Raw: 1, Payload: 2},
+			// каналы не калибруются
+			{SensorID: 1, Timestamp: start.Add(time.Hour), Channel: "humidity", Raw: 1, Payload: 1},
+		}, nil)
+		er.MockEventRepository.EXPECT().GetEventsBySensorID(derivedFrom(ctx), int64(1), "", middle, end).Return([]*domain.Event{
+			{SensorID: 1, Timestamp: end, Raw: 3, Payload: 3},
+		}, nil)
+		var payloads []float64
+		er.MockEventRecalibrationRepository.EXPECT().UpdateEventPayloads(derivedFrom(ctx), gomock.Any()).Times(2).DoAndReturn(
+			func(_ context.Context, events []*domain.Event) (int64, error) {
+				for _, event := range events {
+					payloads = append(payloads, event.Payload)
+				}
+				return int64(len(events)), nil
+			})
+
+		updated, err := NewEvent(er, sr).Recalibrate(ctx, 1, start, end)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), updated)
+		assert.Equal(t, []float64{2, 6}, payloads)
+	})
+
+	t.Run("fail, not supported", func(t *testing.T) {
+		_, err := NewEvent(NewMockEventRepository(ctrl), NewMockSensorRepository(ctrl)).Recalibrate(context.Background(), 1, start, start)
+		assert.ErrorIs(t, err, ErrRecalibrationNotSupported)
+	})
+
+	t.Run("fail, invalid range", func(t *testing.T) {
+		er := recalibratedEventRepository{NewMockEventRepository(ctrl), NewMockEventRecalibrationRepository(ctrl)}
+		_, err := NewEvent(er, NewMockSensorRepository(ctrl)).Recalibrate(context.Background(), 1, start, start.Add(-time.Second))
+		assert.ErrorIs(t, err, ErrInvalidEventTimestamp)
+	})
+}
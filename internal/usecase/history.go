@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"homework/internal/domain"
+	"homework/internal/sensortype"
+	"maps"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MaxFilledPoints - сколько точек можно подставить в пропуски за один запрос истории
+const MaxFilledPoints = 10_000
+
+// GetHistory - события датчика sensor за период с найденными пропусками, заполненными так, как просит query.Fill.
+//
+// Пропуски ищутся только у датчиков, тип которых задаёт интервал отчётов: пропуск - промежуток между
+// событиями одного канала или между событием и краем периода длиннее полутора интервалов, то есть хотя бы
+// один отчёт потерян, а не просто опоздал. Край периода - не раньше регистрации датчика и не позже
+// текущего момента. Датчик без интервала отчётов присылает события только при смене состояния:
+// пропусков у него не бывает, а заполнение повторяет состояние между событиями.
+// Состояния датчиков вида binary не интерполируются: FillLinear для них повторяет последнее состояние
+func (e *Event) GetHistory(ctx context.Context, sensor *domain.Sensor, query domain.HistoryQuery) (_ *domain.History, err error) {
+	ctx, span := tracer.Start(ctx, "Event.GetHistory", trace.WithAttributes(
+		attribute.Int64("sensor.id", sensor.ID), attribute.String("history.fill", string(query.Fill))))
+	defer func() { endSpan(span, err) }()
+
+	f := &filler{sensor: sensor, fill: query.Fill, step: query.Step, budget: MaxFilledPoints}
+	if sensorType, ok := e.types.Lookup(sensor.Type); ok {
+		f.interval = sensorType.ReportInterval
+		f.binary = sensorType.Kind == sensortype.KindBinary
+	}
+	if f.step == 0 {
+		f.step = f.interval
+	}
+	if f.filling() && f.step <= 0 {
+		return nil, ErrFillStepRequired
+	}
+
+	events, err := e.eventRepo.GetEventsBySensorID(ctx, sensor.ID, query.Channel, query.Start, query.End)
+	if err != nil {
+		return nil, err
+	}
+
+	f.lo, f.hi = query.Start, query.End
+	if sensor.RegisteredAt.After(f.lo) {
+		f.lo = sensor.RegisteredAt
+	}
+	if now := time.Now(); now.Before(f.hi) {
+		f.hi = now
+	}
+	// у канала без событий за период весь период - пропуск, поэтому каналы берутся и из датчика
+	byChannel := make(map[string][]*domain.Event)
+	switch {
+	case query.Channel != "":
+		byChannel[query.Channel] = nil
+	case len(sensor.Channels) > 0:
+		for _, channel := range sensor.Channels {
+			byChannel[channel.Name] = nil
+		}
+	default:
+		byChannel[""] = nil
+	}
+	for _, event := range events {
+		byChannel[event.Channel] = append(byChannel[event.Channel], event)
+	}
+	for _, channel := range slices.Sorted(maps.Keys(byChannel)) {
+		if err := f.series(channel, byChannel[channel]); err != nil {
+			return nil, err
+		}
+	}
+
+	slices.SortStableFunc(f.history.Points, func(a, b domain.HistoryPoint) int {
+		return cmp.Or(a.Timestamp.Compare(b.Timestamp), cmp.Compare(a.Channel, b.Channel))
+	})
+	slices.SortStableFunc(f.history.Gaps, func(a, b domain.Gap) int {
+		return cmp.Or(a.Start.Compare(b.Start), cmp.Compare(a.Channel, b.Channel))
+	})
+	return &f.history, nil
+}
+
+// filler - собирает историю одного запроса
+type filler struct {
+	sensor *domain.Sensor
+	fill   domain.Fill
+	step   time.Duration
+	// interval - интервал отчётов типа датчика, 0 - только при смене состояния
+	interval time.Duration
+	binary   bool
+	// lo, hi - края периода: до первого события и после последнего пропуски ищутся только внутри них
+	lo, hi time.Time
+	// budget - сколько точек ещё можно подставить
+	budget int
+
+	history domain.History
+}
+
+func (f *filler) filling() bool {
+	return f.fill != "" && f.fill != domain.FillNone
+}
+
+// series - события одного канала по времени и промежутки между ними
+func (f *filler) series(channel string, events []*domain.Event) error {
+	var prev *domain.Event
+	from := f.lo
+	for _, event := range events {
+		f.history.Points = append(f.history.Points, domain.HistoryPoint{Event: event})
+		if err := f.window(channel, from, event.Timestamp, prev, event); err != nil {
+			return err
+		}
+		prev, from = event, event.Timestamp
+	}
+	return f.window(channel, from, f.hi, prev, nil)
+}
+
+// window - промежуток (from, to) без событий между prev и next; nil - край периода
+func (f *filler) window(channel string, from, to time.Time, prev, next *domain.Event) error {
+	if !to.After(from) {
+		return nil
+	}
+	if f.interval > 0 {
+		if to.Sub(from) <= f.interval*3/2 {
+			return nil
+		}
+		f.history.Gaps = append(f.history.Gaps, domain.Gap{Channel: channel, Start: from, End: to})
+	} else if f.fill == domain.FillNull {
+		// у датчика без интервала отчётов молчание - не отсутствие данных
+		return nil
+	}
+	if !f.filling() {
+		return nil
+	}
+
+	for t := from.Add(f.step); t.Before(to); t = t.Add(f.step) {
+		point, ok := f.point(channel, t, prev, next)
+		if !ok {
+			// значение не выводится ни в одной точке промежутка
+			return nil
+		}
+		if f.budget == 0 {
+			return ErrFillTooLarge
+		}
+		f.budget--
+		f.history.Points = append(f.history.Points, point)
+	}
+	return nil
+}
+
+// point - точка в момент t между prev и next или false, если значение в ней не выводится
+func (f *filler) point(channel string, t time.Time, prev, next *domain.Event) (domain.HistoryPoint, bool) {
+	unit := f.sensor.Unit
+	if c := f.sensor.Channel(channel); c != nil {
+		unit = c.Unit
+	}
+	event := &domain.Event{
+		Timestamp:          t,
+		SensorSerialNumber: f.sensor.SerialNumber,
+		SensorID:           f.sensor.ID,
+		Unit:               unit,
+		Channel:            channel,
+	}
+	switch {
+	case f.fill == domain.FillNull:
+		return domain.HistoryPoint{Event: event, Filled: true, Null: true}, true
+	case prev == nil:
+		return domain.HistoryPoint{}, false
+	case f.fill == domain.FillPrevious || f.binary:
+		event.Payload, event.Raw = prev.Payload, prev.Raw
+	case next == nil:
+		return domain.HistoryPoint{}, false
+	default:
+		ratio := float64(t.Sub(prev.Timestamp)) / float64(next.Timestamp.Sub(prev.Timestamp))
+		event.Payload = prev.Payload + (next.Payload-prev.Payload)*ratio
+		event.Raw = prev.Raw + (next.Raw-prev.Raw)*ratio
+	}
+	return domain.HistoryPoint{Event: event, Filled: true}, true
+}
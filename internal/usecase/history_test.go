@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"context"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_event_GetHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	adc := &domain.Sensor{ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeADC, Unit: "°C"}
+	cc := &domain.Sensor{ID: 2, SerialNumber: "0000000002", Type: domain.SensorTypeContactClosure}
+
+	// value - значение точки истории: время, подставлена ли она, значение
+	type value struct {
+		at      time.Time
+		filled  bool
+		null    bool
+		payload float64
+	}
+	values := func(history *domain.History) []value {
+		var values []value
+		for _, point := range history.Points {
+			values = append(values, value{point.Timestamp, point.Filled, point.Null, point.Payload})
+		}
+		return values
+	}
+	history := func(t *testing.T, sensor *domain.Sensor, events []*domain.Event, query domain.HistoryQuery) (*domain.History, error) {
+		t.Helper()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().GetEventsBySensorID(derivedFrom(ctx), sensor.ID, query.Channel, query.Start, query.End).Return(events, nil)
+		return NewEvent(er, nil).GetHistory(ctx, sensor, query)
+	}
+	adcEvents := []*domain.Event{
+		{SensorID: 1, Timestamp: at(0), Payload: 10, Raw: 10, Unit: "°C"},
+		{SensorID: 1, Timestamp: at(1), Payload: 10, Raw: 10, Unit: "°C"},
+		{SensorID: 1, Timestamp: at(5), Payload: 50, Raw: 50, Unit: "°C"},
+	}
+
+	t.Run("ok, gaps without fill", func(t *testing.T) {
+		h, err := history(t, adc, adcEvents, domain.HistoryQuery{Start: at(0), End: at(6), Fill: domain.FillNone})
+		require.NoError(t, err)
+		assert.Equal(t, []domain.Gap{{Start: at(1), End: at(5)}}, h.Gaps)
+		assert.Equal(t, []value{{at(0), false, false, 10}, {at(1), false, false, 10}, {at(5), false, false, 50}}, values(h))
+	})
+
+	t.Run("ok, linear", func(t *testing.T) {
+		h, err := history(t, adc, adcEvents, domain.HistoryQuery{Start: at(0), End: at(10), Fill: domain.FillLinear})
+		require.NoError(t, err)
+		// после последнего события интерполировать не к чему
+		assert.Equal(t, []domain.Gap{{Start: at(1), End: at(5)}, {Start: at(5), End: at(10)}}, h.Gaps)
+		assert.Equal(t, []value{
+			{at(0), false, false, 10},
+			{at(1), false, false, 10},
+			{at(2), true, false, 20},
+			{at(3), true, false, 30},
+			{at(4), true, false, 40},
+			{at(5), false, false, 50},
+		}, values(h))
+		assert.Equal(t, "°C", h.Points[2].Unit)
+		assert.Equal(t, "0000000001", h.Points[2].SensorSerialNumber)
+		assert.Equal(t, 20.0, h.Points[2].Raw)
+	})
+
+	t.Run("ok, previous with step", func(t *testing.T) {
+		h, err := history(t, adc, adcEvents[1:], domain.HistoryQuery{Start: at(0), End: at(8), Fill: domain.FillPrevious, Step: 2 * time.Minute})
+		require.NoError(t, err)
+		// до первого события повторять нечего
+		assert.Equal(t, []value{
+			{at(1), false, false, 10},
+			{at(3), true, false, 10},
+			{at(5), false, false, 50},
+			{at(7), true, false, 50},
+		}, values(h))
+	})
+
+	t.Run("ok, null", func(t *testing.T) {
+		h, err := history(t, adc, adcEvents[2:], domain.HistoryQuery{Start: at(0), End: at(5), Fill: domain.FillNull})
+		require.NoError(t, err)
+		assert.Equal(t, []domain.Gap{{Start: at(0), End: at(5)}}, h.Gaps)
+		assert.Equal(t, []value{
+			{at(1), true, true, 0},
+			{at(2), true, true, 0},
+			{at(3), true, true, 0},
+			{at(4), true, true, 0},
+			{at(5), false, false, 50},
+		}, values(h))
+	})
+
+	t.Run("ok, gaps from registration", func(t *testing.T) {
+		registered := *adc
+		registered.RegisteredAt = at(4)
+		h, err := history(t, &registered, adcEvents[2:], domain.HistoryQuery{Start: at(0), End: at(5), Fill: domain.FillNone})
+		require.NoError(t, err)
+		assert.Empty(t, h.Gaps)
+	})
+
+	t.Run("ok, channel without events", func(t *testing.T) {
+		sensor := &domain.Sensor{ID: 1, Type: domain.SensorTypeADC, Channels: []domain.Channel{{Name: "temperature"}, {Name: "humidity", Unit: "%"}}}
+		events := []*domain.Event{
+			{SensorID: 1, Timestamp: at(0), Channel: "temperature", Payload: 21},
+			{SensorID: 1, Timestamp: at(1), Channel: "temperature", Payload: 21},
+		}
+		h, err := history(t, sensor, events, domain.HistoryQuery{Start: at(0), End: at(2), Fill: domain.FillNull})
+		require.NoError(t, err)
+		assert.Equal(t, []domain.Gap{{Channel: "humidity", Start: at(0), End: at(2)}}, h.Gaps)
+		require.Len(t, h.Points, 3)
+		assert.Equal(t, "humidity", h.Points[1].Channel)
+		assert.Equal(t, "%", h.Points[1].Unit)
+		assert.True(t, h.Points[1].Null)
+	})
+
+	ccEvents := []*domain.Event{
+		{SensorID: 2, Timestamp: at(0), Payload: 1, Raw: 1},
+		{SensorID: 2, Timestamp: at(3), Payload: 0, Raw: 0},
+	}
+
+	t.Run("ok, state is held", func(t *testing.T) {
+		h, err := history(t, cc, ccEvents, domain.HistoryQuery{Start: at(0), End: at(5), Fill: domain.FillLinear, Step: time.Minute})
+		require.NoError(t, err)
+		assert.Empty(t, h.Gaps)
+		assert.Equal(t, []value{
+			{at(0), false, false, 1},
+			{at(1), true, false, 1},
+			{at(2), true, false, 1},
+			{at(3), false, false, 0},
+			{at(4), true, false, 0},
+		}, values(h))
+	})
+
+	t.Run("ok, silence of state is not a gap", func(t *testing.T) {
+		h, err := history(t, cc, ccEvents, domain.HistoryQuery{Start: at(0), End: at(5), Fill: domain.FillNull, Step: time.Minute})
+		require.NoError(t, err)
+		assert.Empty(t, h.Gaps)
+		assert.Len(t, h.Points, 2)
+	})
+
+	t.Run("fail, step required", func(t *testing.T) {
+		_, err := NewEvent(NewMockEventRepository(ctrl), nil).GetHistory(context.Background(), cc, domain.HistoryQuery{Start: at(0), End: at(5), Fill: domain.FillPrevious})
+		assert.ErrorIs(t, err, ErrFillStepRequired)
+	})
+
+	t.Run("fail, too many points", func(t *testing.T) {
+		_, err := history(t, adc, adcEvents, domain.HistoryQuery{Start: at(0), End: at(10), Fill: domain.FillNull, Step: time.Millisecond})
+		assert.ErrorIs(t, err, ErrFillTooLarge)
+	})
+}
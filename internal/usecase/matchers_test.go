@@ -0,0 +1,27 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/mock/gomock"
+)
+
+type derivedContext struct {
+	parent context.Context
+}
+
+// derivedFrom - совпадает с parent и контекстами, полученными из него через WithValue,
+// например с контекстом, в который usecase положил свой спан
+func derivedFrom(parent context.Context) gomock.Matcher {
+	return derivedContext{parent: parent}
+}
+
+func (m derivedContext) Matches(x any) bool {
+	ctx, ok := x.(context.Context)
+	return ok && ctx.Done() == m.parent.Done()
+}
+
+func (m derivedContext) String() string {
+	return fmt.Sprintf("is derived from %v", m.parent)
+}
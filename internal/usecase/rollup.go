@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/logging"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RebuildRollups - пересобирает сводки событий датчиков ids, пустой - всех, за [start, end) из сохранённых
+// событий, если хранилище их ведёт; возвращает число записанных сводок. Нужна после правки событий
+// в обход приёма или если сводки разошлись с событиями
+func (e *Event) RebuildRollups(ctx context.Context, ids []int64, start, end time.Time) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "Event.RebuildRollups", trace.WithAttributes(attribute.Int64Slice("sensor.ids", ids)))
+	defer func() { endSpan(span, err) }()
+
+	repo, ok := e.eventRepo.(EventRollupRepository)
+	if !ok {
+		return 0, ErrRollupsNotSupported
+	}
+	if start.IsZero() || !end.After(start) {
+		return 0, ErrInvalidEventTimestamp
+	}
+	rebuilt, err := repo.RebuildRollups(ctx, ids, start, end)
+	if err != nil {
+		return 0, err
+	}
+	logging.FromContext(ctx).Info("event rollups rebuilt", "sensor_ids", ids, "start", start, "end", end, "rebuilt", rebuilt)
+	return rebuilt, nil
+}
+
+// rebuildRollups - пересобирает сводки после изменения событий датчика id за [start, end]; хранилище
+// без сводок пропускается
+func (e *Event) rebuildRollups(ctx context.Context, id int64, start, end time.Time) error {
+	repo, ok := e.eventRepo.(EventRollupRepository)
+	if !ok {
+		return nil
+	}
+	// конец периода сводок не входит в него, а событие в end тоже могло измениться
+	_, err := repo.RebuildRollups(ctx, []int64{id}, start, end.Add(time.Nanosecond))
+	if errors.Is(err, ErrRollupsNotSupported) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rollupEventRepository - хранилище событий со сводками, умеющее менять значения событий
+type rollupEventRepository struct {
+	*MockEventRepository
+	*MockEventRecalibrationRepository
+	*MockEventRollupRepository
+}
+
+func Test_event_RebuildRollups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(48 * time.Hour)
+
+	t.Run("ok", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		er := rollupEventRepository{NewMockEventRepository(ctrl), nil, NewMockEventRollupRepository(ctrl)}
+		er.MockEventRollupRepository.EXPECT().RebuildRollups(derivedFrom(ctx), []int64{1, 2}, start, end).Return(int64(10), nil)
+
+		rebuilt, err := NewEvent(er, nil).RebuildRollups(ctx, []int64{1, 2}, start, end)
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), rebuilt)
+	})
+
+	t.Run("fail, repository error", func(t *testing.T) {
+		expectedError := errors.New("some error")
+		er := rollupEventRepository{NewMockEventRepository(ctrl), nil, NewMockEventRollupRepository(ctrl)}
+		er.MockEventRollupRepository.EXPECT().RebuildRollups(gomock.Any(), gomock.Nil(), start, end).Return(int64(0), expectedError)
+
+		_, err := NewEvent(er, nil).RebuildRollups(context.Background(), nil, start, end)
+		assert.ErrorIs(t, err, expectedError)
+	})
+
+	t.Run("fail, wrong period", func(t *testing.T) {
+		er := rollupEventRepository{NewMockEventRepository(ctrl), nil, NewMockEventRollupRepository(ctrl)}
+		for _, period := range [][2]time.Time{{{}, end}, {start, start}, {end, start}} {
+			_, err := NewEvent(er, nil).RebuildRollups(context.Background(), nil, period[0], period[1])
+			assert.ErrorIs(t, err, ErrInvalidEventTimestamp)
+		}
+	})
+
+	t.Run("fail, not supported", func(t *testing.T) {
+		_, err := NewEvent(NewMockEventRepository(ctrl), nil).RebuildRollups(context.Background(), nil, start, end)
+		assert.ErrorIs(t, err, ErrRollupsNotSupported)
+	})
+}
+
+func Test_event_Recalibrate_Rollups(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	sensor := &domain.Sensor{ID: 1, Calibration: &domain.Calibration{Gain: 2}}
+	recalibrate := func(t *testing.T, events []*domain.Event, expect func(ctx context.Context, er rollupEventRepository)) error {
+		t.Helper()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), int64(1)).Return(sensor, nil)
+		er := rollupEventRepository{NewMockEventRepository(ctrl), NewMockEventRecalibrationRepository(ctrl), NewMockEventRollupRepository(ctrl)}
+		er.MockEventRepository.EXPECT().GetEventsBySensorID(derivedFrom(ctx), int64(1), "", start, end).Return(events, nil)
+		expect(ctx, er)
+		_, err := NewEvent(er, sr).Recalibrate(ctx, 1, start, end)
+		return err
+	}
+
+	t.Run("ok, rollups rebuilt", func(t *testing.T) {
+		err := recalibrate(t, []*domain.Event{{SensorID: 1, Timestamp: end, Raw: 1, Payload: 1}}, func(ctx context.Context, er rollupEventRepository) {
+			er.MockEventRecalibrationRepository.EXPECT().UpdateEventPayloads(derivedFrom(ctx), gomock.Any()).Return(int64(1), nil)
+			er.MockEventRollupRepository.EXPECT().RebuildRollups(derivedFrom(ctx), []int64{1}, start, end.Add(time.Nanosecond)).Return(int64(3), nil)
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("ok, nothing changed", func(t *testing.T) {
+		err := recalibrate(t, []*domain.Event{{SensorID: 1, Timestamp: end, Raw: 1, Payload: 2}}, func(context.Context, rollupEventRepository) {})
+		require.NoError(t, err)
+	})
+
+	t.Run("ok, storage without rollups", func(t *testing.T) {
+		err := recalibrate(t, []*domain.Event{{SensorID: 1, Timestamp: end, Raw: 1, Payload: 1}}, func(ctx context.Context, er rollupEventRepository) {
+			er.MockEventRecalibrationRepository.EXPECT().UpdateEventPayloads(derivedFrom(ctx), gomock.Any()).Return(int64(1), nil)
+			er.MockEventRollupRepository.EXPECT().RebuildRollups(derivedFrom(ctx), []int64{1}, start, end.Add(time.Nanosecond)).Return(int64(0), ErrRollupsNotSupported)
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("fail, rebuild error", func(t *testing.T) {
+		expectedError := errors.New("some error")
+		err := recalibrate(t, []*domain.Event{{SensorID: 1, Timestamp: end, Raw: 1, Payload: 1}}, func(ctx context.Context, er rollupEventRepository) {
+			er.MockEventRecalibrationRepository.EXPECT().UpdateEventPayloads(derivedFrom(ctx), gomock.Any()).Return(int64(1), nil)
+			er.MockEventRollupRepository.EXPECT().RebuildRollups(derivedFrom(ctx), []int64{1}, start, end.Add(time.Nanosecond)).Return(int64(0), expectedError)
+		})
+		assert.ErrorIs(t, err, expectedError)
+	})
+}
@@ -4,26 +4,68 @@ import (
 	"context"
 	"errors"
 	"homework/internal/domain"
+	"homework/internal/logging"
+	"homework/internal/sensortype"
+	"math"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// MaxSensorScale - наибольший десятичный масштаб показаний: 10^9 ещё точно представимо в float64
+	MaxSensorScale = 9
+	// MaxSensorChannels - наибольшее число каналов датчика
+	MaxSensorChannels = 16
+	// MaxCalibrationPoints - наибольшее число точек в таблице линеаризации
+	MaxCalibrationPoints = 64
 )
 
 type Sensor struct {
 	repo SensorRepository
+	// types - допустимые типы датчиков
+	types *sensortype.Registry
+}
+
+func NewSensor(sr SensorRepository, options ...func(*Sensor)) *Sensor {
+	s := &Sensor{repo: sr, types: sensortype.Default()}
+	for _, o := range options {
+		o(s)
+	}
+	return s
 }
 
-func NewSensor(sr SensorRepository) *Sensor {
-	return &Sensor{repo: sr}
+// WithSensorTypes - регистрировать датчики только типов из types; по умолчанию встроенные cc и adc
+func WithSensorTypes(types *sensortype.Registry) func(*Sensor) {
+	return func(s *Sensor) {
+		s.types = types
+	}
 }
 
-func (s *Sensor) RegisterSensor(ctx context.Context, sensor *domain.Sensor) (*domain.Sensor, error) {
+func (s *Sensor) RegisterSensor(ctx context.Context, sensor *domain.Sensor) (_ *domain.Sensor, err error) {
+	ctx, span := tracer.Start(ctx, "Sensor.RegisterSensor")
+	defer func() { endSpan(span, err) }()
+
 	if sensor == nil {
 		return nil, errors.New("sensor is nil")
 	}
-	if sensor.Type != domain.SensorTypeADC && sensor.Type != domain.SensorTypeContactClosure {
+	sensorType, ok := s.types.Lookup(sensor.Type)
+	if !ok {
 		return nil, ErrWrongSensorType
 	}
-	if len(sensor.SerialNumber) != 10 {
+	if !sensorType.ValidSerialNumber(sensor.SerialNumber) {
 		return nil, ErrWrongSensorSerialNumber
 	}
+	if !validScale(sensor.Scale) {
+		return nil, ErrWrongSensorScale
+	}
+	if err := validChannels(sensor.Channels); err != nil {
+		return nil, err
+	}
+	if err := validCalibration(sensor, sensor.Calibration); err != nil {
+		return nil, err
+	}
 
 	if sens, err := s.repo.GetSensorBySerialNumber(ctx, sensor.SerialNumber); err == nil {
 		return sens, nil
@@ -34,13 +76,162 @@ func (s *Sensor) RegisterSensor(ctx context.Context, sensor *domain.Sensor) (*do
 	if err := s.repo.SaveSensor(ctx, sensor); err != nil {
 		return nil, err
 	}
+	logging.FromContext(ctx).Info("sensor registered",
+		"sensor_id", sensor.ID, "sensor_serial", sensor.SerialNumber, "sensor_type", sensor.Type)
 	return sensor, nil
 }
 
-func (s *Sensor) GetSensors(ctx context.Context) ([]domain.Sensor, error) {
+func validScale(scale int) bool {
+	return scale >= 0 && scale <= MaxSensorScale
+}
+
+// validChannels - у каналов непустые уникальные имена и допустимый масштаб
+func validChannels(channels []domain.Channel) error {
+	if len(channels) > MaxSensorChannels {
+		return ErrWrongSensorChannels
+	}
+	names := make(map[string]bool, len(channels))
+	for _, channel := range channels {
+		if channel.Name == "" || names[channel.Name] {
+			return ErrWrongSensorChannels
+		}
+		names[channel.Name] = true
+		if !validScale(channel.Scale) {
+			return ErrWrongSensorScale
+		}
+	}
+	return nil
+}
+
+// validCalibration - профиль калибровки датчика sensor конечен, с ненулевым усилением
+// и таблицей по строго возрастающим показаниям; nil допустим всегда
+func validCalibration(sensor *domain.Sensor, calibration *domain.Calibration) error {
+	if calibration == nil {
+		return nil
+	}
+	if len(sensor.Channels) > 0 {
+		return ErrCalibrationChannels
+	}
+	finite := func(v float64) bool { return !math.IsNaN(v) && !math.IsInf(v, 0) }
+	if !finite(calibration.Offset) || !finite(calibration.Gain) || calibration.Gain == 0 {
+		return ErrWrongCalibration
+	}
+	if len(calibration.Table) == 1 || len(calibration.Table) > MaxCalibrationPoints {
+		return ErrWrongCalibration
+	}
+	for i, point := range calibration.Table {
+		if !finite(point.Raw) || !finite(point.Value) || i > 0 && point.Raw <= calibration.Table[i-1].Raw {
+			return ErrWrongCalibration
+		}
+	}
+	return nil
+}
+
+// GetSensorTypes - зарегистрированные типы датчиков в порядке имён
+func (s *Sensor) GetSensorTypes() []sensortype.Type {
+	return s.types.Types()
+}
+
+func (s *Sensor) GetSensors(ctx context.Context) (_ []domain.Sensor, err error) {
+	ctx, span := tracer.Start(ctx, "Sensor.GetSensors")
+	defer func() { endSpan(span, err) }()
+
 	return s.repo.GetSensors(ctx)
 }
 
-func (s *Sensor) GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error) {
+func (s *Sensor) GetSensorByID(ctx context.Context, id int64) (_ *domain.Sensor, err error) {
+	ctx, span := tracer.Start(ctx, "Sensor.GetSensorByID", trace.WithAttributes(attribute.Int64("sensor.id", id)))
+	defer func() { endSpan(span, err) }()
+
 	return s.repo.GetSensorByID(ctx, id)
 }
+
+// UpdateSensor - меняет настройки датчика. Если задан precondition, датчик меняется, только когда
+// precondition принимает его текущее состояние, иначе ErrSensorModified. Изменения, сделанные другим
+// запросом между чтением и записью, тоже приводят к ErrSensorModified, а не перезаписываются
+func (s *Sensor) UpdateSensor(ctx context.Context, id int64, patch domain.SensorPatch, precondition func(*domain.Sensor) bool) (_ *domain.Sensor, err error) {
+	ctx, span := tracer.Start(ctx, "Sensor.UpdateSensor", trace.WithAttributes(attribute.Int64("sensor.id", id)))
+	defer func() { endSpan(span, err) }()
+
+	sensor, err := s.repo.GetSensorByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if precondition != nil && !precondition(sensor) {
+		return nil, ErrSensorModified
+	}
+
+	version := sensor.Version
+	if patch.Description != nil {
+		sensor.Description = *patch.Description
+	}
+	if patch.IsActive != nil {
+		sensor.IsActive = *patch.IsActive
+	}
+	if patch.Unit != nil {
+		sensor.Unit = *patch.Unit
+	}
+	if patch.Scale != nil {
+		if !validScale(*patch.Scale) {
+			return nil, ErrWrongSensorScale
+		}
+		sensor.Scale = *patch.Scale
+	}
+	if err := s.repo.UpdateSensor(ctx, sensor, version); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("sensor updated", "sensor_id", sensor.ID, "sensor_version", sensor.Version)
+	return sensor, nil
+}
+
+// UpdateCalibration - задаёт датчику калибровку change.Calibration или, если она nil, снимает её
+// и добавляет в журнал калибровки запись change с причиной и id запроса от вызывающего.
+// precondition - как в UpdateSensor. Новые события калибруются сразу, уже сохранённые
+// пересчитывает Event.Recalibrate
+func (s *Sensor) UpdateCalibration(ctx context.Context, id int64, change domain.CalibrationChange, precondition func(*domain.Sensor) bool) (_ *domain.Sensor, err error) {
+	ctx, span := tracer.Start(ctx, "Sensor.UpdateCalibration", trace.WithAttributes(attribute.Int64("sensor.id", id)))
+	defer func() { endSpan(span, err) }()
+
+	repo, ok := s.repo.(SensorCalibrationRepository)
+	if !ok {
+		return nil, ErrCalibrationNotSupported
+	}
+	sensor, err := s.repo.GetSensorByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if precondition != nil && !precondition(sensor) {
+		return nil, ErrSensorModified
+	}
+	if err := validCalibration(sensor, change.Calibration); err != nil {
+		return nil, err
+	}
+
+	version := sensor.Version
+	change.SensorID = sensor.ID
+	change.Previous = sensor.Calibration
+	change.Calibration = change.Calibration.Clone()
+	change.ChangedAt = time.Now()
+	sensor.Calibration = change.Calibration.Clone()
+	if err := repo.UpdateSensorCalibration(ctx, sensor, version, &change); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Info("sensor calibration updated",
+		"sensor_id", sensor.ID, "sensor_version", sensor.Version, "calibrated", sensor.Calibration != nil)
+	return sensor, nil
+}
+
+// GetCalibrationChanges - журнал калибровки датчика от старых изменений к новым
+func (s *Sensor) GetCalibrationChanges(ctx context.Context, id int64) (_ []domain.CalibrationChange, err error) {
+	ctx, span := tracer.Start(ctx, "Sensor.GetCalibrationChanges", trace.WithAttributes(attribute.Int64("sensor.id", id)))
+	defer func() { endSpan(span, err) }()
+
+	repo, ok := s.repo.(SensorCalibrationRepository)
+	if !ok {
+		return nil, ErrCalibrationNotSupported
+	}
+	if _, err := s.repo.GetSensorByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return repo.GetCalibrationChanges(ctx, id)
+}
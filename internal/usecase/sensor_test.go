@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"homework/internal/domain"
+	"homework/internal/sensortype"
+	"math"
+	"regexp"
 	"testing"
 	"time"
 
@@ -20,7 +23,7 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 		defer cancel()
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(0)
+		sr.EXPECT().SaveSensor(derivedFrom(ctx), gomock.Any()).Times(0)
 
 		s := NewSensor(sr)
 
@@ -41,6 +44,20 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 			SerialNumber: "123456789011", // wrong, should be 10 digits
 		})
 		assert.ErrorIs(t, err, ErrWrongSensorSerialNumber)
+
+		_, err = s.RegisterSensor(ctx, &domain.Sensor{
+			Type:         domain.SensorTypeADC,
+			SerialNumber: "1234567890",
+			Scale:        MaxSensorScale + 1,
+		})
+		assert.ErrorIs(t, err, ErrWrongSensorScale)
+
+		_, err = s.RegisterSensor(ctx, &domain.Sensor{
+			Type:         domain.SensorTypeADC,
+			SerialNumber: "1234567890",
+			Channels:     []domain.Channel{{Name: "temperature"}, {Name: "temperature"}},
+		})
+		assert.ErrorIs(t, err, ErrWrongSensorChannels)
 	})
 
 	t.Run("fail, repository return an error", func(t *testing.T) {
@@ -49,7 +66,7 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 
 		sr := NewMockSensorRepository(ctrl)
 		expectedError := errors.New("some error")
-		sr.EXPECT().GetSensorBySerialNumber(ctx, gomock.Any()).Return(nil, expectedError)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), gomock.Any()).Return(nil, expectedError)
 
 		s := NewSensor(sr)
 
@@ -67,8 +84,8 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 
 		sr := NewMockSensorRepository(ctrl)
 		expectedError := errors.New("some error")
-		sr.EXPECT().GetSensorBySerialNumber(ctx, gomock.Any()).Return(nil, ErrSensorNotFound)
-		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).Return(expectedError)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), gomock.Any()).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().SaveSensor(derivedFrom(ctx), gomock.Any()).Times(1).Return(expectedError)
 
 		a := NewSensor(sr)
 
@@ -91,7 +108,7 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 		}
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, ss *domain.Sensor) error {
+		sr.EXPECT().SaveSensor(derivedFrom(ctx), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, ss *domain.Sensor) error {
 			assert.Empty(t, ss.RegisteredAt)
 			assert.Empty(t, ss.LastActivity)
 			assert.Equal(t, sensor.Description, ss.Description)
@@ -103,7 +120,7 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 
 			return nil
 		})
-		sr.EXPECT().GetSensorBySerialNumber(ctx, sensor.SerialNumber).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), sensor.SerialNumber).Return(nil, ErrSensorNotFound)
 
 		s := NewSensor(sr)
 
@@ -114,6 +131,32 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 		assert.Equal(t, int64(1), sensor.ID)
 	})
 
+	t.Run("ok, registered sensor type", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		types, err := sensortype.NewRegistry(sensortype.Type{
+			Name:         "co2",
+			Kind:         sensortype.KindInteger,
+			SerialNumber: regexp.MustCompile(`^CO2-\d{6}$`),
+			Aggregation:  sensortype.AggregationMean,
+		})
+		assert.NoError(t, err)
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), "CO2-000001").Return(nil, ErrSensorNotFound)
+		sr.EXPECT().SaveSensor(derivedFrom(ctx), gomock.Any()).Return(nil)
+
+		s := NewSensor(sr, WithSensorTypes(types))
+
+		_, err = s.RegisterSensor(ctx, &domain.Sensor{Type: "co2", SerialNumber: "CO2-000001"})
+		assert.NoError(t, err)
+		_, err = s.RegisterSensor(ctx, &domain.Sensor{Type: "co2", SerialNumber: "1234567890"})
+		assert.ErrorIs(t, err, ErrWrongSensorSerialNumber)
+		_, err = s.RegisterSensor(ctx, &domain.Sensor{Type: domain.SensorTypeADC, SerialNumber: "1234567890"})
+		assert.ErrorIs(t, err, ErrWrongSensorType)
+	})
+
 	t.Run("ok, register idempotency", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
@@ -125,7 +168,7 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 		}
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().SaveSensor(ctx, gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, ss *domain.Sensor) error {
+		sr.EXPECT().SaveSensor(derivedFrom(ctx), gomock.Any()).Times(1).DoAndReturn(func(_ context.Context, ss *domain.Sensor) error {
 			assert.Empty(t, ss.RegisteredAt)
 			assert.Empty(t, ss.LastActivity)
 			assert.Equal(t, sensor.Description, ss.Description)
@@ -137,7 +180,7 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 
 			return nil
 		})
-		sr.EXPECT().GetSensorBySerialNumber(ctx, sensor.SerialNumber).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), sensor.SerialNumber).Return(nil, ErrSensorNotFound)
 
 		s := NewSensor(sr)
 
@@ -147,7 +190,7 @@ func Test_sensor_RegisterSensor(t *testing.T) {
 		assert.NotEmpty(t, sensor.RegisteredAt)
 		assert.Equal(t, int64(1), sensor.ID)
 
-		sr.EXPECT().GetSensorBySerialNumber(ctx, sensor.SerialNumber).Return(sensor, nil)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), sensor.SerialNumber).Return(sensor, nil)
 
 		sensor2, err := s.RegisterSensor(ctx, &domain.Sensor{
 			Type:         domain.SensorTypeContactClosure,
@@ -174,7 +217,7 @@ func Test_sensor_GetSensors(t *testing.T) {
 
 		sr := NewMockSensorRepository(ctrl)
 		expectedError := errors.New("some error")
-		sr.EXPECT().GetSensors(ctx).Times(1).Return(nil, expectedError)
+		sr.EXPECT().GetSensors(derivedFrom(ctx)).Times(1).Return(nil, expectedError)
 
 		s := NewSensor(sr)
 
@@ -187,7 +230,7 @@ func Test_sensor_GetSensors(t *testing.T) {
 		defer cancel()
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().GetSensors(ctx).Times(1).Return([]domain.Sensor{
+		sr.EXPECT().GetSensors(derivedFrom(ctx)).Times(1).Return([]domain.Sensor{
 			{},
 			{},
 		}, nil)
@@ -210,7 +253,7 @@ func Test_sensor_GetSensorByID(t *testing.T) {
 
 		sr := NewMockSensorRepository(ctrl)
 		expectedError := errors.New("some error")
-		sr.EXPECT().GetSensorByID(ctx, gomock.Any()).Times(1).Return(nil, expectedError)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), gomock.Any()).Times(1).Return(nil, expectedError)
 
 		s := NewSensor(sr)
 
@@ -223,7 +266,7 @@ func Test_sensor_GetSensorByID(t *testing.T) {
 		defer cancel()
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().GetSensorByID(ctx, gomock.Any()).Times(1).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), gomock.Any()).Times(1).Return(nil, ErrSensorNotFound)
 
 		s := NewSensor(sr)
 
@@ -236,7 +279,7 @@ func Test_sensor_GetSensorByID(t *testing.T) {
 		defer cancel()
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().GetSensorByID(ctx, gomock.Any()).Times(1).Return(&domain.Sensor{
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), gomock.Any()).Times(1).Return(&domain.Sensor{
 			ID:           1,
 			SerialNumber: "0123456789",
 			Type:         domain.SensorTypeADC,
@@ -253,3 +296,191 @@ func Test_sensor_GetSensorByID(t *testing.T) {
 		assert.NotNil(t, sensor)
 	})
 }
+
+func Test_sensor_UpdateSensor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	current := func() *domain.Sensor {
+		return &domain.Sensor{ID: 1, SerialNumber: "1234567890", Description: "old", IsActive: true, Version: 3}
+	}
+	description := "new"
+
+	t.Run("ok, patch applied to current version", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), int64(1)).Return(current(), nil)
+		sr.EXPECT().UpdateSensor(derivedFrom(ctx), gomock.Any(), int64(3)).DoAndReturn(
+			func(_ context.Context, sensor *domain.Sensor, _ int64) error {
+				sensor.Version = 4
+				return nil
+			})
+
+		sensor, err := NewSensor(sr).UpdateSensor(ctx, 1, domain.SensorPatch{Description: &description}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "new", sensor.Description)
+		assert.True(t, sensor.IsActive)
+		assert.Equal(t, int64(4), sensor.Version)
+	})
+
+	t.Run("fail, precondition rejects", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), int64(1)).Return(current(), nil)
+		sr.EXPECT().UpdateSensor(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := NewSensor(sr).UpdateSensor(ctx, 1, domain.SensorPatch{Description: &description},
+			func(s *domain.Sensor) bool { return s.Version == 2 })
+		assert.ErrorIs(t, err, ErrSensorModified)
+	})
+
+	t.Run("fail, modified concurrently", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), int64(1)).Return(current(), nil)
+		sr.EXPECT().UpdateSensor(derivedFrom(ctx), gomock.Any(), int64(3)).Return(ErrSensorModified)
+
+		_, err := NewSensor(sr).UpdateSensor(ctx, 1, domain.SensorPatch{Description: &description},
+			func(s *domain.Sensor) bool { return s.Version == 3 })
+		assert.ErrorIs(t, err, ErrSensorModified)
+	})
+
+	t.Run("ok, unit and scale", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), int64(1)).Return(current(), nil)
+		sr.EXPECT().UpdateSensor(derivedFrom(ctx), gomock.Any(), int64(3)).Return(nil)
+
+		unit, scale := "°C", 1
+		sensor, err := NewSensor(sr).UpdateSensor(ctx, 1, domain.SensorPatch{Unit: &unit, Scale: &scale}, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "°C", sensor.Unit)
+		assert.Equal(t, 1, sensor.Scale)
+		assert.Equal(t, "old", sensor.Description)
+	})
+
+	t.Run("fail, wrong scale", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), int64(1)).Return(current(), nil)
+		sr.EXPECT().UpdateSensor(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		scale := -1
+		_, err := NewSensor(sr).UpdateSensor(ctx, 1, domain.SensorPatch{Scale: &scale}, nil)
+		assert.ErrorIs(t, err, ErrWrongSensorScale)
+	})
+
+	t.Run("fail, not found", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), int64(1)).Return(nil, ErrSensorNotFound)
+
+		_, err := NewSensor(sr).UpdateSensor(ctx, 1, domain.SensorPatch{}, nil)
+		assert.ErrorIs(t, err, ErrSensorNotFound)
+	})
+}
+
+// calibratedSensorRepository - хранилище датчиков с журналом калибровки
+type calibratedSensorRepository struct {
+	*MockSensorRepository
+	*MockSensorCalibrationRepository
+}
+
+func Test_sensor_UpdateCalibration(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	previous := &domain.Calibration{Gain: 1, Offset: 1}
+	current := func() *domain.Sensor {
+		return &domain.Sensor{ID: 1, SerialNumber: "1234567890", Version: 3, Calibration: previous.Clone()}
+	}
+
+	t.Run("ok, change logged", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := calibratedSensorRepository{NewMockSensorRepository(ctrl), NewMockSensorCalibrationRepository(ctrl)}
+		sr.MockSensorRepository.EXPECT().GetSensorByID(derivedFrom(ctx), int64(1)).Return(current(), nil)
+		calibration := &domain.Calibration{Gain: 2, Table: []domain.CalibrationPoint{{Raw: 0, Value: 0}, {Raw: 1, Value: 2}}}
+		sr.MockSensorCalibrationRepository.EXPECT().UpdateSensorCalibration(derivedFrom(ctx), gomock.Any(), int64(3), gomock.Any()).DoAndReturn(
+			func(_ context.Context, sensor *domain.Sensor, _ int64, change *domain.CalibrationChange) error {
+				assert.Equal(t, calibration, sensor.Calibration)
+				assert.Equal(t, int64(1), change.SensorID)
+				assert.Equal(t, calibration, change.Calibration)
+				assert.Equal(t, previous, change.Previous)
+				assert.Equal(t, "поверка", change.Reason)
+				assert.Equal(t, "req-1", change.RequestID)
+				assert.False(t, change.ChangedAt.IsZero())
+				sensor.Version = 4
+				return nil
+			})
+
+		sensor, err := NewSensor(sr).UpdateCalibration(ctx, 1, domain.CalibrationChange{
+			Calibration: calibration, Reason: "поверка", RequestID: "req-1",
+		}, func(s *domain.Sensor) bool { return s.Version == 3 })
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), sensor.Version)
+		assert.Equal(t, calibration, sensor.Calibration)
+	})
+
+	t.Run("ok, calibration removed", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := calibratedSensorRepository{NewMockSensorRepository(ctrl), NewMockSensorCalibrationRepository(ctrl)}
+		sr.MockSensorRepository.EXPECT().GetSensorByID(derivedFrom(ctx), int64(1)).Return(current(), nil)
+		sr.MockSensorCalibrationRepository.EXPECT().UpdateSensorCalibration(derivedFrom(ctx), gomock.Any(), int64(3), gomock.Any()).Return(nil)
+
+		sensor, err := NewSensor(sr).UpdateCalibration(ctx, 1, domain.CalibrationChange{}, nil)
+		assert.NoError(t, err)
+		assert.Nil(t, sensor.Calibration)
+	})
+
+	t.Run("fail, wrong calibration", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			sensor      domain.Sensor
+			calibration domain.Calibration
+			err         error
+		}{
+			{"zero gain", domain.Sensor{}, domain.Calibration{}, ErrWrongCalibration},
+			{"nan offset", domain.Sensor{}, domain.Calibration{Gain: 1, Offset: math.NaN()}, ErrWrongCalibration},
+			{"single point", domain.Sensor{}, domain.Calibration{Gain: 1, Table: []domain.CalibrationPoint{{}}}, ErrWrongCalibration},
+			{"unordered table", domain.Sensor{}, domain.Calibration{Gain: 1, Table: []domain.CalibrationPoint{{Raw: 1}, {Raw: 1}}}, ErrWrongCalibration},
+			{"too many points", domain.Sensor{}, domain.Calibration{Gain: 1, Table: make([]domain.CalibrationPoint, MaxCalibrationPoints+1)}, ErrWrongCalibration},
+			{"channels", domain.Sensor{Channels: []domain.Channel{{Name: "t"}}}, domain.Calibration{Gain: 1}, ErrCalibrationChannels},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+
+				sensor := tt.sensor
+				sensor.ID = 1
+				sr := calibratedSensorRepository{NewMockSensorRepository(ctrl), NewMockSensorCalibrationRepository(ctrl)}
+				sr.MockSensorRepository.EXPECT().GetSensorByID(derivedFrom(ctx), int64(1)).Return(&sensor, nil)
+				sr.MockSensorCalibrationRepository.EXPECT().UpdateSensorCalibration(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+				_, err := NewSensor(sr).UpdateCalibration(ctx, 1, domain.CalibrationChange{Calibration: &tt.calibration}, nil)
+				assert.ErrorIs(t, err, tt.err)
+			})
+		}
+	})
+
+	t.Run("fail, not supported", func(t *testing.T) {
+		_, err := NewSensor(NewMockSensorRepository(ctrl)).UpdateCalibration(context.Background(), 1, domain.CalibrationChange{}, nil)
+		assert.ErrorIs(t, err, ErrCalibrationNotSupported)
+	})
+}
@@ -0,0 +1,319 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/sensortype"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SeriesLimits - ограничения запроса рядов, чтобы один запрос не просматривал всю историю; 0 снимает ограничение
+type SeriesLimits struct {
+	// MaxSensors - сколько датчиков можно сравнить одним запросом
+	MaxSensors int
+	// MaxRange - наибольшая длина периода
+	MaxRange time.Duration
+	// MaxBuckets - сколько интервалов может быть в ряду
+	MaxBuckets int
+}
+
+// DefaultSeriesLimits - ограничения по умолчанию: 20 датчиков, год, 1000 интервалов
+var DefaultSeriesLimits = SeriesLimits{MaxSensors: 20, MaxRange: 366 * 24 * time.Hour, MaxBuckets: 1000}
+
+// WithEventSeriesLimits - ограничения запросов рядов вместо DefaultSeriesLimits
+func WithEventSeriesLimits(limits SeriesLimits) func(*Event) {
+	return func(e *Event) {
+		e.seriesLimits = limits
+	}
+}
+
+// QuerySeries - значения датчиков query.SensorIDs, сведённые по интервалам query.Bucket от query.Start,
+// чтобы сравнить их на одном графике. allowed - датчики, доступные вызывающему: датчик не из них, даже
+// несуществующий, - ErrSensorAccessDenied, чтобы ответ не выдавал чужие датчики. Без query.Aggregation
+// значения сводятся функцией типа датчика. Хранилище, которое умеет сводить события само
+// (EventAggregationRepository), отдаёт сводки всех датчиков одним запросом; у остальных события
+// каждого датчика читаются и сводятся здесь. Суточные интервалы разной длины хранилище сводит
+// по их общему делителю, обычно часу, а здесь сводки собираются в сутки
+func (e *Event) QuerySeries(ctx context.Context, query domain.SeriesQuery, allowed []domain.Sensor) (_ *domain.SeriesResult, err error) {
+	ctx, span := tracer.Start(ctx, "Event.QuerySeries", trace.WithAttributes(
+		attribute.Int("series.sensors", len(query.SensorIDs)), attribute.String("series.bucket", query.Bucket.String())))
+	defer func() { endSpan(span, err) }()
+
+	bounds, err := e.seriesBounds(query)
+	if err != nil {
+		return nil, err
+	}
+	sensors := make([]*domain.Sensor, len(query.SensorIDs))
+	for i, id := range query.SensorIDs {
+		j := slices.IndexFunc(allowed, func(s domain.Sensor) bool { return s.ID == id })
+		if j < 0 {
+			return nil, ErrSensorAccessDenied
+		}
+		sensors[i] = &allowed[j]
+	}
+
+	aggregates, err := e.aggregate(ctx, query, bounds)
+	if err != nil {
+		return nil, err
+	}
+	byKey := make(map[seriesKey][]domain.Aggregate)
+	for _, a := range aggregates {
+		key := seriesKey{a.SensorID, a.Channel}
+		byKey[key] = append(byKey[key], a)
+	}
+
+	n := len(bounds) - 1
+	result := &domain.SeriesResult{Buckets: bounds[:n]}
+	for _, sensor := range sensors {
+		for _, channel := range seriesChannels(sensor, byKey) {
+			result.Series = append(result.Series, e.series(sensor, channel, byKey[seriesKey{sensor.ID, channel}], query, bounds))
+		}
+	}
+	return result, nil
+}
+
+// seriesKey - ряд: датчик или его канал
+type seriesKey struct {
+	sensorID int64
+	channel  string
+}
+
+// seriesBounds - проверяет запрос и ограничения и возвращает границы интервалов:
+// i-й интервал - [bounds[i], bounds[i+1]), последний может выходить за конец периода
+func (e *Event) seriesBounds(query domain.SeriesQuery) ([]time.Time, error) {
+	if len(query.SensorIDs) == 0 || query.Start.IsZero() || !query.End.After(query.Start) || query.Bucket <= 0 {
+		return nil, ErrWrongSeriesQuery
+	}
+	if query.Aggregation != "" && !slices.Contains(domain.Aggregations, query.Aggregation) {
+		return nil, ErrWrongSeriesQuery
+	}
+	if ids := slices.Sorted(slices.Values(query.SensorIDs)); len(slices.Compact(ids)) != len(query.SensorIDs) {
+		return nil, ErrWrongSeriesQuery
+	}
+
+	origin, days := query.Start, 0
+	if query.Bucket%(24*time.Hour) == 0 {
+		location := query.Location
+		if location == nil {
+			location = time.UTC
+		}
+		start := query.Start.In(location)
+		origin, days = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, location), int(query.Bucket/(24*time.Hour))
+	}
+	// смена времени сдвигает суточные границы на час, а не на интервал, так что оценки хватает для ограничений
+	n := (query.End.Sub(origin) + query.Bucket - 1) / query.Bucket
+	limits := e.seriesLimits
+	if limits.MaxSensors > 0 && len(query.SensorIDs) > limits.MaxSensors ||
+		limits.MaxRange > 0 && query.End.Sub(query.Start) > limits.MaxRange ||
+		limits.MaxBuckets > 0 && n > time.Duration(limits.MaxBuckets) {
+		return nil, ErrSeriesQueryTooLarge
+	}
+
+	bounds := make([]time.Time, 0, n+2)
+	for i, t := 0, origin; ; i++ {
+		bounds = append(bounds, t)
+		if !t.Before(query.End) {
+			return bounds, nil
+		}
+		if days > 0 {
+			t = origin.AddDate(0, 0, (i+1)*days)
+		} else {
+			t = t.Add(query.Bucket)
+		}
+	}
+}
+
+// aggregate - сводки событий датчиков запроса по интервалам bounds в порядке датчика, канала и начала интервала
+func (e *Event) aggregate(ctx context.Context, query domain.SeriesQuery, bounds []time.Time) ([]domain.Aggregate, error) {
+	// интервалы одной длины сводятся сразу, разной - по общему делителю длин и потом собираются
+	var step time.Duration
+	for _, bound := range bounds[1:] {
+		step = gcd(step, bound.Sub(bounds[0]))
+	}
+	start := bounds[0]
+
+	if repo, ok := e.eventRepo.(EventAggregationRepository); ok {
+		aggregates, err := repo.AggregateEvents(ctx, query.SensorIDs, start, query.End, step)
+		if !errors.Is(err, ErrAggregationNotSupported) {
+			return mergeAggregates(aggregates, bounds), err
+		}
+	}
+	var aggregates []domain.Aggregate
+	for _, id := range query.SensorIDs {
+		events, err := e.eventRepo.GetEventsBySensorID(ctx, id, "", start, query.End)
+		if err != nil {
+			return nil, err
+		}
+		aggregates = append(aggregates, aggregateEvents(events, start, query.End, step)...)
+	}
+	return mergeAggregates(aggregates, bounds), nil
+}
+
+func gcd(a, b time.Duration) time.Duration {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// mergeAggregates - собирает сводки, упорядоченные как их отдаёт EventAggregationRepository, в интервалы bounds
+func mergeAggregates(aggregates []domain.Aggregate, bounds []time.Time) []domain.Aggregate {
+	var merged []domain.Aggregate
+	for _, a := range aggregates {
+		i, found := slices.BinarySearchFunc(bounds, a.Start, time.Time.Compare)
+		if !found {
+			i--
+		}
+		start := bounds[i]
+		if k := len(merged) - 1; k >= 0 && merged[k].SensorID == a.SensorID && merged[k].Channel == a.Channel && merged[k].Start.Equal(start) {
+			m := &merged[k]
+			m.Count += a.Count
+			m.Sum += a.Sum
+			m.Min = min(m.Min, a.Min)
+			m.Max = max(m.Max, a.Max)
+			m.Last = a.Last
+			m.Mean = m.Sum / float64(m.Count)
+			continue
+		}
+		a.Start = start
+		merged = append(merged, a)
+	}
+	return merged
+}
+
+// aggregateEvents - сводки событий одного датчика по интервалам, как их отдаёт EventAggregationRepository.
+// События должны идти по времени
+func aggregateEvents(events []*domain.Event, start, end time.Time, bucket time.Duration) []domain.Aggregate {
+	type key struct {
+		channel string
+		bucket  time.Duration
+	}
+	index := make(map[key]int)
+	var aggregates []domain.Aggregate
+	for _, event := range events {
+		// GetEventsBySensorID включает конец периода, а интервалы - нет
+		if !event.Timestamp.Before(end) {
+			continue
+		}
+		k := key{event.Channel, event.Timestamp.Sub(start) / bucket}
+		i, ok := index[k]
+		if !ok {
+			i = len(aggregates)
+			index[k] = i
+			aggregates = append(aggregates, domain.Aggregate{
+				SensorID: event.SensorID,
+				Channel:  event.Channel,
+				Start:    start.Add(k.bucket * bucket),
+				Min:      event.Payload,
+				Max:      event.Payload,
+			})
+		}
+		a := &aggregates[i]
+		a.Count++
+		a.Sum += event.Payload
+		a.Min = min(a.Min, event.Payload)
+		a.Max = max(a.Max, event.Payload)
+		a.Last = event.Payload
+	}
+	for i := range aggregates {
+		aggregates[i].Mean = aggregates[i].Sum / float64(aggregates[i].Count)
+	}
+	slices.SortFunc(aggregates, func(a, b domain.Aggregate) int {
+		return cmp.Or(cmp.Compare(a.Channel, b.Channel), a.Start.Compare(b.Start))
+	})
+	return aggregates
+}
+
+// seriesChannels - каналы датчика, для которых строятся ряды: все объявленные, даже без событий,
+// и каналы, события которых остались с прежней конфигурации датчика
+func seriesChannels(sensor *domain.Sensor, byKey map[seriesKey][]domain.Aggregate) []string {
+	channels := []string{""}
+	if len(sensor.Channels) > 0 {
+		channels = channels[:0]
+		for _, channel := range sensor.Channels {
+			channels = append(channels, channel.Name)
+		}
+	}
+	var extra []string
+	for key := range byKey {
+		if key.sensorID == sensor.ID && !slices.Contains(channels, key.channel) {
+			extra = append(extra, key.channel)
+		}
+	}
+	slices.Sort(extra)
+	return append(channels, extra...)
+}
+
+// series - ряд канала channel датчика sensor из его сводок; пустые интервалы заполняются по query.Fill
+func (e *Event) series(sensor *domain.Sensor, channel string, aggregates []domain.Aggregate, query domain.SeriesQuery, bounds []time.Time) domain.Series {
+	sensorType, known := e.types.Lookup(sensor.Type)
+	aggregation := query.Aggregation
+	if aggregation == "" {
+		aggregation = domain.AggregationMean
+		if known {
+			aggregation = sensorType.Aggregation
+		}
+	}
+	unit := sensor.Unit
+	if c := sensor.Channel(channel); c != nil {
+		unit = c.Unit
+	}
+	n := len(bounds) - 1
+	series := domain.Series{
+		SensorID:    sensor.ID,
+		Channel:     channel,
+		Unit:        unit,
+		Aggregation: aggregation,
+		Values:      make([]*float64, n),
+		Counts:      make([]int64, n),
+	}
+	for _, a := range aggregates {
+		i, _ := slices.BinarySearchFunc(bounds, a.Start, time.Time.Compare)
+		value := a.Value(aggregation)
+		series.Values[i], series.Counts[i] = &value, a.Count
+	}
+	if query.Fill == domain.FillPrevious || query.Fill == domain.FillLinear {
+		hi := query.End
+		if now := time.Now(); now.Before(hi) {
+			hi = now
+		}
+		linear := query.Fill == domain.FillLinear && !(known && sensorType.Kind == sensortype.KindBinary)
+		fillSeries(series.Values, bounds, hi, linear)
+	}
+	return series
+}
+
+// fillSeries - заполняет пустые интервалы, начатые до hi, как пропуски истории: повторяет значение
+// предыдущего интервала или, если linear, интерполирует между соседними по началам интервалов.
+// До первого значения заполнять нечем, после последнего - не к чему интерполировать
+func fillSeries(values []*float64, bounds []time.Time, hi time.Time, linear bool) {
+	prev := -1
+	for i := range values {
+		if values[i] != nil {
+			prev = i
+			continue
+		}
+		if prev < 0 || !bounds[i].Before(hi) {
+			continue
+		}
+		value := *values[prev]
+		if linear {
+			next := i + 1
+			for next < len(values) && values[next] == nil {
+				next++
+			}
+			if next == len(values) {
+				continue
+			}
+			ratio := float64(bounds[i].Sub(bounds[prev])) / float64(bounds[next].Sub(bounds[prev]))
+			value += (*values[next] - value) * ratio
+		}
+		values[i] = &value
+	}
+}
@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"context"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// aggregatedEventRepository - хранилище событий, умеющее сводить их по интервалам
+type aggregatedEventRepository struct {
+	*MockEventRepository
+	*MockEventAggregationRepository
+}
+
+func Test_event_QuerySeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+	kitchen := domain.Sensor{ID: 1, Type: domain.SensorTypeADC, Unit: "°C"}
+	door := domain.Sensor{ID: 2, Type: domain.SensorTypeContactClosure}
+	bathroom := domain.Sensor{ID: 3, Type: domain.SensorTypeADC, Channels: []domain.Channel{{Name: "temperature", Unit: "°C"}, {Name: "humidity", Unit: "%"}}}
+	allowed := []domain.Sensor{kitchen, door, bathroom}
+	query := domain.SeriesQuery{SensorIDs: []int64{1, 2}, Start: at(0), End: at(30), Bucket: 10 * time.Minute}
+
+	values := func(series domain.Series) []any {
+		values := make([]any, len(series.Values))
+		for i, v := range series.Values {
+			if v != nil {
+				values[i] = *v
+			}
+		}
+		return values
+	}
+
+	t.Run("ok, aggregated by storage", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		er := aggregatedEventRepository{NewMockEventRepository(ctrl), NewMockEventAggregationRepository(ctrl)}
+		er.MockEventAggregationRepository.EXPECT().AggregateEvents(derivedFrom(ctx), []int64{1, 2}, at(0), at(30), 10*time.Minute).Return([]domain.Aggregate{
+			{SensorID: 1, Start: at(0), Count: 2, Mean: 21, Min: 20, Max: 22, Sum: 42, Last: 22},
+			{SensorID: 1, Start: at(20), Count: 1, Mean: 23, Min: 23, Max: 23, Sum: 23, Last: 23},
+			{SensorID: 2, Start: at(10), Count: 3, Mean: 1.0 / 3, Min: 0, Max: 1, Sum: 1, Last: 0},
+		}, nil)
+
+		result, err := NewEvent(er, nil).QuerySeries(ctx, query, allowed)
+		require.NoError(t, err)
+		assert.Equal(t, []time.Time{at(0), at(10), at(20)}, result.Buckets)
+		require.Len(t, result.Series, 2)
+		// без функции в запросе у каждого типа своя
+		assert.Equal(t, domain.AggregationMean, result.Series[0].Aggregation)
+		assert.Equal(t, "°C", result.Series[0].Unit)
+		assert.Equal(t, []any{21.0, nil, 23.0}, values(result.Series[0]))
+		assert.Equal(t, []int64{2, 0, 1}, result.Series[0].Counts)
+		assert.Equal(t, domain.AggregationLast, result.Series[1].Aggregation)
+		assert.Equal(t, []any{nil, 0.0, nil}, values(result.Series[1]))
+	})
+
+	t.Run("ok, aggregated here", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().GetEventsBySensorID(derivedFrom(ctx), int64(3), "", at(0), at(30)).Return([]*domain.Event{
+			{SensorID: 3, Timestamp: at(0), Channel: "humidity", Payload: 40},
+			{SensorID: 3, Timestamp: at(0), Channel: "temperature", Payload: 20},
+			{SensorID: 3, Timestamp: at(5), Channel: "temperature", Payload: 22},
+			{SensorID: 3, Timestamp: at(25), Channel: "humidity", Payload: 50},
+			// конец периода не входит в интервалы
+			{SensorID: 3, Timestamp: at(30), Channel: "humidity", Payload: 90},
+		}, nil)
+
+		q := query
+		q.SensorIDs, q.Aggregation, q.Fill = []int64{3}, domain.AggregationMax, domain.FillLinear
+		result, err := NewEvent(er, nil).QuerySeries(ctx, q, allowed)
+		require.NoError(t, err)
+		require.Len(t, result.Series, 2)
+		assert.Equal(t, "temperature", result.Series[0].Channel)
+		assert.Equal(t, domain.AggregationMax, result.Series[0].Aggregation)
+		assert.Equal(t, []any{22.0, nil, nil}, values(result.Series[0]))
+		assert.Equal(t, "humidity", result.Series[1].Channel)
+		assert.Equal(t, "%", result.Series[1].Unit)
+		assert.Equal(t, []any{40.0, 45.0, 50.0}, values(result.Series[1]))
+		assert.Equal(t, []int64{1, 0, 1}, result.Series[1].Counts)
+	})
+
+	t.Run("ok, previous", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		er := aggregatedEventRepository{NewMockEventRepository(ctrl), NewMockEventAggregationRepository(ctrl)}
+		er.MockEventAggregationRepository.EXPECT().AggregateEvents(derivedFrom(ctx), []int64{2}, at(0), at(30), 10*time.Minute).Return([]domain.Aggregate{
+			{SensorID: 2, Start: at(0), Count: 1, Mean: 1, Min: 1, Max: 1, Sum: 1, Last: 1},
+		}, nil)
+
+		q := query
+		q.SensorIDs, q.Fill = []int64{2}, domain.FillPrevious
+		result, err := NewEvent(er, nil).QuerySeries(ctx, q, allowed)
+		require.NoError(t, err)
+		assert.Equal(t, []any{1.0, 1.0, 1.0}, values(result.Series[0]))
+		assert.Equal(t, []int64{1, 0, 0}, result.Series[0].Counts)
+	})
+
+	t.Run("ok, days in time zone", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		require.NoError(t, err)
+		day := func(d int) time.Time { return time.Date(2025, 3, d, 0, 0, 0, 0, berlin) }
+		// 30 марта в Берлине переводят часы, эти сутки короче на час, и сводятся они по часам
+		er := aggregatedEventRepository{NewMockEventRepository(ctrl), NewMockEventAggregationRepository(ctrl)}
+		er.MockEventAggregationRepository.EXPECT().AggregateEvents(derivedFrom(ctx), []int64{1}, day(29), day(31).Add(time.Hour), time.Hour).Return([]domain.Aggregate{
+			{SensorID: 1, Start: day(29).Add(time.Hour), Count: 1, Mean: 10, Min: 10, Max: 10, Sum: 10, Last: 10},
+			{SensorID: 1, Start: day(30).Add(time.Hour), Count: 1, Mean: 20, Min: 20, Max: 20, Sum: 20, Last: 20},
+			{SensorID: 1, Start: day(31).Add(-time.Hour), Count: 3, Mean: 40, Min: 30, Max: 50, Sum: 120, Last: 30},
+			{SensorID: 1, Start: day(31), Count: 1, Mean: 5, Min: 5, Max: 5, Sum: 5, Last: 5},
+		}, nil)
+
+		// начало периода в UTC - ещё 28 марта, но сутки отсчитываются от полуночи по Берлину
+		q := domain.SeriesQuery{SensorIDs: []int64{1}, Start: day(29).UTC(), End: day(31).Add(time.Hour), Bucket: 24 * time.Hour, Location: berlin}
+		result, err := NewEvent(er, nil).QuerySeries(ctx, q, allowed)
+		require.NoError(t, err)
+		require.Equal(t, []time.Time{day(29), day(30), day(31)}, result.Buckets)
+		assert.Equal(t, 23*time.Hour, result.Buckets[2].Sub(result.Buckets[1]))
+		assert.Equal(t, []any{10.0, 35.0, 5.0}, values(result.Series[0]))
+		assert.Equal(t, []int64{1, 4, 1}, result.Series[0].Counts)
+	})
+
+	t.Run("fail, access denied", func(t *testing.T) {
+		q := query
+		q.SensorIDs = []int64{1, 4}
+		_, err := NewEvent(NewMockEventRepository(ctrl), nil).QuerySeries(context.Background(), q, allowed)
+		assert.ErrorIs(t, err, ErrSensorAccessDenied)
+	})
+
+	t.Run("fail, wrong query", func(t *testing.T) {
+		for name, change := range map[string]func(q *domain.SeriesQuery){
+			"no sensors":        func(q *domain.SeriesQuery) { q.SensorIDs = nil },
+			"repeated sensor":   func(q *domain.SeriesQuery) { q.SensorIDs = []int64{1, 1} },
+			"end before start":  func(q *domain.SeriesQuery) { q.End = q.Start },
+			"no bucket":         func(q *domain.SeriesQuery) { q.Bucket = 0 },
+			"unknown aggregate": func(q *domain.SeriesQuery) { q.Aggregation = "median" },
+		} {
+			q := query
+			change(&q)
+			_, err := NewEvent(NewMockEventRepository(ctrl), nil).QuerySeries(context.Background(), q, allowed)
+			assert.ErrorIs(t, err, ErrWrongSeriesQuery, name)
+		}
+	})
+
+	t.Run("fail, too large", func(t *testing.T) {
+		e := NewEvent(NewMockEventRepository(ctrl), nil, WithEventSeriesLimits(SeriesLimits{MaxSensors: 1, MaxRange: time.Hour, MaxBuckets: 6}))
+		for name, change := range map[string]func(q *domain.SeriesQuery){
+			"sensors": func(q *domain.SeriesQuery) {},
+			"range":   func(q *domain.SeriesQuery) { q.SensorIDs = []int64{1}; q.End, q.Bucket = at(61), time.Hour },
+			"buckets": func(q *domain.SeriesQuery) { q.SensorIDs = []int64{1}; q.Bucket = 4 * time.Minute },
+		} {
+			q := query
+			change(&q)
+			_, err := e.QuerySeries(context.Background(), q, allowed)
+			assert.ErrorIs(t, err, ErrSeriesQueryTooLarge, name)
+		}
+	})
+}
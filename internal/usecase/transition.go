@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"homework/internal/sensortype"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// saveTransition - записывает в журнал смену состояния from на состояние события event. Длительность
+// прежнего состояния считается от прошлой смены того же канала. Хранилище без журнала смен пропускается:
+// приём событий от него не зависит
+func (e *Event) saveTransition(ctx context.Context, event *domain.Event, from float64) error {
+	repo, ok := e.eventRepo.(EventTransitionRepository)
+	if !ok {
+		return nil
+	}
+	last, err := repo.GetLastTransition(ctx, event.SensorID, event.Channel)
+	switch {
+	case errors.Is(err, ErrTransitionsNotSupported):
+		return nil
+	case errors.Is(err, ErrNoTransition):
+		last = nil
+	case err != nil:
+		return err
+	}
+	transition := &domain.Transition{
+		SensorID:  event.SensorID,
+		Channel:   event.Channel,
+		Timestamp: event.Timestamp,
+		From:      from,
+		To:        event.Payload,
+	}
+	if last != nil && event.Timestamp.After(last.Timestamp) {
+		transition.Lasted = event.Timestamp.Sub(last.Timestamp)
+	}
+	return repo.SaveTransition(ctx, transition)
+}
+
+// GetTransitions - смены состояния датчика sensor вида binary, подходящие под filter, от старых к новым
+func (e *Event) GetTransitions(ctx context.Context, sensor *domain.Sensor, filter domain.TransitionFilter) (_ []domain.Transition, err error) {
+	ctx, span := tracer.Start(ctx, "Event.GetTransitions", trace.WithAttributes(attribute.Int64("sensor.id", sensor.ID)))
+	defer func() { endSpan(span, err) }()
+
+	repo, err := e.transitionRepository(sensor)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Start.IsZero() || !filter.End.After(filter.Start) || filter.MinLasted < 0 || filter.Limit < 0 ||
+		filter.To != nil && *filter.To != 0 && *filter.To != 1 {
+		return nil, ErrWrongTransitionFilter
+	}
+	return repo.GetTransitions(ctx, sensor.ID, filter)
+}
+
+// CountOpenings - сколько раз за каждые календарные сутки в location датчик sensor вида binary переходил
+// в состояние 1 (дверь открывалась, протечка начиналась) за [start, end]. Сутки без открытий тоже
+// есть в ответе, первые - сутки start, последние - сутки перед end. Длина периода ограничена
+// SeriesLimits.MaxRange
+func (e *Event) CountOpenings(ctx context.Context, sensor *domain.Sensor, start, end time.Time, location *time.Location) (_ []domain.DayCount, err error) {
+	ctx, span := tracer.Start(ctx, "Event.CountOpenings", trace.WithAttributes(attribute.Int64("sensor.id", sensor.ID)))
+	defer func() { endSpan(span, err) }()
+
+	repo, err := e.transitionRepository(sensor)
+	if err != nil {
+		return nil, err
+	}
+	if start.IsZero() || !end.After(start) {
+		return nil, ErrWrongTransitionFilter
+	}
+	if e.seriesLimits.MaxRange > 0 && end.Sub(start) > e.seriesLimits.MaxRange {
+		return nil, ErrSeriesQueryTooLarge
+	}
+	if location == nil {
+		location = time.UTC
+	}
+
+	opened := 1.0
+	transitions, err := repo.GetTransitions(ctx, sensor.ID, domain.TransitionFilter{Start: start, End: end, To: &opened})
+	if err != nil {
+		return nil, err
+	}
+	local := start.In(location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	var counts []domain.DayCount
+	for day.Before(end) {
+		counts = append(counts, domain.DayCount{Day: day})
+		day = day.AddDate(0, 0, 1)
+	}
+	for _, transition := range transitions {
+		t := transition.Timestamp.In(location)
+		// сутки считаются по календарю, а не по 24 часам: в дни смены времени они короче или длиннее
+		i := int(time.Date(t.Year(), t.Month(), t.Day(), 12, 0, 0, 0, time.UTC).Sub(
+			time.Date(counts[0].Day.Year(), counts[0].Day.Month(), counts[0].Day.Day(), 12, 0, 0, 0, time.UTC)) / (24 * time.Hour))
+		if i >= 0 && i < len(counts) {
+			counts[i].Count++
+		}
+	}
+	return counts, nil
+}
+
+// transitionRepository - журнал смен состояния для датчика sensor; у датчиков не вида binary смен нет
+func (e *Event) transitionRepository(sensor *domain.Sensor) (EventTransitionRepository, error) {
+	if sensorType, ok := e.types.Lookup(sensor.Type); !ok || sensorType.Kind != sensortype.KindBinary {
+		return nil, ErrNotBinarySensor
+	}
+	repo, ok := e.eventRepo.(EventTransitionRepository)
+	if !ok {
+		return nil, ErrTransitionsNotSupported
+	}
+	return repo, nil
+}
@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"homework/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// transitionEventRepository - хранилище событий с журналом смен состояния
+type transitionEventRepository struct {
+	*MockEventRepository
+	*MockEventTransitionRepository
+}
+
+func Test_event_ReceiveEvent_Transitions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	receive := func(t *testing.T, sensor *domain.Sensor, payload float64, expect func(ctx context.Context, er transitionEventRepository)) error {
+		t.Helper()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), sensor.SerialNumber).Return(sensor, nil)
+		sr.EXPECT().UpdateSensor(derivedFrom(ctx), sensor, gomock.Any()).Return(nil).AnyTimes()
+		er := transitionEventRepository{NewMockEventRepository(ctrl), NewMockEventTransitionRepository(ctrl)}
+		er.MockEventRepository.EXPECT().SaveEvent(derivedFrom(ctx), gomock.Any()).Return(nil)
+		expect(ctx, er)
+		return NewEvent(er, sr).ReceiveEvent(ctx, &domain.Event{
+			Timestamp: at, SensorSerialNumber: sensor.SerialNumber, Payload: payload,
+		})
+	}
+
+	t.Run("ok, state changed", func(t *testing.T) {
+		sensor := &domain.Sensor{ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeContactClosure,
+			CurrentState: 0, LastActivity: at.Add(-time.Minute)}
+		err := receive(t, sensor, 1, func(ctx context.Context, er transitionEventRepository) {
+			er.MockEventTransitionRepository.EXPECT().GetLastTransition(derivedFrom(ctx), int64(1), "").
+				Return(&domain.Transition{SensorID: 1, Timestamp: at.Add(-time.Hour), From: 1, To: 0}, nil)
+			er.MockEventTransitionRepository.EXPECT().SaveTransition(derivedFrom(ctx), &domain.Transition{
+				SensorID: 1, Timestamp: at, From: 0, To: 1, Lasted: time.Hour,
+			}).Return(nil)
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, sensor.CurrentState)
+	})
+
+	t.Run("ok, first change has unknown duration", func(t *testing.T) {
+		sensor := &domain.Sensor{ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeContactClosure,
+			CurrentState: 1, LastActivity: at.Add(-time.Minute)}
+		err := receive(t, sensor, 0, func(ctx context.Context, er transitionEventRepository) {
+			er.MockEventTransitionRepository.EXPECT().GetLastTransition(derivedFrom(ctx), int64(1), "").Return(nil, ErrNoTransition)
+			er.MockEventTransitionRepository.EXPECT().SaveTransition(derivedFrom(ctx), &domain.Transition{
+				SensorID: 1, Timestamp: at, From: 1, To: 0,
+			}).Return(nil)
+		})
+		require.NoError(t, err)
+	})
+
+	for name, sensor := range map[string]*domain.Sensor{
+		"same state":        {ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeContactClosure, CurrentState: 1, LastActivity: at.Add(-time.Minute)},
+		"first event":       {ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeContactClosure},
+		"late event":        {ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeContactClosure, LastActivity: at.Add(time.Minute)},
+		"not binary sensor": {ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeADC, LastActivity: at.Add(-time.Minute)},
+	} {
+		t.Run("ok, no transition, "+name, func(t *testing.T) {
+			require.NoError(t, receive(t, sensor, 1, func(context.Context, transitionEventRepository) {}))
+		})
+	}
+
+	t.Run("err, transition save error", func(t *testing.T) {
+		sensor := &domain.Sensor{ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeContactClosure, LastActivity: at.Add(-time.Minute)}
+		expectedError := errors.New("some error")
+		err := receive(t, sensor, 1, func(ctx context.Context, er transitionEventRepository) {
+			er.MockEventTransitionRepository.EXPECT().GetLastTransition(derivedFrom(ctx), int64(1), "").Return(nil, ErrNoTransition)
+			er.MockEventTransitionRepository.EXPECT().SaveTransition(derivedFrom(ctx), gomock.Any()).Return(expectedError)
+		})
+		assert.ErrorIs(t, err, expectedError)
+	})
+
+	t.Run("ok, late event between in-order ones", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		sensor := &domain.Sensor{ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeContactClosure,
+			CurrentState: 0, LastActivity: at.Add(-time.Minute)}
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), sensor.SerialNumber).Return(sensor, nil).Times(3)
+		sr.EXPECT().UpdateSensor(derivedFrom(ctx), sensor, gomock.Any()).Return(nil).Times(3)
+		er := transitionEventRepository{NewMockEventRepository(ctrl), NewMockEventTransitionRepository(ctrl)}
+		er.MockEventRepository.EXPECT().SaveEvent(derivedFrom(ctx), gomock.Any()).Return(nil).Times(3)
+		// единственная смена - первое событие; опоздавшее и следующее за ним её не дают
+		er.MockEventTransitionRepository.EXPECT().GetLastTransition(derivedFrom(ctx), int64(1), "").Return(nil, ErrNoTransition)
+		er.MockEventTransitionRepository.EXPECT().SaveTransition(derivedFrom(ctx), &domain.Transition{
+			SensorID: 1, Timestamp: at, From: 0, To: 1,
+		}).Return(nil)
+		uc := NewEvent(er, sr)
+
+		receive := func(timestamp time.Time, payload float64) {
+			t.Helper()
+			require.NoError(t, uc.ReceiveEvent(ctx, &domain.Event{
+				Timestamp: timestamp, SensorSerialNumber: sensor.SerialNumber, Payload: payload,
+			}))
+		}
+		receive(at, 1)
+		receive(at.Add(-30*time.Second), 0)
+		assert.Equal(t, 1.0, sensor.CurrentState)
+		assert.Equal(t, at, sensor.LastActivity)
+		receive(at.Add(time.Minute), 1)
+		assert.Equal(t, 1.0, sensor.CurrentState)
+		assert.Equal(t, at.Add(time.Minute), sensor.LastActivity)
+	})
+
+	t.Run("ok, storage without transition log", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		sensor := &domain.Sensor{ID: 1, SerialNumber: "0000000001", Type: domain.SensorTypeContactClosure, LastActivity: at.Add(-time.Minute)}
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorBySerialNumber(derivedFrom(ctx), sensor.SerialNumber).Return(sensor, nil)
+		sr.EXPECT().UpdateSensor(derivedFrom(ctx), sensor, gomock.Any()).Return(nil)
+		er := NewMockEventRepository(ctrl)
+		er.EXPECT().SaveEvent(derivedFrom(ctx), gomock.Any()).Return(nil)
+		require.NoError(t, NewEvent(er, sr).ReceiveEvent(ctx, &domain.Event{Timestamp: at, SensorSerialNumber: sensor.SerialNumber, Payload: 1}))
+	})
+}
+
+func Test_event_GetTransitions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cc := &domain.Sensor{ID: 2, Type: domain.SensorTypeContactClosure}
+	opened := 1.0
+
+	t.Run("ok", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		filter := domain.TransitionFilter{Start: start, End: start.Add(time.Hour), To: &opened, Limit: 10}
+		expected := []domain.Transition{{SensorID: 2, Timestamp: start.Add(time.Minute), To: 1}}
+		er := transitionEventRepository{NewMockEventRepository(ctrl), NewMockEventTransitionRepository(ctrl)}
+		er.MockEventTransitionRepository.EXPECT().GetTransitions(derivedFrom(ctx), int64(2), filter).Return(expected, nil)
+
+		actual, err := NewEvent(er, nil).GetTransitions(ctx, cc, filter)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("fail, not binary sensor", func(t *testing.T) {
+		er := transitionEventRepository{NewMockEventRepository(ctrl), NewMockEventTransitionRepository(ctrl)}
+		_, err := NewEvent(er, nil).GetTransitions(context.Background(), &domain.Sensor{ID: 1, Type: domain.SensorTypeADC},
+			domain.TransitionFilter{Start: start, End: start.Add(time.Hour)})
+		assert.ErrorIs(t, err, ErrNotBinarySensor)
+	})
+
+	t.Run("fail, wrong filter", func(t *testing.T) {
+		er := transitionEventRepository{NewMockEventRepository(ctrl), NewMockEventTransitionRepository(ctrl)}
+		closedOpen := 0.5
+		for _, filter := range []domain.TransitionFilter{
+			{Start: start},
+			{Start: start, End: start.Add(time.Hour), To: &closedOpen},
+			{Start: start, End: start.Add(time.Hour), Limit: -1},
+		} {
+			_, err := NewEvent(er, nil).GetTransitions(context.Background(), cc, filter)
+			assert.ErrorIs(t, err, ErrWrongTransitionFilter)
+		}
+	})
+
+	t.Run("fail, storage without transition log", func(t *testing.T) {
+		_, err := NewEvent(NewMockEventRepository(ctrl), nil).GetTransitions(context.Background(), cc,
+			domain.TransitionFilter{Start: start, End: start.Add(time.Hour)})
+		assert.ErrorIs(t, err, ErrTransitionsNotSupported)
+	})
+}
+
+func Test_event_CountOpenings(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	cc := &domain.Sensor{ID: 2, Type: domain.SensorTypeContactClosure}
+	// 30 марта 2025 в Берлине переводят часы, и сутки длятся 23 часа
+	start := time.Date(2025, 3, 29, 8, 0, 0, 0, berlin)
+	end := time.Date(2025, 3, 31, 8, 0, 0, 0, berlin)
+	opened := 1.0
+
+	t.Run("ok, days in time zone", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		er := transitionEventRepository{NewMockEventRepository(ctrl), NewMockEventTransitionRepository(ctrl)}
+		er.MockEventTransitionRepository.EXPECT().GetTransitions(derivedFrom(ctx), int64(2), domain.TransitionFilter{Start: start, End: end, To: &opened}).
+			Return([]domain.Transition{
+				{SensorID: 2, Timestamp: time.Date(2025, 3, 29, 23, 30, 0, 0, time.UTC), To: 1}, // 00:30 30 марта в Берлине
+				{SensorID: 2, Timestamp: time.Date(2025, 3, 30, 21, 59, 0, 0, time.UTC), To: 1}, // 23:59 30 марта
+				{SensorID: 2, Timestamp: time.Date(2025, 3, 31, 5, 0, 0, 0, time.UTC), To: 1},
+			}, nil)
+
+		counts, err := NewEvent(er, nil).CountOpenings(ctx, cc, start, end, berlin)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.DayCount{
+			{Day: time.Date(2025, 3, 29, 0, 0, 0, 0, berlin), Count: 0},
+			{Day: time.Date(2025, 3, 30, 0, 0, 0, 0, berlin), Count: 2},
+			{Day: time.Date(2025, 3, 31, 0, 0, 0, 0, berlin), Count: 1},
+		}, counts)
+	})
+
+	t.Run("fail, period too long", func(t *testing.T) {
+		er := transitionEventRepository{NewMockEventRepository(ctrl), NewMockEventTransitionRepository(ctrl)}
+		e := NewEvent(er, nil, WithEventSeriesLimits(SeriesLimits{MaxRange: 24 * time.Hour}))
+		_, err := e.CountOpenings(context.Background(), cc, start, end, berlin)
+		assert.ErrorIs(t, err, ErrSeriesQueryTooLarge)
+	})
+
+	t.Run("fail, wrong period", func(t *testing.T) {
+		er := transitionEventRepository{NewMockEventRepository(ctrl), NewMockEventTransitionRepository(ctrl)}
+		_, err := NewEvent(er, nil).CountOpenings(context.Background(), cc, end, start, berlin)
+		assert.ErrorIs(t, err, ErrWrongTransitionFilter)
+	})
+}
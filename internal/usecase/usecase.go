@@ -2,21 +2,10 @@ package usecase
 
 import (
 	"context"
-	"errors"
 	"homework/internal/domain"
 	"time"
 )
 
-var (
-	ErrWrongSensorSerialNumber = errors.New("wrong sensor serial number")
-	ErrWrongSensorType         = errors.New("wrong sensor type")
-	ErrInvalidEventTimestamp   = errors.New("invalid event timestamp")
-	ErrInvalidUserName         = errors.New("invalid user name")
-	ErrSensorNotFound          = errors.New("sensor not found")
-	ErrUserNotFound            = errors.New("user not found")
-	ErrEventNotFound           = errors.New("event not found")
-)
-
 //go:generate mockgen -source usecase.go -package usecase -destination usecase_mock.go
 type SensorRepository interface {
 	// SaveSensor - функция сохранения датчика
@@ -27,6 +16,19 @@ type SensorRepository interface {
 	GetSensorByID(ctx context.Context, id int64) (*domain.Sensor, error)
 	// GetSensorBySerialNumber - функция получения датчика по серийному номеру
 	GetSensorBySerialNumber(ctx context.Context, sn string) (*domain.Sensor, error)
+	// UpdateSensor - функция обновления датчика, если его версия в хранилище равна version,
+	// иначе ErrSensorModified. Новая версия записывается в sensor.Version
+	UpdateSensor(ctx context.Context, sensor *domain.Sensor, version int64) error
+}
+
+// SensorCalibrationRepository - необязательная возможность хранилища датчиков вести журнал калибровки
+type SensorCalibrationRepository interface {
+	// UpdateSensorCalibration - функция обновления датчика, как UpdateSensor, вместе с добавлением записи
+	// change в журнал калибровки: либо записывается и то и другое, либо ничего. change.Version
+	// получает новую версию датчика
+	UpdateSensorCalibration(ctx context.Context, sensor *domain.Sensor, version int64, change *domain.CalibrationChange) error
+	// GetCalibrationChanges - функция получения журнала калибровки датчика от старых записей к новым
+	GetCalibrationChanges(ctx context.Context, sensorID int64) ([]domain.CalibrationChange, error)
 }
 
 type EventRepository interface {
@@ -34,8 +36,52 @@ type EventRepository interface {
 	SaveEvent(ctx context.Context, event *domain.Event) error
 	// GetLastEventBySensorID - функция получения последнего события по ID датчика
 	GetLastEventBySensorID(ctx context.Context, id int64) (*domain.Event, error)
-	// GetEventsBySensorID - функция получения событий по ID датчика в указанном диапазоне
-	GetEventsBySensorID(ctx context.Context, id int64, start, end time.Time) ([]*domain.Event, error)
+	// GetEventsBySensorID - функция получения событий по ID датчика в указанном диапазоне;
+	// channel - канал многоканального датчика, пустой - события всех каналов
+	GetEventsBySensorID(ctx context.Context, id int64, channel string, start, end time.Time) ([]*domain.Event, error)
+}
+
+// EventRetentionRepository - необязательная возможность хранилища событий удалять устаревшие события
+type EventRetentionRepository interface {
+	// DeleteEventsBefore - функция удаления событий старше before, возвращает число удалённых
+	DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// EventRecalibrationRepository - необязательная возможность хранилища событий менять значения сохранённых событий
+type EventRecalibrationRepository interface {
+	// UpdateEventPayloads - функция замены Payload сохранённых событий. Событие находится по датчику,
+	// времени и каналу, остальные поля не меняются; возвращает число изменённых
+	UpdateEventPayloads(ctx context.Context, events []*domain.Event) (int64, error)
+}
+
+// EventAggregationRepository - необязательная возможность хранилища событий сводить события по интервалам
+// одним запросом, не передавая сами события
+type EventAggregationRepository interface {
+	// AggregateEvents - функция получения сводок событий датчиков ids за [start, end) по интервалам длины
+	// bucket, отсчитываемым от start, в порядке датчика, канала и начала интервала; интервалы без событий
+	// пропускаются
+	AggregateEvents(ctx context.Context, ids []int64, start, end time.Time, bucket time.Duration) ([]domain.Aggregate, error)
+}
+
+// EventRollupRepository - необязательная возможность хранилища событий вести сводки событий по интервалам
+// domain.RollupResolutions. Сводки пополняются вместе с сохранением событий, а AggregateEvents берёт
+// из них выровненные интервалы; после изменения сохранённых событий их нужно пересобрать
+type EventRollupRepository interface {
+	// RebuildRollups - функция пересборки сводок датчиков ids, пустой - всех, за [start, end) из сохранённых
+	// событий; период расширяется до целых суток UTC. Возвращает число записанных сводок
+	RebuildRollups(ctx context.Context, ids []int64, start, end time.Time) (int64, error)
+}
+
+// EventTransitionRepository - необязательная возможность хранилища событий вести журнал смен состояния
+// датчиков вида binary
+type EventTransitionRepository interface {
+	// SaveTransition - функция добавления смены состояния в журнал
+	SaveTransition(ctx context.Context, transition *domain.Transition) error
+	// GetLastTransition - функция получения последней по времени смены состояния канала channel датчика;
+	// ErrNoTransition, если смен ещё не было
+	GetLastTransition(ctx context.Context, sensorID int64, channel string) (*domain.Transition, error)
+	// GetTransitions - функция получения смен состояния датчика, подходящих под filter, от старых к новым
+	GetTransitions(ctx context.Context, sensorID int64, filter domain.TransitionFilter) ([]domain.Transition, error)
 }
 
 type UserRepository interface {
@@ -95,6 +95,72 @@ func (mr *MockSensorRepositoryMockRecorder) SaveSensor(ctx, sensor interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSensor", reflect.TypeOf((*MockSensorRepository)(nil).SaveSensor), ctx, sensor)
 }
 
+// UpdateSensor mocks base method.
+func (m *MockSensorRepository) UpdateSensor(ctx context.Context, sensor *domain.Sensor, version int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSensor", ctx, sensor, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSensor indicates an expected call of UpdateSensor.
+func (mr *MockSensorRepositoryMockRecorder) UpdateSensor(ctx, sensor, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSensor", reflect.TypeOf((*MockSensorRepository)(nil).UpdateSensor), ctx, sensor, version)
+}
+
+// MockSensorCalibrationRepository is a mock of SensorCalibrationRepository interface.
+type MockSensorCalibrationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSensorCalibrationRepositoryMockRecorder
+}
+
+// MockSensorCalibrationRepositoryMockRecorder is the mock recorder for MockSensorCalibrationRepository.
+type MockSensorCalibrationRepositoryMockRecorder struct {
+	mock *MockSensorCalibrationRepository
+}
+
+// NewMockSensorCalibrationRepository creates a new mock instance.
+func NewMockSensorCalibrationRepository(ctrl *gomock.Controller) *MockSensorCalibrationRepository {
+	mock := &MockSensorCalibrationRepository{ctrl: ctrl}
+	mock.recorder = &MockSensorCalibrationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSensorCalibrationRepository) EXPECT() *MockSensorCalibrationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetCalibrationChanges mocks base method.
+func (m *MockSensorCalibrationRepository) GetCalibrationChanges(ctx context.Context, sensorID int64) ([]domain.CalibrationChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCalibrationChanges", ctx, sensorID)
+	ret0, _ := ret[0].([]domain.CalibrationChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCalibrationChanges indicates an expected call of GetCalibrationChanges.
+func (mr *MockSensorCalibrationRepositoryMockRecorder) GetCalibrationChanges(ctx, sensorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCalibrationChanges", reflect.TypeOf((*MockSensorCalibrationRepository)(nil).GetCalibrationChanges), ctx, sensorID)
+}
+
+// UpdateSensorCalibration mocks base method.
+func (m *MockSensorCalibrationRepository) UpdateSensorCalibration(ctx context.Context, sensor *domain.Sensor, version int64, change *domain.CalibrationChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSensorCalibration", ctx, sensor, version, change)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSensorCalibration indicates an expected call of UpdateSensorCalibration.
+func (mr *MockSensorCalibrationRepositoryMockRecorder) UpdateSensorCalibration(ctx, sensor, version, change interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSensorCalibration", reflect.TypeOf((*MockSensorCalibrationRepository)(nil).UpdateSensorCalibration), ctx, sensor, version, change)
+}
+
 // MockEventRepository is a mock of EventRepository interface.
 type MockEventRepository struct {
 	ctrl     *gomock.Controller
@@ -119,18 +185,18 @@ func (m *MockEventRepository) EXPECT() *MockEventRepositoryMockRecorder {
 }
 
 // GetEventsBySensorID mocks base method.
-func (m *MockEventRepository) GetEventsBySensorID(ctx context.Context, id int64, start, end time.Time) ([]*domain.Event, error) {
+func (m *MockEventRepository) GetEventsBySensorID(ctx context.Context, id int64, channel string, start, end time.Time) ([]*domain.Event, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetEventsBySensorID", ctx, id, start, end)
+	ret := m.ctrl.Call(m, "GetEventsBySensorID", ctx, id, channel, start, end)
 	ret0, _ := ret[0].([]*domain.Event)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetEventsBySensorID indicates an expected call of GetEventsBySensorID.
-func (mr *MockEventRepositoryMockRecorder) GetEventsBySensorID(ctx, id, start, end interface{}) *gomock.Call {
+func (mr *MockEventRepositoryMockRecorder) GetEventsBySensorID(ctx, id, channel, start, end interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEventsBySensorID", reflect.TypeOf((*MockEventRepository)(nil).GetEventsBySensorID), ctx, id, start, end)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEventsBySensorID", reflect.TypeOf((*MockEventRepository)(nil).GetEventsBySensorID), ctx, id, channel, start, end)
 }
 
 // GetLastEventBySensorID mocks base method.
@@ -162,6 +228,225 @@ func (mr *MockEventRepositoryMockRecorder) SaveEvent(ctx, event interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEvent", reflect.TypeOf((*MockEventRepository)(nil).SaveEvent), ctx, event)
 }
 
+// MockEventRetentionRepository is a mock of EventRetentionRepository interface.
+type MockEventRetentionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventRetentionRepositoryMockRecorder
+}
+
+// MockEventRetentionRepositoryMockRecorder is the mock recorder for MockEventRetentionRepository.
+type MockEventRetentionRepositoryMockRecorder struct {
+	mock *MockEventRetentionRepository
+}
+
+// NewMockEventRetentionRepository creates a new mock instance.
+func NewMockEventRetentionRepository(ctrl *gomock.Controller) *MockEventRetentionRepository {
+	mock := &MockEventRetentionRepository{ctrl: ctrl}
+	mock.recorder = &MockEventRetentionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventRetentionRepository) EXPECT() *MockEventRetentionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteEventsBefore mocks base method.
+func (m *MockEventRetentionRepository) DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEventsBefore", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteEventsBefore indicates an expected call of DeleteEventsBefore.
+func (mr *MockEventRetentionRepositoryMockRecorder) DeleteEventsBefore(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEventsBefore", reflect.TypeOf((*MockEventRetentionRepository)(nil).DeleteEventsBefore), ctx, before)
+}
+
+// MockEventRecalibrationRepository is a mock of EventRecalibrationRepository interface.
+type MockEventRecalibrationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventRecalibrationRepositoryMockRecorder
+}
+
+// MockEventRecalibrationRepositoryMockRecorder is the mock recorder for MockEventRecalibrationRepository.
+type MockEventRecalibrationRepositoryMockRecorder struct {
+	mock *MockEventRecalibrationRepository
+}
+
+// NewMockEventRecalibrationRepository creates a new mock instance.
+func NewMockEventRecalibrationRepository(ctrl *gomock.Controller) *MockEventRecalibrationRepository {
+	mock := &MockEventRecalibrationRepository{ctrl: ctrl}
+	mock.recorder = &MockEventRecalibrationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventRecalibrationRepository) EXPECT() *MockEventRecalibrationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// UpdateEventPayloads mocks base method.
+func (m *MockEventRecalibrationRepository) UpdateEventPayloads(ctx context.Context, events []*domain.Event) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEventPayloads", ctx, events)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateEventPayloads indicates an expected call of UpdateEventPayloads.
+func (mr *MockEventRecalibrationRepositoryMockRecorder) UpdateEventPayloads(ctx, events interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEventPayloads", reflect.TypeOf((*MockEventRecalibrationRepository)(nil).UpdateEventPayloads), ctx, events)
+}
+
+// MockEventAggregationRepository is a mock of EventAggregationRepository interface.
+type MockEventAggregationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventAggregationRepositoryMockRecorder
+}
+
+// MockEventAggregationRepositoryMockRecorder is the mock recorder for MockEventAggregationRepository.
+type MockEventAggregationRepositoryMockRecorder struct {
+	mock *MockEventAggregationRepository
+}
+
+// NewMockEventAggregationRepository creates a new mock instance.
+func NewMockEventAggregationRepository(ctrl *gomock.Controller) *MockEventAggregationRepository {
+	mock := &MockEventAggregationRepository{ctrl: ctrl}
+	mock.recorder = &MockEventAggregationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventAggregationRepository) EXPECT() *MockEventAggregationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AggregateEvents mocks base method.
+func (m *MockEventAggregationRepository) AggregateEvents(ctx context.Context, ids []int64, start, end time.Time, bucket time.Duration) ([]domain.Aggregate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AggregateEvents", ctx, ids, start, end, bucket)
+	ret0, _ := ret[0].([]domain.Aggregate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AggregateEvents indicates an expected call of AggregateEvents.
+func (mr *MockEventAggregationRepositoryMockRecorder) AggregateEvents(ctx, ids, start, end, bucket interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AggregateEvents", reflect.TypeOf((*MockEventAggregationRepository)(nil).AggregateEvents), ctx, ids, start, end, bucket)
+}
+
+// MockEventRollupRepository is a mock of EventRollupRepository interface.
+type MockEventRollupRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventRollupRepositoryMockRecorder
+}
+
+// MockEventRollupRepositoryMockRecorder is the mock recorder for MockEventRollupRepository.
+type MockEventRollupRepositoryMockRecorder struct {
+	mock *MockEventRollupRepository
+}
+
+// NewMockEventRollupRepository creates a new mock instance.
+func NewMockEventRollupRepository(ctrl *gomock.Controller) *MockEventRollupRepository {
+	mock := &MockEventRollupRepository{ctrl: ctrl}
+	mock.recorder = &MockEventRollupRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventRollupRepository) EXPECT() *MockEventRollupRepositoryMockRecorder {
+	return m.recorder
+}
+
+// RebuildRollups mocks base method.
+func (m *MockEventRollupRepository) RebuildRollups(ctx context.Context, ids []int64, start, end time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RebuildRollups", ctx, ids, start, end)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RebuildRollups indicates an expected call of RebuildRollups.
+func (mr *MockEventRollupRepositoryMockRecorder) RebuildRollups(ctx, ids, start, end interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RebuildRollups", reflect.TypeOf((*MockEventRollupRepository)(nil).RebuildRollups), ctx, ids, start, end)
+}
+
+// MockEventTransitionRepository is a mock of EventTransitionRepository interface.
+type MockEventTransitionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventTransitionRepositoryMockRecorder
+}
+
+// MockEventTransitionRepositoryMockRecorder is the mock recorder for MockEventTransitionRepository.
+type MockEventTransitionRepositoryMockRecorder struct {
+	mock *MockEventTransitionRepository
+}
+
+// NewMockEventTransitionRepository creates a new mock instance.
+func NewMockEventTransitionRepository(ctrl *gomock.Controller) *MockEventTransitionRepository {
+	mock := &MockEventTransitionRepository{ctrl: ctrl}
+	mock.recorder = &MockEventTransitionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventTransitionRepository) EXPECT() *MockEventTransitionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetLastTransition mocks base method.
+func (m *MockEventTransitionRepository) GetLastTransition(ctx context.Context, sensorID int64, channel string) (*domain.Transition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastTransition", ctx, sensorID, channel)
+	ret0, _ := ret[0].(*domain.Transition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastTransition indicates an expected call of GetLastTransition.
+func (mr *MockEventTransitionRepositoryMockRecorder) GetLastTransition(ctx, sensorID, channel interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastTransition", reflect.TypeOf((*MockEventTransitionRepository)(nil).GetLastTransition), ctx, sensorID, channel)
+}
+
+// GetTransitions mocks base method.
+func (m *MockEventTransitionRepository) GetTransitions(ctx context.Context, sensorID int64, filter domain.TransitionFilter) ([]domain.Transition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransitions", ctx, sensorID, filter)
+	ret0, _ := ret[0].([]domain.Transition)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransitions indicates an expected call of GetTransitions.
+func (mr *MockEventTransitionRepositoryMockRecorder) GetTransitions(ctx, sensorID, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransitions", reflect.TypeOf((*MockEventTransitionRepository)(nil).GetTransitions), ctx, sensorID, filter)
+}
+
+// SaveTransition mocks base method.
+func (m *MockEventTransitionRepository) SaveTransition(ctx context.Context, transition *domain.Transition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveTransition", ctx, transition)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveTransition indicates an expected call of SaveTransition.
+func (mr *MockEventTransitionRepositoryMockRecorder) SaveTransition(ctx, transition interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTransition", reflect.TypeOf((*MockEventTransitionRepository)(nil).SaveTransition), ctx, transition)
+}
+
 // MockUserRepository is a mock of UserRepository interface.
 type MockUserRepository struct {
 	ctrl     *gomock.Controller
@@ -4,6 +4,11 @@ import (
 	"context"
 	"errors"
 	"homework/internal/domain"
+	"homework/internal/logging"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type User struct {
@@ -20,34 +25,81 @@ func NewUser(ur UserRepository, sor SensorOwnerRepository, sr SensorRepository)
 	}
 }
 
-func (u *User) RegisterUser(ctx context.Context, user *domain.User) (*domain.User, error) {
+// LoadTimeZone - часовой пояс по имени из базы IANA или ErrInvalidTimeZone.
+// Local не принимается: он зависит от сервера, а не от дома пользователя
+func LoadTimeZone(name string) (*time.Location, error) {
+	location, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, ErrInvalidTimeZone
+	}
+	return location, nil
+}
+
+func (u *User) RegisterUser(ctx context.Context, user *domain.User) (_ *domain.User, err error) {
+	ctx, span := tracer.Start(ctx, "User.RegisterUser")
+	defer func() { endSpan(span, err) }()
+
 	if user == nil {
 		return nil, errors.New("user is nil")
 	}
 	if user.Name == "" {
 		return nil, ErrInvalidUserName
 	}
+	if user.TimeZone == "" {
+		user.TimeZone = "UTC"
+	}
+	if _, err := LoadTimeZone(user.TimeZone); err != nil {
+		return nil, err
+	}
 	if err := u.userRepo.SaveUser(ctx, user); err != nil {
 		return nil, err
 	}
+	logging.FromContext(ctx).Info("user registered", "user_id", user.ID)
 	return user, nil
 }
 
-func (u *User) AttachSensorToUser(ctx context.Context, userID, sensorID int64) error {
+// GetUser - пользователь по id
+func (u *User) GetUser(ctx context.Context, userID int64) (_ *domain.User, err error) {
+	ctx, span := tracer.Start(ctx, "User.GetUser", trace.WithAttributes(attribute.Int64("user.id", userID)))
+	defer func() { endSpan(span, err) }()
+
+	return u.userRepo.GetUserByID(ctx, userID)
+}
+
+func (u *User) AttachSensorToUser(ctx context.Context, userID, sensorID int64) (err error) {
+	ctx, span := tracer.Start(ctx, "User.AttachSensorToUser", trace.WithAttributes(
+		attribute.Int64("user.id", userID),
+		attribute.Int64("sensor.id", sensorID),
+	))
+	defer func() { endSpan(span, err) }()
+
 	if _, err := u.userRepo.GetUserByID(ctx, userID); err != nil {
 		return err
 	}
 	if _, err := u.sensorRepo.GetSensorByID(ctx, sensorID); err != nil {
 		return err
 	}
-	err := u.sorRepo.SaveSensorOwner(ctx, domain.SensorOwner{UserID: userID, SensorID: sensorID})
+	owned, err := u.sorRepo.GetSensorsByUserID(ctx, userID)
 	if err != nil {
 		return err
 	}
+	for _, so := range owned {
+		if so.SensorID == sensorID {
+			return ErrSensorAlreadyAttached
+		}
+	}
+	err = u.sorRepo.SaveSensorOwner(ctx, domain.SensorOwner{UserID: userID, SensorID: sensorID})
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).Info("sensor attached to user", "user_id", userID, "sensor_id", sensorID)
 	return nil
 }
 
-func (u *User) GetUserSensors(ctx context.Context, userID int64) ([]domain.Sensor, error) {
+func (u *User) GetUserSensors(ctx context.Context, userID int64) (_ []domain.Sensor, err error) {
+	ctx, span := tracer.Start(ctx, "User.GetUserSensors", trace.WithAttributes(attribute.Int64("user.id", userID)))
+	defer func() { endSpan(span, err) }()
+
 	if _, err := u.userRepo.GetUserByID(ctx, userID); err != nil {
 		return nil, err
 	}
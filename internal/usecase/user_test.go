@@ -24,13 +24,21 @@ func Test_user_RegisterUser(t *testing.T) {
 		assert.ErrorIs(t, err, ErrInvalidUserName)
 	})
 
+	t.Run("fail, unknown time zone", func(t *testing.T) {
+		u := NewUser(nil, nil, nil)
+		for _, zone := range []string{"Mars/Olympus", "Local"} {
+			_, err := u.RegisterUser(context.Background(), &domain.User{Name: "Homer Simpson", TimeZone: zone})
+			assert.ErrorIs(t, err, ErrInvalidTimeZone, zone)
+		}
+	})
+
 	t.Run("fail, repo fail", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
 		expectedError := errors.New("doh")
-		ur.EXPECT().SaveUser(ctx, gomock.Any()).Times(1).Return(expectedError)
+		ur.EXPECT().SaveUser(derivedFrom(ctx), gomock.Any()).Times(1).Return(expectedError)
 
 		u := NewUser(ur, nil, nil)
 
@@ -45,8 +53,9 @@ func Test_user_RegisterUser(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().SaveUser(ctx, gomock.Any()).Times(1).Do(func(_ context.Context, u *domain.User) {
+		ur.EXPECT().SaveUser(derivedFrom(ctx), gomock.Any()).Times(1).Do(func(_ context.Context, u *domain.User) {
 			assert.Equal(t, "Homer Simpson", u.Name)
+			assert.Equal(t, "UTC", u.TimeZone)
 			u.ID = 1
 		})
 
@@ -69,7 +78,7 @@ func Test_user_AttachSensorToUser(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().GetUserByID(ctx, gomock.Any()).Times(1).Return(nil, ErrUserNotFound)
+		ur.EXPECT().GetUserByID(derivedFrom(ctx), gomock.Any()).Times(1).Return(nil, ErrUserNotFound)
 
 		u := NewUser(ur, nil, nil)
 
@@ -82,10 +91,10 @@ func Test_user_AttachSensorToUser(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().GetUserByID(ctx, gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
+		ur.EXPECT().GetUserByID(derivedFrom(ctx), gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().GetSensorByID(ctx, gomock.Any()).Times(1).Return(nil, ErrSensorNotFound)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), gomock.Any()).Times(1).Return(nil, ErrSensorNotFound)
 
 		u := NewUser(ur, nil, sr)
 
@@ -98,14 +107,15 @@ func Test_user_AttachSensorToUser(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().GetUserByID(ctx, gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
+		ur.EXPECT().GetUserByID(derivedFrom(ctx), gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().GetSensorByID(ctx, gomock.Any()).Times(1).Return(&domain.Sensor{ID: 1}, nil)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), gomock.Any()).Times(1).Return(&domain.Sensor{ID: 1}, nil)
 
 		sor := NewMockSensorOwnerRepository(ctrl)
+		sor.EXPECT().GetSensorsByUserID(derivedFrom(ctx), int64(1)).Times(1).Return(nil, nil)
 		expectedError := errors.New("some error")
-		sor.EXPECT().SaveSensorOwner(ctx, gomock.Any()).Times(1).Return(expectedError)
+		sor.EXPECT().SaveSensorOwner(derivedFrom(ctx), gomock.Any()).Times(1).Return(expectedError)
 
 		u := NewUser(ur, sor, sr)
 
@@ -118,13 +128,14 @@ func Test_user_AttachSensorToUser(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().GetUserByID(ctx, gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
+		ur.EXPECT().GetUserByID(derivedFrom(ctx), gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().GetSensorByID(ctx, gomock.Any()).Times(1).Return(&domain.Sensor{ID: 1}, nil)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), gomock.Any()).Times(1).Return(&domain.Sensor{ID: 1}, nil)
 
 		sor := NewMockSensorOwnerRepository(ctrl)
-		sor.EXPECT().SaveSensorOwner(ctx, gomock.Any()).Times(1).Return(nil).Do(func(_ context.Context, o domain.SensorOwner) {
+		sor.EXPECT().GetSensorsByUserID(derivedFrom(ctx), int64(1)).Times(1).Return([]domain.SensorOwner{{UserID: 1, SensorID: 2}}, nil)
+		sor.EXPECT().SaveSensorOwner(derivedFrom(ctx), gomock.Any()).Times(1).Return(nil).Do(func(_ context.Context, o domain.SensorOwner) {
 			assert.Equal(t, int64(1), o.UserID)
 			assert.Equal(t, int64(1), o.SensorID)
 		})
@@ -134,6 +145,26 @@ func Test_user_AttachSensorToUser(t *testing.T) {
 		err := u.AttachSensorToUser(ctx, 1, 1)
 		assert.NoError(t, err)
 	})
+
+	t.Run("fail, already attached", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		ur := NewMockUserRepository(ctrl)
+		ur.EXPECT().GetUserByID(derivedFrom(ctx), gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
+
+		sr := NewMockSensorRepository(ctrl)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), gomock.Any()).Times(1).Return(&domain.Sensor{ID: 1}, nil)
+
+		sor := NewMockSensorOwnerRepository(ctrl)
+		sor.EXPECT().GetSensorsByUserID(derivedFrom(ctx), int64(1)).Times(1).Return([]domain.SensorOwner{{UserID: 1, SensorID: 1}}, nil)
+
+		u := NewUser(ur, sor, sr)
+
+		err := u.AttachSensorToUser(ctx, 1, 1)
+		assert.ErrorIs(t, err, ErrSensorAlreadyAttached)
+		assert.Equal(t, KindConflict, KindOf(err))
+	})
 }
 
 func Test_user_GetUserSensors(t *testing.T) {
@@ -145,7 +176,7 @@ func Test_user_GetUserSensors(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().GetUserByID(ctx, gomock.Any()).Times(1).Return(nil, ErrUserNotFound)
+		ur.EXPECT().GetUserByID(derivedFrom(ctx), gomock.Any()).Times(1).Return(nil, ErrUserNotFound)
 
 		u := NewUser(ur, nil, nil)
 
@@ -158,11 +189,11 @@ func Test_user_GetUserSensors(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().GetUserByID(ctx, gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
+		ur.EXPECT().GetUserByID(derivedFrom(ctx), gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
 
 		sor := NewMockSensorOwnerRepository(ctrl)
 		expectedError := errors.New("some error")
-		sor.EXPECT().GetSensorsByUserID(ctx, gomock.Any()).Times(1).Return(nil, expectedError)
+		sor.EXPECT().GetSensorsByUserID(derivedFrom(ctx), gomock.Any()).Times(1).Return(nil, expectedError)
 
 		u := NewUser(ur, sor, nil)
 
@@ -175,10 +206,10 @@ func Test_user_GetUserSensors(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().GetUserByID(ctx, gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
+		ur.EXPECT().GetUserByID(derivedFrom(ctx), gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
 
 		sor := NewMockSensorOwnerRepository(ctrl)
-		sor.EXPECT().GetSensorsByUserID(ctx, gomock.Any()).Times(1).Return([]domain.SensorOwner{
+		sor.EXPECT().GetSensorsByUserID(derivedFrom(ctx), gomock.Any()).Times(1).Return([]domain.SensorOwner{
 			{
 				UserID:   1,
 				SensorID: 1,
@@ -187,7 +218,7 @@ func Test_user_GetUserSensors(t *testing.T) {
 
 		sr := NewMockSensorRepository(ctrl)
 		expectedError := errors.New("some error")
-		sr.EXPECT().GetSensorByID(ctx, gomock.Any()).Times(1).Return(nil, expectedError)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), gomock.Any()).Times(1).Return(nil, expectedError)
 
 		u := NewUser(ur, sor, sr)
 
@@ -200,10 +231,10 @@ func Test_user_GetUserSensors(t *testing.T) {
 		defer cancel()
 
 		ur := NewMockUserRepository(ctrl)
-		ur.EXPECT().GetUserByID(ctx, gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
+		ur.EXPECT().GetUserByID(derivedFrom(ctx), gomock.Any()).Times(1).Return(&domain.User{ID: 1}, nil)
 
 		sor := NewMockSensorOwnerRepository(ctrl)
-		sor.EXPECT().GetSensorsByUserID(ctx, gomock.Any()).Times(1).Return([]domain.SensorOwner{
+		sor.EXPECT().GetSensorsByUserID(derivedFrom(ctx), gomock.Any()).Times(1).Return([]domain.SensorOwner{
 			{
 				UserID:   1,
 				SensorID: 1,
@@ -219,9 +250,9 @@ func Test_user_GetUserSensors(t *testing.T) {
 		}, nil)
 
 		sr := NewMockSensorRepository(ctrl)
-		sr.EXPECT().GetSensorByID(ctx, int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeADC}, nil)
-		sr.EXPECT().GetSensorByID(ctx, int64(2)).Times(1).Return(&domain.Sensor{ID: 2, Type: domain.SensorTypeContactClosure}, nil)
-		sr.EXPECT().GetSensorByID(ctx, int64(3)).Times(1).Return(&domain.Sensor{ID: 3, Type: domain.SensorTypeContactClosure}, nil)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), int64(1)).Times(1).Return(&domain.Sensor{ID: 1, Type: domain.SensorTypeADC}, nil)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), int64(2)).Times(1).Return(&domain.Sensor{ID: 2, Type: domain.SensorTypeContactClosure}, nil)
+		sr.EXPECT().GetSensorByID(derivedFrom(ctx), int64(3)).Times(1).Return(&domain.Sensor{ID: 3, Type: domain.SensorTypeContactClosure}, nil)
 
 		u := NewUser(ur, sor, sr)
 
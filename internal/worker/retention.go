@@ -0,0 +1,59 @@
+// Package worker - фоновые задачи сервера.
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// EventPurger - то, что умеет удалять события старше заданного момента
+type EventPurger interface {
+	PurgeEventsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Retention - периодически удаляет события старше maxAge
+type Retention struct {
+	events   EventPurger
+	maxAge   time.Duration
+	interval time.Duration
+	now      func() time.Time
+	beat     func()
+}
+
+func NewRetention(events EventPurger, maxAge, interval time.Duration, options ...func(*Retention)) *Retention {
+	r := &Retention{events: events, maxAge: maxAge, interval: interval, now: time.Now, beat: func() {}}
+	for _, o := range options {
+		o(r)
+	}
+	return r
+}
+
+// WithHeartbeat - вызывать beat после каждой итерации, в том числе неудачной, чтобы /readyz видел, что задача жива
+func WithHeartbeat(beat func()) func(*Retention) {
+	return func(r *Retention) {
+		r.beat = beat
+	}
+}
+
+// Run - удаляет устаревшие события сразу и затем раз в interval, пока не отменён ctx
+func (r *Retention) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("retention: purge failed", "err", err)
+		}
+		r.beat()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce - удаляет события старше maxAge и возвращает их число
+func (r *Retention) RunOnce(ctx context.Context) (int64, error) {
+	return r.events.PurgeEventsBefore(ctx, r.now().Add(-r.maxAge))
+}
@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"context"
+	"homework/internal/domain"
+	"homework/internal/usecase"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	eventInmemory "homework/internal/repository/event/inmemory"
+	sensorInmemory "homework/internal/repository/sensor/inmemory"
+)
+
+func TestRetention_RunOnce(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	er := eventInmemory.NewEventRepository()
+	for _, age := range []time.Duration{72 * time.Hour, 48 * time.Hour, time.Hour} {
+		require.NoError(t, er.SaveEvent(ctx, &domain.Event{Timestamp: now.Add(-age), SensorID: 1}))
+	}
+
+	r := NewRetention(usecase.NewEvent(er, sensorInmemory.NewSensorRepository()), 24*time.Hour, time.Hour)
+	r.now = func() time.Time { return now }
+
+	deleted, err := r.RunOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+
+	events, err := er.GetEventsBySensorID(ctx, 1, "", now.Add(-100*time.Hour), now)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, now.Add(-time.Hour), events[0].Timestamp)
+}
+
+func TestRetention_RunOnce_NotSupported(t *testing.T) {
+	r := NewRetention(usecase.NewEvent(usecase.EventRepository(nil), nil), time.Hour, time.Hour)
+
+	_, err := r.RunOnce(context.Background())
+	assert.ErrorIs(t, err, usecase.ErrRetentionNotSupported)
+}
+
+func TestRetention_Run_Heartbeat(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	beats := make(chan struct{}, 10)
+	r := NewRetention(usecase.NewEvent(eventInmemory.NewEventRepository(), nil), time.Hour, time.Millisecond,
+		WithHeartbeat(func() {
+			select {
+			case beats <- struct{}{}:
+			default:
+			}
+		}))
+
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx)
+		close(done)
+	}()
+	for i := 0; i < 3; i++ {
+		select {
+		case <-beats:
+		case <-time.After(time.Second):
+			t.Fatal("no heartbeat")
+		}
+	}
+	cancel()
+	<-done
+}
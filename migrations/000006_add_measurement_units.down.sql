@@ -0,0 +1,6 @@
+alter table sensors drop column scale;
+alter table sensors drop column unit;
+alter table sensors alter column current_state type bigint using round(current_state);
+
+alter table events drop column unit;
+alter table events alter column payload type bigint using round(payload);
@@ -0,0 +1,6 @@
+alter table events alter column payload type double precision;
+alter table events add column unit text not null default '';
+
+alter table sensors alter column current_state type double precision;
+alter table sensors add column unit text not null default '';
+alter table sensors add column scale smallint not null default 0;
@@ -0,0 +1,6 @@
+create type sensor_type as enum ('cc', 'adc');
+
+delete from sensors_users where sensor_id in (select id from sensors where type not in ('cc', 'adc'));
+delete from events where sensor_id in (select id from sensors where type not in ('cc', 'adc'));
+delete from sensors where type not in ('cc', 'adc');
+alter table sensors alter column type type sensor_type using type::sensor_type;
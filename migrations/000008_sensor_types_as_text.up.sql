@@ -0,0 +1,3 @@
+-- типы датчиков задаются реестром приложения, база хранит только имя типа
+alter table sensors alter column type type text using type::text;
+drop type sensor_type;
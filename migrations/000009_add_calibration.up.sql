@@ -0,0 +1,19 @@
+alter table events add column raw double precision;
+update events set raw = payload;
+alter table events alter column raw set not null;
+
+alter table sensors add column calibration jsonb;
+
+create table sensor_calibrations
+(
+    id          bigserial   primary key,
+    sensor_id   bigint      not null,
+    version     bigint      not null,
+    calibration jsonb,
+    previous    jsonb,
+    reason      text        not null default '',
+    request_id  text        not null default '',
+    changed_at  timestamp   not null
+);
+
+create index sensor_calibrations_sensor_id_idx on sensor_calibrations (sensor_id);
@@ -0,0 +1 @@
+alter table events add column quality smallint not null default 0;
@@ -0,0 +1 @@
+alter table users add column time_zone text not null default 'UTC';
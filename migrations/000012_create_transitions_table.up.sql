@@ -0,0 +1,11 @@
+create table transitions
+(
+    sensor_id   bigint              not null,
+    channel     text                not null default '',
+    timestamp   timestamp           not null,
+    from_state  double precision    not null,
+    to_state    double precision    not null,
+    lasted      bigint              not null default 0
+);
+
+create index transitions_sensor_id_timestamp_idx on transitions (sensor_id, timestamp);
@@ -0,0 +1,22 @@
+-- сводки событий по минутам, часам и суткам UTC; resolution - длина интервала в секундах
+create table event_rollups
+(
+    resolution  bigint              not null,
+    sensor_id   bigint              not null,
+    channel     text                not null default '',
+    bucket      timestamp           not null,
+    count       bigint              not null,
+    total       double precision    not null,
+    minimum     double precision    not null,
+    maximum     double precision    not null,
+    latest      double precision    not null,
+    latest_at   timestamp           not null,
+    primary key (resolution, sensor_id, channel, bucket)
+);
+
+insert into event_rollups (resolution, sensor_id, channel, bucket, count, total, minimum, maximum, latest, latest_at)
+select r.seconds, sensor_id, channel, date_bin(r.seconds * interval '1 second', timestamp, timestamp '1970-01-01') as bucket,
+       count(*), sum(payload), min(payload), max(payload), (array_agg(payload order by timestamp desc))[1], max(timestamp)
+from events
+         cross join (values (60), (3600), (86400)) as r (seconds)
+group by r.seconds, sensor_id, channel, bucket;
@@ -0,0 +1,9 @@
+// Package migrations содержит миграции схемы для PostgreSQL, встроенные в бинарник.
+package migrations
+
+import "embed"
+
+// FS - миграции в формате golang-migrate
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,11 @@
+create table sensors
+(
+    id            integer not null primary key autoincrement,
+    serial_number text,
+    type          text    not null check (type in ('cc', 'adc')),
+    current_state integer,
+    description   text,
+    is_active     boolean,
+    registered_at integer,
+    last_activity integer
+);
@@ -0,0 +1,6 @@
+create table sensors_users
+(
+    id        integer not null primary key autoincrement,
+    sensor_id integer not null,
+    user_id   integer not null
+);
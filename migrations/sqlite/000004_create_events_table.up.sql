@@ -0,0 +1,9 @@
+create table events
+(
+    timestamp            integer not null,
+    sensor_serial_number text    not null,
+    sensor_id            integer not null,
+    payload              integer not null
+);
+
+create index events_sensor_id_timestamp_idx on events (sensor_id, timestamp);
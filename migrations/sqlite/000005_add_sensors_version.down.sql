@@ -0,0 +1 @@
+alter table sensors drop column version;
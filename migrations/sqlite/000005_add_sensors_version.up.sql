@@ -0,0 +1 @@
+alter table sensors add column version integer not null default 1;
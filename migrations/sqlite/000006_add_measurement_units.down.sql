@@ -0,0 +1,4 @@
+alter table sensors drop column scale;
+alter table sensors drop column unit;
+
+alter table events drop column unit;
@@ -0,0 +1,6 @@
+-- payload и current_state остаются integer: при integer affinity дробные значения
+-- хранятся как real без потерь, а перестройка таблицы events не нужна
+alter table events add column unit text not null default '';
+
+alter table sensors add column unit text not null default '';
+alter table sensors add column scale integer not null default 0;
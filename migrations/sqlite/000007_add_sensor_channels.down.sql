@@ -0,0 +1,4 @@
+delete from events where channel <> '';
+alter table events drop column channel;
+
+alter table sensors drop column channels;
@@ -0,0 +1,3 @@
+alter table sensors add column channels text not null default '[]';
+
+alter table events add column channel text not null default '';
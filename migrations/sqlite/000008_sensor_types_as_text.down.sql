@@ -0,0 +1,28 @@
+delete from sensors_users where sensor_id in (select id from sensors where type not in ('cc', 'adc'));
+delete from events where sensor_id in (select id from sensors where type not in ('cc', 'adc'));
+delete from sensors where type not in ('cc', 'adc');
+
+create table sensors_old
+(
+    id            integer not null primary key autoincrement,
+    serial_number text,
+    type          text    not null check (type in ('cc', 'adc')),
+    current_state integer,
+    description   text,
+    is_active     boolean,
+    registered_at integer,
+    last_activity integer,
+    version       integer not null default 1,
+    unit          text    not null default '',
+    scale         integer not null default 0,
+    channels      text    not null default '[]'
+);
+
+insert into sensors_old (id, serial_number, type, current_state, description, is_active, registered_at, last_activity,
+                         version, unit, scale, channels)
+select id, serial_number, type, current_state, description, is_active, registered_at, last_activity,
+       version, unit, scale, channels
+from sensors;
+
+drop table sensors;
+alter table sensors_old rename to sensors;
@@ -0,0 +1,25 @@
+-- типы датчиков задаются реестром приложения: sqlite не умеет снимать check, поэтому таблица перестраивается
+create table sensors_new
+(
+    id            integer not null primary key autoincrement,
+    serial_number text,
+    type          text    not null,
+    current_state integer,
+    description   text,
+    is_active     boolean,
+    registered_at integer,
+    last_activity integer,
+    version       integer not null default 1,
+    unit          text    not null default '',
+    scale         integer not null default 0,
+    channels      text    not null default '[]'
+);
+
+insert into sensors_new (id, serial_number, type, current_state, description, is_active, registered_at, last_activity,
+                         version, unit, scale, channels)
+select id, serial_number, type, current_state, description, is_active, registered_at, last_activity,
+       version, unit, scale, channels
+from sensors;
+
+drop table sensors;
+alter table sensors_new rename to sensors;
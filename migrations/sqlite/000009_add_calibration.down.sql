@@ -0,0 +1,5 @@
+drop table sensor_calibrations;
+
+alter table sensors drop column calibration;
+
+alter table events drop column raw;
@@ -0,0 +1,18 @@
+alter table events add column raw real not null default 0;
+update events set raw = payload;
+
+alter table sensors add column calibration text;
+
+create table sensor_calibrations
+(
+    id          integer not null primary key autoincrement,
+    sensor_id   integer not null,
+    version     integer not null,
+    calibration text,
+    previous    text,
+    reason      text    not null default '',
+    request_id  text    not null default '',
+    changed_at  integer not null
+);
+
+create index sensor_calibrations_sensor_id_idx on sensor_calibrations (sensor_id);
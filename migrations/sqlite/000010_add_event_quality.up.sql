@@ -0,0 +1 @@
+alter table events add column quality integer not null default 0;
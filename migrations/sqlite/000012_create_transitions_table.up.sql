@@ -0,0 +1,11 @@
+create table transitions
+(
+    sensor_id   integer not null,
+    channel     text    not null default '',
+    timestamp   integer not null,
+    from_state  real    not null,
+    to_state    real    not null,
+    lasted      integer not null default 0
+);
+
+create index transitions_sensor_id_timestamp_idx on transitions (sensor_id, timestamp);
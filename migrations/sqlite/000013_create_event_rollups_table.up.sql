@@ -0,0 +1,27 @@
+-- сводки событий по минутам, часам и суткам UTC; resolution - длина интервала в секундах,
+-- bucket и latest_at, как и время событий, - микросекунды Unix
+create table event_rollups
+(
+    resolution  integer not null,
+    sensor_id   integer not null,
+    channel     text    not null default '',
+    bucket      integer not null,
+    count       integer not null,
+    total       real    not null,
+    minimum     real    not null,
+    maximum     real    not null,
+    latest      real    not null,
+    latest_at   integer not null,
+    primary key (resolution, sensor_id, channel, bucket)
+);
+
+insert into event_rollups (resolution, sensor_id, channel, bucket, count, total, minimum, maximum, latest, latest_at)
+select seconds, sensor_id, channel, bucket, count(*), sum(payload), min(payload), max(payload), max(latest), max(timestamp)
+from (select r.seconds, sensor_id, channel, payload, timestamp,
+             timestamp - ((timestamp % (r.seconds * 1000000)) + r.seconds * 1000000) % (r.seconds * 1000000) as bucket,
+             last_value(payload) over (partition by r.seconds, sensor_id, channel,
+                 timestamp - ((timestamp % (r.seconds * 1000000)) + r.seconds * 1000000) % (r.seconds * 1000000)
+                 order by timestamp rows between unbounded preceding and unbounded following) as latest
+      from events
+               cross join (select 60 as seconds union all select 3600 union all select 86400) as r)
+group by seconds, sensor_id, channel, bucket;
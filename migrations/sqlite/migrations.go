@@ -0,0 +1,9 @@
+// Package sqlite содержит миграции схемы для SQLite, встроенные в бинарник.
+package sqlite
+
+import "embed"
+
+// FS - миграции в формате golang-migrate
+//
+//go:embed *.sql
+var FS embed.FS
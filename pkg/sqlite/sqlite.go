@@ -0,0 +1,62 @@
+// Package sqlite открывает базу SQLite и накатывает на неё встроенные миграции.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	migrateSqlite "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	_ "modernc.org/sqlite" // регистрирует драйвер database/sql "sqlite"
+
+	sqliteMigrations "homework/migrations/sqlite"
+)
+
+// Open - открывает (или создаёт) файл базы по пути path и применяет миграции.
+// Для in-memory базы можно передать ":memory:".
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite: %w", err)
+	}
+	// SQLite допускает только одного писателя, поэтому держим одно соединение
+	// и не ловим SQLITE_BUSY на конкурентных запросах.
+	db.SetMaxOpenConns(1)
+
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("ping sqlite: %w", err)
+	}
+	if err := Migrate(db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Migrate - применяет встроенные миграции к уже открытой базе.
+func Migrate(db *sql.DB) error {
+	source, err := iofs.New(sqliteMigrations.FS, ".")
+	if err != nil {
+		return fmt.Errorf("load sqlite migrations: %w", err)
+	}
+	driver, err := migrateSqlite.WithInstance(db, &migrateSqlite.Config{})
+	if err != nil {
+		return fmt.Errorf("init sqlite migrations: %w", err)
+	}
+	m, err := migrate.NewWithInstance("iofs", source, "sqlite", driver)
+	if err != nil {
+		return fmt.Errorf("init sqlite migrations: %w", err)
+	}
+	// m.Close() закрыл бы и саму базу, поэтому закрываем только источник.
+	defer source.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("apply sqlite migrations: %w", err)
+	}
+	return nil
+}
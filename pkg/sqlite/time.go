@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TimeValue - переводит время в микросекунды UTC для INTEGER-колонки.
+// Нулевое время хранится как NULL.
+func TimeValue(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UnixMicro()
+}
+
+// ScanTime - возвращает sql.Scanner, читающий значение, записанное TimeValue, в dst.
+func ScanTime(dst *time.Time) sql.Scanner {
+	return timeScanner{dst: dst}
+}
+
+type timeScanner struct {
+	dst *time.Time
+}
+
+func (s timeScanner) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*s.dst = time.Time{}
+	case int64:
+		*s.dst = time.UnixMicro(v).UTC()
+	default:
+		return fmt.Errorf("unsupported timestamp type %T", src)
+	}
+	return nil
+}
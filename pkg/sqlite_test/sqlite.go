@@ -0,0 +1,46 @@
+package sqlite_test
+
+//nolint: revive // test stub
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"homework/pkg/sqlite"
+)
+
+type TestDatabase struct {
+	DbInstance *sql.DB
+	DbPath     string
+	dir        string
+}
+
+func SetupTestDatabase() *TestDatabase {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	dir, err := os.MkdirTemp("", "sqlite_test")
+	if err != nil {
+		log.Fatal("failed to create temp dir", err)
+	}
+	path := filepath.Join(dir, "db.sqlite")
+
+	db, err := sqlite.Open(ctx, path)
+	if err != nil {
+		log.Fatal("failed to setup test", err)
+	}
+
+	return &TestDatabase{
+		DbInstance: db,
+		DbPath:     path,
+		dir:        dir,
+	}
+}
+
+func (tdb *TestDatabase) TearDown() {
+	_ = tdb.DbInstance.Close()
+	_ = os.RemoveAll(tdb.dir)
+}
@@ -0,0 +1,216 @@
+// Package tlsreload - TLS-конфигурация сервера, которая подхватывает новые сертификаты без перезапуска.
+//
+// Reloader при рукопожатии, не чаще раза в интервал, сверяет время изменения и размер файлов
+// сертификата, ключа и корневых сертификатов клиентов и перечитывает их, если что-то поменялось.
+// Если новые файлы не читаются (например, ключ уже заменён, а сертификат ещё нет), остаётся
+// прежняя пара, а ошибка пишется в лог; следующая попытка будет через интервал.
+package tlsreload
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// ClientAuth - проверка клиентских сертификатов
+type ClientAuth string
+
+const (
+	// ClientAuthNone - клиентские сертификаты не запрашиваются
+	ClientAuthNone ClientAuth = "none"
+	// ClientAuthOptional - сертификат проверяется, если клиент его прислал
+	ClientAuthOptional ClientAuth = "optional"
+	// ClientAuthRequire - без действительного сертификата соединение не устанавливается
+	ClientAuthRequire ClientAuth = "require"
+)
+
+// ParseClientAuth - режим проверки клиентских сертификатов по имени
+func ParseClientAuth(name string) (ClientAuth, error) {
+	switch mode := ClientAuth(name); mode {
+	case ClientAuthNone, ClientAuthOptional, ClientAuthRequire:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown client auth %q, want one of none, optional, require", name)
+}
+
+func (c ClientAuth) tlsType() tls.ClientAuthType {
+	switch c {
+	case ClientAuthOptional:
+		return tls.VerifyClientCertIfGiven
+	case ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert
+	default:
+		return tls.NoClientCert
+	}
+}
+
+// Reloader - сертификат сервера и корневые сертификаты клиентов, перечитываемые при изменении файлов
+type Reloader struct {
+	certFile   string
+	keyFile    string
+	caFile     string
+	clientAuth ClientAuth
+	interval   time.Duration
+	now        func() time.Time
+	logger     *slog.Logger
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	stamps    map[string]stamp
+	checked   time.Time
+}
+
+// stamp - признаки изменения файла
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// New - загружает сертификат и ключ сервера; ошибка означает, что их нельзя прочитать сейчас
+func New(certFile, keyFile string, options ...func(*Reloader)) (*Reloader, error) {
+	r := &Reloader{
+		certFile:   certFile,
+		keyFile:    keyFile,
+		clientAuth: ClientAuthNone,
+		interval:   10 * time.Second,
+		now:        time.Now,
+		logger:     slog.Default(),
+	}
+	for _, o := range options {
+		o(r)
+	}
+	if r.caFile == "" && r.clientAuth != ClientAuthNone {
+		return nil, errors.New("client certificate verification requires a client CA file")
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.checked = r.now()
+	return r, nil
+}
+
+// WithClientCA - проверять клиентские сертификаты по корневым сертификатам из caFile
+func WithClientCA(caFile string, auth ClientAuth) func(*Reloader) {
+	return func(r *Reloader) {
+		r.caFile = caFile
+		r.clientAuth = auth
+	}
+}
+
+// WithInterval - как часто проверять файлы на изменения
+func WithInterval(interval time.Duration) func(*Reloader) {
+	return func(r *Reloader) {
+		r.interval = interval
+	}
+}
+
+// WithLogger - куда писать ошибки перечитывания
+func WithLogger(logger *slog.Logger) func(*Reloader) {
+	return func(r *Reloader) {
+		r.logger = logger
+	}
+}
+
+// TLSConfig - конфигурация для http.Server; сертификаты берутся из Reloader при каждом рукопожатии
+func (r *Reloader) TLSConfig() *tls.Config {
+	base := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		ClientAuth:     r.clientAuth.tlsType(),
+		GetCertificate: r.GetCertificate,
+	}
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		cfg := base.Clone()
+		cfg.GetConfigForClient = nil
+		cfg.ClientCAs = r.ClientCAs()
+		return cfg, nil
+	}
+	return base
+}
+
+// GetCertificate - текущий сертификат сервера, подходит для tls.Config.GetCertificate
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maybeReload()
+	return r.cert, nil
+}
+
+// ClientCAs - текущие корневые сертификаты клиентов, nil без WithClientCA
+func (r *Reloader) ClientCAs() *x509.CertPool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maybeReload()
+	return r.clientCAs
+}
+
+// maybeReload - перечитывает файлы, если с последней проверки прошёл интервал и они изменились
+func (r *Reloader) maybeReload() {
+	now := r.now()
+	if now.Sub(r.checked) < r.interval {
+		return
+	}
+	r.checked = now
+	if !r.changed() {
+		return
+	}
+	if err := r.load(); err != nil {
+		r.logger.Error("tls: reload failed, keeping previous certificates", "err", err)
+		return
+	}
+	r.logger.Info("tls: certificates reloaded", "cert_file", r.certFile)
+}
+
+func (r *Reloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.caFile != "" {
+		files = append(files, r.caFile)
+	}
+	return files
+}
+
+func (r *Reloader) changed() bool {
+	for _, name := range r.files() {
+		fi, err := os.Stat(name)
+		if err != nil || r.stamps[name] != (stamp{fi.ModTime(), fi.Size()}) {
+			return true
+		}
+	}
+	return false
+}
+
+// load - читает все файлы; при ошибке ничего не меняет
+func (r *Reloader) load() error {
+	stamps := make(map[string]stamp)
+	for _, name := range r.files() {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		stamps[name] = stamp{fi.ModTime(), fi.Size()}
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load key pair: %w", err)
+	}
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("read client CA: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates in client CA file %s", r.caFile)
+		}
+	}
+
+	r.cert, r.clientCAs, r.stamps = &cert, pool, stamps
+	return nil
+}
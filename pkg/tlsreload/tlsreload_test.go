@@ -0,0 +1,92 @@
+package tlsreload
+
+import (
+	"crypto/tls"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tlstest "homework/pkg/tls_test"
+)
+
+// replace - перезаписывает файл и сдвигает время изменения, чтобы его заметил Reloader
+func replace(t *testing.T, name string, content []byte, at time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(name, content, 0o600))
+	require.NoError(t, os.Chtimes(name, at, at))
+}
+
+func leafCN(t *testing.T, cert *tls.Certificate) string {
+	t.Helper()
+	require.NotNil(t, cert)
+	require.NotNil(t, cert.Leaf)
+	return cert.Leaf.Subject.CommonName
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	ca := tlstest.NewCA(t, "test ca")
+	certFile, keyFile := ca.Server(t, "first").Write(t, dir, "server")
+	caFile := dir + "/ca.pem"
+	require.NoError(t, os.WriteFile(caFile, ca.PEM, 0o600))
+
+	now := time.Now()
+	r, err := New(certFile, keyFile, WithClientCA(caFile, ClientAuthRequire), WithInterval(time.Second))
+	require.NoError(t, err)
+	r.now = func() time.Time { return now }
+
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "first", leafCN(t, cert))
+	require.NotNil(t, r.ClientCAs())
+
+	second := ca.Server(t, "second")
+	replace(t, certFile, second.CertPEM, now.Add(time.Minute))
+	replace(t, keyFile, second.KeyPEM, now.Add(time.Minute))
+
+	// до истечения интервала файлы не проверяются
+	cert, _ = r.GetCertificate(nil)
+	assert.Equal(t, "first", leafCN(t, cert))
+
+	now = now.Add(2 * time.Second)
+	cert, _ = r.GetCertificate(nil)
+	assert.Equal(t, "second", leafCN(t, cert))
+
+	// сломанный файл не заменяет рабочий сертификат
+	replace(t, keyFile, []byte("garbage"), now.Add(2*time.Minute))
+	now = now.Add(2 * time.Second)
+	cert, _ = r.GetCertificate(nil)
+	assert.Equal(t, "second", leafCN(t, cert))
+
+	// новый CA клиентов подхватывается так же
+	other := tlstest.NewCA(t, "other ca")
+	replace(t, keyFile, second.KeyPEM, now.Add(3*time.Minute))
+	replace(t, caFile, other.PEM, now.Add(3*time.Minute))
+	now = now.Add(2 * time.Second)
+	assert.True(t, r.ClientCAs().Equal(other.Pool()))
+}
+
+func TestNew_Errors(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := tlstest.NewCA(t, "ca").Server(t, "server").Write(t, dir, "server")
+
+	_, err := New(dir+"/missing.crt", keyFile)
+	assert.Error(t, err)
+	_, err = New(certFile, keyFile, WithClientCA("", ClientAuthRequire))
+	assert.Error(t, err)
+	_, err = New(certFile, keyFile, WithClientCA(certFile+".missing", ClientAuthOptional))
+	assert.Error(t, err)
+}
+
+func TestParseClientAuth(t *testing.T) {
+	for _, name := range []string{"none", "optional", "require"} {
+		mode, err := ParseClientAuth(name)
+		require.NoError(t, err)
+		assert.Equal(t, ClientAuth(name), mode)
+	}
+	_, err := ParseClientAuth("always")
+	assert.Error(t, err)
+}
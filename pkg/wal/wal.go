@@ -0,0 +1,426 @@
+// Package wal реализует журнал упреждающей записи (write-ahead log) со снапшотами.
+//
+// Журнал хранится в каталоге из двух файлов:
+//   - wal.log - последовательность записей, каждая в кадре [len][crc32][seq][payload];
+//   - snapshot - последний снапшот состояния с номером последней вошедшей в него записи.
+//
+// При загрузке читается снапшот, затем записи журнала с номером больше снапшотного.
+// Повреждённый или недописанный хвост журнала отбрасывается, а файл обрезается до
+// последней целой записи, так что после аварийного завершения журнал остаётся рабочим.
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	logFileName      = "wal.log"
+	snapshotFileName = "snapshot"
+
+	// headerSize - длина, контрольная сумма и номер записи
+	headerSize = 4 + 4 + 8
+)
+
+// Ограничения проверяются и при записи, и при чтении: запись, которую нельзя прочитать,
+// при загрузке отбросилась бы вместе со всем хвостом журнала. Переменные, а не константы,
+// чтобы тесты могли их уменьшить.
+var (
+	// maxRecordSize - предельный размер записи журнала, заодно защита от чтения мусора вместо длины
+	maxRecordSize = 64 << 20
+	// maxSnapshotSize - предельный размер снапшота, он держит всё состояние и потому больше записи
+	maxSnapshotSize = 1 << 30
+)
+
+// fsync - сброс файла на диск, подменяется в тестах
+var fsync = (*os.File).Sync
+
+var (
+	ErrClosed    = errors.New("wal is closed")
+	ErrNotLoaded = errors.New("wal is not loaded")
+	// ErrBroken - недописанную запись не удалось отрезать, дальнейшие записи легли бы после мусора
+	ErrBroken = errors.New("wal is broken")
+	// ErrTooLarge - запись или снапшот больше, чем журнал сможет прочитать при загрузке
+	ErrTooLarge = errors.New("wal record is too large")
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// SyncPolicy - политика сброса журнала на диск
+type SyncPolicy int
+
+const (
+	// SyncAlways - fsync после каждой записи, ничего не теряется
+	SyncAlways SyncPolicy = iota
+	// SyncInterval - fsync раз в Options.SyncInterval, при сбое теряется не больше интервала
+	SyncInterval
+	// SyncNever - fsync остаётся на усмотрение ОС
+	SyncNever
+)
+
+// ParseSyncPolicy - разбирает политику из строки: always, interval или never
+func ParseSyncPolicy(s string) (SyncPolicy, error) {
+	switch s {
+	case "always", "":
+		return SyncAlways, nil
+	case "interval":
+		return SyncInterval, nil
+	case "never":
+		return SyncNever, nil
+	default:
+		return 0, fmt.Errorf("unknown wal sync policy %q", s)
+	}
+}
+
+// Options - настройки журнала
+type Options struct {
+	// Sync - политика сброса на диск
+	Sync SyncPolicy
+	// SyncInterval - период fsync для SyncInterval, по умолчанию 1s
+	SyncInterval time.Duration
+}
+
+// Log - журнал упреждающей записи
+type Log struct {
+	mu     sync.Mutex
+	dir    string
+	opts   Options
+	file   *os.File
+	seq    uint64
+	size   int64
+	dirty  bool
+	loaded bool
+	closed bool
+	// broken - причина, по которой журнал больше не принимает записи
+	broken error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Open - открывает журнал в каталоге dir, создавая его при необходимости.
+// Перед первой записью журнал нужно загрузить методом Load.
+func Open(dir string, opts Options) (*Log, error) {
+	if opts.SyncInterval <= 0 {
+		opts.SyncInterval = time.Second
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create wal dir: %w", err)
+	}
+	file, err := os.OpenFile(filepath.Join(dir, logFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open wal: %w", err)
+	}
+	return &Log{dir: dir, opts: opts, file: file}, nil
+}
+
+// Load - восстанавливает состояние: передаёт снапшот (если он есть) в applySnapshot,
+// затем каждую запись журнала после снапшота в apply.
+func (l *Log) Load(applySnapshot func(data []byte) error, apply func(record []byte) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return ErrClosed
+	}
+
+	snapshotSeq, data, err := readSnapshot(filepath.Join(l.dir, snapshotFileName))
+	if err != nil {
+		return err
+	}
+	if data != nil {
+		if err := applySnapshot(data); err != nil {
+			return fmt.Errorf("apply snapshot: %w", err)
+		}
+	}
+	l.seq = snapshotSeq
+
+	if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(l.file)
+	var offset int64
+	for {
+		seq, payload, n, err := readFrame(reader, maxRecordSize)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			slog.Warn("wal: dropping corrupted tail", "offset", offset, "err", err)
+			break
+		}
+		if seq > snapshotSeq {
+			if err := apply(payload); err != nil {
+				return fmt.Errorf("apply wal record %d: %w", seq, err)
+			}
+		}
+		if seq > l.seq {
+			l.seq = seq
+		}
+		offset += n
+	}
+
+	if err := l.file.Truncate(offset); err != nil {
+		return fmt.Errorf("truncate wal: %w", err)
+	}
+	if _, err := l.file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	l.size = offset
+	l.loaded = true
+
+	if l.opts.Sync == SyncInterval {
+		l.stop = make(chan struct{})
+		l.done = make(chan struct{})
+		go l.syncLoop()
+	}
+	return nil
+}
+
+// Append - дописывает запись в журнал с учётом политики сброса на диск
+func (l *Log) Append(record []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return ErrClosed
+	}
+	if !l.loaded {
+		return ErrNotLoaded
+	}
+	if l.broken != nil {
+		return l.broken
+	}
+
+	if len(record) > maxRecordSize {
+		return fmt.Errorf("%w: %d bytes", ErrTooLarge, len(record))
+	}
+
+	frame := encodeFrame(l.seq+1, record)
+	if _, err := l.file.Write(frame); err != nil {
+		l.discard()
+		return fmt.Errorf("write wal: %w", err)
+	}
+	if l.opts.Sync == SyncAlways {
+		if err := fsync(l.file); err != nil {
+			// вызывающий считает запись несостоявшейся, значит её не должно быть и при загрузке
+			l.discard()
+			return fmt.Errorf("sync wal: %w", err)
+		}
+	} else {
+		l.dirty = true
+	}
+	l.seq++
+	l.size += int64(len(frame))
+	return nil
+}
+
+// discard - отрезает кадр, который не удалось записать или сбросить на диск целиком, иначе
+// следующая запись встанет после мусора и при загрузке отбросится вместе с ним.
+// Если отрезать не получилось, журнал перестаёт принимать записи. Вызывается под l.mu
+func (l *Log) discard() {
+	if err := l.rewind(); err != nil {
+		l.broken = fmt.Errorf("%w: %w", ErrBroken, err)
+		slog.Error("wal: failed to drop partial record", "err", err)
+	}
+}
+
+// rewind - обрезает файл до последней целой записи; вызывается под l.mu
+func (l *Log) rewind() error {
+	if err := l.file.Truncate(l.size); err != nil {
+		return err
+	}
+	_, err := l.file.Seek(l.size, io.SeekStart)
+	return err
+}
+
+// Size - текущий размер журнала в байтах
+func (l *Log) Size() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.size
+}
+
+// Snapshot - атомарно записывает снапшот, покрывающий все записи журнала, и очищает журнал.
+// Вызывающий должен гарантировать, что data соответствует состоянию после последнего Append.
+func (l *Log) Snapshot(data []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return ErrClosed
+	}
+	if !l.loaded {
+		return ErrNotLoaded
+	}
+
+	if len(data) > maxSnapshotSize {
+		return fmt.Errorf("%w: snapshot of %d bytes", ErrTooLarge, len(data))
+	}
+
+	path := filepath.Join(l.dir, snapshotFileName)
+	tmp := path + ".tmp"
+	if err := writeFileSync(tmp, encodeFrame(l.seq, data)); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename snapshot: %w", err)
+	}
+	if err := syncDir(l.dir); err != nil {
+		return err
+	}
+
+	// Если упадём здесь, записи журнала с номерами <= seq будут пропущены при загрузке.
+	if err := l.file.Truncate(0); err != nil {
+		return fmt.Errorf("truncate wal: %w", err)
+	}
+	if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	l.size = 0
+	l.dirty = false
+	l.broken = nil
+	return l.file.Sync()
+}
+
+// Sync - принудительно сбрасывает журнал на диск
+func (l *Log) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	l.dirty = false
+	return l.file.Sync()
+}
+
+// Close - сбрасывает журнал на диск и закрывает его
+func (l *Log) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	stop := l.stop
+	l.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-l.done
+	}
+	return errors.Join(l.file.Sync(), l.file.Close())
+}
+
+func (l *Log) syncLoop() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.opts.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			if l.dirty && !l.closed {
+				if err := l.file.Sync(); err != nil {
+					slog.Error("wal: sync failed", "err", err)
+				} else {
+					l.dirty = false
+				}
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+func encodeFrame(seq uint64, payload []byte) []byte {
+	frame := make([]byte, headerSize+len(payload))
+	binary.LittleEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint64(frame[8:16], seq)
+	copy(frame[headerSize:], payload)
+	binary.LittleEndian.PutUint32(frame[4:8], crc32.Checksum(frame[8:], crcTable))
+	return frame
+}
+
+// readFrame - читает один кадр не длиннее limit; io.EOF означает чистый конец журнала
+func readFrame(r io.Reader, limit int) (uint64, []byte, int64, error) {
+	header := make([]byte, headerSize)
+	if n, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.EOF) && n == 0 {
+			return 0, nil, 0, io.EOF
+		}
+		return 0, nil, 0, fmt.Errorf("short header: %w", err)
+	}
+	length := binary.LittleEndian.Uint32(header[0:4])
+	if int64(length) > int64(limit) {
+		return 0, nil, 0, fmt.Errorf("record too large: %d bytes", length)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, 0, fmt.Errorf("short record: %w", err)
+	}
+	crc := crc32.Update(crc32.Checksum(header[8:16], crcTable), crcTable, payload)
+	if crc != binary.LittleEndian.Uint32(header[4:8]) {
+		return 0, nil, 0, errors.New("checksum mismatch")
+	}
+	return binary.LittleEndian.Uint64(header[8:16]), payload, int64(headerSize + length), nil
+}
+
+func readSnapshot(path string) (uint64, []byte, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil, nil
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("open snapshot: %w", err)
+	}
+	defer file.Close()
+
+	seq, data, _, err := readFrame(bufio.NewReader(file), maxSnapshotSize)
+	if errors.Is(err, io.EOF) {
+		return 0, nil, nil
+	}
+	if err != nil {
+		// Снапшот пишется через rename, поэтому битый файл - это порча диска, а не сбой записи.
+		return 0, nil, fmt.Errorf("read snapshot: %w", err)
+	}
+	return seq, data, nil
+}
+
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("sync wal dir: %w", err)
+	}
+	return nil
+}
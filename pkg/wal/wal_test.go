@@ -0,0 +1,308 @@
+package wal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func load(t *testing.T, l *Log) ([]byte, []string) {
+	t.Helper()
+	var snapshot []byte
+	var records []string
+	err := l.Load(func(data []byte) error {
+		snapshot = data
+		return nil
+	}, func(record []byte) error {
+		records = append(records, string(record))
+		return nil
+	})
+	require.NoError(t, err)
+	return snapshot, records
+}
+
+func TestLog_AppendAndReplay(t *testing.T) {
+	for _, policy := range []SyncPolicy{SyncAlways, SyncInterval, SyncNever} {
+		dir := t.TempDir()
+
+		l, err := Open(dir, Options{Sync: policy, SyncInterval: time.Millisecond})
+		require.NoError(t, err)
+		_, records := load(t, l)
+		assert.Empty(t, records)
+
+		require.NoError(t, l.Append([]byte("first")))
+		require.NoError(t, l.Append([]byte("second")))
+		require.NoError(t, l.Close())
+
+		l, err = Open(dir, Options{Sync: policy})
+		require.NoError(t, err)
+		snapshot, records := load(t, l)
+		assert.Nil(t, snapshot)
+		assert.Equal(t, []string{"first", "second"}, records)
+		require.NoError(t, l.Close())
+	}
+}
+
+func TestLog_NotLoaded(t *testing.T) {
+	l, err := Open(t.TempDir(), Options{})
+	require.NoError(t, err)
+	defer l.Close()
+
+	assert.ErrorIs(t, l.Append([]byte("record")), ErrNotLoaded)
+}
+
+func TestLog_Closed(t *testing.T) {
+	l, err := Open(t.TempDir(), Options{})
+	require.NoError(t, err)
+	load(t, l)
+	require.NoError(t, l.Close())
+
+	assert.ErrorIs(t, l.Append([]byte("record")), ErrClosed)
+	assert.NoError(t, l.Close())
+}
+
+func TestLog_Snapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, Options{})
+	require.NoError(t, err)
+	load(t, l)
+	require.NoError(t, l.Append([]byte("first")))
+	require.NoError(t, l.Snapshot([]byte("state after first")))
+	assert.Zero(t, l.Size())
+	require.NoError(t, l.Append([]byte("second")))
+	require.NoError(t, l.Close())
+
+	l, err = Open(dir, Options{})
+	require.NoError(t, err)
+	snapshot, records := load(t, l)
+	assert.Equal(t, "state after first", string(snapshot))
+	assert.Equal(t, []string{"second"}, records)
+	require.NoError(t, l.Close())
+}
+
+func TestLog_SkipsRecordsCoveredBySnapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, Options{})
+	require.NoError(t, err)
+	load(t, l)
+	require.NoError(t, l.Append([]byte("first")))
+	require.NoError(t, l.Append([]byte("second")))
+	require.NoError(t, l.Close())
+
+	walData, err := os.ReadFile(filepath.Join(dir, logFileName))
+	require.NoError(t, err)
+
+	l, err = Open(dir, Options{})
+	require.NoError(t, err)
+	load(t, l)
+	require.NoError(t, l.Snapshot([]byte("state")))
+	require.NoError(t, l.Close())
+
+	// эмулируем падение между записью снапшота и очисткой журнала
+	require.NoError(t, os.WriteFile(filepath.Join(dir, logFileName), walData, 0o644))
+
+	l, err = Open(dir, Options{})
+	require.NoError(t, err)
+	snapshot, records := load(t, l)
+	assert.Equal(t, "state", string(snapshot))
+	assert.Empty(t, records)
+
+	require.NoError(t, l.Append([]byte("third")))
+	require.NoError(t, l.Close())
+
+	l, err = Open(dir, Options{})
+	require.NoError(t, err)
+	_, records = load(t, l)
+	assert.Equal(t, []string{"third"}, records)
+	require.NoError(t, l.Close())
+}
+
+func TestLog_CorruptedTail(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(data []byte) []byte
+	}{
+		{"truncated record", func(data []byte) []byte { return data[:len(data)-3] }},
+		{"truncated header", func(data []byte) []byte { return append(data, 1, 2, 3) }},
+		{"flipped bit", func(data []byte) []byte {
+			data[len(data)-1] ^= 0xff
+			return data
+		}},
+		{"garbage length", func(data []byte) []byte {
+			return append(data, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, logFileName)
+
+			l, err := Open(dir, Options{})
+			require.NoError(t, err)
+			load(t, l)
+			require.NoError(t, l.Append([]byte("first")))
+			require.NoError(t, l.Append([]byte("second")))
+			require.NoError(t, l.Close())
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(path, tt.corrupt(data), 0o644))
+
+			l, err = Open(dir, Options{})
+			require.NoError(t, err)
+			_, records := load(t, l)
+			assert.Equal(t, "first", records[0])
+
+			require.NoError(t, l.Append([]byte("third")))
+			require.NoError(t, l.Close())
+
+			l, err = Open(dir, Options{})
+			require.NoError(t, err)
+			_, replayed := load(t, l)
+			assert.Equal(t, append(records, "third"), replayed)
+			require.NoError(t, l.Close())
+		})
+	}
+}
+
+func TestLog_PartialAppend(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, Options{})
+	require.NoError(t, err)
+	load(t, l)
+	require.NoError(t, l.Append([]byte("first")))
+
+	// кусок кадра, оставшийся от неудачной записи
+	_, err = l.file.Write([]byte{1, 2, 3})
+	require.NoError(t, err)
+	require.NoError(t, l.rewind())
+
+	require.NoError(t, l.Append([]byte("second")))
+	require.NoError(t, l.Close())
+
+	l, err = Open(dir, Options{})
+	require.NoError(t, err)
+	_, records := load(t, l)
+	assert.Equal(t, []string{"first", "second"}, records)
+	require.NoError(t, l.Close())
+}
+
+func TestLog_BrokenAfterFailedAppend(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, Options{})
+	require.NoError(t, err)
+	load(t, l)
+	require.NoError(t, l.Append([]byte("first")))
+
+	// файл только для чтения: запись не проходит, и обрезать его тоже нельзя
+	file := l.file
+	readOnly, err := os.Open(filepath.Join(dir, logFileName))
+	require.NoError(t, err)
+	l.file = readOnly
+
+	assert.Error(t, l.Append([]byte("second")))
+	assert.ErrorIs(t, l.Append([]byte("third")), ErrBroken)
+
+	l.file = file
+	require.NoError(t, readOnly.Close())
+	require.NoError(t, l.Close())
+
+	l, err = Open(dir, Options{})
+	require.NoError(t, err)
+	_, records := load(t, l)
+	assert.Equal(t, []string{"first"}, records)
+	require.NoError(t, l.Close())
+}
+
+// limitSizes - уменьшает ограничения на время теста, чтобы не выделять гигабайты
+func limitSizes(t *testing.T, record, snapshot int) {
+	t.Helper()
+	prevRecord, prevSnapshot := maxRecordSize, maxSnapshotSize
+	maxRecordSize, maxSnapshotSize = record, snapshot
+	t.Cleanup(func() { maxRecordSize, maxSnapshotSize = prevRecord, prevSnapshot })
+}
+
+func TestLog_RecordTooLarge(t *testing.T) {
+	limitSizes(t, 8, 64)
+	dir := t.TempDir()
+
+	l, err := Open(dir, Options{})
+	require.NoError(t, err)
+	load(t, l)
+	require.NoError(t, l.Append([]byte("first")))
+	assert.ErrorIs(t, l.Append([]byte("far too long")), ErrTooLarge)
+	require.NoError(t, l.Append([]byte("second")))
+	require.NoError(t, l.Close())
+
+	// слишком длинная запись не попала в журнал и не утянула за собой следующие
+	l, err = Open(dir, Options{})
+	require.NoError(t, err)
+	_, records := load(t, l)
+	assert.Equal(t, []string{"first", "second"}, records)
+	require.NoError(t, l.Close())
+}
+
+func TestLog_SnapshotTooLarge(t *testing.T) {
+	limitSizes(t, 8, 16)
+	dir := t.TempDir()
+
+	l, err := Open(dir, Options{})
+	require.NoError(t, err)
+	load(t, l)
+	require.NoError(t, l.Snapshot([]byte("state")))
+	require.NoError(t, l.Append([]byte("first")))
+	assert.ErrorIs(t, l.Snapshot([]byte("state that does not fit")), ErrTooLarge)
+	require.NoError(t, l.Close())
+
+	// прежний снапшот и журнал остались читаемыми
+	l, err = Open(dir, Options{})
+	require.NoError(t, err)
+	snapshot, records := load(t, l)
+	assert.Equal(t, "state", string(snapshot))
+	assert.Equal(t, []string{"first"}, records)
+	require.NoError(t, l.Close())
+}
+
+func TestLog_FailedSync(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, Options{Sync: SyncAlways})
+	require.NoError(t, err)
+	load(t, l)
+	require.NoError(t, l.Append([]byte("first")))
+	size := l.Size()
+
+	fsync = func(*os.File) error { return errors.New("disk is gone") }
+	err = l.Append([]byte("second"))
+	fsync = (*os.File).Sync
+	require.Error(t, err)
+	assert.Equal(t, size, l.Size())
+
+	require.NoError(t, l.Append([]byte("third")))
+	require.NoError(t, l.Close())
+
+	// запись, о сбое которой узнал вызывающий, не воскресает при загрузке
+	l, err = Open(dir, Options{})
+	require.NoError(t, err)
+	_, records := load(t, l)
+	assert.Equal(t, []string{"first", "third"}, records)
+	require.NoError(t, l.Close())
+}
+
+func TestParseSyncPolicy(t *testing.T) {
+	policy, err := ParseSyncPolicy("interval")
+	assert.NoError(t, err)
+	assert.Equal(t, SyncInterval, policy)
+
+	_, err = ParseSyncPolicy("sometimes")
+	assert.Error(t, err)
+}